@@ -0,0 +1,222 @@
+package aws
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * AssumeRoleProvider provides temporary credentials for a role, obtained
+ * from STS with the credentials of Source. The credentials are cached
+ * and assumed again shortly before they expire, so long-running services
+ * can keep using a role of another account.
+ */
+type AssumeRoleProvider struct {
+	// Source provides the credentials used to call STS.
+	Source *Credentials
+
+	RoleARN         string
+	RoleSessionName string // defaults to a name derived from the time
+	ExternalID      string
+
+	// SerialNumber identifies the MFA device of the caller, for roles
+	// that may only be assumed with MFA. The code of the device is then
+	// taken from TokenProvider or, if that is nil, from TokenCode. As a
+	// code can be used only once, TokenCode only works for a single
+	// AssumeRole call; set TokenProvider to refresh credentials.
+	SerialNumber  string
+	TokenCode     string
+	TokenProvider func() (string, error)
+
+	// Duration of the credentials. Defaults to one hour.
+	Duration time.Duration
+
+	// ExpiryWindow is how long before their expiration credentials are
+	// assumed again. Defaults to five minutes.
+	ExpiryWindow time.Duration
+
+	// Region of the STS endpoint, such as "eu-west-1". Defaults to the
+	// global endpoint, which is signed for us-east-1.
+	Region string
+
+	// Endpoint of STS, such as a VPC endpoint. Defaults to the endpoint
+	// of Region. Requests to it are signed for Region or, if that is
+	// empty, the region named in the host, as in
+	// https://sts.eu-west-1.amazonaws.com.
+	Endpoint string
+
+	// Client sends the requests. Defaults to DefaultQueryHTTPClient.
+	Client *http.Client
+
+	mu      sync.Mutex
+	expires time.Time
+}
+
+/**
+ * DefaultSTSEndpoint is the global STS endpoint, signed for us-east-1.
+ */
+const DefaultSTSEndpoint = "https://sts.amazonaws.com/"
+
+type assumeRoleResponse struct {
+	Credentials struct {
+		AccessKeyId     string
+		SecretAccessKey string
+		SessionToken    string
+		Expiration      time.Time
+	} `xml:"AssumeRoleResult>Credentials"`
+}
+
+func (self *AssumeRoleProvider) Retrieve() (Auth, error) {
+	return self.RetrieveWithContext(context.Background())
+}
+
+/**
+ * RetrieveWithContext is like Retrieve but aborts when ctx is done.
+ */
+func (self *AssumeRoleProvider) RetrieveWithContext(ctx context.Context) (Auth, error) {
+	if self.Source == nil {
+		return Auth{}, errors.New("AssumeRoleProvider has no source credentials")
+	}
+
+	params := url.Values{}
+	params.Set("RoleArn", self.RoleARN)
+	params.Set("RoleSessionName", self.sessionName())
+	duration := self.Duration
+	if duration == 0 {
+		duration = time.Hour
+	}
+	params.Set("DurationSeconds", strconv.Itoa(int(duration/time.Second)))
+	if self.ExternalID != "" {
+		params.Set("ExternalId", self.ExternalID)
+	}
+
+	if self.SerialNumber != "" {
+		code, err := self.tokenCode()
+		if err != nil {
+			return Auth{}, err
+		}
+		params.Set("SerialNumber", self.SerialNumber)
+		params.Set("TokenCode", code)
+	}
+
+	client := &QueryClient{
+		Credentials: self.Source,
+		Service:     "sts",
+		Version:     "2011-06-15",
+		Endpoint:    self.endpoint(),
+		SigV4Only:   true,
+		Client:      self.Client,
+	}
+	if self.SerialNumber == "" {
+		// An MFA code is accepted only once, so a retry sending it
+		// again would be rejected.
+		client.IdempotentActions = map[string]bool{"AssumeRole": true}
+	}
+	var resp assumeRoleResponse
+	if err := client.Do(ctx, "AssumeRole", params, &resp); err != nil {
+		return Auth{}, fmt.Errorf("AssumeRole %s: %w", self.RoleARN, err)
+	}
+
+	self.mu.Lock()
+	self.expires = resp.Credentials.Expiration
+	self.mu.Unlock()
+
+	return Auth{
+		AccessKey: resp.Credentials.AccessKeyId,
+		SecretKey: resp.Credentials.SecretAccessKey,
+		Token:     resp.Credentials.SessionToken,
+	}, nil
+}
+
+func (self *AssumeRoleProvider) IsExpired() bool {
+	window := self.ExpiryWindow
+	if window == 0 {
+		window = 5 * time.Minute
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.expires.IsZero() || time.Now().Add(window).After(self.expires)
+}
+
+func (self *AssumeRoleProvider) tokenCode() (string, error) {
+	if self.TokenProvider != nil {
+		return self.TokenProvider()
+	}
+	if self.TokenCode == "" {
+		return "", errors.New("role " + self.RoleARN + " requires an MFA code but neither TokenCode nor TokenProvider is set")
+	}
+	return self.TokenCode, nil
+}
+
+/**
+ * StdinTokenProvider prompts for an MFA code on standard error and reads
+ * it from standard input. It is meant for AssumeRoleProvider.TokenProvider
+ * in interactive programs.
+ */
+func StdinTokenProvider() (string, error) {
+	fmt.Fprint(os.Stderr, "Enter MFA code: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (self *AssumeRoleProvider) sessionName() string {
+	if self.RoleSessionName != "" {
+		return self.RoleSessionName
+	}
+	return "go-aws-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+/**
+ * endpoint returns the STS endpoint of the provider and the region to
+ * sign requests to it for.
+ */
+func (self *AssumeRoleProvider) endpoint() Endpoint {
+	endpoint := Endpoint{URL: DefaultSTSEndpoint, SigningRegion: self.Region, SigningName: "sts"}
+	if self.Region != "" {
+		endpoint.URL = "https://sts." + self.Region + ".amazonaws.com/"
+		if strings.HasPrefix(self.Region, "cn-") {
+			endpoint.URL = "https://sts." + self.Region + ".amazonaws.com.cn/"
+		}
+	}
+	if self.Endpoint != "" {
+		endpoint.URL = self.Endpoint
+		if endpoint.SigningRegion == "" {
+			endpoint.SigningRegion = stsRegionFromURL(self.Endpoint)
+		}
+	}
+	if endpoint.SigningRegion == "" {
+		endpoint.SigningRegion = USEast.Name
+	}
+	return endpoint
+}
+
+/**
+ * stsRegionFromURL returns the region named in the host of a regional STS
+ * endpoint, such as https://sts.eu-west-1.amazonaws.com or the VPC
+ * endpoint https://vpce-1a2b.sts.eu-west-1.vpce.amazonaws.com, or "".
+ */
+func stsRegionFromURL(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	labels := strings.Split(u.Hostname(), ".")
+	for i := 0; i+2 < len(labels); i++ {
+		if labels[i] == "sts" && labels[i+1] != "amazonaws" {
+			return labels[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestSTSServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAssumeRoleProviderSignsForEndpointRegion(t *testing.T) {
+	var authorization string
+	srv := newTestSTSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		authorization = r.Header.Get("Authorization")
+		if r.Form.Get("Action") != "AssumeRole" || r.Form.Get("RoleArn") != "arn:aws:iam::123:role/r" {
+			t.Errorf("unexpected request %v", r.Form)
+		}
+		fmt.Fprint(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ASIAKEY</AccessKeyId><SecretAccessKey>sekrit</SecretAccessKey>
+<SessionToken>token</SessionToken><Expiration>2030-01-01T00:00:00Z</Expiration>
+</Credentials></AssumeRoleResult></AssumeRoleResponse>`)
+	})
+
+	provider := &AssumeRoleProvider{
+		Source:   NewCredentials(&StaticProvider{Auth{AccessKey: "AKID", SecretKey: "secret"}}),
+		RoleARN:  "arn:aws:iam::123:role/r",
+		Region:   "eu-west-1",
+		Endpoint: srv.URL,
+	}
+	auth, err := provider.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != (Auth{AccessKey: "ASIAKEY", SecretKey: "sekrit", Token: "token"}) {
+		t.Fatalf("got %+v", auth)
+	}
+	if !strings.Contains(authorization, "/eu-west-1/sts/aws4_request") {
+		t.Fatalf("request signed with %q", authorization)
+	}
+	if provider.IsExpired() {
+		t.Fatal("credentials expiring in 2030 reported as expired")
+	}
+}
+
+func TestAssumeRoleProviderReturnsServiceErrors(t *testing.T) {
+	srv := newTestSTSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(403)
+		fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code>
+<Message>not allowed</Message></Error><RequestId>req-1</RequestId></ErrorResponse>`)
+	})
+
+	provider := &AssumeRoleProvider{
+		Source:   NewCredentials(&StaticProvider{Auth{AccessKey: "AKID", SecretKey: "secret"}}),
+		RoleARN:  "arn:aws:iam::123:role/r",
+		Endpoint: srv.URL,
+	}
+	_, err := provider.Retrieve()
+	if ErrorCode(err) != "AccessDenied" || !strings.Contains(err.Error(), "arn:aws:iam::123:role/r") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestAssumeRoleProviderEndpoint(t *testing.T) {
+	tests := []struct {
+		region, endpoint string
+		want             Endpoint
+	}{
+		{"", "", Endpoint{DefaultSTSEndpoint, "us-east-1", "sts"}},
+		{"eu-west-1", "", Endpoint{"https://sts.eu-west-1.amazonaws.com/", "eu-west-1", "sts"}},
+		{"", "https://sts.ap-south-1.amazonaws.com", Endpoint{"https://sts.ap-south-1.amazonaws.com", "ap-south-1", "sts"}},
+		{"", "https://vpce-1a2b.sts.eu-west-2.vpce.amazonaws.com", Endpoint{"https://vpce-1a2b.sts.eu-west-2.vpce.amazonaws.com", "eu-west-2", "sts"}},
+		{"", "https://sts.amazonaws.com/", Endpoint{"https://sts.amazonaws.com/", "us-east-1", "sts"}},
+		{"us-west-2", "http://localhost:4566", Endpoint{"http://localhost:4566", "us-west-2", "sts"}},
+	}
+	for _, test := range tests {
+		provider := &AssumeRoleProvider{Region: test.region, Endpoint: test.endpoint}
+		if got := provider.endpoint(); got != test.want {
+			t.Errorf("%q, %q: got %+v, want %+v", test.region, test.endpoint, got, test.want)
+		}
+	}
+}
+
+// newFlakySTSServer fails the first request with a server error and
+// answers the others with credentials. It returns the number of requests.
+func newFlakySTSServer(t *testing.T) (*httptest.Server, *int32) {
+	var requests int32
+	srv := newTestSTSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(500)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>InternalFailure</Code></Error></ErrorResponse>`)
+			return
+		}
+		fmt.Fprint(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ASIAKEY</AccessKeyId><SecretAccessKey>sekrit</SecretAccessKey>
+<Expiration>2030-01-01T00:00:00Z</Expiration>
+</Credentials></AssumeRoleResult></AssumeRoleResponse>`)
+	})
+	return srv, &requests
+}
+
+func TestAssumeRoleProviderRetriesWithoutMFA(t *testing.T) {
+	srv, requests := newFlakySTSServer(t)
+	provider := &AssumeRoleProvider{
+		Source:   NewCredentials(&StaticProvider{Auth{AccessKey: "AKID", SecretKey: "secret"}}),
+		RoleARN:  "arn:aws:iam::123:role/r",
+		Endpoint: srv.URL,
+	}
+	if _, err := provider.Retrieve(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Fatalf("got %d requests, want 2", n)
+	}
+}
+
+func TestAssumeRoleProviderDoesNotResendMFACode(t *testing.T) {
+	srv, requests := newFlakySTSServer(t)
+	provider := &AssumeRoleProvider{
+		Source:       NewCredentials(&StaticProvider{Auth{AccessKey: "AKID", SecretKey: "secret"}}),
+		RoleARN:      "arn:aws:iam::123:role/r",
+		Endpoint:     srv.URL,
+		SerialNumber: "arn:aws:iam::123:mfa/user",
+		TokenCode:    "123456",
+	}
+	if _, err := provider.Retrieve(); err == nil {
+		t.Fatal("no error")
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Fatalf("got %d requests, want 1", n)
+	}
+}
+
+func TestAssumeRoleProviderWithContext(t *testing.T) {
+	srv := newTestSTSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent with a canceled context")
+	})
+	provider := &AssumeRoleProvider{
+		Source:   NewCredentials(&StaticProvider{Auth{AccessKey: "AKID", SecretKey: "secret"}}),
+		RoleARN:  "arn:aws:iam::123:role/r",
+		Endpoint: srv.URL,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewCredentials(provider).GetWithContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
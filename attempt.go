@@ -1,6 +1,9 @@
 package aws
 
 import (
+	"context"
+	"math"
+	"math/rand"
 	"time"
 )
 
@@ -13,6 +16,18 @@ type AttemptStrategy struct {
 	Total time.Duration // total duration of attempt.
 	Delay time.Duration // interval between each try in the burst.
 	Min   int           // minimum number of retries; overrides Total
+
+	// Multiplier, if greater than one, makes the interval grow
+	// exponentially: the n-th retry waits Delay * Multiplier^(n-1).
+	Multiplier float64
+
+	// MaxDelay, if set, caps the interval between tries.
+	MaxDelay time.Duration
+
+	// Jitter waits a random duration between zero and the interval
+	// instead of the interval itself ("full jitter"), so that clients
+	// failing together don't retry in lockstep.
+	Jitter bool
 }
 
 type Attempt struct {
@@ -21,20 +36,96 @@ type Attempt struct {
 	end      time.Time
 	force    bool
 	count    int
+	delay    time.Duration // interval before the next try
+	now      func() time.Time
+	sleep    func(time.Duration)
+	ctx      context.Context
 }
 
 /**
  * Start begins a new sequence of attempts for the given strategy.
  */
 func (self AttemptStrategy) Start() *Attempt {
-	now := time.Now()
+	return self.start(time.Now, time.Sleep)
+}
+
+/**
+ * StartWithContext is like Start, but the sequence of attempts stops as
+ * soon as ctx is done, including while waiting for the next attempt.
+ */
+func (self AttemptStrategy) StartWithContext(ctx context.Context) *Attempt {
+	attempt := self.start(time.Now, func(d time.Duration) {
+		SleepWithContext(ctx, d)
+	})
+	attempt.ctx = ctx
+	return attempt
+}
+
+/**
+ * SleepWithContext pauses for d or until ctx is done, whichever happens
+ * first, and returns the context's error in the latter case.
+ */
+func SleepWithContext(ctx context.Context, d time.Duration) error {
+	if ctx == nil {
+		time.Sleep(d)
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (self AttemptStrategy) start(now func() time.Time, sleep func(time.Duration)) *Attempt {
+	started := now()
 
 	return &Attempt{
 		strategy: self,
-		last:     now,
-		end:      now.Add(self.Total),
+		last:     started,
+		end:      started.Add(self.Total),
 		force:    true,
+		delay:    self.delay(1),
+		now:      now,
+		sleep:    sleep,
+	}
+}
+
+/**
+ * MaxSimulatedAttempts bounds the schedule returned by Simulate, for
+ * strategies that would otherwise never give up on a virtual clock.
+ */
+const MaxSimulatedAttempts = 10000
+
+/**
+ * Simulate returns the schedule of attempts the strategy would make if
+ * every attempt failed, as offsets from the start of the sequence. It runs
+ * against a virtual clock and never sleeps, so strategies can be checked
+ * in unit tests and their worst case latency reasoned about.
+ *
+ * If latency is not nil, it returns how long the attempt with the given
+ * zero based number takes, to model slow or timing out calls. Otherwise
+ * attempts are assumed to take no time at all.
+ */
+func (self AttemptStrategy) Simulate(latency func(try int) time.Duration) []time.Duration {
+	var clock time.Time
+	now := func() time.Time { return clock }
+	sleep := func(d time.Duration) { clock = clock.Add(d) }
+
+	var schedule []time.Duration
+	for attempt := self.start(now, sleep); attempt.Next(); {
+		if len(schedule) == MaxSimulatedAttempts {
+			break
+		}
+		schedule = append(schedule, clock.Sub(time.Time{}))
+		if latency != nil {
+			sleep(latency(len(schedule) - 1))
+		}
 	}
+	return schedule
 }
 
 /**
@@ -42,7 +133,13 @@ func (self AttemptStrategy) Start() *Attempt {
  * false if it is time to stop trying.
  */
 func (self *Attempt) Next() bool {
-	now := time.Now()
+	// The first attempt is always made, so callers get the context's
+	// error from the operation itself.
+	if self.count > 0 && self.done() {
+		return false
+	}
+
+	now := self.now()
 	sleep := self.nextSleep(now)
 
 	if !self.force && !now.Add(sleep).Before(self.end) && self.strategy.Min <= self.count {
@@ -52,18 +149,49 @@ func (self *Attempt) Next() bool {
 	self.force = false
 
 	if sleep > 0 && self.count > 0 {
-		time.Sleep(sleep)
-		now = time.Now()
+		self.sleep(sleep)
+		if self.done() {
+			return false
+		}
+		now = self.now()
 	}
 
 	self.count++
 	self.last = now
+	self.delay = self.strategy.delay(self.count)
 
 	return true
 }
 
+/**
+ * delay returns the interval to wait before the try following the
+ * given number of tries. With Jitter set, it is random.
+ */
+func (self AttemptStrategy) delay(tries int) time.Duration {
+	delay := self.Delay
+	if self.Multiplier > 1 && tries > 1 {
+		d := float64(delay) * math.Pow(self.Multiplier, float64(tries-1))
+		if d >= float64(math.MaxInt64) {
+			delay = math.MaxInt64
+		} else {
+			delay = time.Duration(d)
+		}
+	}
+	if self.MaxDelay > 0 && delay > self.MaxDelay {
+		delay = self.MaxDelay
+	}
+	if self.Jitter && delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay)))
+	}
+	return delay
+}
+
+func (self *Attempt) done() bool {
+	return self.ctx != nil && self.ctx.Err() != nil
+}
+
 func (self *Attempt) nextSleep(now time.Time) time.Duration {
-	sleep := self.strategy.Delay - now.Sub(self.last)
+	sleep := self.delay - now.Sub(self.last)
 
 	if sleep < 0 {
 		return 0
@@ -75,14 +203,18 @@ func (self *Attempt) nextSleep(now time.Time) time.Duration {
 /** 
  * HasNext returns whether another attempt will be made if the current
  * one fails. If it returns true, the following call to Next is
- * guaranteed to return true.
+ * guaranteed to return true, unless the attempt's context is done by then.
  */
 func (self *Attempt) HasNext() bool {
+	if self.done() {
+		return false
+	}
+
 	if self.force || self.strategy.Min > self.count {
 		return true
 	}
 
-	now := time.Now()
+	now := self.now()
 
 	if now.Add(self.nextSleep(now)).Before(self.end) {
 		self.force = true
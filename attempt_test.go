@@ -0,0 +1,152 @@
+package aws
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSimulate(t *testing.T) {
+	for _, test := range []struct {
+		strategy AttemptStrategy
+		latency  func(try int) time.Duration
+		want     []time.Duration
+	}{{
+		strategy: AttemptStrategy{},
+		want:     []time.Duration{0},
+	}, {
+		strategy: AttemptStrategy{Total: 20 * time.Second, Delay: 5 * time.Second},
+		want:     []time.Duration{0, 5 * time.Second, 10 * time.Second, 15 * time.Second},
+	}, {
+		// Min overrides Total.
+		strategy: AttemptStrategy{Min: 3, Delay: time.Second},
+		want:     []time.Duration{0, time.Second, 2 * time.Second},
+	}, {
+		// Time spent in attempts counts against the delay.
+		strategy: AttemptStrategy{Total: 10 * time.Second, Delay: 3 * time.Second},
+		latency:  func(try int) time.Duration { return 2 * time.Second },
+		want:     []time.Duration{0, 3 * time.Second, 6 * time.Second, 9 * time.Second},
+	}, {
+		// Attempts slower than the delay are retried at once.
+		strategy: AttemptStrategy{Total: 10 * time.Second, Delay: time.Second},
+		latency:  func(try int) time.Duration { return 4 * time.Second },
+		want:     []time.Duration{0, 4 * time.Second, 8 * time.Second},
+	}} {
+		if got := test.strategy.Simulate(test.latency); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%+v: got %v, want %v", test.strategy, got, test.want)
+		}
+	}
+}
+
+func TestSimulateIsBounded(t *testing.T) {
+	strategy := AttemptStrategy{Total: time.Hour}
+	if got := len(strategy.Simulate(nil)); got != MaxSimulatedAttempts {
+		t.Fatalf("simulated %d attempts", got)
+	}
+}
+
+func TestSimulateMatchesAttempts(t *testing.T) {
+	strategy := AttemptStrategy{Min: 5, Delay: 2 * time.Millisecond}
+	var got []time.Duration
+	start := time.Now()
+	for attempt := strategy.Start(); attempt.Next(); {
+		got = append(got, time.Since(start))
+	}
+	want := strategy.Simulate(nil)
+	if len(got) != len(want) {
+		t.Fatalf("made %d attempts, simulated %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] < want[i] {
+			t.Errorf("attempt %d after %v, simulated %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	strategy := AttemptStrategy{Min: 6, Delay: time.Second, Multiplier: 2, MaxDelay: 10 * time.Second}
+	want := []time.Duration{0, 1 * time.Second, 3 * time.Second, 7 * time.Second, 15 * time.Second, 25 * time.Second}
+	if got := strategy.Simulate(nil); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// A huge multiplier saturates instead of overflowing.
+	strategy = AttemptStrategy{Delay: time.Second, Multiplier: 1e300}
+	if got := strategy.delay(3); got != math.MaxInt64 {
+		t.Fatalf("got %v", got)
+	}
+	strategy.MaxDelay = time.Minute
+	if got := strategy.delay(3); got != time.Minute {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	strategy := AttemptStrategy{Delay: time.Second, Multiplier: 2, MaxDelay: 4 * time.Second, Jitter: true}
+	distinct := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		for tries, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 5: 4 * time.Second} {
+			d := strategy.delay(tries)
+			if d < 0 || d >= max {
+				t.Fatalf("delay after %d tries is %v, want below %v", tries, d, max)
+			}
+			distinct[d] = true
+		}
+	}
+	if len(distinct) < 50 {
+		t.Fatalf("only %d distinct delays", len(distinct))
+	}
+
+	// Without a delay there is nothing to randomize.
+	strategy.Delay = 0
+	if got := strategy.delay(1); got != 0 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestSleepWithContext(t *testing.T) {
+	if err := SleepWithContext(context.Background(), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := SleepWithContext(ctx, time.Minute); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("slept %v with a canceled context", elapsed)
+	}
+}
+
+func TestStartWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// The first attempt is made regardless, so the operation reports
+	// the context's error itself.
+	attempt := AttemptStrategy{Min: 5}.StartWithContext(ctx)
+	if !attempt.Next() {
+		t.Fatal("no first attempt")
+	}
+	if attempt.HasNext() || attempt.Next() {
+		t.Fatal("attempt after the context was canceled")
+	}
+
+	// A canceled context cuts the wait for the next attempt short.
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	attempt = AttemptStrategy{Min: 5, Delay: time.Minute}.StartWithContext(ctx)
+	start := time.Now()
+	tries := 0
+	for attempt.Next() {
+		tries++
+	}
+	if tries != 1 {
+		t.Fatalf("made %d attempts, want 1", tries)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("waited %v for a canceled context", elapsed)
+	}
+}
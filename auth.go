@@ -23,37 +23,17 @@ type credentials struct {
 
 /** 
  * GetAuth creates an Auth based on either passed in credentials,
- * environment information or instance based role credentials.
+ * environment information, the shared credentials file or instance
+ * based role credentials, in that order. The lookup is done by a chain
+ * of providers; see DefaultProviders and NewChainCredentials to compose
+ * a different order.
  */
 func GetAuth(accessKey string, secretKey string) (Auth, error) {
-	// First try passed in credentials
-	if accessKey != "" && secretKey != "" {
-		return Auth{accessKey, secretKey, ""}, nil
-	}
-
-	// Next try to get auth from the environment
-  auth, error := EnvAuth()
-
-	if error == nil {
-		// Found auth, return
-		return auth, nil
-	}
-
-	// Next try getting auth from the instance role
-	credentials, error := getInstanceCredentials()
-
-	if error == nil {
-		// Found auth, return
-		auth.AccessKey = credentials.AccessKeyId
-		auth.SecretKey = credentials.SecretAccessKey
-		auth.Token = credentials.Token
-
-		return auth, nil
-
-	} else {
-    return auth, errors.New("No valid AWS authentication found")
+	providers := append([]Provider{
+		&StaticProvider{Auth{accessKey, secretKey, ""}},
+	}, DefaultProviders()...)
 
-  }
+	return NewChainCredentials(providers...).Get()
 }
 
 /** 
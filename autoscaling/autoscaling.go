@@ -0,0 +1,54 @@
+// Package autoscaling interacts with Amazon EC2 Auto Scaling.
+package autoscaling
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/dkln/go-aws"
+)
+
+// APIVersion is the version of the Auto Scaling API the package speaks.
+const APIVersion = "2011-01-01"
+
+// The AutoScaling type encapsulates operations with Auto Scaling in a
+// region.
+type AutoScaling struct {
+	*aws.QueryClient
+}
+
+// New creates a new AutoScaling.
+func New(auth aws.Auth, region aws.Region) *AutoScaling {
+	client := aws.NewQueryClient(auth, region, "autoscaling", APIVersion)
+	client.IdempotentActions = map[string]bool{
+		"UpdateAutoScalingGroup": true,
+		"SetDesiredCapacity":     true,
+		"PutScalingPolicy":       true,
+		"PutLifecycleHook":       true,
+	}
+	return &AutoScaling{client}
+}
+
+// Tag is a tag of an auto scaling group, which is also put on the
+// instances it launches if PropagateAtLaunch is set.
+type Tag struct {
+	Key               string
+	Value             string
+	PropagateAtLaunch bool
+}
+
+// describe calls a Describe action for every page of its results and
+// decodes them all into resp, whose slices accumulate the items of the
+// pages. nextToken points to the NextToken field of resp.
+func (self *AutoScaling) describe(ctx context.Context, action string, params url.Values, resp interface{}, nextToken *string) error {
+	for {
+		*nextToken = ""
+		if err := self.Do(ctx, action, params, resp); err != nil {
+			return err
+		}
+		if *nextToken == "" {
+			return nil
+		}
+		params.Set("NextToken", *nextToken)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build !goaws_stable
+
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/x/awstest"
+)
+
+func newTestAutoScaling(t *testing.T, responses map[string][]string) (*AutoScaling, *awstest.QueryServer) {
+	server := awstest.NewQueryServer(responses)
+	t.Cleanup(server.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.Region{Name: "us-east-1", SigV4Only: true})
+	client.Endpoint.URL = server.URL
+	client.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+	return client, server
+}
+
+func TestDescribeFollowsNextToken(t *testing.T) {
+	client, server := newTestAutoScaling(t, map[string][]string{
+		"DescribeLaunchConfigurations": {
+			"<LaunchConfigurations><member><LaunchConfigurationName>a</LaunchConfigurationName></member></LaunchConfigurations><NextToken>t1</NextToken>",
+			"<LaunchConfigurations><member><LaunchConfigurationName>b</LaunchConfigurationName></member></LaunchConfigurations><NextToken>t2</NextToken>",
+			"<LaunchConfigurations><member><LaunchConfigurationName>c</LaunchConfigurationName></member></LaunchConfigurations>",
+		},
+	})
+	configs, err := client.DescribeLaunchConfigurations(context.Background(), "a", "b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, config := range configs {
+		names = append(names, config.LaunchConfigurationName)
+	}
+	if fmt.Sprint(names) != "[a b c]" {
+		t.Fatalf("got %v", names)
+	}
+	for n, token := range []string{"", "t1", "t2"} {
+		if sent := server.Request(n); sent.Get("NextToken") != token || sent.Get("LaunchConfigurationNames.member.3") != "c" {
+			t.Fatalf("request %d sent %v", n, sent)
+		}
+	}
+}
+
+func TestOnlyIdempotentActionsAreRetried(t *testing.T) {
+	client, server := newTestAutoScaling(t, map[string][]string{
+		"SetDesiredCapacity":     {""},
+		"CreateAutoScalingGroup": {""},
+	})
+	ctx := context.Background()
+	server.Fail("SetDesiredCapacity", 1)
+	if err := client.SetDesiredCapacity(ctx, "web", 3, true); err != nil {
+		t.Fatal(err)
+	}
+	if server.Count() != 2 {
+		t.Fatalf("sent %d requests", server.Count())
+	}
+
+	server.Fail("CreateAutoScalingGroup", 1)
+	err := client.CreateAutoScalingGroup(ctx, &Group{AutoScalingGroupName: "web"})
+	if aws.ErrorCode(err) != "InternalFailure" || aws.HTTPStatusCode(err) != 500 {
+		t.Fatalf("got %v", err)
+	}
+	if server.Count() != 3 {
+		t.Fatalf("sent %d requests", server.Count())
+	}
+}
@@ -0,0 +1,145 @@
+package autoscaling
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// Group is an auto scaling group.
+type Group struct {
+	AutoScalingGroupName    string
+	AutoScalingGroupARN     string
+	LaunchConfigurationName string
+	MinSize                 int
+	MaxSize                 int
+	DesiredCapacity         int
+	DefaultCooldown         int             // in seconds
+	AvailabilityZones       []string        `xml:"AvailabilityZones>member"`
+	LoadBalancerNames       []string        `xml:"LoadBalancerNames>member"`
+	TargetGroupARNs         []string        `xml:"TargetGroupARNs>member"`
+	HealthCheckType         string          // "EC2" or "ELB"
+	HealthCheckGracePeriod  int             // in seconds
+	Instances               []GroupInstance `xml:"Instances>member"`
+	Tags                    []Tag           `xml:"Tags>member"`
+	Status                  string
+	CreatedTime             time.Time
+
+	// VPCZoneIdentifier lists the IDs of the subnets to launch in,
+	// separated by commas.
+	VPCZoneIdentifier string
+}
+
+// GroupInstance is an instance of an auto scaling group.
+type GroupInstance struct {
+	InstanceId              string
+	AvailabilityZone        string
+	LifecycleState          string // "Pending", "InService", "Terminating", ...
+	HealthStatus            string // "Healthy" or "Unhealthy"
+	LaunchConfigurationName string
+	ProtectedFromScaleIn    bool
+}
+
+func (self *Group) params() url.Values {
+	params := url.Values{"AutoScalingGroupName": {self.AutoScalingGroupName}}
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	set("LaunchConfigurationName", self.LaunchConfigurationName)
+	set("HealthCheckType", self.HealthCheckType)
+	set("VPCZoneIdentifier", self.VPCZoneIdentifier)
+	params.Set("MinSize", strconv.Itoa(self.MinSize))
+	params.Set("MaxSize", strconv.Itoa(self.MaxSize))
+	if self.DesiredCapacity != 0 {
+		params.Set("DesiredCapacity", strconv.Itoa(self.DesiredCapacity))
+	}
+	if self.DefaultCooldown != 0 {
+		params.Set("DefaultCooldown", strconv.Itoa(self.DefaultCooldown))
+	}
+	if self.HealthCheckGracePeriod != 0 {
+		params.Set("HealthCheckGracePeriod", strconv.Itoa(self.HealthCheckGracePeriod))
+	}
+	aws.SetList(params, "AvailabilityZones.member", self.AvailabilityZones)
+	return params
+}
+
+// CreateAutoScalingGroup creates an auto scaling group from the
+// configuration fields of group: name, launch configuration, sizes,
+// cooldown, zones or subnets, load balancers, health check and tags.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_CreateAutoScalingGroup.html for details.
+func (self *AutoScaling) CreateAutoScalingGroup(ctx context.Context, group *Group) error {
+	params := group.params()
+	aws.SetList(params, "LoadBalancerNames.member", group.LoadBalancerNames)
+	aws.SetList(params, "TargetGroupARNs.member", group.TargetGroupARNs)
+	for i, tag := range group.Tags {
+		prefix := "Tags.member." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"Key", tag.Key)
+		params.Set(prefix+"Value", tag.Value)
+		params.Set(prefix+"PropagateAtLaunch", strconv.FormatBool(tag.PropagateAtLaunch))
+	}
+	return self.Do(ctx, "CreateAutoScalingGroup", params, nil)
+}
+
+// UpdateAutoScalingGroup changes the launch configuration, sizes,
+// cooldown, zones or subnets and health check of an auto scaling group
+// to those of group.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_UpdateAutoScalingGroup.html for details.
+func (self *AutoScaling) UpdateAutoScalingGroup(ctx context.Context, group *Group) error {
+	return self.Do(ctx, "UpdateAutoScalingGroup", group.params(), nil)
+}
+
+// DeleteAutoScalingGroup deletes the auto scaling group named name. With
+// force set, its instances are terminated; otherwise it must have none.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_DeleteAutoScalingGroup.html for details.
+func (self *AutoScaling) DeleteAutoScalingGroup(ctx context.Context, name string, force bool) error {
+	params := url.Values{"AutoScalingGroupName": {name}}
+	if force {
+		params.Set("ForceDelete", "true")
+	}
+	return self.Do(ctx, "DeleteAutoScalingGroup", params, nil)
+}
+
+// DescribeAutoScalingGroups describes the auto scaling groups with the
+// given names, or all of them if there are none.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_DescribeAutoScalingGroups.html for details.
+func (self *AutoScaling) DescribeAutoScalingGroups(ctx context.Context, names ...string) ([]Group, error) {
+	params := url.Values{}
+	aws.SetList(params, "AutoScalingGroupNames.member", names)
+	var resp struct {
+		Groups    []Group `xml:"DescribeAutoScalingGroupsResult>AutoScalingGroups>member"`
+		NextToken string  `xml:"DescribeAutoScalingGroupsResult>NextToken"`
+	}
+	if err := self.describe(ctx, "DescribeAutoScalingGroups", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.Groups, nil
+}
+
+// SetDesiredCapacity changes the number of instances of the auto scaling
+// group named name. With honorCooldown set, it fails during the group's
+// cooldown period.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_SetDesiredCapacity.html for details.
+func (self *AutoScaling) SetDesiredCapacity(ctx context.Context, name string, capacity int, honorCooldown bool) error {
+	params := url.Values{
+		"AutoScalingGroupName": {name},
+		"DesiredCapacity":      {strconv.Itoa(capacity)},
+		"HonorCooldown":        {strconv.FormatBool(honorCooldown)},
+	}
+	return self.Do(ctx, "SetDesiredCapacity", params, nil)
+}
+
+// SubnetIdentifier joins subnet IDs into a VPCZoneIdentifier.
+func SubnetIdentifier(subnetIds ...string) string {
+	return strings.Join(subnetIds, ",")
+}
@@ -0,0 +1,124 @@
+//go:build !goaws_stable
+
+package autoscaling
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCreateAutoScalingGroup(t *testing.T) {
+	client, server := newTestAutoScaling(t, map[string][]string{"CreateAutoScalingGroup": nil})
+	err := client.CreateAutoScalingGroup(context.Background(), &Group{
+		AutoScalingGroupName:    "web",
+		LaunchConfigurationName: "web-v1",
+		MinSize:                 0,
+		MaxSize:                 4,
+		DesiredCapacity:         2,
+		HealthCheckType:         "ELB",
+		HealthCheckGracePeriod:  300,
+		VPCZoneIdentifier:       SubnetIdentifier("subnet-1", "subnet-2"),
+		LoadBalancerNames:       []string{"web-lb"},
+		TargetGroupARNs:         []string{"arn:tg"},
+		Tags:                    []Tag{{Key: "Name", Value: "web", PropagateAtLaunch: true}, {Key: "team", Value: "ops"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := server.Request(0)
+	for name, want := range map[string]string{
+		"LaunchConfigurationName":         "web-v1",
+		"MinSize":                         "0",
+		"MaxSize":                         "4",
+		"DesiredCapacity":                 "2",
+		"HealthCheckType":                 "ELB",
+		"HealthCheckGracePeriod":          "300",
+		"VPCZoneIdentifier":               "subnet-1,subnet-2",
+		"LoadBalancerNames.member.1":      "web-lb",
+		"TargetGroupARNs.member.1":        "arn:tg",
+		"Tags.member.1.Key":               "Name",
+		"Tags.member.1.PropagateAtLaunch": "true",
+		"Tags.member.2.Value":             "ops",
+		"Tags.member.2.PropagateAtLaunch": "false",
+	} {
+		if got := sent.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, name := range []string{"DefaultCooldown", "AvailabilityZones.member.1"} {
+		if _, ok := sent[name]; ok {
+			t.Errorf("sent unset %s", name)
+		}
+	}
+}
+
+func TestUpdateAutoScalingGroupLeavesAttachmentsAlone(t *testing.T) {
+	client, server := newTestAutoScaling(t, map[string][]string{"UpdateAutoScalingGroup": nil})
+	err := client.UpdateAutoScalingGroup(context.Background(), &Group{
+		AutoScalingGroupName: "web",
+		MaxSize:              8,
+		AvailabilityZones:    []string{"us-east-1a"},
+		LoadBalancerNames:    []string{"web-lb"},
+		Tags:                 []Tag{{Key: "Name", Value: "web"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := server.Request(0)
+	if sent.Get("MaxSize") != "8" || sent.Get("AvailabilityZones.member.1") != "us-east-1a" {
+		t.Fatalf("sent %v", sent)
+	}
+	for _, name := range []string{"LoadBalancerNames.member.1", "Tags.member.1.Key"} {
+		if _, ok := sent[name]; ok {
+			t.Errorf("sent %s", name)
+		}
+	}
+}
+
+func TestDescribeAutoScalingGroups(t *testing.T) {
+	client, _ := newTestAutoScaling(t, map[string][]string{
+		"DescribeAutoScalingGroups": {`<AutoScalingGroups><member>
+			<AutoScalingGroupName>web</AutoScalingGroupName>
+			<MinSize>1</MinSize><MaxSize>4</MaxSize><DesiredCapacity>2</DesiredCapacity>
+			<AvailabilityZones><member>us-east-1a</member><member>us-east-1b</member></AvailabilityZones>
+			<Instances><member>
+				<InstanceId>i-1</InstanceId><LifecycleState>InService</LifecycleState>
+				<HealthStatus>Healthy</HealthStatus><ProtectedFromScaleIn>true</ProtectedFromScaleIn>
+			</member></Instances>
+			<Tags><member><Key>Name</Key><Value>web</Value><PropagateAtLaunch>true</PropagateAtLaunch></member></Tags>
+			<CreatedTime>2024-03-01T12:00:00.000Z</CreatedTime>
+		</member></AutoScalingGroups>`},
+	})
+	groups, err := client.DescribeAutoScalingGroups(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("got %+v", groups)
+	}
+	group := groups[0]
+	if group.DesiredCapacity != 2 || len(group.AvailabilityZones) != 2 || len(group.Instances) != 1 ||
+		!group.Instances[0].ProtectedFromScaleIn || group.Instances[0].LifecycleState != "InService" ||
+		len(group.Tags) != 1 || !group.Tags[0].PropagateAtLaunch ||
+		!group.CreatedTime.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("got %+v", group)
+	}
+}
+
+func TestDeleteAutoScalingGroup(t *testing.T) {
+	client, server := newTestAutoScaling(t, map[string][]string{"DeleteAutoScalingGroup": nil})
+	ctx := context.Background()
+	if err := client.DeleteAutoScalingGroup(ctx, "web", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteAutoScalingGroup(ctx, "web", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Request(0)["ForceDelete"]; ok {
+		t.Errorf("sent %v", server.Request(0))
+	}
+	if server.Request(1).Get("ForceDelete") != "true" {
+		t.Errorf("sent %v", server.Request(1))
+	}
+}
@@ -0,0 +1,83 @@
+package autoscaling
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// LaunchConfiguration describes the instances an auto scaling group
+// launches.
+type LaunchConfiguration struct {
+	LaunchConfigurationName  string
+	LaunchConfigurationARN   string
+	ImageId                  string
+	InstanceType             string
+	KeyName                  string
+	SecurityGroups           []string `xml:"SecurityGroups>member"`
+	UserData                 string   // base64 encoded
+	IamInstanceProfile       string
+	SpotPrice                string
+	AssociatePublicIpAddress bool
+	EbsOptimized             bool
+	CreatedTime              time.Time
+}
+
+// CreateLaunchConfiguration creates a launch configuration. UserData is
+// given as is and base64 encoded by CreateLaunchConfiguration.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_CreateLaunchConfiguration.html for details.
+func (self *AutoScaling) CreateLaunchConfiguration(ctx context.Context, config *LaunchConfiguration, userData []byte) error {
+	params := url.Values{
+		"LaunchConfigurationName": {config.LaunchConfigurationName},
+		"ImageId":                 {config.ImageId},
+		"InstanceType":            {config.InstanceType},
+	}
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	set("KeyName", config.KeyName)
+	set("IamInstanceProfile", config.IamInstanceProfile)
+	set("SpotPrice", config.SpotPrice)
+	aws.SetList(params, "SecurityGroups.member", config.SecurityGroups)
+	if len(userData) > 0 {
+		params.Set("UserData", base64.StdEncoding.EncodeToString(userData))
+	}
+	if config.AssociatePublicIpAddress {
+		params.Set("AssociatePublicIpAddress", "true")
+	}
+	if config.EbsOptimized {
+		params.Set("EbsOptimized", "true")
+	}
+	return self.Do(ctx, "CreateLaunchConfiguration", params, nil)
+}
+
+// DeleteLaunchConfiguration deletes the launch configuration named name,
+// which no group may use.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_DeleteLaunchConfiguration.html for details.
+func (self *AutoScaling) DeleteLaunchConfiguration(ctx context.Context, name string) error {
+	return self.Do(ctx, "DeleteLaunchConfiguration", url.Values{"LaunchConfigurationName": {name}}, nil)
+}
+
+// DescribeLaunchConfigurations describes the launch configurations with
+// the given names, or all of them if there are none.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_DescribeLaunchConfigurations.html for details.
+func (self *AutoScaling) DescribeLaunchConfigurations(ctx context.Context, names ...string) ([]LaunchConfiguration, error) {
+	params := url.Values{}
+	aws.SetList(params, "LaunchConfigurationNames.member", names)
+	var resp struct {
+		LaunchConfigurations []LaunchConfiguration `xml:"DescribeLaunchConfigurationsResult>LaunchConfigurations>member"`
+		NextToken            string                `xml:"DescribeLaunchConfigurationsResult>NextToken"`
+	}
+	if err := self.describe(ctx, "DescribeLaunchConfigurations", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.LaunchConfigurations, nil
+}
@@ -0,0 +1,32 @@
+//go:build !goaws_stable
+
+package autoscaling
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCreateLaunchConfiguration(t *testing.T) {
+	client, server := newTestAutoScaling(t, map[string][]string{"CreateLaunchConfiguration": nil})
+	config := &LaunchConfiguration{
+		LaunchConfigurationName: "web-v1",
+		ImageId:                 "ami-1",
+		InstanceType:            "t3.micro",
+		SecurityGroups:          []string{"sg-1", "sg-2"},
+		EbsOptimized:            true,
+	}
+	if err := client.CreateLaunchConfiguration(context.Background(), config, []byte("#!/bin/sh\n")); err != nil {
+		t.Fatal(err)
+	}
+	sent := server.Request(0)
+	if sent.Get("UserData") != "IyEvYmluL3NoCg==" || sent.Get("SecurityGroups.member.2") != "sg-2" ||
+		sent.Get("EbsOptimized") != "true" || sent.Get("ImageId") != "ami-1" {
+		t.Fatalf("sent %v", sent)
+	}
+	for _, name := range []string{"KeyName", "SpotPrice", "AssociatePublicIpAddress"} {
+		if _, ok := sent[name]; ok {
+			t.Errorf("sent unset %s", name)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package autoscaling
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/dkln/go-aws"
+)
+
+// Lifecycle transitions hooks apply to.
+const (
+	TransitionLaunching   = "autoscaling:EC2_INSTANCE_LAUNCHING"
+	TransitionTerminating = "autoscaling:EC2_INSTANCE_TERMINATING"
+)
+
+// LifecycleHook pauses instances of an auto scaling group in a lifecycle
+// transition until the action is completed, or HeartbeatTimeout passes,
+// so they can be set up or drained.
+type LifecycleHook struct {
+	LifecycleHookName     string
+	AutoScalingGroupName  string
+	LifecycleTransition   string // TransitionLaunching or TransitionTerminating
+	NotificationTargetARN string // SQS queue or SNS topic notified of transitions
+	RoleARN               string // allowing Auto Scaling to publish to the target
+	NotificationMetadata  string
+	HeartbeatTimeout      int    // in seconds
+	DefaultResult         string // "CONTINUE" or "ABANDON", on timeout
+}
+
+// PutLifecycleHook creates or replaces a lifecycle hook.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_PutLifecycleHook.html for details.
+func (self *AutoScaling) PutLifecycleHook(ctx context.Context, hook *LifecycleHook) error {
+	params := url.Values{
+		"LifecycleHookName":    {hook.LifecycleHookName},
+		"AutoScalingGroupName": {hook.AutoScalingGroupName},
+		"LifecycleTransition":  {hook.LifecycleTransition},
+	}
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	set("NotificationTargetARN", hook.NotificationTargetARN)
+	set("RoleARN", hook.RoleARN)
+	set("NotificationMetadata", hook.NotificationMetadata)
+	set("DefaultResult", hook.DefaultResult)
+	if hook.HeartbeatTimeout != 0 {
+		params.Set("HeartbeatTimeout", strconv.Itoa(hook.HeartbeatTimeout))
+	}
+	return self.Do(ctx, "PutLifecycleHook", params, nil)
+}
+
+// DeleteLifecycleHook deletes the lifecycle hook named name of a group.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_DeleteLifecycleHook.html for details.
+func (self *AutoScaling) DeleteLifecycleHook(ctx context.Context, groupName, name string) error {
+	params := url.Values{
+		"AutoScalingGroupName": {groupName},
+		"LifecycleHookName":    {name},
+	}
+	return self.Do(ctx, "DeleteLifecycleHook", params, nil)
+}
+
+// DescribeLifecycleHooks describes the lifecycle hooks of a group with
+// the given names, or all of them if there are none.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_DescribeLifecycleHooks.html for details.
+func (self *AutoScaling) DescribeLifecycleHooks(ctx context.Context, groupName string, names ...string) ([]LifecycleHook, error) {
+	params := url.Values{"AutoScalingGroupName": {groupName}}
+	aws.SetList(params, "LifecycleHookNames.member", names)
+	var resp struct {
+		Hooks []LifecycleHook `xml:"DescribeLifecycleHooksResult>LifecycleHooks>member"`
+	}
+	if err := self.Do(ctx, "DescribeLifecycleHooks", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Hooks, nil
+}
+
+// CompleteLifecycleAction ends the lifecycle action of the instance with
+// the given ID paused by a hook, with result "CONTINUE" or "ABANDON".
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_CompleteLifecycleAction.html for details.
+func (self *AutoScaling) CompleteLifecycleAction(ctx context.Context, groupName, hookName, instanceId, result string) error {
+	params := url.Values{
+		"AutoScalingGroupName":  {groupName},
+		"LifecycleHookName":     {hookName},
+		"InstanceId":            {instanceId},
+		"LifecycleActionResult": {result},
+	}
+	return self.Do(ctx, "CompleteLifecycleAction", params, nil)
+}
+
+// RecordLifecycleActionHeartbeat restarts the timeout of the lifecycle
+// action of the instance with the given ID, to keep it paused longer.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_RecordLifecycleActionHeartbeat.html for details.
+func (self *AutoScaling) RecordLifecycleActionHeartbeat(ctx context.Context, groupName, hookName, instanceId string) error {
+	params := url.Values{
+		"AutoScalingGroupName": {groupName},
+		"LifecycleHookName":    {hookName},
+		"InstanceId":           {instanceId},
+	}
+	return self.Do(ctx, "RecordLifecycleActionHeartbeat", params, nil)
+}
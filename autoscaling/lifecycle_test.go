@@ -0,0 +1,55 @@
+//go:build !goaws_stable
+
+package autoscaling
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	client, server := newTestAutoScaling(t, map[string][]string{
+		"PutLifecycleHook": nil,
+		"DescribeLifecycleHooks": {`<LifecycleHooks><member>
+			<LifecycleHookName>drain</LifecycleHookName><AutoScalingGroupName>web</AutoScalingGroupName>
+			<LifecycleTransition>autoscaling:EC2_INSTANCE_TERMINATING</LifecycleTransition>
+			<HeartbeatTimeout>600</HeartbeatTimeout><DefaultResult>CONTINUE</DefaultResult>
+		</member></LifecycleHooks>`},
+		"RecordLifecycleActionHeartbeat": nil,
+		"CompleteLifecycleAction":        nil,
+	})
+	ctx := context.Background()
+	hook := &LifecycleHook{
+		LifecycleHookName:    "drain",
+		AutoScalingGroupName: "web",
+		LifecycleTransition:  TransitionTerminating,
+		HeartbeatTimeout:     600,
+		DefaultResult:        "CONTINUE",
+	}
+	if err := client.PutLifecycleHook(ctx, hook); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Request(0); sent.Get("LifecycleTransition") != TransitionTerminating || sent.Get("HeartbeatTimeout") != "600" {
+		t.Fatalf("sent %v", sent)
+	} else if _, ok := sent["RoleARN"]; ok {
+		t.Fatalf("sent unset RoleARN")
+	}
+
+	hooks, err := client.DescribeLifecycleHooks(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 1 || *hook != hooks[0] {
+		t.Fatalf("got %+v", hooks)
+	}
+
+	if err := client.RecordLifecycleActionHeartbeat(ctx, "web", "drain", "i-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CompleteLifecycleAction(ctx, "web", "drain", "i-1", "CONTINUE"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Request(3); sent.Get("InstanceId") != "i-1" || sent.Get("LifecycleActionResult") != "CONTINUE" {
+		t.Fatalf("sent %v", sent)
+	}
+}
@@ -0,0 +1,91 @@
+package autoscaling
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// ScalingPolicy changes the capacity of an auto scaling group when it is
+// executed, typically by a CloudWatch alarm.
+type ScalingPolicy struct {
+	AutoScalingGroupName string
+	PolicyName           string
+	PolicyARN            string
+	PolicyType           string // "SimpleScaling" (the default), "StepScaling" or "TargetTrackingScaling"
+
+	// AdjustmentType is "ChangeInCapacity", "ExactCapacity" or
+	// "PercentChangeInCapacity", which ScalingAdjustment is then in.
+	AdjustmentType    string
+	ScalingAdjustment int
+	Cooldown          int // in seconds; if zero, the group's default
+}
+
+// PutScalingPolicy creates or replaces a simple scaling policy and
+// returns its ARN, which CloudWatch alarms execute it by.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_PutScalingPolicy.html for details.
+func (self *AutoScaling) PutScalingPolicy(ctx context.Context, policy *ScalingPolicy) (string, error) {
+	params := url.Values{
+		"AutoScalingGroupName": {policy.AutoScalingGroupName},
+		"PolicyName":           {policy.PolicyName},
+		"AdjustmentType":       {policy.AdjustmentType},
+		"ScalingAdjustment":    {strconv.Itoa(policy.ScalingAdjustment)},
+	}
+	if policy.PolicyType != "" {
+		params.Set("PolicyType", policy.PolicyType)
+	}
+	if policy.Cooldown != 0 {
+		params.Set("Cooldown", strconv.Itoa(policy.Cooldown))
+	}
+	var resp struct {
+		PolicyARN string `xml:"PutScalingPolicyResult>PolicyARN"`
+	}
+	if err := self.Do(ctx, "PutScalingPolicy", params, &resp); err != nil {
+		return "", err
+	}
+	return resp.PolicyARN, nil
+}
+
+// DeletePolicy deletes the scaling policy named name of a group.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_DeletePolicy.html for details.
+func (self *AutoScaling) DeletePolicy(ctx context.Context, groupName, name string) error {
+	params := url.Values{
+		"AutoScalingGroupName": {groupName},
+		"PolicyName":           {name},
+	}
+	return self.Do(ctx, "DeletePolicy", params, nil)
+}
+
+// DescribePolicies describes the scaling policies of the group named
+// groupName, or of all groups if it is empty.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_DescribePolicies.html for details.
+func (self *AutoScaling) DescribePolicies(ctx context.Context, groupName string) ([]ScalingPolicy, error) {
+	params := url.Values{}
+	if groupName != "" {
+		params.Set("AutoScalingGroupName", groupName)
+	}
+	var resp struct {
+		Policies  []ScalingPolicy `xml:"DescribePoliciesResult>ScalingPolicies>member"`
+		NextToken string          `xml:"DescribePoliciesResult>NextToken"`
+	}
+	if err := self.describe(ctx, "DescribePolicies", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.Policies, nil
+}
+
+// ExecutePolicy executes the scaling policy named name of a group. With
+// honorCooldown set, it fails during the group's cooldown period.
+//
+// See https://docs.aws.amazon.com/autoscaling/ec2/APIReference/API_ExecutePolicy.html for details.
+func (self *AutoScaling) ExecutePolicy(ctx context.Context, groupName, name string, honorCooldown bool) error {
+	params := url.Values{
+		"AutoScalingGroupName": {groupName},
+		"PolicyName":           {name},
+		"HonorCooldown":        {strconv.FormatBool(honorCooldown)},
+	}
+	return self.Do(ctx, "ExecutePolicy", params, nil)
+}
@@ -0,0 +1,56 @@
+//go:build !goaws_stable
+
+package autoscaling
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScalingPolicies(t *testing.T) {
+	client, server := newTestAutoScaling(t, map[string][]string{
+		"PutScalingPolicy": {"<PolicyARN>arn:aws:autoscaling:policy/up</PolicyARN>"},
+		"DescribePolicies": {`<ScalingPolicies><member>
+			<AutoScalingGroupName>web</AutoScalingGroupName><PolicyName>up</PolicyName>
+			<PolicyARN>arn:aws:autoscaling:policy/up</PolicyARN><AdjustmentType>ChangeInCapacity</AdjustmentType>
+			<ScalingAdjustment>-1</ScalingAdjustment>
+		</member></ScalingPolicies>`},
+		"ExecutePolicy": nil,
+	})
+	ctx := context.Background()
+	arn, err := client.PutScalingPolicy(ctx, &ScalingPolicy{
+		AutoScalingGroupName: "web",
+		PolicyName:           "up",
+		AdjustmentType:       "ChangeInCapacity",
+		ScalingAdjustment:    -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arn != "arn:aws:autoscaling:policy/up" {
+		t.Fatalf("got %q", arn)
+	}
+	if sent := server.Request(0); sent.Get("ScalingAdjustment") != "-1" {
+		t.Fatalf("sent %v", sent)
+	} else if _, ok := sent["Cooldown"]; ok {
+		t.Fatalf("sent unset Cooldown")
+	}
+
+	policies, err := client.DescribePolicies(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 1 || policies[0].ScalingAdjustment != -1 || policies[0].PolicyARN != arn {
+		t.Fatalf("got %+v", policies)
+	}
+	if _, ok := server.Request(1)["AutoScalingGroupName"]; ok {
+		t.Fatalf("sent %v", server.Request(1))
+	}
+
+	if err := client.ExecutePolicy(ctx, "web", "up", false); err != nil {
+		t.Fatal(err)
+	}
+	if server.Request(2).Get("HonorCooldown") != "false" {
+		t.Fatalf("sent %v", server.Request(2))
+	}
+}
@@ -0,0 +1,175 @@
+package aws
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/**
+ * RetryBudget limits retries to a fraction of the requests made, so that
+ * a struggling service is not hit with a multiple of its normal load.
+ * Every request adds Ratio to the budget and every retry takes one from
+ * it; the budget starts with and never exceeds Reserve, which allows
+ * bursts of retries after a quiet period. It is safe for concurrent use
+ * and may be shared by several transports.
+ */
+type RetryBudget struct {
+	Ratio   float64
+	Reserve float64
+
+	mu      sync.Mutex
+	balance float64
+	started bool
+}
+
+/**
+ * NewRetryBudget returns a RetryBudget allowing retries for ratio of the
+ * requests, with reserve retries allowed in a burst.
+ */
+func NewRetryBudget(ratio float64, reserve int) *RetryBudget {
+	return &RetryBudget{Ratio: ratio, Reserve: float64(reserve)}
+}
+
+/**
+ * DefaultRetryBudget is the budget of the transport of RetryingClient:
+ * at most one retry for every ten requests, or ten in a burst.
+ */
+var DefaultRetryBudget = NewRetryBudget(0.1, 10)
+
+func (self *RetryBudget) init() {
+	if !self.started {
+		self.started = true
+		self.balance = self.Reserve
+	}
+}
+
+/**
+ * Deposit records a request.
+ */
+func (self *RetryBudget) Deposit() {
+	self.mu.Lock()
+	self.init()
+	self.balance += self.Ratio
+	if self.balance > self.Reserve {
+		self.balance = self.Reserve
+	}
+	self.mu.Unlock()
+}
+
+/**
+ * Withdraw reports whether a retry is within the budget, and records it
+ * if so.
+ */
+func (self *RetryBudget) Withdraw() bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.init()
+	if self.balance < 1 {
+		return false
+	}
+	self.balance--
+	return true
+}
+
+/**
+ * ErrCircuitOpen is returned without sending the request when the circuit
+ * breaker of its host is open.
+ */
+var ErrCircuitOpen = errors.New("circuit breaker open: endpoint is failing")
+
+/**
+ * CircuitBreaker stops sending requests to a host after Threshold
+ * consecutive failures and fails them fast with ErrCircuitOpen instead,
+ * for Cooldown. After that a single request is let through; if it
+ * succeeds requests flow again, otherwise the breaker stays open for
+ * another Cooldown. Failures are network errors and 5xx responses. It is
+ * safe for concurrent use.
+ */
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+/**
+ * NewCircuitBreaker returns a CircuitBreaker opening after threshold
+ * consecutive failures for cooldown.
+ */
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+/**
+ * Allow reports whether a request to host may be sent. Once the cooldown
+ * of an open circuit has passed, it lets a single probe request through;
+ * the caller must then report its outcome with Record, or give the slot
+ * back with Release, or the circuit stays closed to further requests.
+ */
+func (self *CircuitBreaker) Allow(host string) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	c := self.hosts[host]
+	if c == nil || c.failures < self.Threshold {
+		return true
+	}
+	if time.Now().Before(c.openUntil) || c.probing {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+/**
+ * Record records the outcome of a request to host.
+ */
+func (self *CircuitBreaker) Record(host string, failed bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.hosts == nil {
+		self.hosts = make(map[string]*circuit)
+	}
+	c := self.hosts[host]
+	if c == nil {
+		c = &circuit{}
+		self.hosts[host] = c
+	}
+	c.probing = false
+	if !failed {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= self.Threshold {
+		c.openUntil = time.Now().Add(self.Cooldown)
+	}
+}
+
+/**
+ * Release gives back the probe slot Allow may have taken for a request to
+ * host that was not sent or whose outcome is unknown, such as a request
+ * canceled by its caller, without recording a success or a failure.
+ */
+func (self *CircuitBreaker) Release(host string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if c := self.hosts[host]; c != nil {
+		c.probing = false
+	}
+}
+
+func isFailure(response *http.Response, err error) bool {
+	return err != nil || response.StatusCode >= 500
+}
@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerProbeSurvivesAbortedRequests(t *testing.T) {
+	status := 500
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	rt := &ResilientTransport{
+		MaxTries:    1,
+		ShouldRetry: func(*http.Request, *http.Response, error) bool { return false },
+		Breaker:     NewCircuitBreaker(1, 10*time.Millisecond),
+	}
+	client := NewClient(rt)
+	get := func(ctx context.Context) error {
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		resp, err := client.Do(req.WithContext(ctx))
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	get(context.Background()) // opens the circuit
+	if err := get(context.Background()); err == nil {
+		t.Fatal("request let through an open circuit")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// A request aborted while waiting for the limiter must not take the
+	// probe slot with it.
+	rt.Limiter = NewRateLimiter(0.001, 1)
+	rt.Limiter.Wait(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := get(ctx); err == nil {
+		t.Fatal("request succeeded despite the limiter")
+	}
+	rt.Limiter = nil
+
+	status = 200
+	if err := get(context.Background()); err != nil {
+		t.Fatalf("probe after cooldown failed: %v", err)
+	}
+	if err := get(context.Background()); err != nil {
+		t.Fatalf("closed circuit refused a request: %v", err)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	budget := NewRetryBudget(0.5, 2)
+	if !budget.Withdraw() || !budget.Withdraw() || budget.Withdraw() {
+		t.Fatal("reserve of 2 retries not honored")
+	}
+	budget.Deposit()
+	budget.Deposit()
+	if !budget.Withdraw() || budget.Withdraw() {
+		t.Fatal("two requests at ratio 0.5 should allow exactly one retry")
+	}
+}
+
+func TestCircuitBreakerRelease(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Millisecond)
+	breaker.Record("h", true)
+	time.Sleep(2 * time.Millisecond)
+	if !breaker.Allow("h") {
+		t.Fatal("no probe after cooldown")
+	}
+	if breaker.Allow("h") {
+		t.Fatal("second probe let through")
+	}
+	breaker.Release("h")
+	if !breaker.Allow("h") {
+		t.Fatal("probe slot not given back by Release")
+	}
+}
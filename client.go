@@ -1,27 +1,109 @@
 package aws
 
 import (
+	"context"
+	"crypto/tls"
 	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 )
 
+// ClientOption tunes the http.Transport built by NewClient.
+type ClientOption func(*http.Transport)
+
+// WithTLSConfig sets the TLS configuration, e.g. for private CAs.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(t *http.Transport) {
+		t.TLSClientConfig = config
+	}
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections are kept per
+// host for reuse.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(t *http.Transport) {
+		t.MaxIdleConnsPerHost = n
+	}
+}
+
+// WithResponseHeaderTimeout bounds the wait for response headers after
+// the request has been written.
+func WithResponseHeaderTimeout(d time.Duration) ClientOption {
+	return func(t *http.Transport) {
+		t.ResponseHeaderTimeout = d
+	}
+}
+
+// WithDialContext replaces the dialer, e.g. to go through a custom
+// network or to resolve names differently. DialTimeout and Deadline of
+// the ResilientTransport still apply.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return func(t *http.Transport) {
+		t.DialContext = dial
+	}
+}
+
+// WithProxy sets the proxy function, replacing http.ProxyFromEnvironment.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) ClientOption {
+	return func(t *http.Transport) {
+		t.Proxy = proxy
+	}
+}
+
+// WithMaxIdleConns sets how many idle connections are kept for reuse
+// across all hosts.
+func WithMaxIdleConns(n int) ClientOption {
+	return func(t *http.Transport) {
+		t.MaxIdleConns = n
+	}
+}
+
+// WithIdleConnTimeout sets how long idle connections are kept for reuse.
+func WithIdleConnTimeout(d time.Duration) ClientOption {
+	return func(t *http.Transport) {
+		t.IdleConnTimeout = d
+	}
+}
+
+// NewSingleTryClient returns a client built by NewClient that sends every
+// request once, for callers that know better when to retry, with dials
+// bounded by dialTimeout. Its connections are counted in Diagnostics.
+func NewSingleTryClient(dialTimeout time.Duration, options ...ClientOption) *http.Client {
+	return NewClient(&ResilientTransport{
+		DialTimeout: dialTimeout,
+		MaxTries:    1,
+		ShouldRetry: func(*http.Request, *http.Response, error) bool { return false },
+	}, options...)
+}
+
 // Convenience method for creating an http client
-func NewClient(rt *ResilientTransport) *http.Client {
+func NewClient(rt *ResilientTransport, options ...ClientOption) *http.Client {
 	rt.transport = &http.Transport{
-		Dial: func(netw, addr string) (net.Conn, error) {
-			c, err := net.DialTimeout(netw, addr, rt.DialTimeout)
-			if err != nil {
-				return nil, err
-			}
+		DialContext: (&net.Dialer{}).DialContext,
+		Proxy:       http.ProxyFromEnvironment,
+	}
+	for _, option := range options {
+		option(rt.transport)
+	}
+
+	dial := rt.transport.DialContext
+	rt.transport.DialContext = func(ctx context.Context, netw, addr string) (net.Conn, error) {
+		if rt.DialTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, rt.DialTimeout)
+			defer cancel()
+		}
+		c, err := dial(ctx, netw, addr)
+		if err != nil {
+			return nil, err
+		}
+		if rt.Deadline != nil {
 			c.SetDeadline(rt.Deadline())
-			return c, nil
-		},
-		Proxy: http.ProxyFromEnvironment,
+		}
+		return newTrackedConn(c), nil
 	}
-	// TODO: Would be nice is ResilientTransport allowed clients to initialize
-	// with http.Transport attributes.
 	return &http.Client{
 		Transport: rt,
 	}
@@ -32,8 +114,12 @@ func NewClient(rt *ResilientTransport) *http.Client {
 var RetryingClient = NewClient(retryingTransport)
 
 func ExpBackoff(try int) {
-	time.Sleep(100 * time.Millisecond *
-		time.Duration(math.Exp2(float64(try))))
+	time.Sleep(ExpBackoffDuration(try))
+}
+
+func ExpBackoffDuration(try int) time.Duration {
+	return 100 * time.Millisecond *
+		time.Duration(math.Exp2(float64(try)))
 }
 
 func LinearBackoff(try int) {
@@ -64,6 +150,15 @@ func awsRetry(req *http.Request, res *http.Response, err error) bool {
 			retry = true
 		}
 	}
+
+	// Retry if we are being throttled, unless we are told to wait longer
+	// than we are willing to.
+	if res != nil && isThrottleResponse(res) {
+		retry = true
+	}
+	if wait, ok := RetryAfter(res); ok && wait > MaxRetryAfter {
+		retry = false
+	}
 	return retry
 }
 
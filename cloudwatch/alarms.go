@@ -0,0 +1,129 @@
+package cloudwatch
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// States of alarms.
+const (
+	StateOK               = "OK"
+	StateAlarm            = "ALARM"
+	StateInsufficientData = "INSUFFICIENT_DATA"
+)
+
+// Alarm watches a statistic of a metric and changes state when it
+// crosses Threshold for EvaluationPeriods periods in a row, triggering
+// the actions of the new state, such as SNS topic or auto scaling policy
+// ARNs.
+type Alarm struct {
+	AlarmName          string
+	AlarmArn           string
+	AlarmDescription   string
+	Namespace          string
+	MetricName         string
+	Dimensions         []Dimension `xml:"Dimensions>member"`
+	Statistic          string
+	Period             int // in seconds
+	EvaluationPeriods  int
+	Threshold          float64
+	ComparisonOperator string // "GreaterThanThreshold", "LessThanOrEqualToThreshold", ...
+	Unit               string
+	TreatMissingData   string // "missing", "notBreaching", "breaching" or "ignore"
+
+	ActionsEnabled          bool
+	AlarmActions            []string `xml:"AlarmActions>member"`
+	OKActions               []string `xml:"OKActions>member"`
+	InsufficientDataActions []string `xml:"InsufficientDataActions>member"`
+
+	// The current state, set by DescribeAlarms.
+	StateValue            string
+	StateReason           string
+	StateUpdatedTimestamp time.Time
+}
+
+// PutMetricAlarm creates or replaces an alarm, from all of its fields
+// but the current state.
+//
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_PutMetricAlarm.html for details.
+func (self *CloudWatch) PutMetricAlarm(ctx context.Context, alarm *Alarm) error {
+	params := url.Values{
+		"AlarmName":          {alarm.AlarmName},
+		"Namespace":          {alarm.Namespace},
+		"MetricName":         {alarm.MetricName},
+		"Statistic":          {alarm.Statistic},
+		"Period":             {strconv.Itoa(alarm.Period)},
+		"EvaluationPeriods":  {strconv.Itoa(alarm.EvaluationPeriods)},
+		"Threshold":          {strconv.FormatFloat(alarm.Threshold, 'g', -1, 64)},
+		"ComparisonOperator": {alarm.ComparisonOperator},
+		"ActionsEnabled":     {strconv.FormatBool(alarm.ActionsEnabled)},
+	}
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	set("AlarmDescription", alarm.AlarmDescription)
+	set("Unit", alarm.Unit)
+	set("TreatMissingData", alarm.TreatMissingData)
+	setDimensions(params, alarm.Dimensions)
+	aws.SetList(params, "AlarmActions.member", alarm.AlarmActions)
+	aws.SetList(params, "OKActions.member", alarm.OKActions)
+	aws.SetList(params, "InsufficientDataActions.member", alarm.InsufficientDataActions)
+	return self.Do(ctx, "PutMetricAlarm", params, nil)
+}
+
+// DescribeAlarmsOptions narrow down the alarms DescribeAlarms returns.
+type DescribeAlarmsOptions struct {
+	AlarmNames      []string
+	AlarmNamePrefix string // cannot be combined with AlarmNames
+	StateValue      string // StateOK, StateAlarm or StateInsufficientData
+	ActionPrefix    string
+}
+
+// DescribeAlarms describes the alarms matching options, which may be nil
+// to describe all alarms.
+//
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_DescribeAlarms.html for details.
+func (self *CloudWatch) DescribeAlarms(ctx context.Context, options *DescribeAlarmsOptions) ([]Alarm, error) {
+	params := url.Values{}
+	if options != nil {
+		aws.SetList(params, "AlarmNames.member", options.AlarmNames)
+		if options.AlarmNamePrefix != "" {
+			params.Set("AlarmNamePrefix", options.AlarmNamePrefix)
+		}
+		if options.StateValue != "" {
+			params.Set("StateValue", options.StateValue)
+		}
+		if options.ActionPrefix != "" {
+			params.Set("ActionPrefix", options.ActionPrefix)
+		}
+	}
+	var resp struct {
+		Alarms    []Alarm `xml:"DescribeAlarmsResult>MetricAlarms>member"`
+		NextToken string  `xml:"DescribeAlarmsResult>NextToken"`
+	}
+	for {
+		resp.NextToken = ""
+		if err := self.Do(ctx, "DescribeAlarms", params, &resp); err != nil {
+			return nil, err
+		}
+		if resp.NextToken == "" {
+			return resp.Alarms, nil
+		}
+		params.Set("NextToken", resp.NextToken)
+	}
+}
+
+// DeleteAlarms deletes the alarms with the given names.
+//
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_DeleteAlarms.html for details.
+func (self *CloudWatch) DeleteAlarms(ctx context.Context, names ...string) error {
+	params := url.Values{}
+	aws.SetList(params, "AlarmNames.member", names)
+	return self.Do(ctx, "DeleteAlarms", params, nil)
+}
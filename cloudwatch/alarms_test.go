@@ -0,0 +1,91 @@
+//go:build !goaws_stable
+
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkln/go-aws"
+)
+
+func TestPutMetricAlarm(t *testing.T) {
+	client, server := newTestCloudWatch(t, map[string][]string{"PutMetricAlarm": nil})
+	server.Fail("PutMetricAlarm", 1)
+	err := client.PutMetricAlarm(context.Background(), &Alarm{
+		AlarmName:          "cpu-high",
+		Namespace:          "AWS/EC2",
+		MetricName:         "CPUUtilization",
+		Dimensions:         []Dimension{{"AutoScalingGroupName", "web"}},
+		Statistic:          StatisticAverage,
+		Period:             300,
+		EvaluationPeriods:  2,
+		Threshold:          80.5,
+		ComparisonOperator: "GreaterThanThreshold",
+		ActionsEnabled:     true,
+		AlarmActions:       []string{"arn:aws:autoscaling:policy/up"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// PutMetricAlarm is idempotent, so the failure was retried.
+	if server.Count() != 2 {
+		t.Fatalf("sent %d requests", server.Count())
+	}
+	sent := server.Request(1)
+	for name, want := range map[string]string{
+		"Threshold":                "80.5",
+		"EvaluationPeriods":        "2",
+		"ActionsEnabled":           "true",
+		"Dimensions.member.1.Name": "AutoScalingGroupName",
+		"AlarmActions.member.1":    "arn:aws:autoscaling:policy/up",
+	} {
+		if got := sent.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, name := range []string{"Unit", "TreatMissingData", "OKActions.member.1"} {
+		if _, ok := sent[name]; ok {
+			t.Errorf("sent unset %s", name)
+		}
+	}
+}
+
+func TestDescribeAlarms(t *testing.T) {
+	client, server := newTestCloudWatch(t, map[string][]string{
+		"DescribeAlarms": {
+			`<MetricAlarms><member><AlarmName>cpu-high</AlarmName><StateValue>ALARM</StateValue>
+				<Threshold>80.5</Threshold><StateUpdatedTimestamp>2024-03-01T12:00:00.000Z</StateUpdatedTimestamp>
+				<AlarmActions><member>arn:up</member></AlarmActions></member></MetricAlarms>
+			<NextToken>t1</NextToken>`,
+			`<MetricAlarms><member><AlarmName>disk-high</AlarmName><StateValue>ALARM</StateValue></member></MetricAlarms>`,
+		},
+	})
+	alarms, err := client.DescribeAlarms(context.Background(), &DescribeAlarmsOptions{AlarmNamePrefix: "cpu-", StateValue: StateAlarm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alarms) != 2 || alarms[0].Threshold != 80.5 || alarms[0].AlarmActions[0] != "arn:up" ||
+		alarms[0].StateUpdatedTimestamp.IsZero() || alarms[1].AlarmName != "disk-high" {
+		t.Fatalf("got %+v", alarms)
+	}
+	first, second := server.Request(0), server.Request(1)
+	if first.Get("AlarmNamePrefix") != "cpu-" || first.Get("StateValue") != "ALARM" || second.Get("NextToken") != "t1" {
+		t.Fatalf("sent %v, then %v", first, second)
+	}
+
+	if _, err := client.DescribeAlarms(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteAlarms(t *testing.T) {
+	client, server := newTestCloudWatch(t, nil)
+	err := client.DeleteAlarms(context.Background(), "cpu-high", "disk-high")
+	if aws.ErrorCode(err) != "ValidationError" {
+		t.Fatalf("got %v", err)
+	}
+	if sent := server.Request(0); sent.Get("AlarmNames.member.2") != "disk-high" {
+		t.Fatalf("sent %v", sent)
+	}
+}
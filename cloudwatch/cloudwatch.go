@@ -0,0 +1,142 @@
+// Package cloudwatch interacts with Amazon CloudWatch metrics and alarms.
+package cloudwatch
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// APIVersion is the version of the CloudWatch API the package speaks.
+const APIVersion = "2010-08-01"
+
+// The CloudWatch type encapsulates operations with CloudWatch in a
+// region.
+type CloudWatch struct {
+	*aws.QueryClient
+}
+
+// New creates a new CloudWatch.
+func New(auth aws.Auth, region aws.Region) *CloudWatch {
+	client := aws.NewQueryClient(auth, region, "monitoring", APIVersion)
+	client.IdempotentActions = map[string]bool{
+		"PutMetricAlarm": true,
+	}
+	return &CloudWatch{client}
+}
+
+// Dimension qualifies a metric, such as InstanceId for EC2 metrics.
+type Dimension struct {
+	Name  string
+	Value string
+}
+
+func setDimensions(params url.Values, dimensions []Dimension) {
+	for i, dimension := range dimensions {
+		prefix := "Dimensions.member." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"Name", dimension.Name)
+		if dimension.Value != "" {
+			params.Set(prefix+"Value", dimension.Value)
+		}
+	}
+}
+
+// Metric identifies a metric.
+type Metric struct {
+	Namespace  string
+	MetricName string
+	Dimensions []Dimension `xml:"Dimensions>member"`
+}
+
+// ListMetrics lists the metrics of namespace named metricName having
+// dimensions; empty arguments match any. A dimension with an empty
+// value matches metrics having that dimension with any value.
+//
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_ListMetrics.html for details.
+func (self *CloudWatch) ListMetrics(ctx context.Context, namespace, metricName string, dimensions []Dimension) ([]Metric, error) {
+	params := url.Values{}
+	if namespace != "" {
+		params.Set("Namespace", namespace)
+	}
+	if metricName != "" {
+		params.Set("MetricName", metricName)
+	}
+	setDimensions(params, dimensions)
+	var resp struct {
+		Metrics   []Metric `xml:"ListMetricsResult>Metrics>member"`
+		NextToken string   `xml:"ListMetricsResult>NextToken"`
+	}
+	for {
+		resp.NextToken = ""
+		if err := self.Do(ctx, "ListMetrics", params, &resp); err != nil {
+			return nil, err
+		}
+		if resp.NextToken == "" {
+			return resp.Metrics, nil
+		}
+		params.Set("NextToken", resp.NextToken)
+	}
+}
+
+// Statistics of metrics.
+const (
+	StatisticAverage     = "Average"
+	StatisticSum         = "Sum"
+	StatisticMinimum     = "Minimum"
+	StatisticMaximum     = "Maximum"
+	StatisticSampleCount = "SampleCount"
+)
+
+// GetMetricStatisticsRequest selects the statistics GetMetricStatistics
+// returns.
+type GetMetricStatisticsRequest struct {
+	Namespace  string
+	MetricName string
+	Dimensions []Dimension
+	StartTime  time.Time
+	EndTime    time.Time
+	Period     int      // length of the datapoints in seconds, a multiple of 60
+	Statistics []string // StatisticAverage, ...
+	Unit       string   // optional
+}
+
+// Datapoint holds the statistics of a metric over a period starting at
+// Timestamp. Only the statistics asked for are set.
+type Datapoint struct {
+	Timestamp   time.Time
+	SampleCount float64
+	Average     float64
+	Sum         float64
+	Minimum     float64
+	Maximum     float64
+	Unit        string
+}
+
+// GetMetricStatistics returns the statistics of a metric, in no
+// particular order.
+//
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_GetMetricStatistics.html for details.
+func (self *CloudWatch) GetMetricStatistics(ctx context.Context, req *GetMetricStatisticsRequest) ([]Datapoint, error) {
+	params := url.Values{
+		"Namespace":  {req.Namespace},
+		"MetricName": {req.MetricName},
+		"StartTime":  {req.StartTime.UTC().Format(time.RFC3339)},
+		"EndTime":    {req.EndTime.UTC().Format(time.RFC3339)},
+		"Period":     {strconv.Itoa(req.Period)},
+	}
+	setDimensions(params, req.Dimensions)
+	aws.SetList(params, "Statistics.member", req.Statistics)
+	if req.Unit != "" {
+		params.Set("Unit", req.Unit)
+	}
+	var resp struct {
+		Datapoints []Datapoint `xml:"GetMetricStatisticsResult>Datapoints>member"`
+	}
+	if err := self.Do(ctx, "GetMetricStatistics", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Datapoints, nil
+}
@@ -0,0 +1,90 @@
+//go:build !goaws_stable
+
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/x/awstest"
+)
+
+func newTestCloudWatch(t *testing.T, responses map[string][]string) (*CloudWatch, *awstest.QueryServer) {
+	server := awstest.NewQueryServer(responses)
+	t.Cleanup(server.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.Region{Name: "us-east-1", SigV4Only: true})
+	client.Endpoint.URL = server.URL
+	client.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+	return client, server
+}
+
+func TestListMetrics(t *testing.T) {
+	client, server := newTestCloudWatch(t, map[string][]string{
+		"ListMetrics": {
+			`<Metrics><member><Namespace>AWS/EC2</Namespace><MetricName>CPUUtilization</MetricName>
+				<Dimensions><member><Name>InstanceId</Name><Value>i-1</Value></member></Dimensions></member></Metrics>
+			<NextToken>t1</NextToken>`,
+			`<Metrics><member><Namespace>AWS/EC2</Namespace><MetricName>CPUUtilization</MetricName>
+				<Dimensions><member><Name>InstanceId</Name><Value>i-2</Value></member></Dimensions></member></Metrics>`,
+		},
+	})
+	metrics, err := client.ListMetrics(context.Background(), "AWS/EC2", "", []Dimension{{Name: "InstanceId"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 2 || metrics[1].Dimensions[0] != (Dimension{"InstanceId", "i-2"}) {
+		t.Fatalf("got %+v", metrics)
+	}
+	first, second := server.Request(0), server.Request(1)
+	if first.Get("Namespace") != "AWS/EC2" || first.Get("Dimensions.member.1.Name") != "InstanceId" || second.Get("NextToken") != "t1" {
+		t.Fatalf("sent %v, then %v", first, second)
+	}
+	for _, name := range []string{"MetricName", "Dimensions.member.1.Value"} {
+		if _, ok := first[name]; ok {
+			t.Errorf("sent unset %s", name)
+		}
+	}
+}
+
+func TestGetMetricStatistics(t *testing.T) {
+	client, server := newTestCloudWatch(t, map[string][]string{
+		"GetMetricStatistics": {`<Label>CPUUtilization</Label><Datapoints>
+			<member><Timestamp>2024-03-01T12:00:00Z</Timestamp><Average>12.5</Average><Maximum>40</Maximum><Unit>Percent</Unit></member>
+			<member><Timestamp>2024-03-01T12:05:00Z</Timestamp><Average>7.25</Average><Maximum>9</Maximum><Unit>Percent</Unit></member>
+		</Datapoints>`},
+	})
+	start := time.Date(2024, 3, 1, 13, 0, 0, 0, time.FixedZone("CET", 3600))
+	datapoints, err := client.GetMetricStatistics(context.Background(), &GetMetricStatisticsRequest{
+		Namespace:  "AWS/EC2",
+		MetricName: "CPUUtilization",
+		Dimensions: []Dimension{{"InstanceId", "i-1"}},
+		StartTime:  start,
+		EndTime:    start.Add(10 * time.Minute),
+		Period:     300,
+		Statistics: []string{StatisticAverage, StatisticMaximum},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datapoints) != 2 || datapoints[1].Average != 7.25 || datapoints[0].Maximum != 40 ||
+		!datapoints[1].Timestamp.Equal(start.Add(5*time.Minute)) {
+		t.Fatalf("got %+v", datapoints)
+	}
+	sent := server.Request(0)
+	for name, want := range map[string]string{
+		"StartTime":                 "2024-03-01T12:00:00Z",
+		"EndTime":                   "2024-03-01T12:10:00Z",
+		"Period":                    "300",
+		"Statistics.member.2":       "Maximum",
+		"Dimensions.member.1.Value": "i-1",
+	} {
+		if got := sent.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, ok := sent["Unit"]; ok {
+		t.Errorf("sent unset Unit")
+	}
+}
@@ -0,0 +1,190 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/**
+ * Config gathers the settings clients are created with: where they
+ * send requests, how they retry and time out, and what signs them.
+ * Dump takes a snapshot of it without any secrets that can be attached
+ * to bug reports, and FromDump recreates it, e.g. to reproduce a
+ * problem in a test.
+ */
+type Config struct {
+	Region string // e.g. "eu-west-1"
+
+	// Endpoints overrides the endpoints of services by service name;
+	// other services are found by DefaultResolver.
+	Endpoints map[string]Endpoint
+
+	// Attempts is the strategy for retrying failed requests. If nil,
+	// each client uses its default.
+	Attempts *AttemptStrategy
+
+	// DialTimeout bounds connecting to a service and RequestTimeout
+	// every request, reading the response included. Zero means the
+	// defaults of the clients.
+	DialTimeout    time.Duration
+	RequestTimeout time.Duration
+
+	// Credentials sign the requests. They are never dumped.
+	Credentials *Credentials
+
+	// CredentialSource names the type of the provider of Credentials,
+	// such as "*aws.AssumeRoleProvider". Dump records it if Credentials
+	// is set; FromDump restores it but leaves Credentials nil.
+	CredentialSource string
+}
+
+/**
+ * configDumpVersion identifies the layout of configDump.
+ */
+const configDumpVersion = 1
+
+type configDump struct {
+	Version          int
+	Region           string
+	Endpoints        map[string]Endpoint
+	Attempts         *AttemptStrategy
+	DialTimeout      time.Duration
+	RequestTimeout   time.Duration
+	CredentialSource string
+}
+
+/**
+ * Dump returns a compact binary snapshot of the configuration. Secrets
+ * are left out: credentials are only identified by the type of their
+ * provider, and user information and signature parameters are removed
+ * from endpoint URLs.
+ */
+func (self *Config) Dump() ([]byte, error) {
+	dump := configDump{
+		Version:          configDumpVersion,
+		Region:           self.Region,
+		Attempts:         self.Attempts,
+		DialTimeout:      self.DialTimeout,
+		RequestTimeout:   self.RequestTimeout,
+		CredentialSource: self.CredentialSource,
+	}
+	if self.Credentials != nil {
+		dump.CredentialSource = self.Credentials.sourceType()
+	}
+	if len(self.Endpoints) > 0 {
+		dump.Endpoints = make(map[string]Endpoint, len(self.Endpoints))
+		for service, endpoint := range self.Endpoints {
+			endpoint.URL = sanitizeURL(endpoint.URL)
+			dump.Endpoints[service] = endpoint
+		}
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&dump); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+/**
+ * FromDump recreates the Config data was dumped from. Its Credentials
+ * are nil; set them to make requests.
+ */
+func FromDump(data []byte) (*Config, error) {
+	var dump configDump
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dump); err != nil {
+		return nil, fmt.Errorf("cannot read config dump: %v", err)
+	}
+	if dump.Version != configDumpVersion {
+		return nil, fmt.Errorf("unsupported config dump version %d", dump.Version)
+	}
+	return &Config{
+		Region:           dump.Region,
+		Endpoints:        dump.Endpoints,
+		Attempts:         dump.Attempts,
+		DialTimeout:      dump.DialTimeout,
+		RequestTimeout:   dump.RequestTimeout,
+		CredentialSource: dump.CredentialSource,
+	}, nil
+}
+
+/**
+ * Resolver returns the resolver of the endpoints of the configuration.
+ */
+func (self *Config) Resolver() EndpointResolver {
+	return &StaticResolver{Endpoints: self.Endpoints}
+}
+
+/**
+ * HTTPClient returns a client with the timeouts of the configuration,
+ * or nil if it sets none, for clients to use their default.
+ */
+func (self *Config) HTTPClient() *http.Client {
+	if self.DialTimeout == 0 && self.RequestTimeout == 0 {
+		return nil
+	}
+	dialTimeout := self.DialTimeout
+	if dialTimeout == 0 {
+		dialTimeout = 10 * time.Second
+	}
+	client := NewSingleTryClient(dialTimeout)
+	client.Timeout = self.RequestTimeout
+	return client
+}
+
+/**
+ * Configure makes the client send its requests as config says. The
+ * service specific settings of the client, such as IdempotentActions,
+ * are kept, so the client is best created by the service package first:
+ *
+ *	client := sqs.New(aws.Auth{}, aws.Region{})
+ *	err := client.Configure(config)
+ */
+func (self *QueryClient) Configure(config *Config) error {
+	endpoint, err := config.Resolver().ResolveEndpoint(self.Service, config.Region)
+	if err != nil {
+		return err
+	}
+	self.Endpoint = endpoint
+	// Every region accepts Signature Version 4.
+	self.SigV4Only = true
+	if config.Credentials != nil {
+		self.Credentials = config.Credentials
+	}
+	if config.Attempts != nil {
+		self.Attempts = config.Attempts
+	}
+	if client := config.HTTPClient(); client != nil {
+		self.Client = client
+	}
+	return nil
+}
+
+/**
+ * sourceType names the type of the provider of the credentials, or of
+ * the provider a chain took them from.
+ */
+func (self *Credentials) sourceType() string {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if chain, ok := self.provider.(*ChainProvider); ok && chain.current != nil {
+		return fmt.Sprintf("%T", chain.current)
+	}
+	return fmt.Sprintf("%T", self.provider)
+}
+
+/**
+ * sanitizeURL removes user information and credentials in the query from
+ * a URL.
+ */
+func sanitizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	return RedactURL(u)
+}
@@ -0,0 +1,316 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * Provider is a source of credentials, such as the environment, a
+ * shared credentials file or the instance metadata service.
+ */
+type Provider interface {
+	// Retrieve returns fresh credentials or an error if the provider has
+	// none to offer.
+	Retrieve() (Auth, error)
+
+	// IsExpired reports whether the credentials last retrieved are no
+	// longer valid and must be retrieved again.
+	IsExpired() bool
+}
+
+/**
+ * ContextProvider is a Provider that can stop retrieving credentials when
+ * a context is done, such as one calling a remote service.
+ */
+type ContextProvider interface {
+	Provider
+
+	// RetrieveWithContext is like Retrieve but aborts when ctx is done.
+	RetrieveWithContext(ctx context.Context) (Auth, error)
+}
+
+/**
+ * retrieve retrieves the credentials of provider, with ctx if the
+ * provider accepts one.
+ */
+func retrieve(ctx context.Context, provider Provider) (Auth, error) {
+	if p, ok := provider.(ContextProvider); ok {
+		return p.RetrieveWithContext(ctx)
+	}
+	return provider.Retrieve()
+}
+
+/**
+ * Credentials caches the credentials of a Provider and retrieves them
+ * again once they expire. It is safe for concurrent use.
+ */
+type Credentials struct {
+	provider Provider
+	mu       sync.Mutex
+	auth     Auth
+	valid    bool
+}
+
+/**
+ * NewCredentials returns Credentials backed by provider.
+ */
+func NewCredentials(provider Provider) *Credentials {
+	return &Credentials{provider: provider}
+}
+
+/**
+ * NewChainCredentials returns Credentials backed by the first of
+ * providers that has credentials to offer.
+ */
+func NewChainCredentials(providers ...Provider) *Credentials {
+	return NewCredentials(&ChainProvider{Providers: providers})
+}
+
+/**
+ * Get returns the cached credentials, retrieving them first if they have
+ * not been retrieved yet or have expired.
+ */
+func (self *Credentials) Get() (Auth, error) {
+	return self.GetWithContext(context.Background())
+}
+
+/**
+ * GetWithContext is like Get, but a retrieval by a ContextProvider aborts
+ * when ctx is done.
+ */
+func (self *Credentials) GetWithContext(ctx context.Context) (Auth, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if !self.valid || self.provider.IsExpired() {
+		auth, err := retrieve(ctx, self.provider)
+		if err != nil {
+			return Auth{}, err
+		}
+		self.auth = auth
+		self.valid = true
+	}
+	return self.auth, nil
+}
+
+/**
+ * Expire forces the credentials to be retrieved again on the next Get,
+ * e.g. after a request was rejected because of them.
+ */
+func (self *Credentials) Expire() {
+	self.mu.Lock()
+	self.valid = false
+	self.mu.Unlock()
+}
+
+/**
+ * NewInstanceCredentials returns Credentials for the IAM role of the EC2
+ * instance the process runs on. Unlike the Auth returned by GetAuth they
+ * are fetched again from the instance metadata before they expire, so
+ * clients using them keep working past the lifetime of a single set of
+ * role credentials.
+ */
+func NewInstanceCredentials() *Credentials {
+	return NewCredentials(&InstanceRoleProvider{})
+}
+
+/**
+ * StaticProvider provides fixed credentials that never expire.
+ */
+type StaticProvider struct {
+	Auth
+}
+
+func (self *StaticProvider) Retrieve() (Auth, error) {
+	if self.AccessKey == "" || self.SecretKey == "" {
+		return Auth{}, errors.New("static credentials are empty")
+	}
+	return self.Auth, nil
+}
+
+func (self *StaticProvider) IsExpired() bool {
+	return false
+}
+
+/**
+ * EnvProvider provides credentials from the AWS_ACCESS_KEY_ID,
+ * AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables
+ * (see EnvAuth).
+ */
+type EnvProvider struct {
+	retrieved bool
+}
+
+func (self *EnvProvider) Retrieve() (Auth, error) {
+	auth, err := EnvAuth()
+	if err != nil {
+		return Auth{}, err
+	}
+	auth.Token = os.Getenv("AWS_SESSION_TOKEN")
+	self.retrieved = true
+	return auth, nil
+}
+
+func (self *EnvProvider) IsExpired() bool {
+	return !self.retrieved
+}
+
+/**
+ * SharedCredentialsProvider provides the credentials of a profile in the
+ * shared credentials file written by the AWS CLI.
+ */
+type SharedCredentialsProvider struct {
+	// Filename defaults to $AWS_SHARED_CREDENTIALS_FILE or else
+	// ~/.aws/credentials.
+	Filename string
+
+	// Profile defaults to $AWS_PROFILE or else "default".
+	Profile string
+
+	retrieved bool
+}
+
+func (self *SharedCredentialsProvider) Retrieve() (Auth, error) {
+	filename := self.Filename
+	if filename == "" {
+		filename = os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	}
+	if filename == "" {
+		filename = filepath.Join(homeDir(), ".aws", "credentials")
+	}
+	profile := self.Profile
+	if profile == "" {
+		profile = defaultProfile()
+	}
+
+	sections, err := parseINIFile(filename)
+	if err != nil {
+		return Auth{}, err
+	}
+	section, ok := sections[profile]
+	if !ok {
+		return Auth{}, errors.New("profile " + profile + " not found in " + filename)
+	}
+	auth := Auth{
+		AccessKey: section["aws_access_key_id"],
+		SecretKey: section["aws_secret_access_key"],
+		Token:     section["aws_session_token"],
+	}
+	if auth.AccessKey == "" || auth.SecretKey == "" {
+		return Auth{}, errors.New("profile " + profile + " in " + filename + " has no credentials")
+	}
+	self.retrieved = true
+	return auth, nil
+}
+
+func (self *SharedCredentialsProvider) IsExpired() bool {
+	return !self.retrieved
+}
+
+/**
+ * InstanceRoleProvider provides the credentials of the IAM role of the
+ * EC2 instance the process runs on, from the instance metadata service.
+ * The credentials are considered expired shortly before their Expiration.
+ */
+type InstanceRoleProvider struct {
+	// ExpiryWindow is how long before their expiration credentials are
+	// retrieved again. Defaults to five minutes.
+	ExpiryWindow time.Duration
+
+	expires time.Time
+}
+
+func (self *InstanceRoleProvider) Retrieve() (Auth, error) {
+	cred, err := getInstanceCredentials()
+	if err != nil {
+		return Auth{}, err
+	}
+	if cred.AccessKeyId == "" {
+		return Auth{}, errors.New("instance metadata has no role credentials")
+	}
+	self.expires, _ = time.Parse(time.RFC3339, cred.Expiration)
+	return Auth{cred.AccessKeyId, cred.SecretAccessKey, cred.Token}, nil
+}
+
+func (self *InstanceRoleProvider) IsExpired() bool {
+	window := self.ExpiryWindow
+	if window == 0 {
+		window = 5 * time.Minute
+	}
+	return self.expires.IsZero() || time.Now().Add(window).After(self.expires)
+}
+
+/**
+ * ChainProvider tries its providers in order and uses the first one that
+ * returns credentials, until those expire.
+ */
+type ChainProvider struct {
+	Providers []Provider
+	current   Provider
+}
+
+/**
+ * ErrNoCredentials is returned by a ChainProvider none of whose providers
+ * has credentials.
+ */
+var ErrNoCredentials = errors.New("No valid AWS authentication found")
+
+func (self *ChainProvider) Retrieve() (Auth, error) {
+	return self.RetrieveWithContext(context.Background())
+}
+
+func (self *ChainProvider) RetrieveWithContext(ctx context.Context) (Auth, error) {
+	var failures []string
+	for _, provider := range self.Providers {
+		auth, err := retrieve(ctx, provider)
+		if err == nil {
+			self.current = provider
+			return auth, nil
+		}
+		failures = append(failures, err.Error())
+	}
+	self.current = nil
+	if len(failures) == 0 {
+		return Auth{}, ErrNoCredentials
+	}
+	return Auth{}, errors.New(ErrNoCredentials.Error() + ": " + strings.Join(failures, "; "))
+}
+
+func (self *ChainProvider) IsExpired() bool {
+	return self.current == nil || self.current.IsExpired()
+}
+
+/**
+ * DefaultProviders returns the providers consulted by GetAuth, in order:
+ * the environment, the shared credentials file and the instance role.
+ */
+func DefaultProviders() []Provider {
+	return []Provider{
+		&EnvProvider{},
+		&SharedCredentialsProvider{},
+		&InstanceRoleProvider{},
+	}
+}
+
+func homeDir() string {
+	if home := os.Getenv("HOME"); home != "" {
+		return home
+	}
+	return os.Getenv("USERPROFILE")
+}
+
+func defaultProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
@@ -0,0 +1,92 @@
+package aws
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+/**
+ * DiagnosticsSnapshot reports the resources currently held by the library,
+ * to help track down connection and goroutine leaks.
+ */
+type DiagnosticsSnapshot struct {
+	OpenConnections  int64 // connections dialed by clients from NewClient and not yet closed
+	InFlightRequests int64 // requests sent whose response body has not been closed
+	Goroutines       int64 // background goroutines started by the library
+	Retries          int64 // requests retried since the process started
+}
+
+var diagnostics struct {
+	openConnections  int64
+	inFlightRequests int64
+	goroutines       int64
+	retries          int64
+}
+
+/**
+ * Diagnostics returns a snapshot of the library's resource counters.
+ */
+func Diagnostics() DiagnosticsSnapshot {
+	return DiagnosticsSnapshot{
+		OpenConnections:  atomic.LoadInt64(&diagnostics.openConnections),
+		InFlightRequests: atomic.LoadInt64(&diagnostics.inFlightRequests),
+		Goroutines:       atomic.LoadInt64(&diagnostics.goroutines),
+		Retries:          atomic.LoadInt64(&diagnostics.retries),
+	}
+}
+
+/**
+ * TrackRequest counts a request as in flight until the returned function
+ * is called. The function may be called more than once. It is meant for
+ * the service packages of this library.
+ */
+func TrackRequest() (done func()) {
+	atomic.AddInt64(&diagnostics.inFlightRequests, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt64(&diagnostics.inFlightRequests, -1)
+		})
+	}
+}
+
+/**
+ * TrackRetry counts a retried request. It is meant for the service
+ * packages of this library.
+ */
+func TrackRetry() {
+	atomic.AddInt64(&diagnostics.retries, 1)
+}
+
+/**
+ * Go runs fn in a new goroutine that is counted in Diagnostics until fn
+ * returns. It is meant for the service packages of this library.
+ */
+func Go(fn func()) {
+	atomic.AddInt64(&diagnostics.goroutines, 1)
+	go func() {
+		defer atomic.AddInt64(&diagnostics.goroutines, -1)
+		fn()
+	}()
+}
+
+/**
+ * trackedConn counts itself as an open connection until closed.
+ */
+type trackedConn struct {
+	net.Conn
+	once sync.Once
+}
+
+func newTrackedConn(c net.Conn) net.Conn {
+	atomic.AddInt64(&diagnostics.openConnections, 1)
+	return &trackedConn{Conn: c}
+}
+
+func (self *trackedConn) Close() error {
+	self.once.Do(func() {
+		atomic.AddInt64(&diagnostics.openConnections, -1)
+	})
+	return self.Conn.Close()
+}
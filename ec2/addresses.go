@@ -0,0 +1,125 @@
+package ec2
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/dkln/go-aws"
+)
+
+// Address is an Elastic IP address. Domain is "standard" for
+// EC2-Classic addresses, which are identified by PublicIp, and "vpc" for
+// VPC addresses, which are identified by AllocationId.
+type Address struct {
+	PublicIp           string `xml:"publicIp"`
+	AllocationId       string `xml:"allocationId"`
+	Domain             string `xml:"domain"`
+	InstanceId         string `xml:"instanceId"`
+	AssociationId      string `xml:"associationId"`
+	NetworkInterfaceId string `xml:"networkInterfaceId"`
+	PrivateIpAddress   string `xml:"privateIpAddress"`
+}
+
+// AllocateAddress allocates an Elastic IP address, for use in a VPC if
+// vpc is set and in EC2-Classic otherwise.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_AllocateAddress.html for details.
+func (self *EC2) AllocateAddress(ctx context.Context, vpc bool) (*Address, error) {
+	params := url.Values{}
+	if vpc {
+		params.Set("Domain", "vpc")
+	}
+	address := &Address{}
+	if err := self.Do(ctx, "AllocateAddress", params, address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// AssociateAddressOptions describe the association of an Elastic IP
+// address: PublicIp for EC2-Classic or AllocationId for a VPC, with
+// InstanceId or, in a VPC, NetworkInterfaceId.
+type AssociateAddressOptions struct {
+	PublicIp           string
+	AllocationId       string
+	InstanceId         string
+	NetworkInterfaceId string
+	PrivateIpAddress   string // of the network interface, if not its primary one
+	AllowReassociation bool   // take the address over if it is associated already
+}
+
+// AssociateAddress associates an Elastic IP address with an instance or
+// network interface and returns the ID of the association in a VPC.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_AssociateAddress.html for details.
+func (self *EC2) AssociateAddress(ctx context.Context, options *AssociateAddressOptions) (string, error) {
+	params := url.Values{}
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	set("PublicIp", options.PublicIp)
+	set("AllocationId", options.AllocationId)
+	set("InstanceId", options.InstanceId)
+	set("NetworkInterfaceId", options.NetworkInterfaceId)
+	set("PrivateIpAddress", options.PrivateIpAddress)
+	if options.AllowReassociation {
+		params.Set("AllowReassociation", "true")
+	}
+	var resp struct {
+		AssociationId string `xml:"associationId"`
+	}
+	if err := self.Do(ctx, "AssociateAddress", params, &resp); err != nil {
+		return "", err
+	}
+	return resp.AssociationId, nil
+}
+
+// DisassociateAddress disassociates an Elastic IP address, identified by
+// publicIp in EC2-Classic or associationId in a VPC, from its instance
+// or network interface.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DisassociateAddress.html for details.
+func (self *EC2) DisassociateAddress(ctx context.Context, publicIp, associationId string) error {
+	params := url.Values{}
+	if associationId != "" {
+		params.Set("AssociationId", associationId)
+	} else {
+		params.Set("PublicIp", publicIp)
+	}
+	return self.Do(ctx, "DisassociateAddress", params, nil)
+}
+
+// ReleaseAddress releases an Elastic IP address, identified by publicIp
+// in EC2-Classic or allocationId in a VPC.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_ReleaseAddress.html for details.
+func (self *EC2) ReleaseAddress(ctx context.Context, publicIp, allocationId string) error {
+	params := url.Values{}
+	if allocationId != "" {
+		params.Set("AllocationId", allocationId)
+	} else {
+		params.Set("PublicIp", publicIp)
+	}
+	return self.Do(ctx, "ReleaseAddress", params, nil)
+}
+
+// DescribeAddresses describes the Elastic IP addresses with the given
+// public IPs (EC2-Classic) and allocation IDs (VPC), or all of them if
+// there are none, that match filter, which may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeAddresses.html for details.
+func (self *EC2) DescribeAddresses(ctx context.Context, publicIps, allocationIds []string, filter *Filter) ([]Address, error) {
+	params := url.Values{}
+	aws.SetList(params, "PublicIp", publicIps)
+	aws.SetList(params, "AllocationId", allocationIds)
+	filter.addParams(params)
+	var resp struct {
+		Addresses []Address `xml:"addressesSet>item"`
+	}
+	if err := self.Do(ctx, "DescribeAddresses", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Addresses, nil
+}
@@ -0,0 +1,132 @@
+//go:build !goaws_stable
+
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestAllocateAddress(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"AllocateAddress": {
+			"<publicIp>198.51.100.1</publicIp><domain>vpc</domain><allocationId>eipalloc-1</allocationId>",
+			"<publicIp>198.51.100.2</publicIp><domain>standard</domain>",
+		},
+	})
+	ctx := context.Background()
+
+	address, err := client.AllocateAddress(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.Request(0).Get("Domain") != "vpc" {
+		t.Errorf("unexpected params %v", server.Request(0))
+	}
+	if *address != (Address{PublicIp: "198.51.100.1", Domain: "vpc", AllocationId: "eipalloc-1"}) {
+		t.Fatalf("unexpected address %+v", address)
+	}
+
+	if address, err = client.AllocateAddress(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Request(1)["Domain"]; ok {
+		t.Errorf("Domain sent for an EC2-Classic address")
+	}
+	if address.PublicIp != "198.51.100.2" || address.Domain != "standard" {
+		t.Fatalf("unexpected address %+v", address)
+	}
+}
+
+func TestAssociateAddress(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"AssociateAddress":    {"<return>true</return><associationId>eipassoc-1</associationId>"},
+		"DisassociateAddress": {"<return>true</return>"},
+	})
+	ctx := context.Background()
+
+	id, err := client.AssociateAddress(ctx, &AssociateAddressOptions{
+		AllocationId:       "eipalloc-1",
+		NetworkInterfaceId: "eni-1",
+		PrivateIpAddress:   "10.0.0.5",
+		AllowReassociation: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "eipassoc-1" {
+		t.Fatalf("got association %q", id)
+	}
+	want := url.Values{
+		"Action":             {"AssociateAddress"},
+		"AllocationId":       {"eipalloc-1"},
+		"NetworkInterfaceId": {"eni-1"},
+		"PrivateIpAddress":   {"10.0.0.5"},
+		"AllowReassociation": {"true"},
+	}
+	got := server.Request(0)
+	checkParams(t, got, want)
+	for _, name := range []string{"PublicIp", "InstanceId"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("unexpected %s=%q", name, got.Get(name))
+		}
+	}
+
+	// A VPC address is disassociated by its association, a classic one
+	// by its IP.
+	if err := client.DisassociateAddress(ctx, "198.51.100.1", "eipassoc-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DisassociateAddress(ctx, "198.51.100.2", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.Request(1); got.Get("AssociationId") != "eipassoc-1" || got.Get("PublicIp") != "" {
+		t.Errorf("unexpected params %v", got)
+	}
+	if got := server.Request(2); got.Get("PublicIp") != "198.51.100.2" || got.Get("AssociationId") != "" {
+		t.Errorf("unexpected params %v", got)
+	}
+}
+
+func TestReleaseAddress(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{"ReleaseAddress": {"<return>true</return>"}})
+	ctx := context.Background()
+
+	if err := client.ReleaseAddress(ctx, "198.51.100.1", "eipalloc-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ReleaseAddress(ctx, "198.51.100.2", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.Request(0); got.Get("AllocationId") != "eipalloc-1" || got.Get("PublicIp") != "" {
+		t.Errorf("unexpected params %v", got)
+	}
+	if got := server.Request(1); got.Get("PublicIp") != "198.51.100.2" || got.Get("AllocationId") != "" {
+		t.Errorf("unexpected params %v", got)
+	}
+}
+
+func TestDescribeAddresses(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeAddresses": {`<addressesSet>
+			<item><publicIp>198.51.100.1</publicIp><allocationId>eipalloc-1</allocationId><domain>vpc</domain>
+				<instanceId>i-1</instanceId><associationId>eipassoc-1</associationId><networkInterfaceId>eni-1</networkInterfaceId><privateIpAddress>10.0.0.5</privateIpAddress></item>
+			<item><publicIp>198.51.100.2</publicIp><domain>standard</domain></item>
+		</addressesSet>`},
+	})
+
+	addresses, err := client.DescribeAddresses(context.Background(), []string{"198.51.100.2"}, []string{"eipalloc-1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(0), url.Values{"PublicIp.1": {"198.51.100.2"}, "AllocationId.1": {"eipalloc-1"}})
+	want := []Address{
+		{"198.51.100.1", "eipalloc-1", "vpc", "i-1", "eipassoc-1", "eni-1", "10.0.0.5"},
+		{PublicIp: "198.51.100.2", Domain: "standard"},
+	}
+	if !reflect.DeepEqual(addresses, want) {
+		t.Fatalf("got %+v", addresses)
+	}
+}
@@ -0,0 +1,119 @@
+// Package ec2 interacts with the Amazon Elastic Compute Cloud.
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/dkln/go-aws"
+)
+
+// APIVersion is the version of the EC2 API the package speaks.
+const APIVersion = "2016-11-15"
+
+// The EC2 type encapsulates operations with EC2 in a region.
+type EC2 struct {
+	*aws.QueryClient
+}
+
+// New creates a new EC2.
+func New(auth aws.Auth, region aws.Region) *EC2 {
+	client := aws.NewQueryClient(auth, region, "ec2", APIVersion)
+	client.IdempotentActions = map[string]bool{
+		"StartInstances":          true,
+		"StopInstances":           true,
+		"TerminateInstances":      true,
+		"CreateTags":              true,
+		"DeleteTags":              true,
+		"ModifyInstanceAttribute": true,
+		"ModifyImageAttribute":    true,
+	}
+	return &EC2{client}
+}
+
+// Filter narrows down the results of Describe operations. Resources
+// match if, for every name, they match any of its values.
+type Filter struct {
+	m map[string][]string
+}
+
+// NewFilter returns an empty filter.
+func NewFilter() *Filter {
+	return &Filter{map[string][]string{}}
+}
+
+// Add adds values to the filter named name, such as
+// "instance-state-name" or "tag:Name".
+func (self *Filter) Add(name string, values ...string) *Filter {
+	self.m[name] = append(self.m[name], values...)
+	return self
+}
+
+// Tag adds values to the filter matching the value of the tag with the
+// given key.
+func (self *Filter) Tag(key string, values ...string) *Filter {
+	return self.Add("tag:"+key, values...)
+}
+
+// TagKey adds keys to the filter matching resources with a tag of that
+// key, whatever its value.
+func (self *Filter) TagKey(keys ...string) *Filter {
+	return self.Add("tag-key", keys...)
+}
+
+// VpcId adds VPC IDs to the filter matching resources of those VPCs.
+func (self *Filter) VpcId(vpcIds ...string) *Filter {
+	return self.Add("vpc-id", vpcIds...)
+}
+
+// AvailabilityZone adds zones to the filter matching resources in those
+// availability zones.
+func (self *Filter) AvailabilityZone(zones ...string) *Filter {
+	return self.Add("availability-zone", zones...)
+}
+
+func (self *Filter) addParams(params url.Values) {
+	if self == nil {
+		return
+	}
+	names := make([]string, 0, len(self.m))
+	for name := range self.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		prefix := "Filter." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"Name", name)
+		aws.SetList(params, prefix+"Value", self.m[name])
+	}
+}
+
+// describe calls a Describe action for every page of its results and
+// decodes them all into resp, whose slices accumulate the items of the
+// pages. nextToken points to the NextToken field of resp.
+func (self *EC2) describe(ctx context.Context, action string, params url.Values, resp interface{}, nextToken *string) error {
+	for {
+		*nextToken = ""
+		if err := self.Do(ctx, action, params, resp); err != nil {
+			return err
+		}
+		if *nextToken == "" {
+			return nil
+		}
+		params.Set("NextToken", *nextToken)
+	}
+}
+
+// Tag is a key/value pair attached to a resource.
+type Tag struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+// SimpleResp is the response of operations returning nothing else.
+type SimpleResp struct {
+	RequestId string `xml:"requestId"`
+	Return    bool   `xml:"return"`
+}
@@ -0,0 +1,119 @@
+//go:build !goaws_stable
+
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/x/awstest"
+)
+
+func newTestEC2(t *testing.T, responses map[string][]string) (*EC2, *awstest.QueryServer) {
+	server := awstest.NewQueryServer(responses)
+	server.EC2 = true
+	t.Cleanup(server.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.USEast)
+	client.Endpoint.URL = server.URL
+	client.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+	return client, server
+}
+
+// checkParams reports the parameters of want that got lacks or has
+// different values for.
+func checkParams(t *testing.T, got, want url.Values) {
+	t.Helper()
+	for name, value := range want {
+		if !reflect.DeepEqual(got[name], value) {
+			t.Errorf("%s: got %q, want %q", name, got[name], value)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	params := url.Values{}
+	NewFilter().
+		Tag("Name", "web", "api").
+		VpcId("vpc-1").
+		TagKey("Env").
+		AvailabilityZone("us-east-1a").
+		Add("instance-state-name", "running").
+		Add("instance-state-name", "pending").
+		addParams(params)
+	want := url.Values{
+		"Filter.1.Name":    {"availability-zone"},
+		"Filter.1.Value.1": {"us-east-1a"},
+		"Filter.2.Name":    {"instance-state-name"},
+		"Filter.2.Value.1": {"running"},
+		"Filter.2.Value.2": {"pending"},
+		"Filter.3.Name":    {"tag-key"},
+		"Filter.3.Value.1": {"Env"},
+		"Filter.4.Name":    {"tag:Name"},
+		"Filter.4.Value.1": {"web"},
+		"Filter.4.Value.2": {"api"},
+		"Filter.5.Name":    {"vpc-id"},
+		"Filter.5.Value.1": {"vpc-1"},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Fatalf("got %v, want %v", params, want)
+	}
+
+	// A nil filter adds nothing.
+	params = url.Values{}
+	(*Filter)(nil).addParams(params)
+	if len(params) != 0 {
+		t.Fatalf("nil filter added %v", params)
+	}
+}
+
+func TestDescribeAllInstancesFollowsNextToken(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeInstances": {
+			"<reservationSet><item><instancesSet><item><instanceId>i-1</instanceId></item><item><instanceId>i-2</instanceId></item></instancesSet></item></reservationSet><nextToken>t1</nextToken>",
+			"<reservationSet><item><instancesSet><item><instanceId>i-3</instanceId></item></instancesSet></item></reservationSet><nextToken>t2</nextToken>",
+			"<reservationSet/>",
+		},
+	})
+
+	instances, err := client.DescribeAllInstances(context.Background(), nil, NewFilter().Tag("Name", "web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, instance := range instances {
+		ids = append(ids, instance.InstanceId)
+	}
+	if !reflect.DeepEqual(ids, []string{"i-1", "i-2", "i-3"}) {
+		t.Fatalf("got instances %v", ids)
+	}
+	if server.Count() != 3 {
+		t.Fatalf("got %d requests, want 3", server.Count())
+	}
+	for i, token := range []string{"", "t1", "t2"} {
+		got := server.Request(i)
+		if got.Get("NextToken") != token || got.Get("Filter.1.Name") != "tag:Name" || got.Get("Filter.1.Value.1") != "web" {
+			t.Errorf("request %d: unexpected params %v", i, got)
+		}
+	}
+}
+
+func TestDescribeAllStopsOnError(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeVolumes": {
+			"<volumeSet><item><volumeId>vol-1</volumeId></item></volumeSet><nextToken>t1</nextToken>",
+			"<volumeSet><item><volumeId>",
+		},
+	})
+
+	volumes, err := client.DescribeVolumes(context.Background(), nil, nil)
+	if err == nil {
+		t.Fatalf("got %+v, want the error of the second page", volumes)
+	}
+	if volumes != nil || server.Count() != 2 {
+		t.Fatalf("got %+v after %d requests", volumes, server.Count())
+	}
+}
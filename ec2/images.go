@@ -0,0 +1,193 @@
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/dkln/go-aws"
+)
+
+// Image is an Amazon Machine Image (AMI).
+type Image struct {
+	ImageId             string               `xml:"imageId"`
+	ImageLocation       string               `xml:"imageLocation"`
+	State               string               `xml:"imageState"` // "pending", "available", "failed", ...
+	OwnerId             string               `xml:"imageOwnerId"`
+	Public              bool                 `xml:"isPublic"`
+	Architecture        string               `xml:"architecture"`
+	ImageType           string               `xml:"imageType"`
+	Name                string               `xml:"name"`
+	Description         string               `xml:"description"`
+	CreationDate        string               `xml:"creationDate"`
+	RootDeviceType      string               `xml:"rootDeviceType"`
+	RootDeviceName      string               `xml:"rootDeviceName"`
+	VirtualizationType  string               `xml:"virtualizationType"`
+	BlockDeviceMappings []BlockDeviceMapping `xml:"blockDeviceMapping>item"`
+	Tags                []Tag                `xml:"tagSet>item"`
+}
+
+// BlockDeviceMapping maps a device of an image or instance to an EBS
+// volume or an instance store.
+type BlockDeviceMapping struct {
+	DeviceName          string `xml:"deviceName"`
+	VirtualName         string `xml:"virtualName"` // "ephemeral0", ... for instance stores
+	SnapshotId          string `xml:"ebs>snapshotId"`
+	VolumeSize          int    `xml:"ebs>volumeSize"` // in GiB
+	VolumeType          string `xml:"ebs>volumeType"`
+	DeleteOnTermination bool   `xml:"ebs>deleteOnTermination"`
+}
+
+func setBlockDeviceMappings(params url.Values, mappings []BlockDeviceMapping) {
+	for i, mapping := range mappings {
+		prefix := "BlockDeviceMapping." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"DeviceName", mapping.DeviceName)
+		if mapping.VirtualName != "" {
+			params.Set(prefix+"VirtualName", mapping.VirtualName)
+			continue
+		}
+		if mapping.SnapshotId != "" {
+			params.Set(prefix+"Ebs.SnapshotId", mapping.SnapshotId)
+		}
+		if mapping.VolumeSize != 0 {
+			params.Set(prefix+"Ebs.VolumeSize", strconv.Itoa(mapping.VolumeSize))
+		}
+		if mapping.VolumeType != "" {
+			params.Set(prefix+"Ebs.VolumeType", mapping.VolumeType)
+		}
+		params.Set(prefix+"Ebs.DeleteOnTermination", strconv.FormatBool(mapping.DeleteOnTermination))
+	}
+}
+
+// CreateImage creates an EBS backed image of the instance with the given
+// ID and returns its ID. Unless noReboot is set, the instance is
+// rebooted to get a consistent file system.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateImage.html for details.
+func (self *EC2) CreateImage(ctx context.Context, instanceId, name, description string, noReboot bool) (string, error) {
+	params := url.Values{
+		"InstanceId": {instanceId},
+		"Name":       {name},
+	}
+	if description != "" {
+		params.Set("Description", description)
+	}
+	if noReboot {
+		params.Set("NoReboot", "true")
+	}
+	var resp struct {
+		ImageId string `xml:"imageId"`
+	}
+	if err := self.Do(ctx, "CreateImage", params, &resp); err != nil {
+		return "", err
+	}
+	return resp.ImageId, nil
+}
+
+// RegisterImageOptions describe an image to register, either from an
+// S3 manifest (ImageLocation) or from EBS snapshots (RootDeviceName and
+// BlockDeviceMappings).
+type RegisterImageOptions struct {
+	Name                string
+	Description         string
+	ImageLocation       string
+	Architecture        string // "x86_64", "arm64", ...
+	RootDeviceName      string // such as "/dev/xvda"
+	VirtualizationType  string // "hvm" or "paravirtual"
+	EnaSupport          bool
+	BlockDeviceMappings []BlockDeviceMapping
+}
+
+// RegisterImage registers an image and returns its ID.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_RegisterImage.html for details.
+func (self *EC2) RegisterImage(ctx context.Context, options *RegisterImageOptions) (string, error) {
+	params := url.Values{"Name": {options.Name}}
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	set("Description", options.Description)
+	set("ImageLocation", options.ImageLocation)
+	set("Architecture", options.Architecture)
+	set("RootDeviceName", options.RootDeviceName)
+	set("VirtualizationType", options.VirtualizationType)
+	if options.EnaSupport {
+		params.Set("EnaSupport", "true")
+	}
+	setBlockDeviceMappings(params, options.BlockDeviceMappings)
+	var resp struct {
+		ImageId string `xml:"imageId"`
+	}
+	if err := self.Do(ctx, "RegisterImage", params, &resp); err != nil {
+		return "", err
+	}
+	return resp.ImageId, nil
+}
+
+// DeregisterImage deregisters the image with the given ID. Its snapshots
+// are kept.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeregisterImage.html for details.
+func (self *EC2) DeregisterImage(ctx context.Context, imageId string) error {
+	return self.Do(ctx, "DeregisterImage", url.Values{"ImageId": {imageId}}, nil)
+}
+
+// DescribeImages describes the images with the given IDs, or all images
+// available to the account if there are none, owned by owners ("self",
+// "amazon" or account IDs) if given, that match filter, which may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeImages.html for details.
+func (self *EC2) DescribeImages(ctx context.Context, imageIds, owners []string, filter *Filter) ([]Image, error) {
+	params := url.Values{}
+	aws.SetList(params, "ImageId", imageIds)
+	aws.SetList(params, "Owner", owners)
+	filter.addParams(params)
+	var resp struct {
+		Images    []Image `xml:"imagesSet>item"`
+		NextToken string  `xml:"nextToken"`
+	}
+	if err := self.describe(ctx, "DescribeImages", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.Images, nil
+}
+
+// LaunchPermission grants the right to launch an image to an account,
+// or to everyone with Group "all".
+type LaunchPermission struct {
+	UserId string
+	Group  string
+}
+
+// ModifyLaunchPermissions grants the launch permissions add and revokes
+// the launch permissions remove of the image with the given ID.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_ModifyImageAttribute.html for details.
+func (self *EC2) ModifyLaunchPermissions(ctx context.Context, imageId string, add, remove []LaunchPermission) error {
+	params := url.Values{"ImageId": {imageId}}
+	set := func(prefix string, permissions []LaunchPermission) {
+		for i, permission := range permissions {
+			n := prefix + strconv.Itoa(i+1) + "."
+			if permission.Group != "" {
+				params.Set(n+"Group", permission.Group)
+			} else {
+				params.Set(n+"UserId", permission.UserId)
+			}
+		}
+	}
+	set("LaunchPermission.Add.", add)
+	set("LaunchPermission.Remove.", remove)
+	return self.Do(ctx, "ModifyImageAttribute", params, nil)
+}
+
+// ModifyImageDescription changes the description of the image with the
+// given ID.
+func (self *EC2) ModifyImageDescription(ctx context.Context, imageId, description string) error {
+	params := url.Values{
+		"ImageId":           {imageId},
+		"Description.Value": {description},
+	}
+	return self.Do(ctx, "ModifyImageAttribute", params, nil)
+}
@@ -0,0 +1,137 @@
+//go:build !goaws_stable
+
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCreateImage(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{"CreateImage": {"<imageId>ami-1</imageId>"}})
+
+	id, err := client.CreateImage(context.Background(), "i-1", "web", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "ami-1" {
+		t.Fatalf("got image %q", id)
+	}
+	got := server.Request(0)
+	checkParams(t, got, url.Values{"InstanceId": {"i-1"}, "Name": {"web"}, "NoReboot": {"true"}})
+	if _, ok := got["Description"]; ok {
+		t.Errorf("empty Description sent")
+	}
+}
+
+func TestRegisterImage(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{"RegisterImage": {"<imageId>ami-2</imageId>"}})
+
+	id, err := client.RegisterImage(context.Background(), &RegisterImageOptions{
+		Name:               "web",
+		Architecture:       "arm64",
+		RootDeviceName:     "/dev/xvda",
+		VirtualizationType: "hvm",
+		EnaSupport:         true,
+		BlockDeviceMappings: []BlockDeviceMapping{
+			{DeviceName: "/dev/xvda", SnapshotId: "snap-1", VolumeSize: 8, VolumeType: "gp3", DeleteOnTermination: true},
+			{DeviceName: "/dev/sdb", VirtualName: "ephemeral0"},
+			{DeviceName: "/dev/sdc", VolumeSize: 100},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "ami-2" {
+		t.Fatalf("got image %q", id)
+	}
+	got := server.Request(0)
+	checkParams(t, got, url.Values{
+		"Name":                                         {"web"},
+		"Architecture":                                 {"arm64"},
+		"RootDeviceName":                               {"/dev/xvda"},
+		"VirtualizationType":                           {"hvm"},
+		"EnaSupport":                                   {"true"},
+		"BlockDeviceMapping.1.DeviceName":              {"/dev/xvda"},
+		"BlockDeviceMapping.1.Ebs.SnapshotId":          {"snap-1"},
+		"BlockDeviceMapping.1.Ebs.VolumeSize":          {"8"},
+		"BlockDeviceMapping.1.Ebs.VolumeType":          {"gp3"},
+		"BlockDeviceMapping.1.Ebs.DeleteOnTermination": {"true"},
+		"BlockDeviceMapping.2.DeviceName":              {"/dev/sdb"},
+		"BlockDeviceMapping.2.VirtualName":             {"ephemeral0"},
+		"BlockDeviceMapping.3.DeviceName":              {"/dev/sdc"},
+		"BlockDeviceMapping.3.Ebs.VolumeSize":          {"100"},
+		"BlockDeviceMapping.3.Ebs.DeleteOnTermination": {"false"},
+	})
+	for _, name := range []string{"Description", "ImageLocation", "BlockDeviceMapping.2.Ebs.DeleteOnTermination", "BlockDeviceMapping.3.Ebs.SnapshotId"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("unexpected %s=%q", name, got.Get(name))
+		}
+	}
+}
+
+func TestDescribeImages(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeImages": {`<imagesSet><item>
+			<imageId>ami-1</imageId><imageState>available</imageState><imageOwnerId>123</imageOwnerId><isPublic>false</isPublic>
+			<architecture>x86_64</architecture><name>web</name><rootDeviceType>ebs</rootDeviceType><rootDeviceName>/dev/xvda</rootDeviceName>
+			<blockDeviceMapping>
+				<item><deviceName>/dev/xvda</deviceName><ebs><snapshotId>snap-1</snapshotId><volumeSize>8</volumeSize><volumeType>gp3</volumeType><deleteOnTermination>true</deleteOnTermination></ebs></item>
+				<item><deviceName>/dev/sdb</deviceName><virtualName>ephemeral0</virtualName></item>
+			</blockDeviceMapping>
+			<tagSet><item><key>Name</key><value>web</value></item></tagSet>
+		</item></imagesSet>`},
+	})
+
+	images, err := client.DescribeImages(context.Background(), nil, []string{"self"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := server.Request(0); got.Get("Owner.1") != "self" {
+		t.Errorf("unexpected params %v", got)
+	}
+	if len(images) != 1 {
+		t.Fatalf("got %d images", len(images))
+	}
+	image := images[0]
+	if image.ImageId != "ami-1" || image.State != "available" || image.OwnerId != "123" || image.Name != "web" || image.RootDeviceName != "/dev/xvda" {
+		t.Fatalf("unexpected image %+v", image)
+	}
+	want := []BlockDeviceMapping{
+		{DeviceName: "/dev/xvda", SnapshotId: "snap-1", VolumeSize: 8, VolumeType: "gp3", DeleteOnTermination: true},
+		{DeviceName: "/dev/sdb", VirtualName: "ephemeral0"},
+	}
+	if !reflect.DeepEqual(image.BlockDeviceMappings, want) || TagMap(image.Tags)["Name"] != "web" {
+		t.Fatalf("unexpected image %+v", image)
+	}
+}
+
+func TestModifyLaunchPermissions(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{"ModifyImageAttribute": {"<return>true</return>"}})
+	server.Fail("ModifyImageAttribute", 1)
+	ctx := context.Background()
+
+	if err := client.ModifyLaunchPermissions(ctx, "ami-1", []LaunchPermission{{UserId: "123"}, {Group: "all"}}, []LaunchPermission{{UserId: "456"}}); err != nil {
+		t.Fatal(err)
+	}
+	if server.Count() != 2 {
+		t.Fatalf("got %d requests, want a retry", server.Count())
+	}
+	got := server.Request(1)
+	checkParams(t, got, url.Values{
+		"ImageId":                          {"ami-1"},
+		"LaunchPermission.Add.1.UserId":    {"123"},
+		"LaunchPermission.Add.2.Group":     {"all"},
+		"LaunchPermission.Remove.1.UserId": {"456"},
+	})
+	if _, ok := got["LaunchPermission.Add.2.UserId"]; ok {
+		t.Errorf("UserId sent for a group permission")
+	}
+
+	if err := client.ModifyImageDescription(ctx, "ami-1", "new"); err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(2), url.Values{"Action": {"ModifyImageAttribute"}, "ImageId": {"ami-1"}, "Description.Value": {"new"}})
+}
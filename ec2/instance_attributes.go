@@ -0,0 +1,96 @@
+package ec2
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Names of instance attributes.
+const (
+	AttrInstanceType                      = "instanceType"
+	AttrUserData                          = "userData"
+	AttrSourceDestCheck                   = "sourceDestCheck"
+	AttrDisableApiTermination             = "disableApiTermination"
+	AttrInstanceInitiatedShutdownBehavior = "instanceInitiatedShutdownBehavior"
+	AttrEbsOptimized                      = "ebsOptimized"
+)
+
+// InstanceAttribute holds the attribute of an instance
+// DescribeInstanceAttribute asked for; the other fields are zero.
+type InstanceAttribute struct {
+	InstanceId                        string `xml:"instanceId"`
+	InstanceType                      string `xml:"instanceType>value"`
+	UserData                          []byte `xml:"-"`
+	SourceDestCheck                   bool   `xml:"sourceDestCheck>value"`
+	DisableApiTermination             bool   `xml:"disableApiTermination>value"`
+	InstanceInitiatedShutdownBehavior string `xml:"instanceInitiatedShutdownBehavior>value"`
+	EbsOptimized                      bool   `xml:"ebsOptimized>value"`
+}
+
+// DescribeInstanceAttribute describes the attribute of the instance with
+// the given ID named name.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeInstanceAttribute.html for details.
+func (self *EC2) DescribeInstanceAttribute(ctx context.Context, instanceId, name string) (*InstanceAttribute, error) {
+	params := url.Values{
+		"InstanceId": {instanceId},
+		"Attribute":  {name},
+	}
+	var resp struct {
+		InstanceAttribute
+		UserData string `xml:"userData>value"`
+	}
+	if err := self.Do(ctx, "DescribeInstanceAttribute", params, &resp); err != nil {
+		return nil, err
+	}
+	attr := resp.InstanceAttribute
+	if resp.UserData != "" {
+		data, err := base64.StdEncoding.DecodeString(resp.UserData)
+		if err != nil {
+			return nil, err
+		}
+		attr.UserData = data
+	}
+	return &attr, nil
+}
+
+// ModifyInstanceAttribute sets the attribute of the instance with the
+// given ID named name to value, as the API expects it. The typed setters
+// below are more convenient.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_ModifyInstanceAttribute.html for details.
+func (self *EC2) ModifyInstanceAttribute(ctx context.Context, instanceId, name, value string) error {
+	// The parameter is named like the attribute, but capitalized.
+	params := url.Values{
+		"InstanceId": {instanceId},
+		strings.ToUpper(name[:1]) + name[1:] + ".Value": {value},
+	}
+	return self.Do(ctx, "ModifyInstanceAttribute", params, nil)
+}
+
+// SetInstanceType changes the type of the stopped instance with the
+// given ID.
+func (self *EC2) SetInstanceType(ctx context.Context, instanceId, instanceType string) error {
+	return self.ModifyInstanceAttribute(ctx, instanceId, AttrInstanceType, instanceType)
+}
+
+// SetUserData replaces the user data of the stopped instance with the
+// given ID.
+func (self *EC2) SetUserData(ctx context.Context, instanceId string, userData []byte) error {
+	return self.ModifyInstanceAttribute(ctx, instanceId, AttrUserData, base64.StdEncoding.EncodeToString(userData))
+}
+
+// SetSourceDestCheck turns the source/destination check of the instance
+// with the given ID on or off. NAT instances need it off.
+func (self *EC2) SetSourceDestCheck(ctx context.Context, instanceId string, check bool) error {
+	return self.ModifyInstanceAttribute(ctx, instanceId, AttrSourceDestCheck, strconv.FormatBool(check))
+}
+
+// SetTerminationProtection turns the termination protection of the
+// instance with the given ID on or off.
+func (self *EC2) SetTerminationProtection(ctx context.Context, instanceId string, protect bool) error {
+	return self.ModifyInstanceAttribute(ctx, instanceId, AttrDisableApiTermination, strconv.FormatBool(protect))
+}
@@ -0,0 +1,85 @@
+//go:build !goaws_stable
+
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestDescribeInstanceAttribute(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeInstanceAttribute": {
+			"<instanceId>i-1</instanceId><instanceType><value>t3.large</value></instanceType>",
+			"<instanceId>i-1</instanceId><userData><value>I2Nsb3VkLWNvbmZpZwo=</value></userData>",
+			"<instanceId>i-1</instanceId><disableApiTermination><value>true</value></disableApiTermination>",
+			"<instanceId>i-1</instanceId><userData/>",
+		},
+	})
+	ctx := context.Background()
+
+	attr, err := client.DescribeInstanceAttribute(ctx, "i-1", AttrInstanceType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(0), url.Values{"InstanceId": {"i-1"}, "Attribute": {"instanceType"}})
+	if attr.InstanceId != "i-1" || attr.InstanceType != "t3.large" || attr.UserData != nil {
+		t.Fatalf("unexpected attribute %+v", attr)
+	}
+
+	if attr, err = client.DescribeInstanceAttribute(ctx, "i-1", AttrUserData); err != nil {
+		t.Fatal(err)
+	}
+	if string(attr.UserData) != "#cloud-config\n" {
+		t.Fatalf("got user data %q", attr.UserData)
+	}
+
+	if attr, err = client.DescribeInstanceAttribute(ctx, "i-1", AttrDisableApiTermination); err != nil {
+		t.Fatal(err)
+	}
+	if !attr.DisableApiTermination || attr.InstanceType != "" {
+		t.Fatalf("unexpected attribute %+v", attr)
+	}
+
+	// Instances without user data have an empty element.
+	if attr, err = client.DescribeInstanceAttribute(ctx, "i-1", AttrUserData); err != nil {
+		t.Fatal(err)
+	}
+	if attr.UserData != nil {
+		t.Fatalf("got user data %q", attr.UserData)
+	}
+}
+
+func TestModifyInstanceAttribute(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{"ModifyInstanceAttribute": {"<return>true</return>"}})
+	ctx := context.Background()
+	server.Fail("ModifyInstanceAttribute", 1)
+
+	if err := client.SetInstanceType(ctx, "i-1", "t3.large"); err != nil {
+		t.Fatal(err)
+	}
+	// The failed first attempt is retried, as the modification is
+	// idempotent.
+	if server.Count() != 2 {
+		t.Fatalf("got %d requests, want 2", server.Count())
+	}
+	checkParams(t, server.Request(1), url.Values{"InstanceId": {"i-1"}, "InstanceType.Value": {"t3.large"}})
+
+	for _, set := range []func() error{
+		func() error { return client.SetUserData(ctx, "i-1", []byte("#cloud-config\n")) },
+		func() error { return client.SetSourceDestCheck(ctx, "i-1", false) },
+		func() error { return client.SetTerminationProtection(ctx, "i-1", true) },
+		func() error {
+			return client.ModifyInstanceAttribute(ctx, "i-1", AttrInstanceInitiatedShutdownBehavior, "terminate")
+		},
+	} {
+		if err := set(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkParams(t, server.Request(2), url.Values{"UserData.Value": {"I2Nsb3VkLWNvbmZpZwo="}})
+	checkParams(t, server.Request(3), url.Values{"SourceDestCheck.Value": {"false"}})
+	checkParams(t, server.Request(4), url.Values{"DisableApiTermination.Value": {"true"}})
+	checkParams(t, server.Request(5), url.Values{"InstanceInitiatedShutdownBehavior.Value": {"terminate"}})
+}
@@ -0,0 +1,243 @@
+package ec2
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// RunInstancesOptions describe the instances to launch.
+type RunInstancesOptions struct {
+	ImageId      string
+	MinCount     int // if zero, 1
+	MaxCount     int // if zero, MinCount
+	InstanceType string
+	KeyName      string
+
+	// UserData is passed to the instances, typically a cloud-init
+	// script or document; see CloudInitParts. It is read from
+	// UserDataReader instead if that is set, compressed if GzipUserData
+	// is set, which cloud-init understands, and base64 encoded by
+	// RunInstances.
+	UserData       []byte
+	UserDataReader io.Reader
+	GzipUserData   bool
+
+	SecurityGroupIds []string
+	SecurityGroups   []string // names, in EC2-Classic and default VPCs only
+
+	SubnetId              string
+	AvailabilityZone      string
+	IamInstanceProfile    string // name or ARN
+	DisableApiTermination bool
+	EbsOptimized          bool
+
+	// ClientToken makes the call idempotent: EC2 launches the instances
+	// only once for calls with the same token. RunInstances uses a
+	// random token if it is empty, so its retries don't launch
+	// instances twice; set it to also cover retries by the caller.
+	ClientToken string
+}
+
+// Instance is an EC2 instance.
+type Instance struct {
+	InstanceId       string          `xml:"instanceId"`
+	ImageId          string          `xml:"imageId"`
+	InstanceType     string          `xml:"instanceType"`
+	State            InstanceState   `xml:"instanceState"`
+	KeyName          string          `xml:"keyName"`
+	LaunchTime       time.Time       `xml:"launchTime"`
+	AvailabilityZone string          `xml:"placement>availabilityZone"`
+	PrivateDNSName   string          `xml:"privateDnsName"`
+	DNSName          string          `xml:"dnsName"`
+	PrivateIPAddress string          `xml:"privateIpAddress"`
+	IPAddress        string          `xml:"ipAddress"`
+	VpcId            string          `xml:"vpcId"`
+	SubnetId         string          `xml:"subnetId"`
+	SecurityGroups   []SecurityGroup `xml:"groupSet>item"`
+	Tags             []Tag           `xml:"tagSet>item"`
+}
+
+// InstanceState is the state of an instance. Code is 0 (pending), 16
+// (running), 32 (shutting-down), 48 (terminated), 64 (stopping) or 80
+// (stopped).
+type InstanceState struct {
+	Code int    `xml:"code"`
+	Name string `xml:"name"`
+}
+
+// SecurityGroup identifies a security group.
+type SecurityGroup struct {
+	Id   string `xml:"groupId"`
+	Name string `xml:"groupName"`
+}
+
+// Reservation is a group of instances launched together.
+type Reservation struct {
+	ReservationId  string          `xml:"reservationId"`
+	OwnerId        string          `xml:"ownerId"`
+	SecurityGroups []SecurityGroup `xml:"groupSet>item"`
+	Instances      []Instance      `xml:"instancesSet>item"`
+}
+
+// RunInstancesResp is the result of RunInstances.
+type RunInstancesResp struct {
+	RequestId string `xml:"requestId"`
+	Reservation
+}
+
+// RunInstances launches instances.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_RunInstances.html for details.
+func (self *EC2) RunInstances(ctx context.Context, options *RunInstancesOptions) (*RunInstancesResp, error) {
+	min, max := options.MinCount, options.MaxCount
+	if min == 0 {
+		min = 1
+	}
+	if max == 0 {
+		max = min
+	}
+	params := url.Values{
+		"ImageId":  {options.ImageId},
+		"MinCount": {strconv.Itoa(min)},
+		"MaxCount": {strconv.Itoa(max)},
+	}
+	if options.ClientToken != "" {
+		params.Set("ClientToken", options.ClientToken)
+	} else {
+		params.Set("ClientToken", aws.NewClientToken())
+	}
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	set("InstanceType", options.InstanceType)
+	set("KeyName", options.KeyName)
+	set("SubnetId", options.SubnetId)
+	set("Placement.AvailabilityZone", options.AvailabilityZone)
+	userData, err := options.encodeUserData()
+	if err != nil {
+		return nil, err
+	}
+	set("UserData", userData)
+	aws.SetList(params, "SecurityGroupId", options.SecurityGroupIds)
+	aws.SetList(params, "SecurityGroup", options.SecurityGroups)
+	if profile := options.IamInstanceProfile; profile != "" {
+		if strings.HasPrefix(profile, "arn:") {
+			params.Set("IamInstanceProfile.Arn", profile)
+		} else {
+			params.Set("IamInstanceProfile.Name", profile)
+		}
+	}
+	if options.DisableApiTermination {
+		params.Set("DisableApiTermination", "true")
+	}
+	if options.EbsOptimized {
+		params.Set("EbsOptimized", "true")
+	}
+	resp := &RunInstancesResp{}
+	if err := self.Do(ctx, "RunInstances", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DescribeInstancesResp is the result of DescribeInstances. If NextToken
+// is not empty, there are more instances, described by passing it to the
+// next call.
+type DescribeInstancesResp struct {
+	RequestId    string        `xml:"requestId"`
+	Reservations []Reservation `xml:"reservationSet>item"`
+	NextToken    string        `xml:"nextToken"`
+}
+
+// Instances returns the instances of all reservations of the result.
+func (self *DescribeInstancesResp) Instances() []Instance {
+	var instances []Instance
+	for _, reservation := range self.Reservations {
+		instances = append(instances, reservation.Instances...)
+	}
+	return instances
+}
+
+// DescribeInstances describes the instances with the given IDs, or all
+// instances if there are none, that match filter, which may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeInstances.html for details.
+func (self *EC2) DescribeInstances(ctx context.Context, instanceIds []string, filter *Filter, nextToken string) (*DescribeInstancesResp, error) {
+	params := url.Values{}
+	aws.SetList(params, "InstanceId", instanceIds)
+	filter.addParams(params)
+	if nextToken != "" {
+		params.Set("NextToken", nextToken)
+	}
+	resp := &DescribeInstancesResp{}
+	if err := self.Do(ctx, "DescribeInstances", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DescribeAllInstances is like DescribeInstances, but returns the
+// instances of all pages.
+func (self *EC2) DescribeAllInstances(ctx context.Context, instanceIds []string, filter *Filter) ([]Instance, error) {
+	params := url.Values{}
+	aws.SetList(params, "InstanceId", instanceIds)
+	filter.addParams(params)
+	resp := &DescribeInstancesResp{}
+	if err := self.describe(ctx, "DescribeInstances", params, resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.Instances(), nil
+}
+
+// InstanceStateChange is the state transition of an instance caused by
+// StartInstances, StopInstances or TerminateInstances.
+type InstanceStateChange struct {
+	InstanceId    string        `xml:"instanceId"`
+	CurrentState  InstanceState `xml:"currentState"`
+	PreviousState InstanceState `xml:"previousState"`
+}
+
+// StartInstances starts the stopped instances with the given IDs.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_StartInstances.html for details.
+func (self *EC2) StartInstances(ctx context.Context, instanceIds ...string) ([]InstanceStateChange, error) {
+	return self.changeState(ctx, "StartInstances", url.Values{}, instanceIds)
+}
+
+// StopInstances stops the instances with the given IDs. With force set,
+// they don't get to shut down cleanly.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_StopInstances.html for details.
+func (self *EC2) StopInstances(ctx context.Context, force bool, instanceIds ...string) ([]InstanceStateChange, error) {
+	params := url.Values{}
+	if force {
+		params.Set("Force", "true")
+	}
+	return self.changeState(ctx, "StopInstances", params, instanceIds)
+}
+
+// TerminateInstances terminates the instances with the given IDs.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_TerminateInstances.html for details.
+func (self *EC2) TerminateInstances(ctx context.Context, instanceIds ...string) ([]InstanceStateChange, error) {
+	return self.changeState(ctx, "TerminateInstances", url.Values{}, instanceIds)
+}
+
+func (self *EC2) changeState(ctx context.Context, action string, params url.Values, instanceIds []string) ([]InstanceStateChange, error) {
+	aws.SetList(params, "InstanceId", instanceIds)
+	var resp struct {
+		Changes []InstanceStateChange `xml:"instancesSet>item"`
+	}
+	if err := self.Do(ctx, action, params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Changes, nil
+}
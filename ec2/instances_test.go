@@ -0,0 +1,196 @@
+//go:build !goaws_stable
+
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+const reservation = `<reservationId>r-1</reservationId><ownerId>123</ownerId>
+<groupSet><item><groupId>sg-1</groupId><groupName>web</groupName></item></groupSet>
+<instancesSet>
+  <item>
+    <instanceId>i-1</instanceId><imageId>ami-1</imageId><instanceType>t3.micro</instanceType>
+    <instanceState><code>0</code><name>pending</name></instanceState>
+    <placement><availabilityZone>us-east-1a</availabilityZone></placement>
+    <tagSet><item><key>Name</key><value>web-1</value></item></tagSet>
+  </item>
+  <item>
+    <instanceId>i-2</instanceId><imageId>ami-1</imageId>
+    <instanceState><code>16</code><name>running</name></instanceState>
+  </item>
+</instancesSet>`
+
+func TestRunInstances(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{"RunInstances": {reservation}})
+
+	resp, err := client.RunInstances(context.Background(), &RunInstancesOptions{
+		ImageId:               "ami-1",
+		MinCount:              2,
+		InstanceType:          "t3.micro",
+		KeyName:               "k",
+		SecurityGroupIds:      []string{"sg-1", "sg-2"},
+		SubnetId:              "subnet-1",
+		AvailabilityZone:      "us-east-1a",
+		IamInstanceProfile:    "arn:aws:iam::123:instance-profile/web",
+		DisableApiTermination: true,
+		ClientToken:           "token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{
+		"ImageId":                    {"ami-1"},
+		"MinCount":                   {"2"},
+		"MaxCount":                   {"2"},
+		"ClientToken":                {"token"},
+		"InstanceType":               {"t3.micro"},
+		"KeyName":                    {"k"},
+		"SecurityGroupId.1":          {"sg-1"},
+		"SecurityGroupId.2":          {"sg-2"},
+		"SubnetId":                   {"subnet-1"},
+		"Placement.AvailabilityZone": {"us-east-1a"},
+		"IamInstanceProfile.Arn":     {"arn:aws:iam::123:instance-profile/web"},
+		"DisableApiTermination":      {"true"},
+	}
+	got := server.Request(0)
+	for name, value := range want {
+		if !reflect.DeepEqual(got[name], value) {
+			t.Errorf("%s: got %q, want %q", name, got[name], value)
+		}
+	}
+	for _, name := range []string{"EbsOptimized", "UserData", "IamInstanceProfile.Name", "SecurityGroup.1"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("unexpected %s=%q", name, got.Get(name))
+		}
+	}
+
+	if resp.ReservationId != "r-1" || resp.OwnerId != "123" || len(resp.SecurityGroups) != 1 || resp.SecurityGroups[0].Name != "web" {
+		t.Fatalf("unexpected reservation %+v", resp.Reservation)
+	}
+	if len(resp.Instances) != 2 {
+		t.Fatalf("got %d instances", len(resp.Instances))
+	}
+	instance := resp.Instances[0]
+	if instance.InstanceId != "i-1" || instance.InstanceType != "t3.micro" || instance.State != (InstanceState{0, "pending"}) ||
+		instance.AvailabilityZone != "us-east-1a" || !reflect.DeepEqual(instance.Tags, []Tag{{"Name", "web-1"}}) {
+		t.Fatalf("unexpected instance %+v", instance)
+	}
+}
+
+func TestRunInstancesDefaults(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{"RunInstances": {reservation}})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.RunInstances(context.Background(), &RunInstancesOptions{
+			ImageId:            "ami-1",
+			SecurityGroups:     []string{"default"},
+			IamInstanceProfile: "web",
+			EbsOptimized:       true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := server.Request(0)
+	if got.Get("MinCount") != "1" || got.Get("MaxCount") != "1" {
+		t.Errorf("got counts %s-%s, want 1-1", got.Get("MinCount"), got.Get("MaxCount"))
+	}
+	if got.Get("SecurityGroup.1") != "default" || got.Get("IamInstanceProfile.Name") != "web" || got.Get("EbsOptimized") != "true" {
+		t.Errorf("unexpected params %v", got)
+	}
+	if token := got.Get("ClientToken"); token == "" || token == server.Request(1).Get("ClientToken") {
+		t.Errorf("want distinct random client tokens, got %q and %q", token, server.Request(1).Get("ClientToken"))
+	}
+}
+
+func TestRunInstancesRetriesWithSameToken(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{"RunInstances": {reservation}})
+	server.Fail("RunInstances", 1)
+
+	if _, err := client.RunInstances(context.Background(), &RunInstancesOptions{ImageId: "ami-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if server.Count() != 2 {
+		t.Fatalf("got %d requests, want 2", server.Count())
+	}
+	if first, second := server.Request(0).Get("ClientToken"), server.Request(1).Get("ClientToken"); first != second {
+		t.Fatalf("retry changed the client token from %q to %q", first, second)
+	}
+}
+
+func TestDescribeInstances(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeInstances": {"<reservationSet><item>" + reservation + "</item><item><instancesSet><item><instanceId>i-3</instanceId></item></instancesSet></item></reservationSet><nextToken>next</nextToken>"},
+	})
+
+	resp, err := client.DescribeInstances(context.Background(), []string{"i-1", "i-2", "i-3"}, nil, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := server.Request(0)
+	if got.Get("InstanceId.1") != "i-1" || got.Get("InstanceId.3") != "i-3" || got.Get("NextToken") != "token" {
+		t.Errorf("unexpected params %v", got)
+	}
+	if resp.NextToken != "next" || len(resp.Reservations) != 2 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	var ids []string
+	for _, instance := range resp.Instances() {
+		ids = append(ids, instance.InstanceId)
+	}
+	if !reflect.DeepEqual(ids, []string{"i-1", "i-2", "i-3"}) {
+		t.Fatalf("got instances %v", ids)
+	}
+}
+
+func TestChangeInstanceState(t *testing.T) {
+	change := func(id, from, to string) string {
+		return "<item><instanceId>" + id + "</instanceId><currentState><code>0</code><name>" + to + "</name></currentState>" +
+			"<previousState><code>0</code><name>" + from + "</name></previousState></item>"
+	}
+	client, server := newTestEC2(t, map[string][]string{
+		"StartInstances":     {"<instancesSet>" + change("i-1", "stopped", "pending") + "</instancesSet>"},
+		"StopInstances":      {"<instancesSet>" + change("i-1", "running", "stopping") + change("i-2", "running", "stopping") + "</instancesSet>"},
+		"TerminateInstances": {"<instancesSet>" + change("i-1", "stopped", "shutting-down") + "</instancesSet>"},
+	})
+	server.Fail("TerminateInstances", 1)
+	ctx := context.Background()
+
+	changes, err := client.StartInstances(ctx, "i-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].InstanceId != "i-1" || changes[0].PreviousState.Name != "stopped" || changes[0].CurrentState.Name != "pending" {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	if got := server.Request(0); got.Get("InstanceId.1") != "i-1" {
+		t.Errorf("unexpected params %v", got)
+	}
+
+	if changes, err = client.StopInstances(ctx, true, "i-1", "i-2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[1].InstanceId != "i-2" || changes[1].CurrentState.Name != "stopping" {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	if got := server.Request(1); got.Get("Force") != "true" || got.Get("InstanceId.2") != "i-2" {
+		t.Errorf("unexpected params %v", got)
+	}
+	if _, err = client.StopInstances(ctx, false, "i-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Request(2)["Force"]; ok {
+		t.Errorf("Force sent without force")
+	}
+
+	// TerminateInstances is idempotent, so it is retried.
+	if changes, err = client.TerminateInstances(ctx, "i-1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].CurrentState.Name != "shutting-down" || server.Count() != 5 {
+		t.Fatalf("unexpected changes %+v after %d requests", changes, server.Count())
+	}
+}
@@ -0,0 +1,71 @@
+package ec2
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+
+	"github.com/dkln/go-aws"
+)
+
+// KeyPair is an SSH key pair. KeyMaterial, the unencrypted PEM encoded
+// private key, is only known right after CreateKeyPair; EC2 doesn't keep
+// it.
+type KeyPair struct {
+	KeyName        string `xml:"keyName"`
+	KeyFingerprint string `xml:"keyFingerprint"`
+	KeyPairId      string `xml:"keyPairId"`
+	KeyMaterial    string `xml:"keyMaterial"`
+}
+
+// CreateKeyPair creates a key pair named name and returns it with its
+// private key.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateKeyPair.html for details.
+func (self *EC2) CreateKeyPair(ctx context.Context, name string) (*KeyPair, error) {
+	key := &KeyPair{}
+	if err := self.Do(ctx, "CreateKeyPair", url.Values{"KeyName": {name}}, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ImportKeyPair creates a key pair named name from a public key, in
+// OpenSSH authorized_keys format or DER/PEM encoded.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_ImportKeyPair.html for details.
+func (self *EC2) ImportKeyPair(ctx context.Context, name string, publicKey []byte) (*KeyPair, error) {
+	params := url.Values{
+		"KeyName":           {name},
+		"PublicKeyMaterial": {base64.StdEncoding.EncodeToString(publicKey)},
+	}
+	key := &KeyPair{}
+	if err := self.Do(ctx, "ImportKeyPair", params, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DeleteKeyPair deletes the key pair named name.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeleteKeyPair.html for details.
+func (self *EC2) DeleteKeyPair(ctx context.Context, name string) error {
+	return self.Do(ctx, "DeleteKeyPair", url.Values{"KeyName": {name}}, nil)
+}
+
+// DescribeKeyPairs describes the key pairs with the given names, or all
+// key pairs if there are none, that match filter, which may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeKeyPairs.html for details.
+func (self *EC2) DescribeKeyPairs(ctx context.Context, names []string, filter *Filter) ([]KeyPair, error) {
+	params := url.Values{}
+	aws.SetList(params, "KeyName", names)
+	filter.addParams(params)
+	var resp struct {
+		Keys []KeyPair `xml:"keySet>item"`
+	}
+	if err := self.Do(ctx, "DescribeKeyPairs", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
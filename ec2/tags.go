@@ -0,0 +1,105 @@
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/dkln/go-aws"
+)
+
+// TagMap returns tags as a map from keys to values.
+func TagMap(tags []Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		m[tag.Key] = tag.Value
+	}
+	return m
+}
+
+// setTags adds tags to params as Tag.N.Key and Tag.N.Value, sorted by
+// key. Empty values are left out if omitEmpty is set.
+func setTags(params url.Values, tags map[string]string, omitEmpty bool) {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		prefix := "Tag." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"Key", key)
+		if !omitEmpty || tags[key] != "" {
+			params.Set(prefix+"Value", tags[key])
+		}
+	}
+}
+
+// CreateTags adds tags to the resources with the given IDs, such as
+// instances, volumes, images and snapshots, replacing those with the
+// same keys.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateTags.html for details.
+func (self *EC2) CreateTags(ctx context.Context, resourceIds []string, tags map[string]string) error {
+	params := url.Values{}
+	aws.SetList(params, "ResourceId", resourceIds)
+	setTags(params, tags, false)
+	return self.Do(ctx, "CreateTags", params, nil)
+}
+
+// DeleteTags removes tags from the resources with the given IDs. A tag
+// with an empty value is removed whatever its value; otherwise only if
+// its value matches.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeleteTags.html for details.
+func (self *EC2) DeleteTags(ctx context.Context, resourceIds []string, tags map[string]string) error {
+	params := url.Values{}
+	aws.SetList(params, "ResourceId", resourceIds)
+	setTags(params, tags, true)
+	return self.Do(ctx, "DeleteTags", params, nil)
+}
+
+// ResourceTag is a tag of a resource.
+type ResourceTag struct {
+	ResourceId   string `xml:"resourceId"`
+	ResourceType string `xml:"resourceType"` // "instance", "volume", "image", ...
+	Key          string `xml:"key"`
+	Value        string `xml:"value"`
+}
+
+// DescribeTagsResp is the result of DescribeTags. If NextToken is not
+// empty, there are more tags, described by passing it to the next call.
+type DescribeTagsResp struct {
+	RequestId string        `xml:"requestId"`
+	Tags      []ResourceTag `xml:"tagSet>item"`
+	NextToken string        `xml:"nextToken"`
+}
+
+// DescribeTags describes the tags matching filter, such as
+// "resource-id", "resource-type" or "key", of all resources.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeTags.html for details.
+func (self *EC2) DescribeTags(ctx context.Context, filter *Filter, nextToken string) (*DescribeTagsResp, error) {
+	params := url.Values{}
+	filter.addParams(params)
+	if nextToken != "" {
+		params.Set("NextToken", nextToken)
+	}
+	resp := &DescribeTagsResp{}
+	if err := self.Do(ctx, "DescribeTags", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DescribeAllTags is like DescribeTags, but returns the tags of all
+// pages.
+func (self *EC2) DescribeAllTags(ctx context.Context, filter *Filter) ([]ResourceTag, error) {
+	params := url.Values{}
+	filter.addParams(params)
+	resp := &DescribeTagsResp{}
+	if err := self.describe(ctx, "DescribeTags", params, resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.Tags, nil
+}
@@ -0,0 +1,85 @@
+//go:build !goaws_stable
+
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCreateAndDeleteTags(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"CreateTags": {"<return>true</return>"},
+		"DeleteTags": {"<return>true</return>"},
+	})
+	ctx := context.Background()
+	server.Fail("CreateTags", 1)
+
+	if err := client.CreateTags(ctx, []string{"i-1", "vol-1"}, map[string]string{"Name": "web", "Env": "", "App": "shop"}); err != nil {
+		t.Fatal(err)
+	}
+	// The failed first attempt is retried, as CreateTags is idempotent.
+	want := url.Values{
+		"Action":       {"CreateTags"},
+		"ResourceId.1": {"i-1"},
+		"ResourceId.2": {"vol-1"},
+		"Tag.1.Key":    {"App"},
+		"Tag.1.Value":  {"shop"},
+		"Tag.2.Key":    {"Env"},
+		"Tag.2.Value":  {""},
+		"Tag.3.Key":    {"Name"},
+		"Tag.3.Value":  {"web"},
+	}
+	if server.Count() != 2 {
+		t.Fatalf("got %d requests, want 2", server.Count())
+	}
+	checkParams(t, server.Request(1), want)
+
+	if err := client.DeleteTags(ctx, []string{"i-1"}, map[string]string{"Name": "", "App": "shop"}); err != nil {
+		t.Fatal(err)
+	}
+	got := server.Request(2)
+	checkParams(t, got, url.Values{
+		"Action":       {"DeleteTags"},
+		"ResourceId.1": {"i-1"},
+		"Tag.1.Key":    {"App"},
+		"Tag.1.Value":  {"shop"},
+		"Tag.2.Key":    {"Name"},
+	})
+	if _, ok := got["Tag.2.Value"]; ok {
+		t.Errorf("DeleteTags sent a value for a tag to delete whatever its value")
+	}
+}
+
+func TestDescribeTags(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeTags": {`<tagSet>
+			<item><resourceId>i-1</resourceId><resourceType>instance</resourceType><key>Name</key><value>web</value></item>
+			<item><resourceId>vol-1</resourceId><resourceType>volume</resourceType><key>Env</key><value/></item>
+		</tagSet><nextToken>next</nextToken>`},
+	})
+
+	resp, err := client.DescribeTags(context.Background(), NewFilter().Add("resource-type", "instance", "volume"), "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(0), url.Values{
+		"Filter.1.Name":    {"resource-type"},
+		"Filter.1.Value.1": {"instance"},
+		"Filter.1.Value.2": {"volume"},
+		"NextToken":        {"token"},
+	})
+	want := []ResourceTag{{"i-1", "instance", "Name", "web"}, {"vol-1", "volume", "Env", ""}}
+	if !reflect.DeepEqual(resp.Tags, want) || resp.NextToken != "next" {
+		t.Fatalf("got %+v", resp)
+	}
+}
+
+func TestTagMap(t *testing.T) {
+	got := TagMap([]Tag{{"Name", "web"}, {"Env", ""}})
+	if !reflect.DeepEqual(got, map[string]string{"Name": "web", "Env": ""}) {
+		t.Fatalf("got %v", got)
+	}
+}
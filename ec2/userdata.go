@@ -0,0 +1,117 @@
+package ec2
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// MaxUserDataSize is the largest user data EC2 accepts, before base64
+// encoding.
+const MaxUserDataSize = 16 * 1024
+
+// ErrUserDataTooLarge is returned by RunInstances for user data larger
+// than MaxUserDataSize, which EC2 would reject with a less helpful error.
+var ErrUserDataTooLarge = errors.New("ec2: user data exceeds 16 KiB")
+
+// Content types of cloud-init user data parts.
+const (
+	ContentTypeCloudConfig    = "text/cloud-config"
+	ContentTypeShellScript    = "text/x-shellscript"
+	ContentTypeCloudBoothook  = "text/cloud-boothook"
+	ContentTypeIncludeURL     = "text/x-include-url"
+	ContentTypePartHandler    = "text/part-handler"
+	ContentTypeUpstartJob     = "text/upstart-job"
+	ContentTypeJinja2Template = "text/jinja2"
+)
+
+// encodeUserData returns the user data of options as RunInstances sends
+// it: read from UserDataReader if set, gzipped with GzipUserData, and
+// base64 encoded.
+func (self *RunInstancesOptions) encodeUserData() (string, error) {
+	data := self.UserData
+	if self.UserDataReader != nil {
+		var err error
+		if data, err = ioutil.ReadAll(self.UserDataReader); err != nil {
+			return "", err
+		}
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+	if self.GzipUserData {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write(data)
+		if err := w.Close(); err != nil {
+			return "", err
+		}
+		data = buf.Bytes()
+	}
+	if len(data) > MaxUserDataSize {
+		return "", ErrUserDataTooLarge
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// CloudInitParts builds multi-part user data, which cloud-init processes
+// part by part according to their content type, so that, for instance, a
+// cloud-config document and a shell script can be passed to the same
+// instance.
+type CloudInitParts struct {
+	buf    bytes.Buffer
+	writer *multipart.Writer
+}
+
+// NewCloudInitParts returns empty multi-part user data.
+func NewCloudInitParts() *CloudInitParts {
+	parts := &CloudInitParts{}
+	parts.writer = multipart.NewWriter(&parts.buf)
+	return parts
+}
+
+// Add adds a part with the given content type, such as
+// ContentTypeCloudConfig or ContentTypeShellScript. The filename is
+// optional and shows up in cloud-init's logs.
+func (self *CloudInitParts) Add(contentType, filename string, content []byte) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+`; charset="us-ascii"`)
+	header.Set("MIME-Version", "1.0")
+	header.Set("Content-Transfer-Encoding", "7bit")
+	if filename != "" {
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	part, err := self.writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+// Bytes returns the user data. No parts can be added afterwards.
+func (self *CloudInitParts) Bytes() ([]byte, error) {
+	if err := self.writer.Close(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n", self.writer.Boundary())
+	buf.WriteString("MIME-Version: 1.0\r\n\r\n")
+	buf.Write(self.buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+// Reader returns the user data as a reader, for UserDataReader.
+func (self *CloudInitParts) Reader() (io.Reader, error) {
+	data, err := self.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,150 @@
+//go:build !goaws_stable
+
+package ec2
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func decodeUserData(t *testing.T, encoded string, gzipped bool) string {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gzipped {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, err = ioutil.ReadAll(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return string(data)
+}
+
+func TestEncodeUserData(t *testing.T) {
+	script := "#!/bin/sh\necho hello\n"
+
+	encoded, err := (&RunInstancesOptions{UserData: []byte(script)}).encodeUserData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeUserData(t, encoded, false); got != script {
+		t.Fatalf("got %q", got)
+	}
+
+	// The reader takes precedence over UserData.
+	options := &RunInstancesOptions{UserData: []byte("ignored"), UserDataReader: strings.NewReader(script), GzipUserData: true}
+	if encoded, err = options.encodeUserData(); err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeUserData(t, encoded, true); got != script {
+		t.Fatalf("got %q", got)
+	}
+
+	if encoded, err = (&RunInstancesOptions{}).encodeUserData(); err != nil || encoded != "" {
+		t.Fatalf("got %q, %v for no user data", encoded, err)
+	}
+}
+
+func TestEncodeUserDataLimit(t *testing.T) {
+	large := bytes.Repeat([]byte("a"), MaxUserDataSize+1)
+	if _, err := (&RunInstancesOptions{UserData: large}).encodeUserData(); err != ErrUserDataTooLarge {
+		t.Fatalf("got %v, want %v", err, ErrUserDataTooLarge)
+	}
+	// The limit applies after compression.
+	encoded, err := (&RunInstancesOptions{UserData: large, GzipUserData: true}).encodeUserData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeUserData(t, encoded, true); got != string(large) {
+		t.Fatalf("got %d bytes, want %d", len(got), len(large))
+	}
+}
+
+func TestCloudInitParts(t *testing.T) {
+	parts := NewCloudInitParts()
+	if err := parts.Add(ContentTypeCloudConfig, "", []byte("#cloud-config\npackages: [nginx]\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := parts.Add(ContentTypeShellScript, "setup.sh", []byte("#!/bin/sh\necho hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := parts.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.Get("MIME-Version") != "1.0" {
+		t.Errorf("missing MIME-Version in %q", data)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("got content type %q, %v", msg.Header.Get("Content-Type"), err)
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	want := []struct{ contentType, filename, content string }{
+		{ContentTypeCloudConfig, "", "#cloud-config\npackages: [nginx]\n"},
+		{ContentTypeShellScript, "setup.sh", "#!/bin/sh\necho hello\n"},
+	}
+	for _, w := range want {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type")); got != w.contentType {
+			t.Errorf("got content type %q, want %q", got, w.contentType)
+		}
+		if part.FileName() != w.filename {
+			t.Errorf("got filename %q, want %q", part.FileName(), w.filename)
+		}
+		content, _ := ioutil.ReadAll(part)
+		if string(content) != w.content {
+			t.Errorf("got content %q, want %q", content, w.content)
+		}
+	}
+	if _, err := reader.NextPart(); err == nil {
+		t.Fatal("got an extra part")
+	}
+}
+
+func TestRunInstancesSendsUserData(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{"RunInstances": {reservation}})
+	parts := NewCloudInitParts()
+	parts.Add(ContentTypeShellScript, "", []byte("#!/bin/sh\necho hello\n"))
+	reader, err := parts.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.RunInstances(context.Background(), &RunInstancesOptions{ImageId: "ami-1", UserDataReader: reader, GzipUserData: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := decodeUserData(t, server.Request(0).Get("UserData"), true)
+	if !strings.HasPrefix(got, "Content-Type: multipart/mixed;") || !strings.Contains(got, "echo hello") {
+		t.Fatalf("got user data %q", got)
+	}
+
+	large := bytes.Repeat([]byte("x"), MaxUserDataSize+1)
+	if _, err := client.RunInstances(context.Background(), &RunInstancesOptions{ImageId: "ami-1", UserData: large}); err != ErrUserDataTooLarge {
+		t.Fatalf("got %v, want %v", err, ErrUserDataTooLarge)
+	}
+	if server.Count() != 1 {
+		t.Fatalf("oversized user data was sent")
+	}
+}
@@ -0,0 +1,297 @@
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// Volume is an EBS volume.
+type Volume struct {
+	VolumeId         string             `xml:"volumeId"`
+	Size             int                `xml:"size"` // in GiB
+	SnapshotId       string             `xml:"snapshotId"`
+	AvailabilityZone string             `xml:"availabilityZone"`
+	Status           string             `xml:"status"` // "creating", "available", "in-use", "deleting", "deleted" or "error"
+	CreateTime       time.Time          `xml:"createTime"`
+	VolumeType       string             `xml:"volumeType"`
+	Iops             int                `xml:"iops"`
+	Encrypted        bool               `xml:"encrypted"`
+	Attachments      []VolumeAttachment `xml:"attachmentSet>item"`
+	Tags             []Tag              `xml:"tagSet>item"`
+}
+
+// VolumeAttachment is the attachment of a volume to an instance.
+type VolumeAttachment struct {
+	VolumeId   string    `xml:"volumeId"`
+	InstanceId string    `xml:"instanceId"`
+	Device     string    `xml:"device"`
+	Status     string    `xml:"status"` // "attaching", "attached", "detaching" or "detached"
+	AttachTime time.Time `xml:"attachTime"`
+}
+
+// CreateVolumeOptions describe a volume to create. Either Size or
+// SnapshotId must be set.
+type CreateVolumeOptions struct {
+	AvailabilityZone string
+	Size             int    // in GiB; if zero, the size of the snapshot
+	SnapshotId       string // to restore
+	VolumeType       string // "gp2", "gp3", "io1", "st1", ...; if empty, "gp2"
+	Iops             int    // for provisioned IOPS volume types
+	Encrypted        bool
+	KmsKeyId         string
+}
+
+// CreateVolume creates a volume.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateVolume.html for details.
+func (self *EC2) CreateVolume(ctx context.Context, options *CreateVolumeOptions) (*Volume, error) {
+	params := url.Values{"AvailabilityZone": {options.AvailabilityZone}}
+	if options.Size != 0 {
+		params.Set("Size", strconv.Itoa(options.Size))
+	}
+	if options.SnapshotId != "" {
+		params.Set("SnapshotId", options.SnapshotId)
+	}
+	if options.VolumeType != "" {
+		params.Set("VolumeType", options.VolumeType)
+	}
+	if options.Iops != 0 {
+		params.Set("Iops", strconv.Itoa(options.Iops))
+	}
+	if options.Encrypted {
+		params.Set("Encrypted", "true")
+	}
+	if options.KmsKeyId != "" {
+		params.Set("KmsKeyId", options.KmsKeyId)
+	}
+	volume := &Volume{}
+	if err := self.Do(ctx, "CreateVolume", params, volume); err != nil {
+		return nil, err
+	}
+	return volume, nil
+}
+
+// DeleteVolume deletes the volume with the given ID, which must not be
+// attached.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeleteVolume.html for details.
+func (self *EC2) DeleteVolume(ctx context.Context, volumeId string) error {
+	return self.Do(ctx, "DeleteVolume", url.Values{"VolumeId": {volumeId}}, nil)
+}
+
+// AttachVolume attaches the volume with the given ID to an instance, as
+// device, such as "/dev/sdf".
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_AttachVolume.html for details.
+func (self *EC2) AttachVolume(ctx context.Context, volumeId, instanceId, device string) (*VolumeAttachment, error) {
+	params := url.Values{
+		"VolumeId":   {volumeId},
+		"InstanceId": {instanceId},
+		"Device":     {device},
+	}
+	attachment := &VolumeAttachment{}
+	if err := self.Do(ctx, "AttachVolume", params, attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// DetachVolume detaches the volume with the given ID from its instance.
+// With force set, it is detached even if the instance doesn't release it,
+// at the risk of losing data.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DetachVolume.html for details.
+func (self *EC2) DetachVolume(ctx context.Context, volumeId string, force bool) (*VolumeAttachment, error) {
+	params := url.Values{"VolumeId": {volumeId}}
+	if force {
+		params.Set("Force", "true")
+	}
+	attachment := &VolumeAttachment{}
+	if err := self.Do(ctx, "DetachVolume", params, attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// DescribeVolumes describes the volumes with the given IDs, or all
+// volumes if there are none, that match filter, which may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeVolumes.html for details.
+func (self *EC2) DescribeVolumes(ctx context.Context, volumeIds []string, filter *Filter) ([]Volume, error) {
+	params := url.Values{}
+	aws.SetList(params, "VolumeId", volumeIds)
+	filter.addParams(params)
+	var resp struct {
+		Volumes   []Volume `xml:"volumeSet>item"`
+		NextToken string   `xml:"nextToken"`
+	}
+	if err := self.describe(ctx, "DescribeVolumes", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.Volumes, nil
+}
+
+// Snapshot is an EBS snapshot.
+type Snapshot struct {
+	SnapshotId  string    `xml:"snapshotId"`
+	VolumeId    string    `xml:"volumeId"`
+	VolumeSize  int       `xml:"volumeSize"` // in GiB
+	Status      string    `xml:"status"`     // "pending", "completed" or "error"
+	StartTime   time.Time `xml:"startTime"`
+	Progress    string    `xml:"progress"` // such as "100%"
+	OwnerId     string    `xml:"ownerId"`
+	Description string    `xml:"description"`
+	Encrypted   bool      `xml:"encrypted"`
+	Tags        []Tag     `xml:"tagSet>item"`
+}
+
+// CreateSnapshot starts a snapshot of the volume with the given ID.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateSnapshot.html for details.
+func (self *EC2) CreateSnapshot(ctx context.Context, volumeId, description string) (*Snapshot, error) {
+	params := url.Values{"VolumeId": {volumeId}}
+	if description != "" {
+		params.Set("Description", description)
+	}
+	snapshot := &Snapshot{}
+	if err := self.Do(ctx, "CreateSnapshot", params, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// DeleteSnapshot deletes the snapshot with the given ID.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeleteSnapshot.html for details.
+func (self *EC2) DeleteSnapshot(ctx context.Context, snapshotId string) error {
+	return self.Do(ctx, "DeleteSnapshot", url.Values{"SnapshotId": {snapshotId}}, nil)
+}
+
+// DescribeSnapshots describes the snapshots with the given IDs, or all
+// snapshots available to the account if there are none, owned by owners
+// ("self", "amazon" or account IDs) if given, that match filter, which
+// may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeSnapshots.html for details.
+func (self *EC2) DescribeSnapshots(ctx context.Context, snapshotIds, owners []string, filter *Filter) ([]Snapshot, error) {
+	params := url.Values{}
+	aws.SetList(params, "SnapshotId", snapshotIds)
+	aws.SetList(params, "Owner", owners)
+	filter.addParams(params)
+	var resp struct {
+		Snapshots []Snapshot `xml:"snapshotSet>item"`
+		NextToken string     `xml:"nextToken"`
+	}
+	if err := self.describe(ctx, "DescribeSnapshots", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.Snapshots, nil
+}
+
+// CopySnapshot copies the snapshot with the given ID from sourceRegion
+// into the region of the EC2 value and returns the ID of the copy.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CopySnapshot.html for details.
+func (self *EC2) CopySnapshot(ctx context.Context, sourceRegion, sourceSnapshotId, description string) (string, error) {
+	params := url.Values{
+		"SourceRegion":     {sourceRegion},
+		"SourceSnapshotId": {sourceSnapshotId},
+	}
+	if description != "" {
+		params.Set("Description", description)
+	}
+	var resp struct {
+		SnapshotId string `xml:"snapshotId"`
+	}
+	if err := self.Do(ctx, "CopySnapshot", params, &resp); err != nil {
+		return "", err
+	}
+	return resp.SnapshotId, nil
+}
+
+// WaitUntilVolumeAvailable waits until the volume with the given ID is
+// available for attachment, after it was created or detached.
+func (self *EC2) WaitUntilVolumeAvailable(ctx context.Context, volumeId string) error {
+	return self.volumeWaiter("VolumeAvailable", volumeId, func(volume *Volume) bool {
+		return volume.Status == "available"
+	}).Wait(ctx)
+}
+
+// WaitUntilVolumeAttached waits until the volume with the given ID is
+// attached to an instance.
+func (self *EC2) WaitUntilVolumeAttached(ctx context.Context, volumeId string) error {
+	return self.volumeWaiter("VolumeAttached", volumeId, func(volume *Volume) bool {
+		for _, attachment := range volume.Attachments {
+			if attachment.Status == "attached" {
+				return true
+			}
+		}
+		return false
+	}).Wait(ctx)
+}
+
+// WaitUntilSnapshotCompleted waits until the snapshot with the given ID
+// is completed.
+func (self *EC2) WaitUntilSnapshotCompleted(ctx context.Context, snapshotId string) error {
+	waiter := &aws.Waiter{
+		Name: "SnapshotCompleted",
+		Poll: func(ctx context.Context) (interface{}, error) {
+			snapshots, err := self.DescribeSnapshots(ctx, []string{snapshotId}, nil, nil)
+			if err != nil || len(snapshots) == 0 {
+				return nil, err
+			}
+			return &snapshots[0], nil
+		},
+		Acceptors: []aws.Acceptor{
+			{State: aws.WaiterSuccess, Matcher: func(result interface{}, err error) bool {
+				snapshot, ok := result.(*Snapshot)
+				return ok && snapshot.Status == "completed"
+			}},
+			{State: aws.WaiterFailure, Matcher: func(result interface{}, err error) bool {
+				snapshot, ok := result.(*Snapshot)
+				return ok && snapshot.Status == "error"
+			}},
+			{State: aws.WaiterRetry, Matcher: aws.MatchErrorCode("InvalidSnapshot.NotFound")},
+		},
+		Strategy: snapshotWaitStrategy,
+	}
+	return waiter.Wait(ctx)
+}
+
+// snapshotWaitStrategy allows for snapshots of large volumes.
+var snapshotWaitStrategy = aws.AttemptStrategy{
+	Total: time.Hour,
+	Delay: 15 * time.Second,
+}
+
+// volumeWaiter returns a Waiter polling the volume with the given ID
+// until ready holds, or it fails.
+func (self *EC2) volumeWaiter(name, volumeId string, ready func(*Volume) bool) *aws.Waiter {
+	return &aws.Waiter{
+		Name: name,
+		Poll: func(ctx context.Context) (interface{}, error) {
+			volumes, err := self.DescribeVolumes(ctx, []string{volumeId}, nil)
+			if err != nil || len(volumes) == 0 {
+				return nil, err
+			}
+			return &volumes[0], nil
+		},
+		Acceptors: []aws.Acceptor{
+			{State: aws.WaiterSuccess, Matcher: func(result interface{}, err error) bool {
+				volume, ok := result.(*Volume)
+				return ok && ready(volume)
+			}},
+			{State: aws.WaiterFailure, Matcher: func(result interface{}, err error) bool {
+				volume, ok := result.(*Volume)
+				return ok && (volume.Status == "error" || volume.Status == "deleted")
+			}},
+			// Volumes may not be visible right after they were created.
+			{State: aws.WaiterRetry, Matcher: aws.MatchErrorCode("InvalidVolume.NotFound")},
+		},
+		Strategy: aws.DefaultWaitStrategy,
+	}
+}
@@ -0,0 +1,142 @@
+//go:build !goaws_stable
+
+package ec2
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+func setWaitStrategies(t *testing.T, strategy aws.AttemptStrategy) {
+	oldDefault, oldSnapshot := aws.DefaultWaitStrategy, snapshotWaitStrategy
+	aws.DefaultWaitStrategy, snapshotWaitStrategy = strategy, strategy
+	t.Cleanup(func() { aws.DefaultWaitStrategy, snapshotWaitStrategy = oldDefault, oldSnapshot })
+}
+
+func volumeSet(status, attachment string) string {
+	volume := "<item><volumeId>vol-1</volumeId><size>8</size><status>" + status + "</status>"
+	if attachment != "" {
+		volume += "<attachmentSet><item><volumeId>vol-1</volumeId><instanceId>i-1</instanceId><status>" + attachment + "</status></item></attachmentSet>"
+	}
+	return "<volumeSet>" + volume + "</item></volumeSet>"
+}
+
+func TestCreateVolume(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"CreateVolume": {"<volumeId>vol-1</volumeId><size>100</size><availabilityZone>us-east-1a</availabilityZone><status>creating</status><volumeType>io1</volumeType><iops>3000</iops><encrypted>true</encrypted>"},
+	})
+
+	volume, err := client.CreateVolume(context.Background(), &CreateVolumeOptions{
+		AvailabilityZone: "us-east-1a",
+		Size:             100,
+		VolumeType:       "io1",
+		Iops:             3000,
+		Encrypted:        true,
+		KmsKeyId:         "key-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(0), url.Values{
+		"AvailabilityZone": {"us-east-1a"},
+		"Size":             {"100"},
+		"VolumeType":       {"io1"},
+		"Iops":             {"3000"},
+		"Encrypted":        {"true"},
+		"KmsKeyId":         {"key-1"},
+	})
+	if _, ok := server.Request(0)["SnapshotId"]; ok {
+		t.Errorf("SnapshotId sent without a snapshot")
+	}
+	if volume.VolumeId != "vol-1" || volume.Size != 100 || volume.Status != "creating" || volume.Iops != 3000 || !volume.Encrypted {
+		t.Fatalf("unexpected volume %+v", volume)
+	}
+}
+
+func TestWaitUntilVolumeAvailable(t *testing.T) {
+	setWaitStrategies(t, aws.AttemptStrategy{Total: 5 * time.Second, Delay: time.Millisecond})
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeVolumes": {volumeSet("creating", ""), volumeSet("creating", ""), volumeSet("available", "")},
+	})
+
+	if err := client.WaitUntilVolumeAvailable(context.Background(), "vol-1"); err != nil {
+		t.Fatal(err)
+	}
+	if server.Count() != 3 {
+		t.Fatalf("got %d polls, want 3", server.Count())
+	}
+	if got := server.Request(0).Get("VolumeId.1"); got != "vol-1" {
+		t.Fatalf("polled volume %q", got)
+	}
+}
+
+func TestWaitUntilVolumeAttached(t *testing.T) {
+	setWaitStrategies(t, aws.AttemptStrategy{Total: 5 * time.Second, Delay: time.Millisecond})
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeVolumes": {volumeSet("available", ""), volumeSet("in-use", "attaching"), volumeSet("in-use", "attached")},
+	})
+
+	if err := client.WaitUntilVolumeAttached(context.Background(), "vol-1"); err != nil {
+		t.Fatal(err)
+	}
+	if server.Count() != 3 {
+		t.Fatalf("got %d polls, want 3", server.Count())
+	}
+}
+
+func TestWaitUntilVolumeAvailableFails(t *testing.T) {
+	setWaitStrategies(t, aws.AttemptStrategy{Total: 5 * time.Second, Delay: time.Millisecond})
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeVolumes": {volumeSet("creating", ""), volumeSet("error", "")},
+	})
+
+	err := client.WaitUntilVolumeAvailable(context.Background(), "vol-1")
+	var waiterErr *aws.WaiterError
+	if !errors.As(err, &waiterErr) || waiterErr.Name != "VolumeAvailable" {
+		t.Fatalf("got %v, want a VolumeAvailable WaiterError", err)
+	}
+	if server.Count() != 2 {
+		t.Fatalf("got %d polls, want 2", server.Count())
+	}
+}
+
+func TestWaitUntilVolumeAvailableRetriesNotFound(t *testing.T) {
+	setWaitStrategies(t, aws.AttemptStrategy{Min: 3, Delay: time.Millisecond})
+	client, server := newTestEC2(t, nil)
+	server.Error("DescribeVolumes", 400, "InvalidVolume.NotFound")
+
+	if err := client.WaitUntilVolumeAvailable(context.Background(), "vol-1"); err != aws.ErrWaiterTimeout {
+		t.Fatalf("got %v, want %v", err, aws.ErrWaiterTimeout)
+	}
+	if server.Count() != 3 {
+		t.Fatalf("got %d polls, want 3", server.Count())
+	}
+}
+
+func TestWaitUntilSnapshotCompleted(t *testing.T) {
+	setWaitStrategies(t, aws.AttemptStrategy{Total: 5 * time.Second, Delay: time.Millisecond})
+	snapshot := func(status string) string {
+		return "<snapshotSet><item><snapshotId>snap-1</snapshotId><volumeId>vol-1</volumeId><status>" + status + "</status></item></snapshotSet>"
+	}
+	client, server := newTestEC2(t, map[string][]string{
+		"DescribeSnapshots": {snapshot("pending"), snapshot("completed")},
+	})
+
+	if err := client.WaitUntilSnapshotCompleted(context.Background(), "snap-1"); err != nil {
+		t.Fatal(err)
+	}
+	if server.Count() != 2 || server.Request(0).Get("SnapshotId.1") != "snap-1" {
+		t.Fatalf("got %d polls of %v", server.Count(), server.Request(0))
+	}
+
+	client, server = newTestEC2(t, map[string][]string{"DescribeSnapshots": {snapshot("error")}})
+	var waiterErr *aws.WaiterError
+	if err := client.WaitUntilSnapshotCompleted(context.Background(), "snap-1"); !errors.As(err, &waiterErr) {
+		t.Fatalf("got %v, want a WaiterError", err)
+	}
+}
@@ -0,0 +1,318 @@
+package ec2
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/dkln/go-aws"
+)
+
+// Vpc is a virtual private cloud.
+type Vpc struct {
+	VpcId           string `xml:"vpcId"`
+	State           string `xml:"state"` // "pending" or "available"
+	CidrBlock       string `xml:"cidrBlock"`
+	DhcpOptionsId   string `xml:"dhcpOptionsId"`
+	InstanceTenancy string `xml:"instanceTenancy"`
+	IsDefault       bool   `xml:"isDefault"`
+	Tags            []Tag  `xml:"tagSet>item"`
+}
+
+// CreateVpc creates a VPC with the IPv4 address range cidrBlock, such
+// as "10.0.0.0/16".
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateVpc.html for details.
+func (self *EC2) CreateVpc(ctx context.Context, cidrBlock string) (*Vpc, error) {
+	var resp struct {
+		Vpc Vpc `xml:"vpc"`
+	}
+	if err := self.Do(ctx, "CreateVpc", url.Values{"CidrBlock": {cidrBlock}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Vpc, nil
+}
+
+// DeleteVpc deletes the VPC with the given ID, which must be empty.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeleteVpc.html for details.
+func (self *EC2) DeleteVpc(ctx context.Context, vpcId string) error {
+	return self.Do(ctx, "DeleteVpc", url.Values{"VpcId": {vpcId}}, nil)
+}
+
+// DescribeVpcs describes the VPCs with the given IDs, or all VPCs if
+// there are none, that match filter, which may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeVpcs.html for details.
+func (self *EC2) DescribeVpcs(ctx context.Context, vpcIds []string, filter *Filter) ([]Vpc, error) {
+	params := url.Values{}
+	aws.SetList(params, "VpcId", vpcIds)
+	filter.addParams(params)
+	var resp struct {
+		Vpcs      []Vpc  `xml:"vpcSet>item"`
+		NextToken string `xml:"nextToken"`
+	}
+	if err := self.describe(ctx, "DescribeVpcs", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.Vpcs, nil
+}
+
+// Subnet is a range of addresses of a VPC in an availability zone.
+type Subnet struct {
+	SubnetId                string `xml:"subnetId"`
+	State                   string `xml:"state"`
+	VpcId                   string `xml:"vpcId"`
+	CidrBlock               string `xml:"cidrBlock"`
+	AvailabilityZone        string `xml:"availabilityZone"`
+	AvailableIpAddressCount int    `xml:"availableIpAddressCount"`
+	DefaultForAz            bool   `xml:"defaultForAz"`
+	MapPublicIpOnLaunch     bool   `xml:"mapPublicIpOnLaunch"`
+	Tags                    []Tag  `xml:"tagSet>item"`
+}
+
+// CreateSubnet creates a subnet of the VPC with the given ID with the
+// address range cidrBlock, in availabilityZone or, if empty, one EC2
+// picks.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateSubnet.html for details.
+func (self *EC2) CreateSubnet(ctx context.Context, vpcId, cidrBlock, availabilityZone string) (*Subnet, error) {
+	params := url.Values{
+		"VpcId":     {vpcId},
+		"CidrBlock": {cidrBlock},
+	}
+	if availabilityZone != "" {
+		params.Set("AvailabilityZone", availabilityZone)
+	}
+	var resp struct {
+		Subnet Subnet `xml:"subnet"`
+	}
+	if err := self.Do(ctx, "CreateSubnet", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Subnet, nil
+}
+
+// DeleteSubnet deletes the subnet with the given ID.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeleteSubnet.html for details.
+func (self *EC2) DeleteSubnet(ctx context.Context, subnetId string) error {
+	return self.Do(ctx, "DeleteSubnet", url.Values{"SubnetId": {subnetId}}, nil)
+}
+
+// DescribeSubnets describes the subnets with the given IDs, or all
+// subnets if there are none, that match filter, which may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeSubnets.html for details.
+func (self *EC2) DescribeSubnets(ctx context.Context, subnetIds []string, filter *Filter) ([]Subnet, error) {
+	params := url.Values{}
+	aws.SetList(params, "SubnetId", subnetIds)
+	filter.addParams(params)
+	var resp struct {
+		Subnets   []Subnet `xml:"subnetSet>item"`
+		NextToken string   `xml:"nextToken"`
+	}
+	if err := self.describe(ctx, "DescribeSubnets", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.Subnets, nil
+}
+
+// InternetGateway connects VPCs to the internet.
+type InternetGateway struct {
+	InternetGatewayId string `xml:"internetGatewayId"`
+	Attachments       []struct {
+		VpcId string `xml:"vpcId"`
+		State string `xml:"state"`
+	} `xml:"attachmentSet>item"`
+	Tags []Tag `xml:"tagSet>item"`
+}
+
+// CreateInternetGateway creates an internet gateway.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateInternetGateway.html for details.
+func (self *EC2) CreateInternetGateway(ctx context.Context) (*InternetGateway, error) {
+	var resp struct {
+		InternetGateway InternetGateway `xml:"internetGateway"`
+	}
+	if err := self.Do(ctx, "CreateInternetGateway", url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.InternetGateway, nil
+}
+
+// AttachInternetGateway attaches an internet gateway to a VPC.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_AttachInternetGateway.html for details.
+func (self *EC2) AttachInternetGateway(ctx context.Context, internetGatewayId, vpcId string) error {
+	params := url.Values{
+		"InternetGatewayId": {internetGatewayId},
+		"VpcId":             {vpcId},
+	}
+	return self.Do(ctx, "AttachInternetGateway", params, nil)
+}
+
+// DetachInternetGateway detaches an internet gateway from a VPC.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DetachInternetGateway.html for details.
+func (self *EC2) DetachInternetGateway(ctx context.Context, internetGatewayId, vpcId string) error {
+	params := url.Values{
+		"InternetGatewayId": {internetGatewayId},
+		"VpcId":             {vpcId},
+	}
+	return self.Do(ctx, "DetachInternetGateway", params, nil)
+}
+
+// DeleteInternetGateway deletes the detached internet gateway with the
+// given ID.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeleteInternetGateway.html for details.
+func (self *EC2) DeleteInternetGateway(ctx context.Context, internetGatewayId string) error {
+	return self.Do(ctx, "DeleteInternetGateway", url.Values{"InternetGatewayId": {internetGatewayId}}, nil)
+}
+
+// DescribeInternetGateways describes the internet gateways with the
+// given IDs, or all of them if there are none, that match filter, which
+// may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeInternetGateways.html for details.
+func (self *EC2) DescribeInternetGateways(ctx context.Context, internetGatewayIds []string, filter *Filter) ([]InternetGateway, error) {
+	params := url.Values{}
+	aws.SetList(params, "InternetGatewayId", internetGatewayIds)
+	filter.addParams(params)
+	var resp struct {
+		InternetGateways []InternetGateway `xml:"internetGatewaySet>item"`
+		NextToken        string            `xml:"nextToken"`
+	}
+	if err := self.describe(ctx, "DescribeInternetGateways", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.InternetGateways, nil
+}
+
+// RouteTable holds the routes of the subnets associated with it.
+type RouteTable struct {
+	RouteTableId string                  `xml:"routeTableId"`
+	VpcId        string                  `xml:"vpcId"`
+	Routes       []Route                 `xml:"routeSet>item"`
+	Associations []RouteTableAssociation `xml:"associationSet>item"`
+	Tags         []Tag                   `xml:"tagSet>item"`
+}
+
+// Route sends the traffic for DestinationCidrBlock to a gateway,
+// instance or network interface.
+type Route struct {
+	DestinationCidrBlock string `xml:"destinationCidrBlock"`
+	GatewayId            string `xml:"gatewayId"` // "local" for the VPC itself
+	InstanceId           string `xml:"instanceId"`
+	NatGatewayId         string `xml:"natGatewayId"`
+	NetworkInterfaceId   string `xml:"networkInterfaceId"`
+	State                string `xml:"state"` // "active" or "blackhole"
+	Origin               string `xml:"origin"`
+}
+
+// RouteTableAssociation associates a route table with a subnet, or is
+// the main route table of a VPC.
+type RouteTableAssociation struct {
+	RouteTableAssociationId string `xml:"routeTableAssociationId"`
+	RouteTableId            string `xml:"routeTableId"`
+	SubnetId                string `xml:"subnetId"`
+	Main                    bool   `xml:"main"`
+}
+
+// CreateRouteTable creates a route table for the VPC with the given ID.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateRouteTable.html for details.
+func (self *EC2) CreateRouteTable(ctx context.Context, vpcId string) (*RouteTable, error) {
+	var resp struct {
+		RouteTable RouteTable `xml:"routeTable"`
+	}
+	if err := self.Do(ctx, "CreateRouteTable", url.Values{"VpcId": {vpcId}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.RouteTable, nil
+}
+
+// DeleteRouteTable deletes the route table with the given ID, which must
+// not be associated with subnets.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeleteRouteTable.html for details.
+func (self *EC2) DeleteRouteTable(ctx context.Context, routeTableId string) error {
+	return self.Do(ctx, "DeleteRouteTable", url.Values{"RouteTableId": {routeTableId}}, nil)
+}
+
+// DescribeRouteTables describes the route tables with the given IDs, or
+// all of them if there are none, that match filter, which may be nil.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeRouteTables.html for details.
+func (self *EC2) DescribeRouteTables(ctx context.Context, routeTableIds []string, filter *Filter) ([]RouteTable, error) {
+	params := url.Values{}
+	aws.SetList(params, "RouteTableId", routeTableIds)
+	filter.addParams(params)
+	var resp struct {
+		RouteTables []RouteTable `xml:"routeTableSet>item"`
+		NextToken   string       `xml:"nextToken"`
+	}
+	if err := self.describe(ctx, "DescribeRouteTables", params, &resp, &resp.NextToken); err != nil {
+		return nil, err
+	}
+	return resp.RouteTables, nil
+}
+
+// CreateRoute adds route to the route table with the given ID. Exactly
+// one of the target fields of route must be set.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateRoute.html for details.
+func (self *EC2) CreateRoute(ctx context.Context, routeTableId string, route *Route) error {
+	params := url.Values{
+		"RouteTableId":         {routeTableId},
+		"DestinationCidrBlock": {route.DestinationCidrBlock},
+	}
+	set := func(name, value string) {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	set("GatewayId", route.GatewayId)
+	set("InstanceId", route.InstanceId)
+	set("NatGatewayId", route.NatGatewayId)
+	set("NetworkInterfaceId", route.NetworkInterfaceId)
+	return self.Do(ctx, "CreateRoute", params, nil)
+}
+
+// DeleteRoute removes the route for destinationCidrBlock from the route
+// table with the given ID.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DeleteRoute.html for details.
+func (self *EC2) DeleteRoute(ctx context.Context, routeTableId, destinationCidrBlock string) error {
+	params := url.Values{
+		"RouteTableId":         {routeTableId},
+		"DestinationCidrBlock": {destinationCidrBlock},
+	}
+	return self.Do(ctx, "DeleteRoute", params, nil)
+}
+
+// AssociateRouteTable associates a route table with a subnet and returns
+// the ID of the association.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_AssociateRouteTable.html for details.
+func (self *EC2) AssociateRouteTable(ctx context.Context, routeTableId, subnetId string) (string, error) {
+	params := url.Values{
+		"RouteTableId": {routeTableId},
+		"SubnetId":     {subnetId},
+	}
+	var resp struct {
+		AssociationId string `xml:"associationId"`
+	}
+	if err := self.Do(ctx, "AssociateRouteTable", params, &resp); err != nil {
+		return "", err
+	}
+	return resp.AssociationId, nil
+}
+
+// DisassociateRouteTable removes the association of a route table with
+// a subnet, which then uses the main route table of its VPC again.
+//
+// See https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DisassociateRouteTable.html for details.
+func (self *EC2) DisassociateRouteTable(ctx context.Context, associationId string) error {
+	return self.Do(ctx, "DisassociateRouteTable", url.Values{"AssociationId": {associationId}}, nil)
+}
@@ -0,0 +1,132 @@
+//go:build !goaws_stable
+
+package ec2
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCreateVpcAndSubnet(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"CreateVpc": {"<vpc><vpcId>vpc-1</vpcId><state>pending</state><cidrBlock>10.0.0.0/16</cidrBlock><instanceTenancy>default</instanceTenancy></vpc>"},
+		"CreateSubnet": {
+			"<subnet><subnetId>subnet-1</subnetId><state>pending</state><vpcId>vpc-1</vpcId><cidrBlock>10.0.1.0/24</cidrBlock>" +
+				"<availabilityZone>us-east-1a</availabilityZone><availableIpAddressCount>251</availableIpAddressCount></subnet>",
+		},
+	})
+	ctx := context.Background()
+
+	vpc, err := client.CreateVpc(ctx, "10.0.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(0), url.Values{"CidrBlock": {"10.0.0.0/16"}})
+	if vpc.VpcId != "vpc-1" || vpc.State != "pending" || vpc.CidrBlock != "10.0.0.0/16" || vpc.InstanceTenancy != "default" {
+		t.Fatalf("unexpected VPC %+v", vpc)
+	}
+
+	subnet, err := client.CreateSubnet(ctx, "vpc-1", "10.0.1.0/24", "us-east-1a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(1), url.Values{"VpcId": {"vpc-1"}, "CidrBlock": {"10.0.1.0/24"}, "AvailabilityZone": {"us-east-1a"}})
+	if subnet.SubnetId != "subnet-1" || subnet.VpcId != "vpc-1" || subnet.AvailableIpAddressCount != 251 {
+		t.Fatalf("unexpected subnet %+v", subnet)
+	}
+
+	if _, err := client.CreateSubnet(ctx, "vpc-1", "10.0.2.0/24", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Request(2)["AvailabilityZone"]; ok {
+		t.Errorf("empty AvailabilityZone sent")
+	}
+}
+
+func TestInternetGateway(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"CreateInternetGateway": {"<internetGateway><internetGatewayId>igw-1</internetGatewayId><attachmentSet/></internetGateway>"},
+		"AttachInternetGateway": {"<return>true</return>"},
+		"DescribeInternetGateways": {
+			"<internetGatewaySet><item><internetGatewayId>igw-1</internetGatewayId>" +
+				"<attachmentSet><item><vpcId>vpc-1</vpcId><state>available</state></item></attachmentSet></item></internetGatewaySet>",
+		},
+	})
+	ctx := context.Background()
+
+	gateway, err := client.CreateInternetGateway(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gateway.InternetGatewayId != "igw-1" || len(gateway.Attachments) != 0 {
+		t.Fatalf("unexpected gateway %+v", gateway)
+	}
+	if err := client.AttachInternetGateway(ctx, "igw-1", "vpc-1"); err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(1), url.Values{"InternetGatewayId": {"igw-1"}, "VpcId": {"vpc-1"}})
+
+	gateways, err := client.DescribeInternetGateways(ctx, []string{"igw-1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(2), url.Values{"InternetGatewayId.1": {"igw-1"}})
+	if len(gateways) != 1 || len(gateways[0].Attachments) != 1 || gateways[0].Attachments[0].VpcId != "vpc-1" || gateways[0].Attachments[0].State != "available" {
+		t.Fatalf("unexpected gateways %+v", gateways)
+	}
+}
+
+func TestRouteTables(t *testing.T) {
+	client, server := newTestEC2(t, map[string][]string{
+		"CreateRoute":         {"<return>true</return>"},
+		"AssociateRouteTable": {"<associationId>rtbassoc-1</associationId>"},
+		"DescribeRouteTables": {`<routeTableSet><item>
+			<routeTableId>rtb-1</routeTableId><vpcId>vpc-1</vpcId>
+			<routeSet>
+				<item><destinationCidrBlock>10.0.0.0/16</destinationCidrBlock><gatewayId>local</gatewayId><state>active</state><origin>CreateRouteTable</origin></item>
+				<item><destinationCidrBlock>0.0.0.0/0</destinationCidrBlock><gatewayId>igw-1</gatewayId><state>active</state><origin>CreateRoute</origin></item>
+			</routeSet>
+			<associationSet><item><routeTableAssociationId>rtbassoc-1</routeTableAssociationId><routeTableId>rtb-1</routeTableId><subnetId>subnet-1</subnetId><main>false</main></item></associationSet>
+		</item></routeTableSet>`},
+	})
+	ctx := context.Background()
+
+	if err := client.CreateRoute(ctx, "rtb-1", &Route{DestinationCidrBlock: "0.0.0.0/0", GatewayId: "igw-1"}); err != nil {
+		t.Fatal(err)
+	}
+	got := server.Request(0)
+	checkParams(t, got, url.Values{"RouteTableId": {"rtb-1"}, "DestinationCidrBlock": {"0.0.0.0/0"}, "GatewayId": {"igw-1"}})
+	for _, name := range []string{"InstanceId", "NatGatewayId", "NetworkInterfaceId"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("unexpected %s=%q", name, got.Get(name))
+		}
+	}
+
+	id, err := client.AssociateRouteTable(ctx, "rtb-1", "subnet-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "rtbassoc-1" {
+		t.Fatalf("got association %q", id)
+	}
+	checkParams(t, server.Request(1), url.Values{"RouteTableId": {"rtb-1"}, "SubnetId": {"subnet-1"}})
+
+	tables, err := client.DescribeRouteTables(ctx, nil, NewFilter().VpcId("vpc-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(2), url.Values{"Filter.1.Name": {"vpc-id"}, "Filter.1.Value.1": {"vpc-1"}})
+	if len(tables) != 1 {
+		t.Fatalf("got %d route tables", len(tables))
+	}
+	wantRoutes := []Route{
+		{DestinationCidrBlock: "10.0.0.0/16", GatewayId: "local", State: "active", Origin: "CreateRouteTable"},
+		{DestinationCidrBlock: "0.0.0.0/0", GatewayId: "igw-1", State: "active", Origin: "CreateRoute"},
+	}
+	wantAssociations := []RouteTableAssociation{{"rtbassoc-1", "rtb-1", "subnet-1", false}}
+	if !reflect.DeepEqual(tables[0].Routes, wantRoutes) || !reflect.DeepEqual(tables[0].Associations, wantAssociations) {
+		t.Fatalf("unexpected route table %+v", tables[0])
+	}
+}
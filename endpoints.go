@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"errors"
+	"strings"
+)
+
+// Endpoint is where and how to address a service in a region.
+type Endpoint struct {
+	URL           string // base URL, e.g. "https://sqs.eu-west-1.amazonaws.com"
+	SigningRegion string // region to sign requests for with SigV4
+	SigningName   string // service name to sign requests for with SigV4
+}
+
+// EndpointResolver finds the endpoint of a service in a region. Service
+// clients ask their resolver instead of reading fields of Region, so new
+// services and custom deployments need no changes to Region.
+type EndpointResolver interface {
+	ResolveEndpoint(service, region string) (Endpoint, error)
+}
+
+// EndpointResolverFunc adapts a function to an EndpointResolver.
+type EndpointResolverFunc func(service, region string) (Endpoint, error)
+
+func (self EndpointResolverFunc) ResolveEndpoint(service, region string) (Endpoint, error) {
+	return self(service, region)
+}
+
+// DefaultResolver resolves endpoints from the registered Regions, falling
+// back to the usual https://service.region.amazonaws.com naming for
+// services Region has no field for.
+var DefaultResolver EndpointResolver = EndpointResolverFunc(resolveEndpoint)
+
+// globalServices are served from a single endpoint signed for us-east-1.
+var globalServices = map[string]string{
+	"iam":        "https://iam.amazonaws.com",
+	"sts":        "https://sts.amazonaws.com",
+	"route53":    "https://route53.amazonaws.com",
+	"cloudfront": "https://cloudfront.amazonaws.com",
+}
+
+func resolveEndpoint(service, region string) (Endpoint, error) {
+	if service == "" {
+		return Endpoint{}, errors.New("cannot resolve an endpoint without a service")
+	}
+	if url, ok := globalServices[service]; ok {
+		return Endpoint{URL: url, SigningRegion: USEast.Name, SigningName: service}, nil
+	}
+	if region == "" {
+		return Endpoint{}, errors.New("cannot resolve an endpoint for " + service + " without a region")
+	}
+
+	endpoint := Endpoint{SigningRegion: region, SigningName: service}
+	if r, ok := GetRegion(region); ok {
+		endpoint.URL = r.endpoint(service)
+	}
+	if endpoint.URL == "" {
+		endpoint.URL = "https://" + service + "." + region + ".amazonaws.com"
+		if strings.HasPrefix(region, "cn-") {
+			endpoint.URL += ".cn"
+		}
+	}
+	return endpoint, nil
+}
+
+// endpoint returns the URL of service set in the region, if any.
+func (self Region) endpoint(service string) string {
+	switch service {
+	case "ec2":
+		return self.EC2Endpoint
+	case "s3":
+		return self.S3Endpoint
+	case "sdb":
+		return self.SDBEndpoint
+	case "sns":
+		return self.SNSEndpoint
+	case "sqs":
+		return self.SQSEndpoint
+	case "iam":
+		return self.IAMEndpoint
+	}
+	return ""
+}
+
+// StaticResolver resolves every service to the endpoints it holds and
+// defers to Fallback, or DefaultResolver if that is nil, for the others.
+// It addresses VPC endpoints and compatible on-premises gateways.
+type StaticResolver struct {
+	Endpoints map[string]Endpoint // by service name
+	Fallback  EndpointResolver
+}
+
+func (self *StaticResolver) ResolveEndpoint(service, region string) (Endpoint, error) {
+	if endpoint, ok := self.Endpoints[service]; ok {
+		if endpoint.SigningRegion == "" {
+			endpoint.SigningRegion = region
+		}
+		if endpoint.SigningName == "" {
+			endpoint.SigningName = service
+		}
+		return endpoint, nil
+	}
+	if self.Fallback != nil {
+		return self.Fallback.ResolveEndpoint(service, region)
+	}
+	return DefaultResolver.ResolveEndpoint(service, region)
+}
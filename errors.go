@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"errors"
+	"strings"
+)
+
+/**
+ * APIError is implemented by the errors service clients return for error
+ * responses, such as *s3.Error.
+ */
+type APIError interface {
+	error
+	ErrorCode() string   // AWS error code, e.g. "NoSuchKey"
+	HTTPStatusCode() int // HTTP status of the response
+}
+
+/**
+ * ErrorCode returns the AWS error code of err, or "" if err, or any
+ * error it wraps, is not an APIError.
+ */
+func ErrorCode(err error) string {
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+/**
+ * HTTPStatusCode returns the HTTP status of the response err was
+ * returned for, or 0 if err is not an APIError.
+ */
+func HTTPStatusCode(err error) int {
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode()
+	}
+	return 0
+}
+
+/**
+ * IsThrottle reports whether err is a service asking the client to slow
+ * down.
+ */
+func IsThrottle(err error) bool {
+	status := HTTPStatusCode(err)
+	return IsThrottleCode(ErrorCode(err)) || status == 429 || status == 503
+}
+
+/**
+ * IsAccessDenied reports whether err is a service refusing a request the
+ * credentials used are not allowed to make.
+ */
+func IsAccessDenied(err error) bool {
+	switch ErrorCode(err) {
+	case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation",
+		"UnauthorizedAccess", "AuthorizationError":
+		return true
+	case "":
+		return HTTPStatusCode(err) == 403
+	}
+	return false
+}
+
+/**
+ * IsNotFound reports whether err is a service saying the resource a
+ * request was about does not exist.
+ */
+func IsNotFound(err error) bool {
+	code := ErrorCode(err)
+	if strings.HasPrefix(code, "NoSuch") || strings.HasSuffix(code, "NotFound") ||
+		strings.HasSuffix(code, "NotFoundException") || strings.HasSuffix(code, ".NotFound") {
+		return true
+	}
+	return code == "" && HTTPStatusCode(err) == 404
+}
@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+)
+
+/**
+ * Hooks are functions service clients call at fixed points of every
+ * request, so tracing headers, audit logging or custom changes to
+ * requests can be added without forking the clients. Hooks of the same
+ * kind run in the order they were added; a nil Hooks has none.
+ */
+type Hooks struct {
+	// BeforeSign run before the request is signed. Changes they make to
+	// the headers or the query are covered by the signature. An error
+	// aborts the request.
+	BeforeSign []func(req *http.Request) error
+
+	// BeforeSend run after the request is signed, right before it is
+	// sent. Changing signed parts of the request invalidates the
+	// signature. An error aborts the request.
+	BeforeSend []func(req *http.Request) error
+
+	// AfterResponse run once the response headers have been received, or
+	// the request has failed with err. They must not consume resp.Body.
+	AfterResponse []func(req *http.Request, resp *http.Response, err error)
+
+	// OnRetry run before a failed request is retried; try counts the
+	// retries, from 0, and ctx is the context of the request.
+	OnRetry []func(ctx context.Context, err error, try int)
+}
+
+/**
+ * RunBeforeSign runs the BeforeSign hooks, stopping at the first error.
+ */
+func (self *Hooks) RunBeforeSign(req *http.Request) error {
+	if self == nil {
+		return nil
+	}
+	for _, hook := range self.BeforeSign {
+		if err := hook(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * RunBeforeSend runs the BeforeSend hooks, stopping at the first error.
+ */
+func (self *Hooks) RunBeforeSend(req *http.Request) error {
+	if self == nil {
+		return nil
+	}
+	for _, hook := range self.BeforeSend {
+		if err := hook(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * RunAfterResponse runs the AfterResponse hooks.
+ */
+func (self *Hooks) RunAfterResponse(req *http.Request, resp *http.Response, err error) {
+	if self == nil {
+		return
+	}
+	for _, hook := range self.AfterResponse {
+		hook(req, resp, err)
+	}
+}
+
+/**
+ * RunOnRetry runs the OnRetry hooks.
+ */
+func (self *Hooks) RunOnRetry(ctx context.Context, err error, try int) {
+	if self == nil {
+		return
+	}
+	for _, hook := range self.OnRetry {
+		hook(ctx, err, try)
+	}
+}
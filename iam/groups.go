@@ -0,0 +1,100 @@
+package iam
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Group is an IAM group, whose users get the permissions of its
+// policies.
+type Group struct {
+	Path       string
+	GroupName  string
+	GroupId    string
+	Arn        string
+	CreateDate time.Time
+}
+
+// CreateGroup creates the group named name with the given path, or "/"
+// if empty.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_CreateGroup.html for details.
+func (self *IAM) CreateGroup(ctx context.Context, name, path string) (*Group, error) {
+	params := url.Values{"GroupName": {name}}
+	if path != "" {
+		params.Set("Path", path)
+	}
+	var resp struct {
+		Group Group `xml:"CreateGroupResult>Group"`
+	}
+	if err := self.Do(ctx, "CreateGroup", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Group, nil
+}
+
+// ListGroups returns the groups whose path starts with pathPrefix, or
+// all groups if it is empty.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListGroups.html for details.
+func (self *IAM) ListGroups(ctx context.Context, pathPrefix string) ([]Group, error) {
+	params := url.Values{}
+	if pathPrefix != "" {
+		params.Set("PathPrefix", pathPrefix)
+	}
+	var resp struct {
+		Groups []Group `xml:"ListGroupsResult>Groups>member"`
+		Marker string  `xml:"ListGroupsResult>Marker"`
+	}
+	if err := self.list(ctx, "ListGroups", params, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	return resp.Groups, nil
+}
+
+// DeleteGroup deletes the group named name, which must have no users
+// nor policies.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_DeleteGroup.html for details.
+func (self *IAM) DeleteGroup(ctx context.Context, name string) error {
+	return self.Do(ctx, "DeleteGroup", url.Values{"GroupName": {name}}, nil)
+}
+
+// AddUserToGroup adds the user named userName to the group named
+// groupName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_AddUserToGroup.html for details.
+func (self *IAM) AddUserToGroup(ctx context.Context, groupName, userName string) error {
+	params := url.Values{
+		"GroupName": {groupName},
+		"UserName":  {userName},
+	}
+	return self.Do(ctx, "AddUserToGroup", params, nil)
+}
+
+// RemoveUserFromGroup removes the user named userName from the group
+// named groupName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_RemoveUserFromGroup.html for details.
+func (self *IAM) RemoveUserFromGroup(ctx context.Context, groupName, userName string) error {
+	params := url.Values{
+		"GroupName": {groupName},
+		"UserName":  {userName},
+	}
+	return self.Do(ctx, "RemoveUserFromGroup", params, nil)
+}
+
+// ListGroupsForUser returns the groups the user named userName is in.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListGroupsForUser.html for details.
+func (self *IAM) ListGroupsForUser(ctx context.Context, userName string) ([]Group, error) {
+	var resp struct {
+		Groups []Group `xml:"ListGroupsForUserResult>Groups>member"`
+		Marker string  `xml:"ListGroupsForUserResult>Marker"`
+	}
+	if err := self.list(ctx, "ListGroupsForUser", url.Values{"UserName": {userName}}, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	return resp.Groups, nil
+}
@@ -0,0 +1,40 @@
+//go:build !goaws_stable
+
+package iam
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	client, server := newTestIAM(t, map[string][]string{
+		"CreateGroup":    {"<Group><GroupName>admins</GroupName><Path>/ops/</Path><Arn>arn:aws:iam::123456789012:group/ops/admins</Arn></Group>"},
+		"AddUserToGroup": {""},
+		"ListGroupsForUser": {
+			"<Groups><member><GroupName>admins</GroupName></member></Groups><IsTruncated>true</IsTruncated><Marker>m</Marker>",
+			"<Groups><member><GroupName>devs</GroupName></member></Groups>",
+		},
+	})
+	ctx := context.Background()
+	group, err := client.CreateGroup(ctx, "admins", "/ops/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.Arn != "arn:aws:iam::123456789012:group/ops/admins" || server.Request(0).Get("Path") != "/ops/" {
+		t.Fatalf("got %+v", group)
+	}
+	if err := client.AddUserToGroup(ctx, "admins", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Request(1); sent.Get("GroupName") != "admins" || sent.Get("UserName") != "alice" {
+		t.Fatalf("sent %v", sent)
+	}
+	groups, err := client.ListGroupsForUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[1].GroupName != "devs" {
+		t.Fatalf("got %+v", groups)
+	}
+}
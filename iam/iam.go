@@ -0,0 +1,57 @@
+// Package iam interacts with AWS Identity and Access Management.
+package iam
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/dkln/go-aws"
+)
+
+// APIVersion is the version of the IAM API the package speaks.
+const APIVersion = "2010-05-08"
+
+// The IAM type encapsulates operations with IAM. IAM is a global
+// service; the region only selects the partition, such as China.
+type IAM struct {
+	*aws.QueryClient
+}
+
+// New creates a new IAM.
+func New(auth aws.Auth, region aws.Region) *IAM {
+	client := aws.NewQueryClient(auth, region, "iam", APIVersion)
+	client.IdempotentActions = map[string]bool{
+		"PutUserPolicy":          true,
+		"PutRolePolicy":          true,
+		"AttachRolePolicy":       true,
+		"AttachUserPolicy":       true,
+		"UpdateAssumeRolePolicy": true,
+	}
+	return &IAM{client}
+}
+
+// list calls a List action for every page of its results and decodes
+// them all into resp, whose slices accumulate the items of the pages.
+// marker points to the Marker field of resp, which IAM only sets when
+// the results are truncated.
+func (self *IAM) list(ctx context.Context, action string, params url.Values, resp interface{}, marker *string) error {
+	for {
+		*marker = ""
+		if err := self.Do(ctx, action, params, resp); err != nil {
+			return err
+		}
+		if *marker == "" {
+			return nil
+		}
+		params.Set("Marker", *marker)
+	}
+}
+
+// decodeDocument returns the policy document doc, which IAM returns URL
+// encoded. Documents that fail to decode are returned as they are.
+func decodeDocument(doc string) string {
+	if decoded, err := url.PathUnescape(doc); err == nil {
+		return decoded
+	}
+	return doc
+}
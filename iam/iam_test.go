@@ -0,0 +1,54 @@
+//go:build !goaws_stable
+
+package iam
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/x/awstest"
+)
+
+func newTestIAM(t *testing.T, responses map[string][]string) (*IAM, *awstest.QueryServer) {
+	server := awstest.NewQueryServer(responses)
+	t.Cleanup(server.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.Region{Name: "us-east-1", IAMEndpoint: server.URL, SigV4Only: true})
+	client.Attempts = &aws.AttemptStrategy{Min: 1, Delay: time.Millisecond}
+	return client, server
+}
+
+func TestListFollowsMarkers(t *testing.T) {
+	client, server := newTestIAM(t, map[string][]string{
+		"ListInstanceProfiles": {
+			"<InstanceProfiles><member><InstanceProfileName>a</InstanceProfileName></member><member><InstanceProfileName>b</InstanceProfileName></member></InstanceProfiles>" +
+				"<IsTruncated>true</IsTruncated><Marker>m1</Marker>",
+			"<InstanceProfiles><member><InstanceProfileName>c</InstanceProfileName></member></InstanceProfiles><IsTruncated>false</IsTruncated>",
+		},
+	})
+	profiles, err := client.ListInstanceProfiles(context.Background(), "/web/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, profile := range profiles {
+		names = append(names, profile.InstanceProfileName)
+	}
+	if fmt.Sprint(names) != "[a b c]" {
+		t.Fatalf("got %v", names)
+	}
+	if server.Request(0).Get("Marker") != "" || server.Request(1).Get("Marker") != "m1" || server.Request(1).Get("PathPrefix") != "/web/" {
+		t.Fatalf("sent %v, then %v", server.Request(0), server.Request(1))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	client, server := newTestIAM(t, nil)
+	server.Error("GetRole", 404, "NoSuchEntity")
+	_, err := client.GetRole(context.Background(), "missing")
+	if aws.ErrorCode(err) != "NoSuchEntity" || !aws.IsNotFound(err) {
+		t.Fatalf("got %v", err)
+	}
+}
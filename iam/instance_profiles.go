@@ -0,0 +1,131 @@
+package iam
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// InstanceProfile passes a role to EC2 instances launched with it,
+// whose applications then get the role's credentials from the instance
+// metadata.
+type InstanceProfile struct {
+	Path                string
+	InstanceProfileName string
+	InstanceProfileId   string
+	Arn                 string
+	CreateDate          time.Time
+	Roles               []Role `xml:"Roles>member"` // at most one
+}
+
+func (self *InstanceProfile) decode() {
+	for i := range self.Roles {
+		self.Roles[i].AssumeRolePolicyDocument = decodeDocument(self.Roles[i].AssumeRolePolicyDocument)
+	}
+}
+
+// CreateInstanceProfile creates the instance profile named name with
+// the given path, or "/" if empty. Add a role to it with
+// AddRoleToInstanceProfile.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_CreateInstanceProfile.html for details.
+func (self *IAM) CreateInstanceProfile(ctx context.Context, name, path string) (*InstanceProfile, error) {
+	params := url.Values{"InstanceProfileName": {name}}
+	if path != "" {
+		params.Set("Path", path)
+	}
+	var resp struct {
+		InstanceProfile InstanceProfile `xml:"CreateInstanceProfileResult>InstanceProfile"`
+	}
+	if err := self.Do(ctx, "CreateInstanceProfile", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.InstanceProfile, nil
+}
+
+// GetInstanceProfile returns the instance profile named name.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_GetInstanceProfile.html for details.
+func (self *IAM) GetInstanceProfile(ctx context.Context, name string) (*InstanceProfile, error) {
+	var resp struct {
+		InstanceProfile InstanceProfile `xml:"GetInstanceProfileResult>InstanceProfile"`
+	}
+	if err := self.Do(ctx, "GetInstanceProfile", url.Values{"InstanceProfileName": {name}}, &resp); err != nil {
+		return nil, err
+	}
+	resp.InstanceProfile.decode()
+	return &resp.InstanceProfile, nil
+}
+
+// ListInstanceProfiles returns the instance profiles whose path starts
+// with pathPrefix, or all instance profiles if it is empty.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListInstanceProfiles.html for details.
+func (self *IAM) ListInstanceProfiles(ctx context.Context, pathPrefix string) ([]InstanceProfile, error) {
+	params := url.Values{}
+	if pathPrefix != "" {
+		params.Set("PathPrefix", pathPrefix)
+	}
+	var resp struct {
+		InstanceProfiles []InstanceProfile `xml:"ListInstanceProfilesResult>InstanceProfiles>member"`
+		Marker           string            `xml:"ListInstanceProfilesResult>Marker"`
+	}
+	if err := self.list(ctx, "ListInstanceProfiles", params, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	for i := range resp.InstanceProfiles {
+		resp.InstanceProfiles[i].decode()
+	}
+	return resp.InstanceProfiles, nil
+}
+
+// ListInstanceProfilesForRole returns the instance profiles holding the
+// role named roleName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListInstanceProfilesForRole.html for details.
+func (self *IAM) ListInstanceProfilesForRole(ctx context.Context, roleName string) ([]InstanceProfile, error) {
+	params := url.Values{"RoleName": {roleName}}
+	var resp struct {
+		InstanceProfiles []InstanceProfile `xml:"ListInstanceProfilesForRoleResult>InstanceProfiles>member"`
+		Marker           string            `xml:"ListInstanceProfilesForRoleResult>Marker"`
+	}
+	if err := self.list(ctx, "ListInstanceProfilesForRole", params, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	for i := range resp.InstanceProfiles {
+		resp.InstanceProfiles[i].decode()
+	}
+	return resp.InstanceProfiles, nil
+}
+
+// AddRoleToInstanceProfile adds the role named roleName to the instance
+// profile named profileName, which can hold a single role.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_AddRoleToInstanceProfile.html for details.
+func (self *IAM) AddRoleToInstanceProfile(ctx context.Context, profileName, roleName string) error {
+	params := url.Values{
+		"InstanceProfileName": {profileName},
+		"RoleName":            {roleName},
+	}
+	return self.Do(ctx, "AddRoleToInstanceProfile", params, nil)
+}
+
+// RemoveRoleFromInstanceProfile removes the role named roleName from the
+// instance profile named profileName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_RemoveRoleFromInstanceProfile.html for details.
+func (self *IAM) RemoveRoleFromInstanceProfile(ctx context.Context, profileName, roleName string) error {
+	params := url.Values{
+		"InstanceProfileName": {profileName},
+		"RoleName":            {roleName},
+	}
+	return self.Do(ctx, "RemoveRoleFromInstanceProfile", params, nil)
+}
+
+// DeleteInstanceProfile deletes the instance profile named name, which
+// must not hold a role.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_DeleteInstanceProfile.html for details.
+func (self *IAM) DeleteInstanceProfile(ctx context.Context, name string) error {
+	return self.Do(ctx, "DeleteInstanceProfile", url.Values{"InstanceProfileName": {name}}, nil)
+}
@@ -0,0 +1,337 @@
+package iam
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Inline policies are embedded in a single user or role; managed
+// policies are standalone and can be attached to many.
+
+// PutUserPolicy creates or replaces the inline policy named name of the
+// user named userName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_PutUserPolicy.html for details.
+func (self *IAM) PutUserPolicy(ctx context.Context, userName, name string, policy *PolicyDocument) error {
+	params := url.Values{
+		"UserName":       {userName},
+		"PolicyName":     {name},
+		"PolicyDocument": {policy.String()},
+	}
+	return self.Do(ctx, "PutUserPolicy", params, nil)
+}
+
+// PutRolePolicy creates or replaces the inline policy named name of the
+// role named roleName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_PutRolePolicy.html for details.
+func (self *IAM) PutRolePolicy(ctx context.Context, roleName, name string, policy *PolicyDocument) error {
+	params := url.Values{
+		"RoleName":       {roleName},
+		"PolicyName":     {name},
+		"PolicyDocument": {policy.String()},
+	}
+	return self.Do(ctx, "PutRolePolicy", params, nil)
+}
+
+// GetUserPolicy returns the inline policy named name of the user named
+// userName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_GetUserPolicy.html for details.
+func (self *IAM) GetUserPolicy(ctx context.Context, userName, name string) (*PolicyDocument, error) {
+	params := url.Values{
+		"UserName":   {userName},
+		"PolicyName": {name},
+	}
+	var resp struct {
+		PolicyDocument string `xml:"GetUserPolicyResult>PolicyDocument"`
+	}
+	if err := self.Do(ctx, "GetUserPolicy", params, &resp); err != nil {
+		return nil, err
+	}
+	return ParsePolicyDocument(resp.PolicyDocument)
+}
+
+// GetRolePolicy returns the inline policy named name of the role named
+// roleName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_GetRolePolicy.html for details.
+func (self *IAM) GetRolePolicy(ctx context.Context, roleName, name string) (*PolicyDocument, error) {
+	params := url.Values{
+		"RoleName":   {roleName},
+		"PolicyName": {name},
+	}
+	var resp struct {
+		PolicyDocument string `xml:"GetRolePolicyResult>PolicyDocument"`
+	}
+	if err := self.Do(ctx, "GetRolePolicy", params, &resp); err != nil {
+		return nil, err
+	}
+	return ParsePolicyDocument(resp.PolicyDocument)
+}
+
+// ListUserPolicies returns the names of the inline policies of the user
+// named userName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListUserPolicies.html for details.
+func (self *IAM) ListUserPolicies(ctx context.Context, userName string) ([]string, error) {
+	var resp struct {
+		PolicyNames []string `xml:"ListUserPoliciesResult>PolicyNames>member"`
+		Marker      string   `xml:"ListUserPoliciesResult>Marker"`
+	}
+	if err := self.list(ctx, "ListUserPolicies", url.Values{"UserName": {userName}}, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	return resp.PolicyNames, nil
+}
+
+// ListRolePolicies returns the names of the inline policies of the role
+// named roleName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListRolePolicies.html for details.
+func (self *IAM) ListRolePolicies(ctx context.Context, roleName string) ([]string, error) {
+	var resp struct {
+		PolicyNames []string `xml:"ListRolePoliciesResult>PolicyNames>member"`
+		Marker      string   `xml:"ListRolePoliciesResult>Marker"`
+	}
+	if err := self.list(ctx, "ListRolePolicies", url.Values{"RoleName": {roleName}}, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	return resp.PolicyNames, nil
+}
+
+// DeleteUserPolicy deletes the inline policy named name of the user
+// named userName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_DeleteUserPolicy.html for details.
+func (self *IAM) DeleteUserPolicy(ctx context.Context, userName, name string) error {
+	params := url.Values{
+		"UserName":   {userName},
+		"PolicyName": {name},
+	}
+	return self.Do(ctx, "DeleteUserPolicy", params, nil)
+}
+
+// DeleteRolePolicy deletes the inline policy named name of the role
+// named roleName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_DeleteRolePolicy.html for details.
+func (self *IAM) DeleteRolePolicy(ctx context.Context, roleName, name string) error {
+	params := url.Values{
+		"RoleName":   {roleName},
+		"PolicyName": {name},
+	}
+	return self.Do(ctx, "DeleteRolePolicy", params, nil)
+}
+
+// Policy is a managed policy.
+type Policy struct {
+	PolicyName       string
+	PolicyId         string
+	Arn              string
+	Path             string
+	DefaultVersionId string // version in effect, e.g. "v1"
+	AttachmentCount  int
+	IsAttachable     bool
+	Description      string
+	CreateDate       time.Time
+	UpdateDate       time.Time
+}
+
+// CreatePolicy creates the managed policy named name with the given
+// path, or "/" if empty, and description, which may be empty.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_CreatePolicy.html for details.
+func (self *IAM) CreatePolicy(ctx context.Context, name, path, description string, policy *PolicyDocument) (*Policy, error) {
+	params := url.Values{
+		"PolicyName":     {name},
+		"PolicyDocument": {policy.String()},
+	}
+	if path != "" {
+		params.Set("Path", path)
+	}
+	if description != "" {
+		params.Set("Description", description)
+	}
+	var resp struct {
+		Policy Policy `xml:"CreatePolicyResult>Policy"`
+	}
+	if err := self.Do(ctx, "CreatePolicy", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Policy, nil
+}
+
+// GetPolicy returns the managed policy with the given ARN. Its document
+// is returned by GetPolicyVersion.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_GetPolicy.html for details.
+func (self *IAM) GetPolicy(ctx context.Context, arn string) (*Policy, error) {
+	var resp struct {
+		Policy Policy `xml:"GetPolicyResult>Policy"`
+	}
+	if err := self.Do(ctx, "GetPolicy", url.Values{"PolicyArn": {arn}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Policy, nil
+}
+
+// PolicyVersion is a version of a managed policy.
+type PolicyVersion struct {
+	VersionId        string
+	IsDefaultVersion bool
+	CreateDate       time.Time
+	Document         *PolicyDocument
+}
+
+// GetPolicyVersion returns the version versionId of the managed policy
+// with the given ARN. Pass the DefaultVersionId of the policy for the
+// version in effect.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_GetPolicyVersion.html for details.
+func (self *IAM) GetPolicyVersion(ctx context.Context, arn, versionId string) (*PolicyVersion, error) {
+	params := url.Values{
+		"PolicyArn": {arn},
+		"VersionId": {versionId},
+	}
+	var resp struct {
+		VersionId        string    `xml:"GetPolicyVersionResult>PolicyVersion>VersionId"`
+		IsDefaultVersion bool      `xml:"GetPolicyVersionResult>PolicyVersion>IsDefaultVersion"`
+		CreateDate       time.Time `xml:"GetPolicyVersionResult>PolicyVersion>CreateDate"`
+		Document         string    `xml:"GetPolicyVersionResult>PolicyVersion>Document"`
+	}
+	if err := self.Do(ctx, "GetPolicyVersion", params, &resp); err != nil {
+		return nil, err
+	}
+	document, err := ParsePolicyDocument(resp.Document)
+	if err != nil {
+		return nil, err
+	}
+	return &PolicyVersion{resp.VersionId, resp.IsDefaultVersion, resp.CreateDate, document}, nil
+}
+
+// Scopes of ListPolicies.
+const (
+	ScopeAll   = "All"
+	ScopeAWS   = "AWS"   // policies managed by AWS
+	ScopeLocal = "Local" // policies of the account
+)
+
+// ListPolicies returns the managed policies of scope, or of all scopes
+// if it is empty, whose path starts with pathPrefix. If onlyAttached is
+// set, only policies attached to a user, group or role are returned.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListPolicies.html for details.
+func (self *IAM) ListPolicies(ctx context.Context, scope, pathPrefix string, onlyAttached bool) ([]Policy, error) {
+	params := url.Values{}
+	if scope != "" {
+		params.Set("Scope", scope)
+	}
+	if pathPrefix != "" {
+		params.Set("PathPrefix", pathPrefix)
+	}
+	if onlyAttached {
+		params.Set("OnlyAttached", "true")
+	}
+	var resp struct {
+		Policies []Policy `xml:"ListPoliciesResult>Policies>member"`
+		Marker   string   `xml:"ListPoliciesResult>Marker"`
+	}
+	if err := self.list(ctx, "ListPolicies", params, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	return resp.Policies, nil
+}
+
+// DeletePolicy deletes the managed policy with the given ARN, which must
+// not be attached and have no versions but the default one.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_DeletePolicy.html for details.
+func (self *IAM) DeletePolicy(ctx context.Context, arn string) error {
+	return self.Do(ctx, "DeletePolicy", url.Values{"PolicyArn": {arn}}, nil)
+}
+
+// AttachedPolicy is a managed policy attached to a user, group or role.
+type AttachedPolicy struct {
+	PolicyName string
+	PolicyArn  string
+}
+
+// AttachRolePolicy attaches the managed policy with the given ARN to the
+// role named roleName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_AttachRolePolicy.html for details.
+func (self *IAM) AttachRolePolicy(ctx context.Context, roleName, arn string) error {
+	params := url.Values{
+		"RoleName":  {roleName},
+		"PolicyArn": {arn},
+	}
+	return self.Do(ctx, "AttachRolePolicy", params, nil)
+}
+
+// DetachRolePolicy detaches the managed policy with the given ARN from
+// the role named roleName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_DetachRolePolicy.html for details.
+func (self *IAM) DetachRolePolicy(ctx context.Context, roleName, arn string) error {
+	params := url.Values{
+		"RoleName":  {roleName},
+		"PolicyArn": {arn},
+	}
+	return self.Do(ctx, "DetachRolePolicy", params, nil)
+}
+
+// ListAttachedRolePolicies returns the managed policies attached to the
+// role named roleName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListAttachedRolePolicies.html for details.
+func (self *IAM) ListAttachedRolePolicies(ctx context.Context, roleName string) ([]AttachedPolicy, error) {
+	var resp struct {
+		AttachedPolicies []AttachedPolicy `xml:"ListAttachedRolePoliciesResult>AttachedPolicies>member"`
+		Marker           string           `xml:"ListAttachedRolePoliciesResult>Marker"`
+	}
+	if err := self.list(ctx, "ListAttachedRolePolicies", url.Values{"RoleName": {roleName}}, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	return resp.AttachedPolicies, nil
+}
+
+// AttachUserPolicy attaches the managed policy with the given ARN to the
+// user named userName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_AttachUserPolicy.html for details.
+func (self *IAM) AttachUserPolicy(ctx context.Context, userName, arn string) error {
+	params := url.Values{
+		"UserName":  {userName},
+		"PolicyArn": {arn},
+	}
+	return self.Do(ctx, "AttachUserPolicy", params, nil)
+}
+
+// DetachUserPolicy detaches the managed policy with the given ARN from
+// the user named userName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_DetachUserPolicy.html for details.
+func (self *IAM) DetachUserPolicy(ctx context.Context, userName, arn string) error {
+	params := url.Values{
+		"UserName":  {userName},
+		"PolicyArn": {arn},
+	}
+	return self.Do(ctx, "DetachUserPolicy", params, nil)
+}
+
+// ListAttachedUserPolicies returns the managed policies attached to the
+// user named userName.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListAttachedUserPolicies.html for details.
+func (self *IAM) ListAttachedUserPolicies(ctx context.Context, userName string) ([]AttachedPolicy, error) {
+	var resp struct {
+		AttachedPolicies []AttachedPolicy `xml:"ListAttachedUserPoliciesResult>AttachedPolicies>member"`
+		Marker           string           `xml:"ListAttachedUserPoliciesResult>Marker"`
+	}
+	if err := self.list(ctx, "ListAttachedUserPolicies", url.Values{"UserName": {userName}}, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	return resp.AttachedPolicies, nil
+}
@@ -0,0 +1,86 @@
+//go:build !goaws_stable
+
+package iam
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+var testPolicy = NewPolicyDocument(Statement{
+	Effect:   "Allow",
+	Action:   StringList{"s3:GetObject"},
+	Resource: StringList{"arn:aws:s3:::bucket/*"},
+})
+
+func TestInlinePolicies(t *testing.T) {
+	client, server := newTestIAM(t, map[string][]string{
+		"PutRolePolicy": {""},
+		"GetRolePolicy": {"<RoleName>app</RoleName><PolicyName>read</PolicyName><PolicyDocument>" + url.PathEscape(testPolicy.String()) + "</PolicyDocument>"},
+	})
+	ctx := context.Background()
+	if err := client.PutRolePolicy(ctx, "app", "read", testPolicy); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Request(0); sent.Get("PolicyDocument") != testPolicy.String() || sent.Get("PolicyName") != "read" {
+		t.Fatalf("sent %v", sent)
+	}
+	policy, err := client.GetRolePolicy(ctx, "app", "read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.String() != testPolicy.String() {
+		t.Fatalf("got %s", policy)
+	}
+}
+
+func TestManagedPolicies(t *testing.T) {
+	client, server := newTestIAM(t, map[string][]string{
+		"CreatePolicy": {"<Policy><PolicyName>read</PolicyName><Arn>arn:aws:iam::123456789012:policy/read</Arn>" +
+			"<DefaultVersionId>v1</DefaultVersionId><IsAttachable>true</IsAttachable></Policy>"},
+		"GetPolicyVersion": {"<PolicyVersion><VersionId>v1</VersionId><IsDefaultVersion>true</IsDefaultVersion>" +
+			"<Document>" + url.PathEscape(testPolicy.String()) + "</Document></PolicyVersion>"},
+		"AttachRolePolicy": {""},
+		"ListAttachedRolePolicies": {"<AttachedPolicies><member><PolicyName>read</PolicyName>" +
+			"<PolicyArn>arn:aws:iam::123456789012:policy/read</PolicyArn></member></AttachedPolicies>"},
+		"ListPolicies": {"<Policies><member><PolicyName>read</PolicyName></member></Policies>"},
+	})
+	ctx := context.Background()
+	policy, err := client.CreatePolicy(ctx, "read", "", "reads the bucket", testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Request(0); sent.Get("Description") != "reads the bucket" || sent["Path"] != nil {
+		t.Fatalf("sent %v", sent)
+	}
+	if policy.DefaultVersionId != "v1" || !policy.IsAttachable {
+		t.Fatalf("got %+v", policy)
+	}
+
+	version, err := client.GetPolicyVersion(ctx, policy.Arn, policy.DefaultVersionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !version.IsDefaultVersion || version.Document.String() != testPolicy.String() {
+		t.Fatalf("got %+v", version)
+	}
+
+	if err := client.AttachRolePolicy(ctx, "app", policy.Arn); err != nil {
+		t.Fatal(err)
+	}
+	attached, err := client.ListAttachedRolePolicies(ctx, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attached) != 1 || attached[0].PolicyArn != policy.Arn {
+		t.Fatalf("got %+v", attached)
+	}
+
+	if _, err := client.ListPolicies(ctx, ScopeLocal, "", true); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Request(4); sent.Get("Scope") != "Local" || sent.Get("OnlyAttached") != "true" || sent["PathPrefix"] != nil {
+		t.Fatalf("sent %v", sent)
+	}
+}
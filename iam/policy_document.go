@@ -0,0 +1,89 @@
+package iam
+
+import (
+	"encoding/json"
+)
+
+// PolicyDocument is an IAM policy, such as the permissions of a policy
+// or the trust policy of a role.
+//
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_elements.html for details.
+type PolicyDocument struct {
+	Version   string // "2012-10-17"
+	Id        string `json:",omitempty"`
+	Statement []Statement
+}
+
+// Statement is a statement of a policy.
+type Statement struct {
+	Sid          string     `json:",omitempty"`
+	Effect       string     // "Allow" or "Deny"
+	Principal    Principals `json:",omitempty"`
+	NotPrincipal Principals `json:",omitempty"`
+	Action       StringList `json:",omitempty"`
+	NotAction    StringList `json:",omitempty"`
+	Resource     StringList `json:",omitempty"`
+	NotResource  StringList `json:",omitempty"`
+
+	// Condition maps condition operators, such as "StringEquals", to
+	// condition keys and their values.
+	Condition map[string]map[string]StringList `json:",omitempty"`
+}
+
+// StringList is a policy element holding one or more strings, which
+// policies give either as a string or as an array.
+type StringList []string
+
+func (self *StringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*self = StringList{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(self))
+}
+
+// Principals maps principal types, such as "AWS" or "Service", to the
+// principals of the type. The anonymous principal "*" is decoded as
+// {"AWS": ["*"]}, which is equivalent.
+type Principals map[string]StringList
+
+func (self *Principals) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*self = Principals{"AWS": {s}}
+		return nil
+	}
+	return json.Unmarshal(data, (*map[string]StringList)(self))
+}
+
+// NewPolicyDocument returns a policy document of the current policy
+// language version with the given statements.
+func NewPolicyDocument(statements ...Statement) *PolicyDocument {
+	return &PolicyDocument{Version: "2012-10-17", Statement: statements}
+}
+
+// ParsePolicyDocument parses the JSON policy document doc. The document
+// may be URL encoded, as IAM returns them.
+func ParsePolicyDocument(doc string) (*PolicyDocument, error) {
+	policy := &PolicyDocument{}
+	if err := json.Unmarshal([]byte(decodeDocument(doc)), policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// String returns the JSON encoding of the policy document.
+func (self *PolicyDocument) String() string {
+	data, err := json.Marshal(self)
+	if err != nil {
+		// Documents only hold strings, which always encode.
+		panic(err)
+	}
+	return string(data)
+}
+
+// AssumeRolePolicy returns the parsed trust policy of the role.
+func (self *Role) AssumeRolePolicy() (*PolicyDocument, error) {
+	return ParsePolicyDocument(self.AssumeRolePolicyDocument)
+}
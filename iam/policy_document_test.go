@@ -0,0 +1,47 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParsePolicyDocument(t *testing.T) {
+	policy, err := ParsePolicyDocument(`{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Effect": "Allow",
+			"Principal": "*",
+			"Action": "s3:GetObject",
+			"Resource": ["arn:aws:s3:::bucket/*", "arn:aws:s3:::bucket"],
+			"Condition": {"IpAddress": {"aws:SourceIp": "10.0.0.0/8"}}
+		}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := policy.Statement[0]
+	if statement.Principal["AWS"][0] != "*" || len(statement.Action) != 1 || len(statement.Resource) != 2 ||
+		statement.Condition["IpAddress"]["aws:SourceIp"][0] != "10.0.0.0/8" {
+		t.Fatalf("got %+v", statement)
+	}
+}
+
+func TestPolicyDocumentRoundTrip(t *testing.T) {
+	policy := NewPolicyDocument(Statement{
+		Effect:   "Allow",
+		Action:   StringList{"sqs:SendMessage"},
+		Resource: StringList{"arn:aws:sqs:us-east-1:123456789012:jobs"},
+	})
+	doc := policy.String()
+	if doc != `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["sqs:SendMessage"],"Resource":["arn:aws:sqs:us-east-1:123456789012:jobs"]}]}` {
+		t.Fatalf("got %s", doc)
+	}
+	parsed, err := ParsePolicyDocument(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := json.Marshal(parsed)
+	if string(again) != doc {
+		t.Fatalf("round trip gave %s", again)
+	}
+}
@@ -0,0 +1,109 @@
+package iam
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// EC2AssumeRolePolicy is the assume role policy document of roles for
+// EC2 instances, letting EC2 assume the role on their behalf.
+const EC2AssumeRolePolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+
+// Role is an IAM role.
+type Role struct {
+	Path                     string // defaults to "/"
+	RoleName                 string
+	RoleId                   string
+	Arn                      string
+	CreateDate               time.Time
+	AssumeRolePolicyDocument string // trust policy, as JSON
+	Description              string
+	MaxSessionDuration       int // in seconds
+}
+
+// CreateRole creates the role named role.RoleName, trusting the
+// principals of role.AssumeRolePolicyDocument, and returns it with the
+// fields IAM fills in, such as its ARN.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_CreateRole.html for details.
+func (self *IAM) CreateRole(ctx context.Context, role *Role) (*Role, error) {
+	params := url.Values{
+		"RoleName":                 {role.RoleName},
+		"AssumeRolePolicyDocument": {role.AssumeRolePolicyDocument},
+	}
+	if role.Path != "" {
+		params.Set("Path", role.Path)
+	}
+	if role.Description != "" {
+		params.Set("Description", role.Description)
+	}
+	if role.MaxSessionDuration != 0 {
+		params.Set("MaxSessionDuration", strconv.Itoa(role.MaxSessionDuration))
+	}
+	var resp struct {
+		Role Role `xml:"CreateRoleResult>Role"`
+	}
+	if err := self.Do(ctx, "CreateRole", params, &resp); err != nil {
+		return nil, err
+	}
+	resp.Role.AssumeRolePolicyDocument = decodeDocument(resp.Role.AssumeRolePolicyDocument)
+	return &resp.Role, nil
+}
+
+// GetRole returns the role named name.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_GetRole.html for details.
+func (self *IAM) GetRole(ctx context.Context, name string) (*Role, error) {
+	var resp struct {
+		Role Role `xml:"GetRoleResult>Role"`
+	}
+	if err := self.Do(ctx, "GetRole", url.Values{"RoleName": {name}}, &resp); err != nil {
+		return nil, err
+	}
+	resp.Role.AssumeRolePolicyDocument = decodeDocument(resp.Role.AssumeRolePolicyDocument)
+	return &resp.Role, nil
+}
+
+// ListRoles returns the roles whose path starts with pathPrefix, or all
+// roles if it is empty.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListRoles.html for details.
+func (self *IAM) ListRoles(ctx context.Context, pathPrefix string) ([]Role, error) {
+	params := url.Values{}
+	if pathPrefix != "" {
+		params.Set("PathPrefix", pathPrefix)
+	}
+	var resp struct {
+		Roles  []Role `xml:"ListRolesResult>Roles>member"`
+		Marker string `xml:"ListRolesResult>Marker"`
+	}
+	if err := self.list(ctx, "ListRoles", params, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	for i := range resp.Roles {
+		resp.Roles[i].AssumeRolePolicyDocument = decodeDocument(resp.Roles[i].AssumeRolePolicyDocument)
+	}
+	return resp.Roles, nil
+}
+
+// UpdateAssumeRolePolicy replaces the trust policy of the role named
+// name.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_UpdateAssumeRolePolicy.html for details.
+func (self *IAM) UpdateAssumeRolePolicy(ctx context.Context, name, document string) error {
+	params := url.Values{
+		"RoleName":       {name},
+		"PolicyDocument": {document},
+	}
+	return self.Do(ctx, "UpdateAssumeRolePolicy", params, nil)
+}
+
+// DeleteRole deletes the role named name. It must not be in an instance
+// profile nor have policies.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_DeleteRole.html for details.
+func (self *IAM) DeleteRole(ctx context.Context, name string) error {
+	return self.Do(ctx, "DeleteRole", url.Values{"RoleName": {name}}, nil)
+}
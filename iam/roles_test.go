@@ -0,0 +1,120 @@
+//go:build !goaws_stable
+
+package iam
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const testRole = `<Role>
+	<Path>/app/</Path>
+	<RoleName>app</RoleName>
+	<RoleId>AROAEXAMPLE</RoleId>
+	<Arn>arn:aws:iam::123456789012:role/app/app</Arn>
+	<CreateDate>2024-01-02T03:04:05Z</CreateDate>
+	<AssumeRolePolicyDocument>` + "%7B%22Version%22%3A%222012-10-17%22%2C%22Statement%22%3A%5B%7B%22Effect%22%3A%22Allow%22%2C%22Principal%22%3A%7B%22Service%22%3A%22ec2.amazonaws.com%22%7D%2C%22Action%22%3A%22sts%3AAssumeRole%22%7D%5D%7D" + `</AssumeRolePolicyDocument>
+	<MaxSessionDuration>3600</MaxSessionDuration>
+</Role>`
+
+func TestCreateRole(t *testing.T) {
+	client, server := newTestIAM(t, map[string][]string{"CreateRole": {testRole}})
+	role, err := client.CreateRole(context.Background(), &Role{
+		Path:                     "/app/",
+		RoleName:                 "app",
+		AssumeRolePolicyDocument: EC2AssumeRolePolicy,
+		MaxSessionDuration:       3600,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := server.Request(0)
+	if sent.Get("AssumeRolePolicyDocument") != EC2AssumeRolePolicy || sent.Get("Path") != "/app/" ||
+		sent.Get("MaxSessionDuration") != "3600" || sent["Description"] != nil {
+		t.Fatalf("sent %v", sent)
+	}
+	if role.Arn != "arn:aws:iam::123456789012:role/app/app" || !role.CreateDate.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("got %+v", role)
+	}
+	if role.AssumeRolePolicyDocument != EC2AssumeRolePolicy {
+		t.Fatalf("trust policy not decoded: %s", role.AssumeRolePolicyDocument)
+	}
+	policy, err := role.AssumeRolePolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Statement[0].Principal["Service"][0] != "ec2.amazonaws.com" || policy.Statement[0].Action[0] != "sts:AssumeRole" {
+		t.Fatalf("got policy %+v", policy)
+	}
+}
+
+func TestListRoles(t *testing.T) {
+	client, server := newTestIAM(t, map[string][]string{
+		"ListRoles": {
+			"<Roles><member>" + testRole[len("<Role>"):len(testRole)-len("</Role>")] + "</member></Roles><IsTruncated>true</IsTruncated><Marker>next</Marker>",
+			"<Roles><member><RoleName>other</RoleName></member></Roles>",
+		},
+	})
+	roles, err := client.ListRoles(context.Background(), "/app/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 2 || roles[0].AssumeRolePolicyDocument != EC2AssumeRolePolicy || roles[1].RoleName != "other" {
+		t.Fatalf("got %+v", roles)
+	}
+	if server.Request(1).Get("PathPrefix") != "/app/" {
+		t.Fatalf("sent %v", server.Request(1))
+	}
+}
+
+func TestInstanceProfiles(t *testing.T) {
+	profile := "<InstanceProfile><InstanceProfileName>web</InstanceProfileName><Arn>arn:aws:iam::123456789012:instance-profile/web</Arn>" +
+		"<Roles><member>" + testRole[len("<Role>"):len(testRole)-len("</Role>")] + "</member></Roles></InstanceProfile>"
+	client, server := newTestIAM(t, map[string][]string{
+		"CreateInstanceProfile":       {"<InstanceProfile><InstanceProfileName>web</InstanceProfileName></InstanceProfile>"},
+		"AddRoleToInstanceProfile":    {""},
+		"GetInstanceProfile":          {profile},
+		"ListInstanceProfilesForRole": {"<InstanceProfiles><member>" + profile[len("<InstanceProfile>"):len(profile)-len("</InstanceProfile>")] + "</member></InstanceProfiles>"},
+	})
+	ctx := context.Background()
+	if _, err := client.CreateInstanceProfile(ctx, "web", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Request(0)["Path"]; ok {
+		t.Fatal("sent an empty path")
+	}
+	if err := client.AddRoleToInstanceProfile(ctx, "web", "app"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Request(1); sent.Get("InstanceProfileName") != "web" || sent.Get("RoleName") != "app" {
+		t.Fatalf("sent %v", sent)
+	}
+
+	got, err := client.GetInstanceProfile(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Roles) != 1 || got.Roles[0].AssumeRolePolicyDocument != EC2AssumeRolePolicy {
+		t.Fatalf("got %+v", got)
+	}
+	profiles, err := client.ListInstanceProfilesForRole(ctx, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 1 || profiles[0].Roles[0].AssumeRolePolicyDocument != EC2AssumeRolePolicy {
+		t.Fatalf("got %+v", profiles)
+	}
+}
+
+func TestDecodeDocument(t *testing.T) {
+	for encoded, want := range map[string]string{
+		url.PathEscape(`{"a":"b c"}`): `{"a":"b c"}`,
+		`{"a":"100%"}`:                `{"a":"100%"}`,
+	} {
+		if got := decodeDocument(encoded); got != want {
+			t.Errorf("decodeDocument(%q) = %q, want %q", encoded, got, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package iam
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// CloudFrontPath is the path server certificates must be uploaded with
+// to be used by CloudFront distributions.
+const CloudFrontPath = "/cloudfront/"
+
+// ServerCertificate is a TLS certificate to upload for load balancers
+// and CloudFront distributions. All PEM encoded.
+type ServerCertificate struct {
+	ServerCertificateName string
+	Path                  string // defaults to "/"; see CloudFrontPath
+	CertificateBody       string
+	PrivateKey            string
+	CertificateChain      string // intermediate certificates, if any
+}
+
+// ServerCertificateMetadata describes an uploaded server certificate.
+type ServerCertificateMetadata struct {
+	Path                  string
+	ServerCertificateName string
+	ServerCertificateId   string
+	Arn                   string
+	UploadDate            time.Time
+	Expiration            time.Time
+}
+
+// UploadServerCertificate uploads cert. To rotate a certificate, upload
+// the new one under another name, switch its users to it and delete the
+// old one.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_UploadServerCertificate.html for details.
+func (self *IAM) UploadServerCertificate(ctx context.Context, cert *ServerCertificate) (*ServerCertificateMetadata, error) {
+	params := url.Values{
+		"ServerCertificateName": {cert.ServerCertificateName},
+		"CertificateBody":       {cert.CertificateBody},
+		"PrivateKey":            {cert.PrivateKey},
+	}
+	if cert.Path != "" {
+		params.Set("Path", cert.Path)
+	}
+	if cert.CertificateChain != "" {
+		params.Set("CertificateChain", cert.CertificateChain)
+	}
+	var resp struct {
+		Metadata ServerCertificateMetadata `xml:"UploadServerCertificateResult>ServerCertificateMetadata"`
+	}
+	if err := self.Do(ctx, "UploadServerCertificate", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Metadata, nil
+}
+
+// ListServerCertificates returns the server certificates whose path
+// starts with pathPrefix, or all server certificates if it is empty.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_ListServerCertificates.html for details.
+func (self *IAM) ListServerCertificates(ctx context.Context, pathPrefix string) ([]ServerCertificateMetadata, error) {
+	params := url.Values{}
+	if pathPrefix != "" {
+		params.Set("PathPrefix", pathPrefix)
+	}
+	var resp struct {
+		Certificates []ServerCertificateMetadata `xml:"ListServerCertificatesResult>ServerCertificateMetadataList>member"`
+		Marker       string                      `xml:"ListServerCertificatesResult>Marker"`
+	}
+	if err := self.list(ctx, "ListServerCertificates", params, &resp, &resp.Marker); err != nil {
+		return nil, err
+	}
+	return resp.Certificates, nil
+}
+
+// DeleteServerCertificate deletes the server certificate named name,
+// which should no longer be in use.
+//
+// See https://docs.aws.amazon.com/IAM/latest/APIReference/API_DeleteServerCertificate.html for details.
+func (self *IAM) DeleteServerCertificate(ctx context.Context, name string) error {
+	return self.Do(ctx, "DeleteServerCertificate", url.Values{"ServerCertificateName": {name}}, nil)
+}
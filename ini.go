@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+/**
+ * parseINIFile reads the sections of an INI file as used by the AWS
+ * shared config and credentials files. Keys are lower case; comments
+ * start with # or ;.
+ */
+func parseINIFile(filename string) (map[string]map[string]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sections := make(map[string]map[string]string)
+	var section map[string]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[' && line[len(line)-1] == ']':
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			section = sections[name]
+			if section == nil {
+				section = make(map[string]string)
+				sections[name] = section
+			}
+		case section != nil:
+			if i := strings.IndexByte(line, '='); i > 0 {
+				key := strings.ToLower(strings.TrimSpace(line[:i]))
+				section[key] = strings.TrimSpace(line[i+1:])
+			}
+		}
+	}
+	return sections, scanner.Err()
+}
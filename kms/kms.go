@@ -0,0 +1,210 @@
+// Package kms interacts with the AWS Key Management Service. Only the
+// operations needed to encrypt and decrypt data keys are implemented.
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/dkln/go-aws"
+)
+
+// KMS speaks the JSON 1.1 protocol of the service: operations are named
+// by the X-Amz-Target header and their parameters and results are JSON.
+type KMS struct {
+	// Auth signs the requests, unless Credentials is set.
+	Auth        aws.Auth
+	Credentials *aws.Credentials
+
+	Endpoint aws.Endpoint
+
+	// Client sends the requests. Defaults to aws.DefaultQueryHTTPClient.
+	Client *http.Client
+
+	// Attempts is the strategy for retrying throttled requests and server
+	// errors. Defaults to aws.DefaultQueryAttempts.
+	Attempts *aws.AttemptStrategy
+}
+
+// New creates a new KMS for region.
+func New(auth aws.Auth, region aws.Region) *KMS {
+	endpoint, err := aws.DefaultResolver.ResolveEndpoint("kms", region.Name)
+	if err != nil {
+		endpoint = aws.Endpoint{SigningRegion: region.Name, SigningName: "kms"}
+	}
+	return &KMS{Auth: auth, Endpoint: endpoint}
+}
+
+// Error is an error response of KMS.
+type Error struct {
+	StatusCode int    // HTTP status code (400, 500, ...)
+	Code       string // e.g. "NotFoundException"
+	Message    string
+	RequestId  string
+}
+
+func (self *Error) Error() string {
+	msg := self.Code + ": " + self.Message
+	if self.RequestId != "" {
+		msg += " (request id " + self.RequestId + ")"
+	}
+	return msg
+}
+
+func (self *Error) ErrorCode() string {
+	return self.Code
+}
+
+func (self *Error) HTTPStatusCode() int {
+	return self.StatusCode
+}
+
+// EncryptResp is the result of Encrypt.
+type EncryptResp struct {
+	CiphertextBlob []byte
+	KeyId          string
+}
+
+// Encrypt encrypts plaintext, at most 4 KB, with the key keyId, which can
+// be a key ID, a key ARN or an alias such as "alias/uploads". The same
+// encryption context must be given to Decrypt.
+//
+// See https://docs.aws.amazon.com/kms/latest/APIReference/API_Encrypt.html for details.
+func (self *KMS) Encrypt(ctx context.Context, keyId string, plaintext []byte, encryptionContext map[string]string) (*EncryptResp, error) {
+	params := map[string]interface{}{
+		"KeyId":     keyId,
+		"Plaintext": plaintext,
+	}
+	if len(encryptionContext) > 0 {
+		params["EncryptionContext"] = encryptionContext
+	}
+	resp := &EncryptResp{}
+	if err := self.do(ctx, "Encrypt", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DecryptResp is the result of Decrypt.
+type DecryptResp struct {
+	Plaintext []byte
+	KeyId     string
+}
+
+// Decrypt decrypts ciphertext returned by Encrypt or GenerateDataKey.
+//
+// See https://docs.aws.amazon.com/kms/latest/APIReference/API_Decrypt.html for details.
+func (self *KMS) Decrypt(ctx context.Context, ciphertext []byte, encryptionContext map[string]string) (*DecryptResp, error) {
+	params := map[string]interface{}{
+		"CiphertextBlob": ciphertext,
+	}
+	if len(encryptionContext) > 0 {
+		params["EncryptionContext"] = encryptionContext
+	}
+	resp := &DecryptResp{}
+	if err := self.do(ctx, "Decrypt", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// do calls operation with params and decodes the result into resp. Every
+// operation implemented here can be repeated safely, so throttled
+// requests, server errors and failed connections are retried.
+func (self *KMS) do(ctx context.Context, operation string, params, resp interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	strategy := aws.DefaultQueryAttempts
+	if self.Attempts != nil {
+		strategy = *self.Attempts
+	}
+	for attempt, try := strategy.StartWithContext(ctx), 0; attempt.Next(); try++ {
+		err = self.run(ctx, operation, body, resp)
+		if err == nil || ctx.Err() != nil || !attempt.HasNext() {
+			break
+		}
+		if status := aws.HTTPStatusCode(err); status != 0 && status < 500 && !aws.IsThrottle(err) {
+			break
+		}
+		aws.TrackRetry()
+		if aws.IsThrottle(err) {
+			aws.SleepWithContext(ctx, aws.ExpBackoffDuration(try))
+		}
+	}
+	return err
+}
+
+func (self *KMS) run(ctx context.Context, operation string, body []byte, resp interface{}) error {
+	req, err := http.NewRequest("POST", self.Endpoint.URL+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+operation)
+	aws.SetUserAgent(req)
+
+	auth := self.Auth
+	if self.Credentials != nil {
+		if auth, err = self.Credentials.GetWithContext(ctx); err != nil {
+			return err
+		}
+	}
+	signer := &aws.V4Signer{Region: self.Endpoint.SigningRegion, Service: self.Endpoint.SigningName}
+	if err := signer.Sign(req, auth); err != nil {
+		return err
+	}
+
+	done := aws.TrackRequest()
+	defer done()
+	hresp, err := self.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(hresp.Body)
+	hresp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if hresp.StatusCode != 200 {
+		return buildError(hresp, data)
+	}
+	return json.Unmarshal(data, resp)
+}
+
+func (self *KMS) httpClient() *http.Client {
+	if self.Client != nil {
+		return self.Client
+	}
+	return aws.DefaultQueryHTTPClient
+}
+
+// buildError decodes the JSON error envelope, whose __type is the error
+// code, optionally qualified by a namespace ending in "#".
+func buildError(hresp *http.Response, data []byte) error {
+	err := &Error{
+		StatusCode: hresp.StatusCode,
+		RequestId:  hresp.Header.Get("x-amzn-RequestId"),
+	}
+	var envelope struct {
+		Type    string `json:"__type"`
+		Message string // sent as "message" or "Message"
+	}
+	if json.Unmarshal(data, &envelope) == nil {
+		err.Code = envelope.Type[strings.LastIndex(envelope.Type, "#")+1:]
+		err.Message = envelope.Message
+	}
+	if err.Message == "" {
+		err.Message = hresp.Status
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+func newTestKMS(t *testing.T, handler http.HandlerFunc) *KMS {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.EUWest)
+	client.Endpoint.URL = srv.URL
+	client.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+	return client
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	client := newTestKMS(t, func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-amz-json-1.1" {
+			t.Errorf("content type %q", ct)
+		}
+		if auth := r.Header.Get("Authorization"); !strings.Contains(auth, "/eu-west-1/kms/") {
+			t.Errorf("signed with %q", auth)
+		}
+		var params struct {
+			KeyId             string
+			Plaintext         []byte
+			CiphertextBlob    []byte
+			EncryptionContext map[string]string
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &params)
+		if params.EncryptionContext["purpose"] != "test" {
+			t.Errorf("encryption context %v", params.EncryptionContext)
+		}
+		switch target := r.Header.Get("X-Amz-Target"); target {
+		case "TrentService.Encrypt":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"KeyId":          "arn:" + params.KeyId,
+				"CiphertextBlob": append([]byte("sealed:"), params.Plaintext...),
+			})
+		case "TrentService.Decrypt":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"KeyId":     "arn:key",
+				"Plaintext": bytes.TrimPrefix(params.CiphertextBlob, []byte("sealed:")),
+			})
+		default:
+			t.Errorf("target %q", target)
+		}
+	})
+
+	ctx := context.Background()
+	encryptionContext := map[string]string{"purpose": "test"}
+	encrypted, err := client.Encrypt(ctx, "key", []byte("secret"), encryptionContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted.KeyId != "arn:key" || string(encrypted.CiphertextBlob) != "sealed:secret" {
+		t.Fatalf("got %+v", encrypted)
+	}
+	decrypted, err := client.Decrypt(ctx, encrypted.CiphertextBlob, encryptionContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decrypted.Plaintext) != "secret" {
+		t.Fatalf("got %q", decrypted.Plaintext)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	calls := 0
+	client := newTestKMS(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("x-amzn-RequestId", "req")
+		switch calls {
+		case 1:
+			w.WriteHeader(400)
+			w.Write([]byte(`{"__type":"ThrottlingException","message":"slow down"}`))
+		case 2:
+			w.WriteHeader(500)
+			w.Write([]byte(`{"__type":"KMSInternalException"}`))
+		default:
+			w.WriteHeader(400)
+			w.Write([]byte(`{"__type":"com.amazonaws.kms#NotFoundException","Message":"no such key"}`))
+		}
+	})
+	_, err := client.Encrypt(context.Background(), "missing", []byte("x"), nil)
+	if calls != 3 {
+		t.Fatalf("sent %d requests, want 3", calls)
+	}
+	if aws.ErrorCode(err) != "NotFoundException" || aws.HTTPStatusCode(err) != 400 {
+		t.Fatalf("got %v", err)
+	}
+	if err.Error() != "NotFoundException: no such key (request id req)" {
+		t.Fatalf("got message %q", err.Error())
+	}
+}
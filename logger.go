@@ -0,0 +1,140 @@
+package aws
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/**
+ * LogLevel selects how much a client logs. Each level includes the
+ * output of the levels below it.
+ */
+type LogLevel int
+
+const (
+	LogOff     LogLevel = iota // log nothing
+	LogRequest                 // one line per request and per response
+	LogWire                    // headers and, except for object data, bodies
+	LogDebug                   // also signing details such as the string to sign
+)
+
+/**
+ * Logger receives the log output of clients. Secrets such as signatures
+ * and session tokens are redacted before they reach it.
+ */
+type Logger interface {
+	Log(level LogLevel, msg string)
+}
+
+/**
+ * LoggerFunc adapts a function to a Logger.
+ */
+type LoggerFunc func(level LogLevel, msg string)
+
+func (self LoggerFunc) Log(level LogLevel, msg string) {
+	self(level, msg)
+}
+
+/**
+ * NewStdLogger returns a Logger writing to l, or to standard error with
+ * the standard flags if l is nil.
+ */
+func NewStdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return LoggerFunc(func(level LogLevel, msg string) {
+		l.Print(msg)
+	})
+}
+
+/**
+ * Logf formats and logs a message to logger if its level is at least
+ * level. A nil logger logs nothing.
+ */
+func Logf(logger Logger, max LogLevel, level LogLevel, format string, args ...interface{}) {
+	if logger == nil || level > max || level == LogOff {
+		return
+	}
+	logger.Log(level, fmt.Sprintf(format, args...))
+}
+
+const redacted = "REDACTED"
+
+var redactedHeaders = []string{"Authorization", "X-Amz-Security-Token"}
+
+var redactedParams = []string{"Signature", "X-Amz-Signature", "X-Amz-Security-Token", "x-amz-security-token"}
+
+// redactedElements match the XML elements of response bodies that carry
+// secrets, such as the temporary credentials returned by STS and the
+// private keys of EC2 key pairs.
+var redactedElements = regexp.MustCompile(`<(SecretAccessKey|SessionToken|[Kk]eyMaterial)>[^<]*</`)
+
+/**
+ * RedactHeader returns a copy of header with the values of credential
+ * carrying headers replaced.
+ */
+func RedactHeader(header http.Header) http.Header {
+	copy := make(http.Header, len(header))
+	for k, v := range header {
+		copy[k] = v
+	}
+	for _, k := range redactedHeaders {
+		if _, ok := copy[k]; ok {
+			copy[k] = []string{redacted}
+		}
+	}
+	return copy
+}
+
+/**
+ * RedactURL returns u as a string with the values of credential carrying
+ * query parameters, as used by presigned URLs, replaced.
+ */
+func RedactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	query := u.Query()
+	for _, k := range redactedParams {
+		if _, ok := query[k]; ok {
+			query[k] = []string{redacted}
+		}
+	}
+	copy := *u
+	copy.RawQuery = query.Encode()
+	return copy.String()
+}
+
+/**
+ * FormatHeader formats header for a log message, redacting credentials.
+ */
+func FormatHeader(header http.Header) string {
+	header = RedactHeader(header)
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString("\n\t" + k + ": " + strings.Join(header[k], ", "))
+	}
+	return b.String()
+}
+
+/**
+ * RedactBody returns the XML response body data with the contents of
+ * secret carrying elements, such as the secret key and session token of
+ * temporary credentials or the private key of a key pair, replaced.
+ */
+func RedactBody(data []byte) []byte {
+	return redactedElements.ReplaceAll(data, []byte("<$1>"+redacted+"</"))
+}
@@ -1,19 +1,302 @@
 package aws
 
 import (
+  "context"
+  "errors"
   "fmt"
+  "net/http"
   "io/ioutil"
+  "os"
   "encoding/json"
+  "strconv"
+  "strings"
+  "sync"
+  "time"
 )
 
+/**
+ * MetaDataAllowV1 controls whether GetMetaData falls back to IMDSv1,
+ * i.e. requests without a session token, when no IMDSv2 token can be
+ * obtained. Set it to false to never make unauthenticated requests.
+ */
+var MetaDataAllowV1 = true
+
+/**
+ * MetaDataTokenTTL is the lifetime requested for IMDSv2 session tokens.
+ */
+var MetaDataTokenTTL = 6 * time.Hour
+
+/**
+ * DefaultMetadataEndpoint is the address of the instance metadata service.
+ */
+const DefaultMetadataEndpoint = "http://169.254.169.254"
+
+/**
+ * MetadataClient reads the instance metadata of the EC2 instance the
+ * process runs on. See
+ * http://docs.aws.amazon.com/AWSEC2/latest/UserGuide/ec2-instance-metadata.html
+ * for the data available.
+ */
+type MetadataClient struct {
+	// Endpoint of the metadata service. Defaults to
+	// $AWS_EC2_METADATA_SERVICE_ENDPOINT or else DefaultMetadataEndpoint.
+	Endpoint string
+
+	// Disabled makes every request fail with ErrMetadataDisabled without
+	// being sent, e.g. on laptops and CI machines where looking for
+	// instance role credentials only wastes time. Setting
+	// $AWS_EC2_METADATA_DISABLED to "true" does the same.
+	Disabled bool
+
+	// DialTimeout bounds connecting to the metadata service, which is
+	// either immediate or not there at all. Defaults to one second.
+	DialTimeout time.Duration
+
+	// Timeout bounds every request, retries included. Defaults to five
+	// seconds.
+	Timeout time.Duration
+
+	// TokenTimeout bounds the wait for an IMDSv2 token. Inside a
+	// container behind an extra network hop, the token response is
+	// dropped when the instance's hop limit is 1 and only IMDSv1 works.
+	// Defaults to one second.
+	TokenTimeout time.Duration
+
+	// Client sends the requests. If set, DialTimeout and Timeout are
+	// left to it.
+	Client *http.Client
+
+	once         sync.Once
+	httpClient   *http.Client
+	mu           sync.Mutex
+	token        string
+	tokenExpires time.Time
+	v1Until      time.Time
+}
+
+/**
+ * ErrMetadataDisabled is returned by a MetadataClient that is disabled.
+ */
+var ErrMetadataDisabled = errors.New("instance metadata service disabled")
+
+/**
+ * DefaultMetadataClient is used by GetMetaData and the instance role
+ * credentials.
+ */
+var DefaultMetadataClient = &MetadataClient{}
+
 /**
  * GetMetaData retrieves instance metadata about the current machine.
  * See http://docs.aws.amazon.com/AWSEC2/latest/UserGuide/AESDG-chapter-instancedata.html for more details.
  */
 func GetMetaData(path string) ([]byte, error) {
-	url := "http://169.254.169.254/latest/meta-data/" + path
+	return GetMetaDataWithContext(context.Background(), path)
+}
+
+/**
+ * GetMetaDataWithContext is like GetMetaData but aborts when ctx is done.
+ */
+func GetMetaDataWithContext(ctx context.Context, path string) ([]byte, error) {
+	return DefaultMetadataClient.GetMetadata(ctx, path)
+}
+
+/**
+ * GetMetadata retrieves the metadata at path below latest/meta-data/.
+ * Requests are authenticated with an IMDSv2 session token, which is
+ * obtained once and reused until shortly before it expires.
+ */
+func (self *MetadataClient) GetMetadata(ctx context.Context, path string) ([]byte, error) {
+	return self.get(ctx, "meta-data/"+path)
+}
+
+/**
+ * GetUserData retrieves the user data the instance was launched with.
+ */
+func (self *MetadataClient) GetUserData(ctx context.Context) ([]byte, error) {
+	return self.get(ctx, "user-data")
+}
+
+/**
+ * InstanceID returns the ID of the instance, e.g. "i-1234567890abcdef0".
+ */
+func (self *MetadataClient) InstanceID(ctx context.Context) (string, error) {
+	return self.getString(ctx, "instance-id")
+}
+
+/**
+ * AvailabilityZone returns the availability zone of the instance.
+ */
+func (self *MetadataClient) AvailabilityZone(ctx context.Context) (string, error) {
+	return self.getString(ctx, "placement/availability-zone")
+}
+
+/**
+ * Region returns the name of the region of the instance.
+ */
+func (self *MetadataClient) Region(ctx context.Context) (string, error) {
+	if region, err := self.getString(ctx, "placement/region"); err == nil {
+		return region, nil
+	}
+	// Older instances only know their zone, which is named after the
+	// region plus a letter, e.g. eu-west-1a.
+	zone, err := self.AvailabilityZone(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(zone, "abcdefghijklmnopqrstuvwxyz"), nil
+}
+
+/**
+ * LocalIPv4 returns the private IPv4 address of the instance.
+ */
+func (self *MetadataClient) LocalIPv4(ctx context.Context) (string, error) {
+	return self.getString(ctx, "local-ipv4")
+}
+
+/**
+ * PublicIPv4 returns the public IPv4 address of the instance, which
+ * fails for instances without one.
+ */
+func (self *MetadataClient) PublicIPv4(ctx context.Context) (string, error) {
+	return self.getString(ctx, "public-ipv4")
+}
+
+/**
+ * IAMInfo describes the instance profile of an instance.
+ */
+type IAMInfo struct {
+	Code               string
+	LastUpdated        time.Time
+	InstanceProfileArn string
+	InstanceProfileId  string
+}
+
+/**
+ * IAMInfo returns the instance profile of the instance, which fails for
+ * instances without one.
+ */
+func (self *MetadataClient) IAMInfo(ctx context.Context) (*IAMInfo, error) {
+	var info IAMInfo
+	if err := self.getJSON(ctx, "iam/info", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+/**
+ * IdentityDocument is the signed document describing an instance.
+ */
+type IdentityDocument struct {
+	AccountId        string    `json:"accountId"`
+	Architecture     string    `json:"architecture"`
+	AvailabilityZone string    `json:"availabilityZone"`
+	ImageId          string    `json:"imageId"`
+	InstanceId       string    `json:"instanceId"`
+	InstanceType     string    `json:"instanceType"`
+	KernelId         string    `json:"kernelId"`
+	PendingTime      time.Time `json:"pendingTime"`
+	PrivateIp        string    `json:"privateIp"`
+	RamdiskId        string    `json:"ramdiskId"`
+	Region           string    `json:"region"`
+	Version          string    `json:"version"`
+}
+
+/**
+ * IdentityDocument returns the instance identity document.
+ */
+func (self *MetadataClient) IdentityDocument(ctx context.Context) (*IdentityDocument, error) {
+	data, err := self.get(ctx, "dynamic/instance-identity/document")
+	if err != nil {
+		return nil, err
+	}
+	var doc IdentityDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (self *MetadataClient) getString(ctx context.Context, path string) (string, error) {
+	data, err := self.GetMetadata(ctx, path)
+	return strings.TrimSpace(string(data)), err
+}
+
+func (self *MetadataClient) getJSON(ctx context.Context, path string, v interface{}) error {
+	data, err := self.GetMetadata(ctx, path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (self *MetadataClient) baseURL() string {
+	endpoint := self.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = DefaultMetadataEndpoint
+	}
+	return strings.TrimRight(endpoint, "/") + "/latest/"
+}
+
+func (self *MetadataClient) disabled() bool {
+	return self.Disabled || strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true")
+}
+
+func (self *MetadataClient) client() *http.Client {
+	if self.Client != nil {
+		return self.Client
+	}
+	self.once.Do(func() {
+		dialTimeout := self.DialTimeout
+		if dialTimeout == 0 {
+			dialTimeout = time.Second
+		}
+		timeout := self.Timeout
+		if timeout == 0 {
+			timeout = 5 * time.Second
+		}
+		self.httpClient = NewClient(&ResilientTransport{
+			DialTimeout: dialTimeout,
+			Deadline: func() time.Time {
+				return time.Now().Add(timeout)
+			},
+			MaxTries:    2,
+			ShouldRetry: awsRetry,
+			Backoff:     ExpBackoffDuration,
+		})
+		self.httpClient.Timeout = timeout
+	})
+	return self.httpClient
+}
+
+func (self *MetadataClient) get(ctx context.Context, path string) ([]byte, error) {
+	if self.disabled() {
+		return nil, ErrMetadataDisabled
+	}
 
-	response, error := RetryingClient.Get(url)
+	url := self.baseURL() + path
+
+	token, error := self.getToken(ctx)
+
+	if error != nil {
+		return nil, error
+	}
+
+	response, error := self.doGet(ctx, url, token)
+
+	if error == nil && response.StatusCode == 401 && token != "" {
+		// The token expired or was revoked; get a new one and try again.
+		response.Body.Close()
+		self.expireToken()
+
+		if token, error = self.getToken(ctx); error != nil {
+			return nil, error
+		}
+
+		response, error = self.doGet(ctx, url, token)
+	}
 
 	if error != nil {
 		return nil, error
@@ -34,6 +317,102 @@ func GetMetaData(path string) ([]byte, error) {
 	return []byte(body), nil
 }
 
+func (self *MetadataClient) doGet(ctx context.Context, url, token string) (*http.Response, error) {
+	request, error := http.NewRequest("GET", url, nil)
+
+	if error != nil {
+		return nil, error
+	}
+
+	if token != "" {
+		request.Header.Set("X-aws-ec2-metadata-token", token)
+	}
+
+	SetUserAgent(request)
+
+	return self.client().Do(request.WithContext(ctx))
+}
+
+/**
+ * getToken returns a cached IMDSv2 session token or obtains a new one.
+ * It returns an empty token if none can be obtained and MetaDataAllowV1
+ * is set.
+ */
+func (self *MetadataClient) getToken(ctx context.Context) (string, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.token != "" && time.Now().Before(self.tokenExpires) {
+		return self.token, nil
+	}
+
+	if MetaDataAllowV1 && time.Now().Before(self.v1Until) {
+		// A recent token request failed; don't wait for another one.
+		return "", nil
+	}
+
+	ttl := int(MetaDataTokenTTL / time.Second)
+
+	request, error := http.NewRequest("PUT", self.baseURL()+"api/token", nil)
+
+	if error != nil {
+		return "", error
+	}
+
+	request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", strconv.Itoa(ttl))
+	SetUserAgent(request)
+
+	timeout := self.TokenTimeout
+	if timeout == 0 {
+		timeout = time.Second
+	}
+
+	tokenCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, error := self.client().Do(request.WithContext(tokenCtx))
+
+	if error != nil {
+		if ctx.Err() != nil || !MetaDataAllowV1 {
+			return "", error
+		}
+
+		// The token request timed out or the service refused it, as
+		// happens when the hop limit keeps the response from reaching
+		// a container. Use IMDSv1 and retry IMDSv2 a little later.
+		self.v1Until = time.Now().Add(5 * time.Minute)
+		return "", nil
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == 200 {
+		body, error := ioutil.ReadAll(response.Body)
+
+		if error != nil {
+			return "", error
+		}
+
+		self.token = string(body)
+		// Renew a minute early so a token never expires in flight.
+		self.tokenExpires = time.Now().Add(time.Duration(ttl)*time.Second - time.Minute)
+
+		return self.token, nil
+	}
+
+	if MetaDataAllowV1 {
+		return "", nil
+	}
+
+	return "", fmt.Errorf("cannot get IMDSv2 token: code %d returned", response.StatusCode)
+}
+
+func (self *MetadataClient) expireToken() {
+	self.mu.Lock()
+	self.token = ""
+	self.mu.Unlock()
+}
+
 /**
  *
  */
@@ -0,0 +1,92 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newHopLimitedIMDS serves metadata but never answers token requests,
+// like the metadata service seen from a container when the hop limit is 1.
+func newHopLimitedIMDS(t *testing.T) (*httptest.Server, *int32) {
+	var puts int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			atomic.AddInt32(&puts, 1)
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token") != "" {
+			w.WriteHeader(400)
+			return
+		}
+		w.Write([]byte("i-1234"))
+	}))
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+	return srv, &puts
+}
+
+func TestMetadataFallsBackToV1WhenTokenTimesOut(t *testing.T) {
+	srv, puts := newHopLimitedIMDS(t)
+	client := &MetadataClient{Endpoint: srv.URL, TokenTimeout: 50 * time.Millisecond}
+
+	for i := 0; i < 2; i++ {
+		id, err := client.InstanceID(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "i-1234" {
+			t.Fatalf("got %q", id)
+		}
+	}
+	if n := atomic.LoadInt32(puts); n == 0 || n > 2 {
+		// One token request, retried at most once by the transport.
+		t.Fatalf("%d token requests", n)
+	}
+}
+
+func TestMetadataTokenTimeoutFailsWithoutV1(t *testing.T) {
+	MetaDataAllowV1 = false
+	t.Cleanup(func() { MetaDataAllowV1 = true })
+
+	srv, _ := newHopLimitedIMDS(t)
+	client := &MetadataClient{Endpoint: srv.URL, TokenTimeout: 50 * time.Millisecond}
+
+	if _, err := client.InstanceID(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestMetadataUsesToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			w.Write([]byte("tok"))
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token") != "tok" {
+			w.WriteHeader(401)
+			return
+		}
+		w.Write([]byte("eu-west-1a"))
+	}))
+	defer srv.Close()
+
+	client := &MetadataClient{Endpoint: srv.URL}
+	az, err := client.AvailabilityZone(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if az != "eu-west-1a" {
+		t.Fatalf("got %q", az)
+	}
+}
@@ -0,0 +1,49 @@
+package aws
+
+import (
+	"time"
+)
+
+/**
+ * Metrics describes one attempt at an operation of a service.
+ */
+type Metrics struct {
+	Service   string // e.g. "s3"
+	Operation string // e.g. "PutObject"
+
+	// Attempt counts the attempts made at the operation before this one,
+	// so the retry count of an operation is the Attempt of its last
+	// observation.
+	Attempt int
+
+	// Latency runs from sending the request until the response body has
+	// been closed, or the request has failed.
+	Latency time.Duration
+
+	StatusCode int   // 0 if no response was received
+	BytesOut   int64 // size of the request body, -1 if unknown
+	BytesIn    int64 // bytes of the response body read
+	Err        error // error of the attempt, if it failed
+
+	// Timings of the connection and response, if the client was asked
+	// to trace requests.
+	Timings *Timings
+}
+
+/**
+ * MetricsCollector receives the Metrics of every attempt made by a
+ * client, e.g. to export them to Prometheus or statsd. It is called
+ * concurrently and should return quickly.
+ */
+type MetricsCollector interface {
+	Observe(metrics Metrics)
+}
+
+/**
+ * MetricsCollectorFunc adapts a function to a MetricsCollector.
+ */
+type MetricsCollectorFunc func(metrics Metrics)
+
+func (self MetricsCollectorFunc) Observe(metrics Metrics) {
+	self(metrics)
+}
@@ -0,0 +1,182 @@
+package aws
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/**
+ * Profile is a named profile from the shared config file written by the
+ * AWS CLI, merged with the profile of the same name in the shared
+ * credentials file.
+ */
+type Profile struct {
+	Name string
+
+	// Region is the region requests made with the profile go to.
+	Region string
+
+	// RoleARN, if set, is the role to assume with the credentials of
+	// SourceProfile.
+	RoleARN         string
+	SourceProfile   string
+	ExternalID      string
+	RoleSessionName string
+
+	// MFASerial is the MFA device that must be used to assume RoleARN.
+	MFASerial string
+
+	// Auth holds the static credentials of the profile, if any.
+	Auth Auth
+}
+
+/**
+ * LoadProfile loads the profile called name from ~/.aws/config (or
+ * $AWS_CONFIG_FILE) and ~/.aws/credentials (or
+ * $AWS_SHARED_CREDENTIALS_FILE). An empty name means $AWS_PROFILE or
+ * else "default". Either file may be missing, but the profile must be
+ * found in at least one of them.
+ */
+func LoadProfile(name string) (*Profile, error) {
+	if name == "" {
+		name = defaultProfile()
+	}
+
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if configFile == "" {
+		configFile = filepath.Join(homeDir(), ".aws", "config")
+	}
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		credentialsFile = filepath.Join(homeDir(), ".aws", "credentials")
+	}
+
+	config, err := parseINIFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	shared, err := parseINIFile(credentialsFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// The config file prefixes every section but the default one with
+	// "profile "; the credentials file does not.
+	configSection := "profile " + name
+	if name == "default" {
+		if _, ok := config[configSection]; !ok {
+			configSection = name
+		}
+	}
+
+	values := make(map[string]string)
+	found := false
+	for _, section := range []map[string]string{config[configSection], shared[name]} {
+		if section == nil {
+			continue
+		}
+		found = true
+		for key, value := range section {
+			values[key] = value
+		}
+	}
+	if !found {
+		return nil, errors.New("profile " + name + " not found in " + configFile + " or " + credentialsFile)
+	}
+
+	return &Profile{
+		Name:            name,
+		Region:          values["region"],
+		RoleARN:         values["role_arn"],
+		SourceProfile:   values["source_profile"],
+		ExternalID:      values["external_id"],
+		RoleSessionName: values["role_session_name"],
+		MFASerial:       values["mfa_serial"],
+		Auth: Auth{
+			AccessKey: values["aws_access_key_id"],
+			SecretKey: values["aws_secret_access_key"],
+			Token:     values["aws_session_token"],
+		},
+	}, nil
+}
+
+/**
+ * GetRegion returns the Region of the profile, or false if it names no
+ * region or one this package does not know.
+ */
+func (self *Profile) GetRegion() (Region, bool) {
+	return GetRegion(strings.TrimSpace(self.Region))
+}
+
+/**
+ * Provider returns a Provider for the credentials of the profile. A
+ * profile with a RoleARN assumes the role with the credentials of its
+ * SourceProfile.
+ */
+func (self *Profile) Provider() (Provider, error) {
+	return self.provider(map[string]bool{})
+}
+
+func (self *Profile) provider(visited map[string]bool) (Provider, error) {
+	if visited[self.Name] {
+		return nil, errors.New("profile " + self.Name + " has a source_profile cycle")
+	}
+	visited[self.Name] = true
+
+	if self.RoleARN == "" {
+		if self.Auth.AccessKey == "" || self.Auth.SecretKey == "" {
+			return nil, errors.New("profile " + self.Name + " has no credentials")
+		}
+		return &StaticProvider{self.Auth}, nil
+	}
+
+	if self.SourceProfile == "" {
+		return nil, errors.New("profile " + self.Name + " has a role_arn but no source_profile")
+	}
+	var source Provider
+	if self.SourceProfile == self.Name {
+		source = &StaticProvider{self.Auth}
+	} else {
+		profile, err := LoadProfile(self.SourceProfile)
+		if err != nil {
+			return nil, err
+		}
+		if source, err = profile.provider(visited); err != nil {
+			return nil, err
+		}
+	}
+	return &AssumeRoleProvider{
+		Source:          NewCredentials(source),
+		RoleARN:         self.RoleARN,
+		RoleSessionName: self.RoleSessionName,
+		ExternalID:      self.ExternalID,
+		SerialNumber:    self.MFASerial,
+		TokenProvider:   profileTokenProvider(self.MFASerial),
+	}, nil
+}
+
+/**
+ * ProfileTokenProvider provides the MFA codes for profiles with an
+ * mfa_serial. It defaults to StdinTokenProvider.
+ */
+var ProfileTokenProvider = StdinTokenProvider
+
+func profileTokenProvider(serial string) func() (string, error) {
+	if serial == "" {
+		return nil
+	}
+	return ProfileTokenProvider
+}
+
+/**
+ * Credentials returns Credentials for the profile.
+ */
+func (self *Profile) Credentials() (*Credentials, error) {
+	provider, err := self.Provider()
+	if err != nil {
+		return nil, err
+	}
+	return NewCredentials(provider), nil
+}
@@ -0,0 +1,557 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * QueryClient sends requests to services speaking the AWS Query protocol,
+ * such as SQS, SNS, EC2 and IAM: form encoded Action and Version
+ * parameters in, XML out. Service packages wrap a QueryClient and only
+ * define their actions and result types.
+ */
+type QueryClient struct {
+	// Auth signs the requests, unless Credentials is set.
+	Auth        Auth
+	Credentials *Credentials
+
+	Service  string // endpoint and signing name, e.g. "sqs"
+	Version  string // API version, e.g. "2012-11-05"
+	Endpoint Endpoint
+
+	// Signer authenticates the requests. If nil, a V4Signer is used for
+	// regions that only accept Signature Version 4 and a V2QuerySigner
+	// elsewhere.
+	Signer    Signer
+	SigV4Only bool
+
+	// Client sends the requests. Defaults to DefaultQueryHTTPClient.
+	Client *http.Client
+
+	// Attempts is the strategy for retrying failed requests. Defaults to
+	// DefaultQueryAttempts.
+	Attempts *AttemptStrategy
+
+	// IdempotentActions names the actions of the service, besides those
+	// starting with Describe, Get or List and those sent with a
+	// ClientToken, that have the same effect however often they are
+	// sent. Only these are retried after server errors and lost
+	// responses; other actions are only retried when throttled or when
+	// the request never reached the service, so they are not performed
+	// twice.
+	IdempotentActions map[string]bool
+
+	Logger   Logger
+	LogLevel LogLevel
+	Metrics  MetricsCollector
+	Hooks    *Hooks
+	Limiter  *RateLimiter
+}
+
+/**
+ * DefaultQueryAttempts is the retry strategy of QueryClients without
+ * Attempts.
+ */
+var DefaultQueryAttempts = AttemptStrategy{
+	Min:        5,
+	Total:      5 * time.Second,
+	Delay:      200 * time.Millisecond,
+	Multiplier: 2,
+	MaxDelay:   2 * time.Second,
+	Jitter:     true,
+}
+
+/**
+ * DefaultQueryHTTPClient sends the requests of QueryClients without a
+ * Client. Its connections are counted in Diagnostics, dials and waits for
+ * response headers are bounded, and it leaves retrying to the
+ * QueryClient, which knows which actions are safe to repeat.
+ */
+var DefaultQueryHTTPClient = newQueryHTTPClient()
+
+func newQueryHTTPClient() *http.Client {
+	client := NewSingleTryClient(10*time.Second, WithMaxIdleConnsPerHost(32), WithResponseHeaderTimeout(time.Minute))
+	// Long polls, such as SQS ReceiveMessage, wait up to 20 seconds.
+	client.Timeout = 2 * time.Minute
+	return client
+}
+
+/**
+ * NewClientToken returns a random token for the ClientToken parameter
+ * that makes actions such as EC2 RunInstances idempotent.
+ */
+func NewClientToken() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// Fall back to a token that is at least unique per process.
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return fmt.Sprintf("%x", b)
+}
+
+/**
+ * NewQueryClient returns a QueryClient for service in region, at the
+ * endpoint set in region or else the one DefaultResolver finds for it.
+ */
+func NewQueryClient(auth Auth, region Region, service, version string) *QueryClient {
+	endpoint, err := DefaultResolver.ResolveEndpoint(service, region.Name)
+	if err != nil {
+		endpoint = Endpoint{SigningRegion: region.Name, SigningName: service}
+	}
+	if url := region.endpoint(service); url != "" {
+		endpoint.URL = url
+	}
+	return &QueryClient{
+		Auth:      auth,
+		Service:   service,
+		Version:   version,
+		Endpoint:  endpoint,
+		SigV4Only: region.SigV4Only,
+	}
+}
+
+/**
+ * QueryError is an error response of a Query protocol service.
+ */
+type QueryError struct {
+	StatusCode int    // HTTP status code (400, 403, ...)
+	Type       string // "Sender" or "Receiver", if given
+	Code       string // AWS error code, e.g. "AWS.SimpleQueueService.NonExistentQueue"
+	Message    string
+	RequestId  string
+}
+
+func (self *QueryError) Error() string {
+	msg := self.Code + ": " + self.Message
+	if self.Code == "" {
+		msg = self.Message
+	}
+	if self.RequestId != "" {
+		msg += " (request id " + self.RequestId + ")"
+	}
+	return msg
+}
+
+func (self *QueryError) ErrorCode() string {
+	return self.Code
+}
+
+func (self *QueryError) HTTPStatusCode() int {
+	return self.StatusCode
+}
+
+/**
+ * queryErrorResponse decodes both error envelopes in use: ErrorResponse
+ * (SQS, SNS, IAM, STS, ...) and Response (EC2).
+ */
+type queryErrorResponse struct {
+	Errors []struct {
+		Type    string
+		Code    string
+		Message string
+	} `xml:"Error"`
+	EC2Errors []struct {
+		Code    string
+		Message string
+	} `xml:"Errors>Error"`
+	RequestId string
+	RequestID string
+}
+
+/**
+ * Do calls action with params at the root of the endpoint and decodes
+ * the XML response into resp, if it is not nil.
+ */
+func (self *QueryClient) Do(ctx context.Context, action string, params url.Values, resp interface{}) error {
+	return self.DoPath(ctx, "/", action, params, resp)
+}
+
+/**
+ * DoPath is like Do, but for a resource below the endpoint, such as an
+ * SQS queue.
+ */
+func (self *QueryClient) DoPath(ctx context.Context, path, action string, params url.Values, resp interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	form := url.Values{}
+	for k, v := range params {
+		form[k] = v
+	}
+	form.Set("Action", action)
+	if self.Version != "" {
+		form.Set("Version", self.Version)
+	}
+
+	strategy := DefaultQueryAttempts
+	if self.Attempts != nil {
+		strategy = *self.Attempts
+	}
+	var err error
+	for attempt, try := strategy.StartWithContext(ctx), 0; attempt.Next(); try++ {
+		err = self.run(ctx, path, action, form, try, resp)
+		if !self.shouldRetry(action, form, err) || !attempt.HasNext() {
+			break
+		}
+		TrackRetry()
+		self.Hooks.RunOnRetry(ctx, err, try)
+		if wait, ok := err.(*QueryError); ok && IsThrottle(wait) {
+			SleepWithContext(ctx, ExpBackoffDuration(try))
+		}
+	}
+	return err
+}
+
+func (self *QueryClient) run(ctx context.Context, path, action string, form url.Values, try int, resp interface{}) error {
+	if err := self.Limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(self.Endpoint.URL)
+	if err != nil {
+		return err
+	}
+	if path != "" && path != "/" {
+		if parsed, err := url.Parse(path); err == nil && parsed.IsAbs() {
+			// SQS queue URLs are absolute.
+			u = parsed
+		} else {
+			u.Path = "/" + strings.TrimPrefix(path, "/")
+		}
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	body := form.Encode()
+	req, err := http.NewRequest("POST", u.String(), strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	SetUserAgent(req)
+
+	if err := self.Hooks.RunBeforeSign(req); err != nil {
+		return err
+	}
+	auth := self.Auth
+	if self.Credentials != nil {
+		if auth, err = self.Credentials.GetWithContext(ctx); err != nil {
+			return err
+		}
+	}
+	if err := self.signer().Sign(req, auth); err != nil {
+		return err
+	}
+	if err := self.Hooks.RunBeforeSend(req); err != nil {
+		return err
+	}
+
+	Logf(self.Logger, self.LogLevel, LogRequest, "%s %s %s", self.Service, action, RedactURL(u))
+	Logf(self.Logger, self.LogLevel, LogWire, "%s request headers:%s", self.Service, FormatHeader(req.Header))
+
+	metrics := Metrics{
+		Service:   self.Service,
+		Operation: action,
+		Attempt:   try,
+		BytesOut:  req.ContentLength,
+	}
+	start := time.Now()
+	done := TrackRequest()
+	hresp, err := self.httpClient().Do(req)
+	self.Hooks.RunAfterResponse(req, hresp, err)
+	if err != nil {
+		done()
+		metrics.Latency = time.Since(start)
+		metrics.Err = err
+		self.observe(metrics)
+		Logf(self.Logger, self.LogLevel, LogRequest, "%s %s -> %v", self.Service, action, err)
+		return err
+	}
+	data, err := ioutil.ReadAll(hresp.Body)
+	hresp.Body.Close()
+	done()
+	metrics.Latency = time.Since(start)
+	metrics.StatusCode = hresp.StatusCode
+	metrics.BytesIn = int64(len(data))
+	Logf(self.Logger, self.LogLevel, LogRequest, "%s %s -> %s in %v", self.Service, action, hresp.Status, metrics.Latency)
+	Logf(self.Logger, self.LogLevel, LogWire, "%s response headers:%s\n%s", self.Service, FormatHeader(hresp.Header), RedactBody(data))
+	if err != nil {
+		metrics.Err = err
+		self.observe(metrics)
+		return err
+	}
+
+	if hresp.StatusCode != 200 {
+		err = NewQueryError(hresp, data)
+		metrics.Err = err
+		self.observe(metrics)
+		if IsExpiredCredentials(err) && self.Credentials != nil {
+			self.Credentials.Expire()
+		}
+		return err
+	}
+	self.observe(metrics)
+	if resp != nil {
+		return xml.Unmarshal(data, resp)
+	}
+	return nil
+}
+
+/**
+ * NewQueryError returns the error of the response hresp with body data,
+ * from the common XML error envelope. REST services sharing the envelope,
+ * such as Route 53, use it too.
+ */
+func NewQueryError(hresp *http.Response, data []byte) error {
+	err := &QueryError{StatusCode: hresp.StatusCode}
+	var envelope queryErrorResponse
+	if xml.Unmarshal(data, &envelope) == nil {
+		if len(envelope.Errors) > 0 {
+			err.Type = envelope.Errors[0].Type
+			err.Code = envelope.Errors[0].Code
+			err.Message = envelope.Errors[0].Message
+		} else if len(envelope.EC2Errors) > 0 {
+			err.Code = envelope.EC2Errors[0].Code
+			err.Message = envelope.EC2Errors[0].Message
+		}
+		err.RequestId = envelope.RequestId
+		if err.RequestId == "" {
+			err.RequestId = envelope.RequestID
+		}
+	}
+	if err.RequestId == "" {
+		err.RequestId = hresp.Header.Get("x-amzn-RequestId")
+	}
+	if err.Message == "" {
+		err.Message = hresp.Status
+	}
+	return err
+}
+
+/**
+ * IsExpiredCredentials reports whether err is a service rejecting
+ * temporary credentials that have expired.
+ */
+func IsExpiredCredentials(err error) bool {
+	switch ErrorCode(err) {
+	case "ExpiredToken", "ExpiredTokenException", "RequestExpired":
+		return true
+	}
+	return false
+}
+
+/**
+ * shouldRetry reports whether action, which failed with err, can be sent
+ * again. Throttled requests and requests that could not be sent were
+ * not performed and are always retried; after server errors and lost
+ * responses only idempotent actions are.
+ */
+func (self *QueryClient) shouldRetry(action string, form url.Values, err error) bool {
+	if err == nil {
+		return false
+	}
+	if e, ok := err.(*QueryError); ok && IsThrottle(e) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	if !self.idempotent(action, form) {
+		return false
+	}
+	switch err {
+	case io.ErrUnexpectedEOF, io.EOF:
+		return true
+	}
+	switch e := err.(type) {
+	case *QueryError:
+		return e.StatusCode >= 500
+	case *url.Error:
+		if neterr, ok := e.Err.(net.Error); ok && neterr.Timeout() {
+			return true
+		}
+		if opErr != nil {
+			return true
+		}
+		return e.Err == io.EOF || e.Err == io.ErrUnexpectedEOF
+	}
+	return false
+}
+
+/**
+ * idempotent reports whether sending action with form more than once has
+ * the same effect as sending it once.
+ */
+func (self *QueryClient) idempotent(action string, form url.Values) bool {
+	for _, prefix := range []string{"Describe", "Get", "List"} {
+		if strings.HasPrefix(action, prefix) {
+			return true
+		}
+	}
+	return form.Get("ClientToken") != "" || self.IdempotentActions[action]
+}
+
+func (self *QueryClient) signer() Signer {
+	if self.Signer != nil {
+		return self.Signer
+	}
+	if self.SigV4Only {
+		region := self.Endpoint.SigningRegion
+		name := self.Endpoint.SigningName
+		if name == "" {
+			name = self.Service
+		}
+		return &V4Signer{Region: region, Service: name}
+	}
+	return V2QuerySigner{}
+}
+
+func (self *QueryClient) httpClient() *http.Client {
+	if self.Client != nil {
+		return self.Client
+	}
+	return DefaultQueryHTTPClient
+}
+
+func (self *QueryClient) observe(metrics Metrics) {
+	if self.Metrics != nil {
+		self.Metrics.Observe(metrics)
+	}
+}
+
+/**
+ * V2QuerySigner signs Query protocol requests with Signature Version 2,
+ * which puts the signature into the form parameters of the request.
+ */
+type V2QuerySigner struct{}
+
+/**
+ * Sign adds the authentication parameters and the signature to the form
+ * encoded body of req.
+ */
+func (self V2QuerySigner) Sign(req *http.Request, auth Auth) error {
+	data, err := readRequestBody(req)
+	if err != nil {
+		return err
+	}
+	params, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	self.sign(req, auth, params, time.Now().UTC().Format(time.RFC3339))
+
+	body := []byte(params.Encode())
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	return nil
+}
+
+/**
+ * Presign adds the authentication parameters and the signature to the
+ * query string of req, valid until expires.
+ */
+func (self V2QuerySigner) Presign(req *http.Request, auth Auth, expires time.Time) error {
+	params := req.URL.Query()
+	params.Del("Timestamp")
+	params.Set("Expires", expires.UTC().Format(time.RFC3339))
+	self.sign(req, auth, params, "")
+	req.URL.RawQuery = params.Encode()
+	return nil
+}
+
+func (V2QuerySigner) sign(req *http.Request, auth Auth, params url.Values, timestamp string) {
+	params.Del("Signature")
+	params.Set("AWSAccessKeyId", auth.AccessKey)
+	params.Set("SignatureVersion", "2")
+	params.Set("SignatureMethod", "HmacSHA256")
+	if timestamp != "" {
+		params.Set("Timestamp", timestamp)
+	}
+	if auth.Token != "" {
+		params.Set("SecurityToken", auth.Token)
+	}
+
+	var pairs []string
+	for k, values := range params {
+		for _, v := range values {
+			pairs = append(pairs, Encode(k)+"="+Encode(v))
+		}
+	}
+	sort.Strings(pairs)
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	payload := req.Method + "\n" + strings.ToLower(requestHost(req)) + "\n" + path + "\n" + strings.Join(pairs, "&")
+	hash := hmac.New(sha256.New, []byte(auth.SecretKey))
+	hash.Write([]byte(payload))
+	params.Set("Signature", base64.StdEncoding.EncodeToString(hash.Sum(nil)))
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	return data, err
+}
+
+/**
+ * SetList adds values to params as the numbered list prefix.1,
+ * prefix.2, ... used by Query protocol APIs.
+ */
+func SetList(params url.Values, prefix string, values []string) {
+	for i, value := range values {
+		params.Set(prefix+"."+strconv.Itoa(i+1), value)
+	}
+}
+
+/**
+ * SetMap adds m to params as the numbered list of key/value pairs
+ * prefix.N.keyName and prefix.N.valueName, sorted by key.
+ */
+func SetMap(params url.Values, prefix, keyName, valueName string, m map[string]string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		n := prefix + "." + strconv.Itoa(i+1) + "."
+		params.Set(n+keyName, k)
+		params.Set(n+valueName, m[k])
+	}
+}
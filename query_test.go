@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var fastAttempts = &AttemptStrategy{Min: 3, Delay: time.Millisecond}
+
+// countingServer answers every request with status and body and counts
+// the requests per action.
+func countingServer(t *testing.T, status int, body string) (*QueryClient, *int32) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	client := NewQueryClient(Auth{AccessKey: "a", SecretKey: "s"}, USEast, "sqs", "2012-11-05")
+	client.Endpoint.URL = srv.URL
+	client.Attempts = fastAttempts
+	return client, &count
+}
+
+const internalError = `<ErrorResponse><Error><Type>Receiver</Type><Code>InternalError</Code><Message>boom</Message></Error><RequestId>r</RequestId></ErrorResponse>`
+
+func TestQueryClientDoesNotRepeatNonIdempotentActions(t *testing.T) {
+	client, count := countingServer(t, 500, internalError)
+	err := client.Do(context.Background(), "SendMessage", url.Values{}, nil)
+	if ErrorCode(err) != "InternalError" {
+		t.Fatalf("got %v", err)
+	}
+	if *count != 1 {
+		t.Fatalf("SendMessage was sent %d times", *count)
+	}
+}
+
+func TestQueryClientRetriesIdempotentActions(t *testing.T) {
+	for _, tc := range []struct {
+		action string
+		params url.Values
+		extra  map[string]bool
+	}{
+		{"ListQueues", url.Values{}, nil},
+		{"RunInstances", url.Values{"ClientToken": {"t"}}, nil},
+		{"DeleteMessage", url.Values{}, map[string]bool{"DeleteMessage": true}},
+	} {
+		client, count := countingServer(t, 500, internalError)
+		client.IdempotentActions = tc.extra
+		client.Do(context.Background(), tc.action, tc.params, nil)
+		if *count != 3 {
+			t.Errorf("%s was sent %d times, want 3", tc.action, *count)
+		}
+	}
+}
+
+func TestQueryClientRetriesThrottledActions(t *testing.T) {
+	client, count := countingServer(t, 400, `<ErrorResponse><Error><Code>Throttling</Code><Message>slow down</Message></Error></ErrorResponse>`)
+	client.Do(context.Background(), "SendMessage", url.Values{}, nil)
+	if *count != 3 {
+		t.Fatalf("throttled SendMessage was sent %d times, want 3", *count)
+	}
+}
+
+func TestQueryClientRetriesUnsentRequests(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.URL
+	srv.Close()
+
+	client := NewQueryClient(Auth{AccessKey: "a", SecretKey: "s"}, USEast, "sqs", "2012-11-05")
+	client.Endpoint.URL = addr
+	client.Attempts = fastAttempts
+	var retries int32
+	client.Hooks = &Hooks{OnRetry: []func(context.Context, error, int){
+		func(context.Context, error, int) { atomic.AddInt32(&retries, 1) },
+	}}
+	if err := client.Do(context.Background(), "SendMessage", url.Values{}, nil); err == nil {
+		t.Fatal("no error from a closed server")
+	}
+	if retries != 2 {
+		t.Fatalf("refused SendMessage was retried %d times, want 2", retries)
+	}
+}
+
+func TestQueryClientUsesTrackedClient(t *testing.T) {
+	client := NewQueryClient(Auth{}, USEast, "sqs", "2012-11-05")
+	if client.httpClient() != DefaultQueryHTTPClient || DefaultQueryHTTPClient.Timeout == 0 {
+		t.Fatal("QueryClient does not default to DefaultQueryHTTPClient")
+	}
+}
@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/**
+ * RateLimiter is a token bucket limiting requests to Rate per second on
+ * average, with bursts of up to Burst requests. Clients that are given
+ * one wait for it before every request, including retries, so batch jobs
+ * can stay below account API limits instead of running into throttling.
+ * It is safe for concurrent use and may be shared by several clients.
+ */
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+/**
+ * NewRateLimiter returns a RateLimiter allowing rate requests per second
+ * and bursts of burst requests, starting with a full bucket.
+ */
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+/**
+ * Wait blocks until a request may be made or ctx is done, in which case
+ * it returns the context's error. A nil RateLimiter never blocks.
+ */
+func (self *RateLimiter) Wait(ctx context.Context) error {
+	if self == nil {
+		return nil
+	}
+	for {
+		wait := self.reserve()
+		if wait == 0 {
+			return nil
+		}
+		if err := SleepWithContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+/**
+ * reserve takes a token and returns 0, or returns how long to wait until
+ * one is available.
+ */
+func (self *RateLimiter) reserve() time.Duration {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	now := time.Now()
+	self.tokens += now.Sub(self.last).Seconds() * self.rate
+	if self.tokens > self.burst {
+		self.tokens = self.burst
+	}
+	self.last = now
+
+	if self.tokens >= 1 {
+		self.tokens--
+		return 0
+	}
+	if self.rate <= 0 {
+		return time.Second
+	}
+	return time.Duration((1 - self.tokens) / self.rate * float64(time.Second))
+}
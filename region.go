@@ -1,5 +1,14 @@
 package aws
 
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
 // Region defines the URLs where AWS services may be accessed.
 //
 // See http://goo.gl/d8BP1 for more details.
@@ -14,6 +23,7 @@ type Region struct {
 	SNSEndpoint          string
 	SQSEndpoint          string
 	IAMEndpoint          string
+	SigV4Only            bool // true if this region only accepts Signature Version 4.
 }
 
 var USEast = Region{
@@ -27,6 +37,7 @@ var USEast = Region{
 	"https://sns.us-east-1.amazonaws.com",
 	"https://sqs.us-east-1.amazonaws.com",
 	"https://iam.amazonaws.com",
+	false,
 }
 
 var USWest = Region{
@@ -40,6 +51,7 @@ var USWest = Region{
 	"https://sns.us-west-1.amazonaws.com",
 	"https://sqs.us-west-1.amazonaws.com",
 	"https://iam.amazonaws.com",
+	false,
 }
 
 var USWest2 = Region{
@@ -53,6 +65,7 @@ var USWest2 = Region{
 	"https://sns.us-west-2.amazonaws.com",
 	"https://sqs.us-west-2.amazonaws.com",
 	"https://iam.amazonaws.com",
+	false,
 }
 
 var EUWest = Region{
@@ -66,6 +79,7 @@ var EUWest = Region{
 	"https://sns.eu-west-1.amazonaws.com",
 	"https://sqs.eu-west-1.amazonaws.com",
 	"https://iam.amazonaws.com",
+	false,
 }
 
 var APSoutheast = Region{
@@ -79,6 +93,7 @@ var APSoutheast = Region{
 	"https://sns.ap-southeast-1.amazonaws.com",
 	"https://sqs.ap-southeast-1.amazonaws.com",
 	"https://iam.amazonaws.com",
+	false,
 }
 
 var APSoutheast2 = Region{
@@ -92,6 +107,7 @@ var APSoutheast2 = Region{
 	"https://sns.ap-southeast-2.amazonaws.com",
 	"https://sqs.ap-southeast-2.amazonaws.com",
 	"https://iam.amazonaws.com",
+	false,
 }
 
 var APNortheast = Region{
@@ -105,6 +121,7 @@ var APNortheast = Region{
 	"https://sns.ap-northeast-1.amazonaws.com",
 	"https://sqs.ap-northeast-1.amazonaws.com",
 	"https://iam.amazonaws.com",
+	false,
 }
 
 var SAEast = Region{
@@ -118,15 +135,301 @@ var SAEast = Region{
 	"https://sns.sa-east-1.amazonaws.com",
 	"https://sqs.sa-east-1.amazonaws.com",
 	"https://iam.amazonaws.com",
+	false,
+}
+
+var USEast2 = Region{
+	"us-east-2",
+	"https://ec2.us-east-2.amazonaws.com",
+	"https://s3.us-east-2.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.us-east-2.amazonaws.com",
+	"https://sqs.us-east-2.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var CACentral = Region{
+	"ca-central-1",
+	"https://ec2.ca-central-1.amazonaws.com",
+	"https://s3.ca-central-1.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.ca-central-1.amazonaws.com",
+	"https://sqs.ca-central-1.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var EUCentral = Region{
+	"eu-central-1",
+	"https://ec2.eu-central-1.amazonaws.com",
+	"https://s3.eu-central-1.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.eu-central-1.amazonaws.com",
+	"https://sqs.eu-central-1.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var EUWest2 = Region{
+	"eu-west-2",
+	"https://ec2.eu-west-2.amazonaws.com",
+	"https://s3.eu-west-2.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.eu-west-2.amazonaws.com",
+	"https://sqs.eu-west-2.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var EUWest3 = Region{
+	"eu-west-3",
+	"https://ec2.eu-west-3.amazonaws.com",
+	"https://s3.eu-west-3.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.eu-west-3.amazonaws.com",
+	"https://sqs.eu-west-3.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var EUNorth = Region{
+	"eu-north-1",
+	"https://ec2.eu-north-1.amazonaws.com",
+	"https://s3.eu-north-1.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.eu-north-1.amazonaws.com",
+	"https://sqs.eu-north-1.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var EUSouth = Region{
+	"eu-south-1",
+	"https://ec2.eu-south-1.amazonaws.com",
+	"https://s3.eu-south-1.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.eu-south-1.amazonaws.com",
+	"https://sqs.eu-south-1.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var APSouth = Region{
+	"ap-south-1",
+	"https://ec2.ap-south-1.amazonaws.com",
+	"https://s3.ap-south-1.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.ap-south-1.amazonaws.com",
+	"https://sqs.ap-south-1.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var APNortheast2 = Region{
+	"ap-northeast-2",
+	"https://ec2.ap-northeast-2.amazonaws.com",
+	"https://s3.ap-northeast-2.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.ap-northeast-2.amazonaws.com",
+	"https://sqs.ap-northeast-2.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var APNortheast3 = Region{
+	"ap-northeast-3",
+	"https://ec2.ap-northeast-3.amazonaws.com",
+	"https://s3.ap-northeast-3.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.ap-northeast-3.amazonaws.com",
+	"https://sqs.ap-northeast-3.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
 }
 
+var APEast = Region{
+	"ap-east-1",
+	"https://ec2.ap-east-1.amazonaws.com",
+	"https://s3.ap-east-1.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.ap-east-1.amazonaws.com",
+	"https://sqs.ap-east-1.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var MESouth = Region{
+	"me-south-1",
+	"https://ec2.me-south-1.amazonaws.com",
+	"https://s3.me-south-1.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.me-south-1.amazonaws.com",
+	"https://sqs.me-south-1.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+var AFSouth = Region{
+	"af-south-1",
+	"https://ec2.af-south-1.amazonaws.com",
+	"https://s3.af-south-1.amazonaws.com",
+	"",
+	true,
+	true,
+	"",
+	"https://sns.af-south-1.amazonaws.com",
+	"https://sqs.af-south-1.amazonaws.com",
+	"https://iam.amazonaws.com",
+	true,
+}
+
+// Regions holds the known regions by name. Use GetRegion and
+// RegisterRegion, which are safe for concurrent use, to look up and add
+// regions; the map itself must not be read or changed directly once the
+// package is initialized, as it is guarded by a lock those functions
+// take.
 var Regions = map[string]Region{
 	APNortheast.Name:  APNortheast,
+	APNortheast2.Name: APNortheast2,
+	APNortheast3.Name: APNortheast3,
 	APSoutheast.Name:  APSoutheast,
 	APSoutheast2.Name: APSoutheast2,
+	APSouth.Name:      APSouth,
+	APEast.Name:       APEast,
+	CACentral.Name:    CACentral,
+	EUCentral.Name:    EUCentral,
 	EUWest.Name:       EUWest,
+	EUWest2.Name:      EUWest2,
+	EUWest3.Name:      EUWest3,
+	EUNorth.Name:      EUNorth,
+	EUSouth.Name:      EUSouth,
+	MESouth.Name:      MESouth,
+	AFSouth.Name:      AFSouth,
 	USEast.Name:       USEast,
+	USEast2.Name:      USEast2,
 	USWest.Name:       USWest,
 	USWest2.Name:      USWest2,
 	SAEast.Name:       SAEast,
 }
+
+var regionsMu sync.RWMutex
+
+// RegisterRegion adds region to Regions, replacing any region of the
+// same name, so that private regions and on-premises gateways can be
+// looked up by name like the public ones. It is safe for concurrent use
+// with GetRegion.
+func RegisterRegion(region Region) error {
+	if region.Name == "" {
+		return errors.New("cannot register a region without a name")
+	}
+	regionsMu.Lock()
+	Regions[region.Name] = region
+	regionsMu.Unlock()
+	return nil
+}
+
+// GetRegion returns the region registered as name.
+func GetRegion(name string) (Region, bool) {
+	regionsMu.RLock()
+	region, ok := Regions[name]
+	regionsMu.RUnlock()
+	return region, ok
+}
+
+// WithEndpoint returns a copy of the region that sends requests for
+// service ("ec2", "s3", "sdb", "sns", "sqs" or "iam") to endpoint, e.g.
+// a VPC endpoint or a compatible on-premises gateway.
+func (self Region) WithEndpoint(service, endpoint string) (Region, error) {
+	switch service {
+	case "ec2":
+		self.EC2Endpoint = endpoint
+	case "s3":
+		self.S3Endpoint = endpoint
+		self.S3BucketEndpoint = ""
+	case "sdb":
+		self.SDBEndpoint = endpoint
+	case "sns":
+		self.SNSEndpoint = endpoint
+	case "sqs":
+		self.SQSEndpoint = endpoint
+	case "iam":
+		self.IAMEndpoint = endpoint
+	default:
+		return self, errors.New("unknown service " + service)
+	}
+	return self, nil
+}
+
+// DefaultRegion determines the region to use when none is configured
+// explicitly. It consults, in order, the AWS_REGION and
+// AWS_DEFAULT_REGION environment variables, the region of the shared
+// config profile (see LoadProfile) and the availability zone of the EC2
+// instance the process runs on.
+func DefaultRegion() (Region, error) {
+	name, err := defaultRegionName()
+	if err != nil {
+		return Region{}, err
+	}
+	region, ok := GetRegion(name)
+	if !ok {
+		return Region{}, errors.New("unknown AWS region " + name)
+	}
+	return region, nil
+}
+
+func defaultRegionName() (string, error) {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if name := strings.TrimSpace(os.Getenv(env)); name != "" {
+			return name, nil
+		}
+	}
+
+	if profile, err := LoadProfile(""); err == nil && profile.Region != "" {
+		return strings.TrimSpace(profile.Region), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	name, err := DefaultMetadataClient.Region(ctx)
+	if err != nil {
+		return "", errors.New("cannot determine AWS region: set AWS_REGION or a region in the shared config file")
+	}
+	return name, nil
+}
@@ -0,0 +1,44 @@
+package aws
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestRegisterRegion(t *testing.T) {
+	if err := RegisterRegion(Region{}); err == nil {
+		t.Fatal("registered a region without a name")
+	}
+
+	region, err := USEast.WithEndpoint("s3", "https://s3.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	region.Name = "test-private-1"
+	defer func() {
+		regionsMu.Lock()
+		delete(Regions, region.Name)
+		regionsMu.Unlock()
+	}()
+
+	// Registering and looking up regions concurrently is safe.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				RegisterRegion(region)
+			} else {
+				GetRegion(USWest2.Name + strconv.Itoa(i))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got, ok := GetRegion("test-private-1")
+	if !ok || got.S3Endpoint != "https://s3.example.com" || got.S3BucketEndpoint != "" || got.EC2Endpoint != USEast.EC2Endpoint {
+		t.Fatalf("got %+v, %v", got, ok)
+	}
+}
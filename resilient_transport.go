@@ -7,6 +7,7 @@ import (
 
 type RetryableFunc func(*http.Request, *http.Response, error) bool
 type WaitFunc func(try int)
+type BackoffFunc func(try int) time.Duration
 type DeadlineFunc func() time.Time
 
 type ResilientTransport struct {
@@ -27,7 +28,24 @@ type ResilientTransport struct {
 	Deadline    DeadlineFunc
 	ShouldRetry RetryableFunc
 	Wait        WaitFunc
-	transport   *http.Transport
+
+	// Backoff, if set, is used instead of Wait and returns how long to
+	// wait before the next try. Unlike Wait, the wait is cut short when
+	// the request's context is done.
+	Backoff BackoffFunc
+
+	// Budget, if set, limits the fraction of requests that may be
+	// retried; tries beyond it are not made.
+	Budget *RetryBudget
+
+	// Limiter, if set, is waited for before every try.
+	Limiter *RateLimiter
+
+	// Breaker, if set, fails requests to endpoints that keep failing
+	// without sending them.
+	Breaker *CircuitBreaker
+
+	transport *http.Transport
 }
 
 var retryingTransport = &ResilientTransport{
@@ -38,7 +56,8 @@ var retryingTransport = &ResilientTransport{
 	DialTimeout: 10 * time.Second,
 	MaxTries:    3,
 	ShouldRetry: awsRetry,
-	Wait:        ExpBackoff,
+	Backoff:     ExpBackoffDuration,
+	Budget:      DefaultRetryBudget,
 }
 
 /**
@@ -58,21 +77,104 @@ func (self *ResilientTransport) tries(request *http.Request) (*http.Response, er
   var response *http.Response
   var error error
 
+	if self.Budget != nil {
+		self.Budget.Deposit()
+	}
+
 	for try := 0; try < self.MaxTries; try++ {
-    response, error = self.transport.RoundTrip(request)
+		if err := self.Limiter.Wait(request.Context()); err != nil {
+			return nil, err
+		}
+
+		attempt := request
+		if try > 0 && hasBody(request) {
+			// The previous try consumed the body; send a fresh copy.
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt = request.Clone(request.Context())
+			attempt.Body = body
+		}
+
+		// Allow takes the probe slot of a half-open circuit, which only
+		// Record gives back, so nothing may return between the two.
+		if self.Breaker != nil && !self.Breaker.Allow(request.URL.Host) {
+			if attempt != request {
+				attempt.Body.Close()
+			}
+			return nil, ErrCircuitOpen
+		}
+
+    response, error = self.transport.RoundTrip(attempt)
+
+		if self.Breaker != nil {
+			if error != nil && request.Context().Err() != nil {
+				// Abandoned by the caller; says nothing about the host.
+				self.Breaker.Release(request.URL.Host)
+			} else {
+				self.Breaker.Record(request.URL.Host, isFailure(response, error))
+			}
+		}
 
 		if !self.ShouldRetry(request, response, error) {
 			break
 		}
 
+		// A body that cannot be replayed must not be sent again, as
+		// the retry would carry whatever is left of it.
+		if hasBody(request) && request.GetBody == nil {
+			break
+		}
+
+		// The last response is returned as is, with its body unread.
+		if try+1 >= self.MaxTries || (self.Budget != nil && !self.Budget.Withdraw()) {
+			break
+		}
+
+		wait, waitRequested := RetryAfter(response)
+
 		if response != nil {
 			response.Body.Close()
 		}
 
-		if self.Wait != nil {
+		TrackRetry()
+
+		if waitRequested {
+			// The server knows best when it can take the request again.
+			if err := SleepWithContext(request.Context(), wait); err != nil {
+				return nil, err
+			}
+		} else if self.Backoff != nil {
+			if err := SleepWithContext(request.Context(), self.Backoff(try)); err != nil {
+				return nil, err
+			}
+		} else if self.Wait != nil {
 			self.Wait(try)
 		}
+
+		if err := request.Context().Err(); err != nil {
+			return nil, err
+		}
 	}
 
 	return response, error
 }
+
+/**
+ * CloseIdleConnections closes the idle keep-alive connections of the
+ * underlying transport, e.g. to drain connections before shutdown.
+ */
+func (self *ResilientTransport) CloseIdleConnections() {
+	if self.transport != nil {
+		self.transport.CloseIdleConnections()
+	}
+}
+
+/**
+ * hasBody reports whether request has a body that is consumed by
+ * sending it.
+ */
+func hasBody(request *http.Request) bool {
+	return request.Body != nil && request.Body != http.NoBody
+}
@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResilientTransportBackoffStopsWithContext(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(500)
+	}))
+	defer srv.Close()
+
+	rt := &ResilientTransport{
+		MaxTries:    5,
+		ShouldRetry: func(*http.Request, *http.Response, error) bool { return true },
+		Backoff:     func(try int) time.Duration { return time.Minute },
+	}
+	client := NewClient(rt)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+
+	start := time.Now()
+	resp, err := client.Do(req.WithContext(ctx))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("no error")
+	}
+	if ctx.Err() == nil {
+		t.Fatalf("returned %v before the context was done", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("backed off for %v", elapsed)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("got %d requests, want 1", n)
+	}
+}
+
+func TestResilientTransportBackoff(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(500)
+		}
+	}))
+	defer srv.Close()
+
+	var tries []int
+	rt := &ResilientTransport{
+		MaxTries: 5,
+		ShouldRetry: func(req *http.Request, resp *http.Response, err error) bool {
+			return err == nil && resp.StatusCode == 500
+		},
+		Backoff: func(try int) time.Duration {
+			tries = append(tries, try)
+			return time.Millisecond
+		},
+	}
+	resp, err := NewClient(rt).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || len(tries) != 2 || tries[0] != 0 || tries[1] != 1 {
+		t.Fatalf("got %s after backoffs %v", resp.Status, tries)
+	}
+}
+
+func TestExpBackoffDuration(t *testing.T) {
+	for try, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond} {
+		if got := ExpBackoffDuration(try); got != want {
+			t.Errorf("ExpBackoffDuration(%d) = %v, want %v", try, got, want)
+		}
+	}
+}
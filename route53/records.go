@@ -0,0 +1,160 @@
+package route53
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// ResourceRecordSet is the set of records of a name and type. Alias
+// records have an AliasTarget instead of TTL and ResourceRecords.
+type ResourceRecordSet struct {
+	Name            string
+	Type            string       // "A", "AAAA", "CNAME", "MX", "TXT", ...
+	TTL             int          `xml:",omitempty"`
+	ResourceRecords []string     `xml:"ResourceRecords>ResourceRecord>Value,omitempty"`
+	AliasTarget     *AliasTarget `xml:",omitempty"`
+
+	// SetIdentifier and Weight make the set one of several weighted
+	// sets of the same name and type.
+	SetIdentifier string `xml:",omitempty"`
+	Weight        *int   `xml:",omitempty"`
+}
+
+// AliasTarget points an alias record to an AWS resource, such as a load
+// balancer or a CloudFront distribution.
+type AliasTarget struct {
+	HostedZoneId         string
+	DNSName              string
+	EvaluateTargetHealth bool
+}
+
+// Change is a change of a resource record set. Action is "CREATE",
+// "DELETE" or "UPSERT".
+type Change struct {
+	Action            string
+	ResourceRecordSet ResourceRecordSet
+}
+
+// ChangeBatch collects changes applied together by
+// ChangeResourceRecordSets: either all of them are applied or none.
+type ChangeBatch struct {
+	Comment string   `xml:",omitempty"`
+	Changes []Change `xml:"Changes>Change"`
+}
+
+// Upsert adds a change creating set or replacing the set of the same
+// name and type.
+func (self *ChangeBatch) Upsert(set ResourceRecordSet) *ChangeBatch {
+	self.Changes = append(self.Changes, Change{"UPSERT", set})
+	return self
+}
+
+// Create adds a change creating set, which must not exist.
+func (self *ChangeBatch) Create(set ResourceRecordSet) *ChangeBatch {
+	self.Changes = append(self.Changes, Change{"CREATE", set})
+	return self
+}
+
+// Delete adds a change deleting set, which must match the existing set
+// exactly.
+func (self *ChangeBatch) Delete(set ResourceRecordSet) *ChangeBatch {
+	self.Changes = append(self.Changes, Change{"DELETE", set})
+	return self
+}
+
+// ChangeResourceRecordSets applies batch to the records of the hosted
+// zone with the given ID. Pass the ID of the returned change to
+// WaitForChange to wait until it has propagated.
+//
+// See https://docs.aws.amazon.com/Route53/latest/APIReference/API_ChangeResourceRecordSets.html for details.
+func (self *Route53) ChangeResourceRecordSets(ctx context.Context, zoneId string, batch *ChangeBatch) (*ChangeInfo, error) {
+	req := struct {
+		XMLName     xml.Name `xml:"ChangeResourceRecordSetsRequest"`
+		Xmlns       string   `xml:"xmlns,attr"`
+		ChangeBatch *ChangeBatch
+	}{Xmlns: xmlns, ChangeBatch: batch}
+	var resp struct {
+		ChangeInfo ChangeInfo
+	}
+	if err := self.do(ctx, "POST", "/hostedzone/"+CleanZoneId(zoneId)+"/rrset", nil, &req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.ChangeInfo, nil
+}
+
+// ListResourceRecordSets returns all resource record sets of the hosted
+// zone with the given ID.
+//
+// See https://docs.aws.amazon.com/Route53/latest/APIReference/API_ListResourceRecordSets.html for details.
+func (self *Route53) ListResourceRecordSets(ctx context.Context, zoneId string) ([]ResourceRecordSet, error) {
+	var sets []ResourceRecordSet
+	query := url.Values{}
+	for {
+		var resp struct {
+			ResourceRecordSets   []ResourceRecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+			IsTruncated          bool
+			NextRecordName       string
+			NextRecordType       string
+			NextRecordIdentifier string
+		}
+		if err := self.do(ctx, "GET", "/hostedzone/"+CleanZoneId(zoneId)+"/rrset", query, nil, &resp); err != nil {
+			return nil, err
+		}
+		sets = append(sets, resp.ResourceRecordSets...)
+		if !resp.IsTruncated {
+			return sets, nil
+		}
+		query = url.Values{
+			"name": {resp.NextRecordName},
+			"type": {resp.NextRecordType},
+		}
+		if resp.NextRecordIdentifier != "" {
+			query.Set("identifier", resp.NextRecordIdentifier)
+		}
+	}
+}
+
+// GetChange returns the state of the change with the given ID.
+//
+// See https://docs.aws.amazon.com/Route53/latest/APIReference/API_GetChange.html for details.
+func (self *Route53) GetChange(ctx context.Context, changeId string) (*ChangeInfo, error) {
+	var resp struct {
+		ChangeInfo ChangeInfo
+	}
+	id := strings.TrimPrefix(changeId, "/change/")
+	if err := self.do(ctx, "GET", "/change/"+id, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.ChangeInfo, nil
+}
+
+// ChangeWaitStrategy is how WaitForChange polls; changes usually
+// propagate within a minute.
+var ChangeWaitStrategy = aws.AttemptStrategy{
+	Total: 10 * time.Minute,
+	Delay: 10 * time.Second,
+}
+
+// WaitForChange waits until the change with the given ID has propagated
+// to all Route 53 servers.
+func (self *Route53) WaitForChange(ctx context.Context, changeId string) error {
+	waiter := &aws.Waiter{
+		Name: "ResourceRecordSetsChanged",
+		Poll: func(ctx context.Context) (interface{}, error) {
+			return self.GetChange(ctx, changeId)
+		},
+		Acceptors: []aws.Acceptor{
+			{State: aws.WaiterSuccess, Matcher: func(result interface{}, err error) bool {
+				change, ok := result.(*ChangeInfo)
+				return ok && change.Status == "INSYNC"
+			}},
+		},
+		Strategy: ChangeWaitStrategy,
+	}
+	return waiter.Wait(ctx)
+}
@@ -0,0 +1,79 @@
+package route53
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+func TestChangeResourceRecordSets(t *testing.T) {
+	client, fake := newTestRoute53(t)
+	ctx := context.Background()
+	weight := 10
+	batch := (&ChangeBatch{Comment: "setup"}).
+		Create(ResourceRecordSet{Name: "a.example.com.", Type: "A", TTL: 300, ResourceRecords: []string{"192.0.2.1", "192.0.2.2"}}).
+		Create(ResourceRecordSet{Name: "b.example.com.", Type: "CNAME", TTL: 60, ResourceRecords: []string{"a.example.com."}}).
+		Create(ResourceRecordSet{Name: "c.example.com.", Type: "A", AliasTarget: &AliasTarget{HostedZoneId: "Z2", DNSName: "lb.example.net.", EvaluateTargetHealth: true}}).
+		Create(ResourceRecordSet{Name: "d.example.com.", Type: "TXT", TTL: 60, ResourceRecords: []string{`"v=1"`}, SetIdentifier: "one", Weight: &weight})
+	change, err := client.ChangeResourceRecordSets(ctx, "/hostedzone/Z1", batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.Id != "/change/C3" || change.Comment != "setup" {
+		t.Fatalf("got %+v", change)
+	}
+
+	sets, err := client.ListResourceRecordSets(ctx, "Z1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 4 {
+		t.Fatalf("got %+v", sets)
+	}
+	if a := sets[0]; a.TTL != 300 || len(a.ResourceRecords) != 2 || a.ResourceRecords[1] != "192.0.2.2" {
+		t.Errorf("got %+v", a)
+	}
+	if c := sets[2]; c.AliasTarget == nil || c.AliasTarget.DNSName != "lb.example.net." || !c.AliasTarget.EvaluateTargetHealth || c.TTL != 0 {
+		t.Errorf("got %+v", c)
+	}
+	if d := sets[3]; d.SetIdentifier != "one" || d.Weight == nil || *d.Weight != 10 {
+		t.Errorf("got %+v", d)
+	}
+	// The second page starts at the next name and type.
+	if q := fake.queries[len(fake.queries)-1]; q != "name=c.example.com.&type=A" {
+		t.Fatalf("sent query %q", q)
+	}
+
+	batch = (&ChangeBatch{}).
+		Upsert(ResourceRecordSet{Name: "a.example.com.", Type: "A", TTL: 30, ResourceRecords: []string{"192.0.2.3"}}).
+		Delete(sets[1])
+	if _, err := client.ChangeResourceRecordSets(ctx, "Z1", batch); err != nil {
+		t.Fatal(err)
+	}
+	if sets, err = client.ListResourceRecordSets(ctx, "Z1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 3 || sets[0].TTL != 30 || sets[0].ResourceRecords[0] != "192.0.2.3" || sets[1].Name != "c.example.com." {
+		t.Fatalf("got %+v", sets)
+	}
+}
+
+func TestWaitForChange(t *testing.T) {
+	client, fake := newTestRoute53(t)
+	defer func(strategy aws.AttemptStrategy) { ChangeWaitStrategy = strategy }(ChangeWaitStrategy)
+	ChangeWaitStrategy = aws.AttemptStrategy{Total: time.Second, Delay: time.Millisecond}
+
+	if err := client.WaitForChange(context.Background(), "/change/C3"); err != nil {
+		t.Fatal(err)
+	}
+	if fake.changes["C3"] != 3 {
+		t.Fatalf("polled %d times", fake.changes["C3"])
+	}
+
+	ChangeWaitStrategy = aws.AttemptStrategy{Total: 0, Delay: time.Millisecond}
+	if err := client.WaitForChange(context.Background(), "C4"); err == nil {
+		t.Fatal("expected the wait to time out")
+	}
+}
@@ -0,0 +1,200 @@
+// Package route53 interacts with Amazon Route 53 DNS.
+package route53
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dkln/go-aws"
+)
+
+// APIVersion is the version of the Route 53 API the package speaks.
+const APIVersion = "2013-04-01"
+
+// DefaultEndpoint is the endpoint of Route 53, a global service.
+const DefaultEndpoint = "https://route53.amazonaws.com"
+
+const xmlns = "https://route53.amazonaws.com/doc/2013-04-01/"
+
+// The Route53 type encapsulates operations with Route 53.
+type Route53 struct {
+	aws.Auth
+
+	// Credentials, if set, supply the keys requests are signed with
+	// instead of Auth.
+	Credentials *aws.Credentials
+
+	// Endpoint is the URL requests are sent to. If empty, DefaultEndpoint.
+	Endpoint string
+
+	// Client sends the requests. If nil, aws.RetryingClient is used.
+	Client *http.Client
+}
+
+// New creates a new Route53.
+func New(auth aws.Auth) *Route53 {
+	return &Route53{Auth: auth}
+}
+
+// do sends a request for path below the API version, with the XML
+// encoding of body, if not nil, and decodes the response into resp, if
+// not nil.
+func (self *Route53) do(ctx context.Context, method, path string, query url.Values, body, resp interface{}) error {
+	endpoint := self.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	u := endpoint + "/" + APIVersion + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var payload io.Reader
+	if body != nil {
+		data, err := xml.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(append([]byte(xml.Header), data...))
+	}
+	req, err := http.NewRequest(method, u, payload)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "text/xml")
+	}
+	aws.SetUserAgent(req)
+
+	auth := self.Auth
+	if self.Credentials != nil {
+		if auth, err = self.Credentials.GetWithContext(ctx); err != nil {
+			return err
+		}
+	}
+	signer := &aws.V4Signer{Region: aws.USEast.Name, Service: "route53"}
+	if err := signer.Sign(req, auth); err != nil {
+		return err
+	}
+
+	client := self.Client
+	if client == nil {
+		client = aws.RetryingClient
+	}
+	hresp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer hresp.Body.Close()
+	data, err := ioutil.ReadAll(hresp.Body)
+	if err != nil {
+		return err
+	}
+	if hresp.StatusCode < 200 || hresp.StatusCode > 299 {
+		err := aws.NewQueryError(hresp, data)
+		if aws.IsExpiredCredentials(err) && self.Credentials != nil {
+			self.Credentials.Expire()
+		}
+		return err
+	}
+	if resp != nil {
+		return xml.Unmarshal(data, resp)
+	}
+	return nil
+}
+
+// CleanZoneId strips the "/hostedzone/" prefix Route 53 returns zone IDs
+// with.
+func CleanZoneId(id string) string {
+	return strings.TrimPrefix(id, "/hostedzone/")
+}
+
+// HostedZone is a DNS zone hosted by Route 53.
+type HostedZone struct {
+	Id                     string // "/hostedzone/<id>"; see CleanZoneId
+	Name                   string // with a trailing dot
+	CallerReference        string
+	Comment                string `xml:"Config>Comment"`
+	PrivateZone            bool   `xml:"Config>PrivateZone"`
+	ResourceRecordSetCount int
+}
+
+// ChangeInfo is the state of a change, which is "PENDING" until it has
+// propagated to all Route 53 servers, and "INSYNC" after.
+type ChangeInfo struct {
+	Id          string
+	Status      string
+	SubmittedAt string
+	Comment     string
+}
+
+// CreateHostedZoneResp is the result of CreateHostedZone.
+type CreateHostedZoneResp struct {
+	HostedZone  HostedZone
+	ChangeInfo  ChangeInfo
+	NameServers []string `xml:"DelegationSet>NameServers>NameServer"`
+}
+
+// CreateHostedZone creates a public hosted zone for the domain name.
+// The callerReference identifies the request, so it can be retried
+// without creating the zone twice; it must be unique.
+//
+// See https://docs.aws.amazon.com/Route53/latest/APIReference/API_CreateHostedZone.html for details.
+func (self *Route53) CreateHostedZone(ctx context.Context, name, callerReference, comment string) (*CreateHostedZoneResp, error) {
+	req := struct {
+		XMLName         xml.Name `xml:"CreateHostedZoneRequest"`
+		Xmlns           string   `xml:"xmlns,attr"`
+		Name            string
+		CallerReference string
+		Comment         string `xml:"HostedZoneConfig>Comment,omitempty"`
+	}{Xmlns: xmlns, Name: name, CallerReference: callerReference, Comment: comment}
+	resp := &CreateHostedZoneResp{}
+	if err := self.do(ctx, "POST", "/hostedzone", nil, &req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteHostedZone deletes the hosted zone with the given ID, which must
+// only hold its SOA and NS records.
+//
+// See https://docs.aws.amazon.com/Route53/latest/APIReference/API_DeleteHostedZone.html for details.
+func (self *Route53) DeleteHostedZone(ctx context.Context, zoneId string) (*ChangeInfo, error) {
+	var resp struct {
+		ChangeInfo ChangeInfo
+	}
+	if err := self.do(ctx, "DELETE", "/hostedzone/"+CleanZoneId(zoneId), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.ChangeInfo, nil
+}
+
+// ListHostedZones returns all hosted zones of the account.
+//
+// See https://docs.aws.amazon.com/Route53/latest/APIReference/API_ListHostedZones.html for details.
+func (self *Route53) ListHostedZones(ctx context.Context) ([]HostedZone, error) {
+	var zones []HostedZone
+	query := url.Values{}
+	for {
+		var resp struct {
+			HostedZones []HostedZone `xml:"HostedZones>HostedZone"`
+			IsTruncated bool
+			NextMarker  string
+		}
+		if err := self.do(ctx, "GET", "/hostedzone", query, nil, &resp); err != nil {
+			return nil, err
+		}
+		zones = append(zones, resp.HostedZones...)
+		if !resp.IsTruncated {
+			return zones, nil
+		}
+		query.Set("marker", resp.NextMarker)
+	}
+}
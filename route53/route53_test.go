@@ -0,0 +1,227 @@
+package route53
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dkln/go-aws"
+)
+
+// fakeRoute53 keeps hosted zones and their record sets in memory and
+// pages its listings two items at a time.
+type fakeRoute53 struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	zones   []HostedZone
+	records map[string][]ResourceRecordSet // by zone ID
+	changes map[string]int                 // GetChange calls by change ID
+	queries []string
+}
+
+func newTestRoute53(t *testing.T) (*Route53, *fakeRoute53) {
+	fake := &fakeRoute53{records: map[string][]ResourceRecordSet{}, changes: map[string]int{}}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"})
+	client.Endpoint = fake.URL
+	return client, fake
+}
+
+func (self *fakeRoute53) serve(w http.ResponseWriter, r *http.Request) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") ||
+		!strings.Contains(r.Header.Get("Authorization"), "/us-east-1/route53/aws4_request") {
+		writeFakeError(w, 403, "SignatureDoesNotMatch")
+		return
+	}
+	self.queries = append(self.queries, r.URL.RawQuery)
+	path := strings.TrimPrefix(r.URL.Path, "/"+APIVersion)
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == "POST" && path == "/hostedzone":
+		var req struct {
+			Name            string
+			CallerReference string
+			Comment         string `xml:"HostedZoneConfig>Comment"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		zone := HostedZone{
+			Id:              fmt.Sprintf("/hostedzone/Z%d", len(self.zones)+1),
+			Name:            req.Name,
+			CallerReference: req.CallerReference,
+			Comment:         req.Comment,
+		}
+		self.zones = append(self.zones, zone)
+		w.WriteHeader(201)
+		encode(w, "CreateHostedZoneResponse", struct {
+			HostedZone  HostedZone
+			ChangeInfo  ChangeInfo
+			NameServers []string `xml:"DelegationSet>NameServers>NameServer"`
+		}{zone, ChangeInfo{Id: "/change/C1", Status: "PENDING"}, []string{"ns-1.example.net"}})
+	case r.Method == "GET" && path == "/hostedzone":
+		start := 0
+		if marker := r.URL.Query().Get("marker"); marker != "" {
+			for start < len(self.zones) && CleanZoneId(self.zones[start].Id) != marker {
+				start++
+			}
+		}
+		end := start + 2
+		resp := struct {
+			HostedZones []HostedZone `xml:"HostedZones>HostedZone"`
+			IsTruncated bool
+			NextMarker  string `xml:",omitempty"`
+		}{}
+		if end < len(self.zones) {
+			resp.IsTruncated, resp.NextMarker = true, CleanZoneId(self.zones[end].Id)
+		} else {
+			end = len(self.zones)
+		}
+		resp.HostedZones = self.zones[start:end]
+		encode(w, "ListHostedZonesResponse", resp)
+	case r.Method == "DELETE" && len(parts) == 2 && parts[0] == "hostedzone":
+		for i, zone := range self.zones {
+			if CleanZoneId(zone.Id) == parts[1] {
+				self.zones = append(self.zones[:i], self.zones[i+1:]...)
+				encode(w, "DeleteHostedZoneResponse", struct{ ChangeInfo ChangeInfo }{ChangeInfo{Id: "/change/C2", Status: "PENDING"}})
+				return
+			}
+		}
+		writeFakeError(w, 404, "NoSuchHostedZone")
+	case r.Method == "POST" && len(parts) == 3 && parts[2] == "rrset":
+		var req struct {
+			ChangeBatch ChangeBatch
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		sets := self.records[parts[1]]
+		for _, change := range req.ChangeBatch.Changes {
+			i := 0
+			for i < len(sets) && (sets[i].Name != change.ResourceRecordSet.Name || sets[i].Type != change.ResourceRecordSet.Type) {
+				i++
+			}
+			switch {
+			case change.Action == "DELETE" && i < len(sets):
+				sets = append(sets[:i], sets[i+1:]...)
+			case change.Action == "UPSERT" && i < len(sets):
+				sets[i] = change.ResourceRecordSet
+			case change.Action != "DELETE" && i == len(sets):
+				sets = append(sets, change.ResourceRecordSet)
+			default:
+				writeFakeError(w, 400, "InvalidChangeBatch")
+				return
+			}
+		}
+		self.records[parts[1]] = sets
+		encode(w, "ChangeResourceRecordSetsResponse", struct{ ChangeInfo ChangeInfo }{ChangeInfo{Id: "/change/C3", Status: "PENDING", Comment: req.ChangeBatch.Comment}})
+	case r.Method == "GET" && len(parts) == 3 && parts[2] == "rrset":
+		sets := self.records[parts[1]]
+		start := 0
+		if name := r.URL.Query().Get("name"); name != "" {
+			for start < len(sets) && (sets[start].Name != name || sets[start].Type != r.URL.Query().Get("type")) {
+				start++
+			}
+		}
+		end := start + 2
+		resp := struct {
+			ResourceRecordSets []ResourceRecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+			IsTruncated        bool
+			NextRecordName     string `xml:",omitempty"`
+			NextRecordType     string `xml:",omitempty"`
+		}{}
+		if end < len(sets) {
+			resp.IsTruncated, resp.NextRecordName, resp.NextRecordType = true, sets[end].Name, sets[end].Type
+		} else {
+			end = len(sets)
+		}
+		resp.ResourceRecordSets = sets[start:end]
+		encode(w, "ListResourceRecordSetsResponse", resp)
+	case r.Method == "GET" && len(parts) == 2 && parts[0] == "change":
+		// Changes are in sync from the third poll on.
+		self.changes[parts[1]]++
+		status := "PENDING"
+		if self.changes[parts[1]] >= 3 {
+			status = "INSYNC"
+		}
+		encode(w, "GetChangeResponse", struct{ ChangeInfo ChangeInfo }{ChangeInfo{Id: "/change/" + parts[1], Status: status}})
+	default:
+		writeFakeError(w, 400, "InvalidInput")
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	data, _ := ioutil.ReadAll(r.Body)
+	if r.Header.Get("Content-Type") != "text/xml" || !strings.Contains(string(data), `xmlns="`+xmlns+`"`) || xml.Unmarshal(data, v) != nil {
+		writeFakeError(w, 400, "InvalidInput")
+		return false
+	}
+	return true
+}
+
+func encode(w http.ResponseWriter, name string, v interface{}) {
+	xml.NewEncoder(w).EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+func writeFakeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<ErrorResponse><Error><Type>Sender</Type><Code>%s</Code><Message>fake</Message></Error><RequestId>req-1</RequestId></ErrorResponse>", code)
+}
+
+func TestHostedZones(t *testing.T) {
+	client, fake := newTestRoute53(t)
+	ctx := context.Background()
+	var ids []string
+	for _, name := range []string{"a.example.com.", "b.example.com.", "c.example.com."} {
+		resp, err := client.CreateHostedZone(ctx, name, "ref-"+name, "zone "+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.HostedZone.Name != name || resp.HostedZone.Comment != "zone "+name || resp.ChangeInfo.Status != "PENDING" ||
+			len(resp.NameServers) != 1 || resp.NameServers[0] != "ns-1.example.net" {
+			t.Fatalf("got %+v", resp)
+		}
+		ids = append(ids, resp.HostedZone.Id)
+	}
+
+	zones, err := client.ListHostedZones(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 3 || zones[2].Name != "c.example.com." || zones[0].CallerReference != "ref-a.example.com." {
+		t.Fatalf("got %+v", zones)
+	}
+	// Listing the zones takes two pages, the second from the marker.
+	if q := fake.queries[len(fake.queries)-1]; q != "marker=Z3" {
+		t.Fatalf("sent query %q", q)
+	}
+
+	if _, err := client.DeleteHostedZone(ctx, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.DeleteHostedZone(ctx, ids[1])
+	if !aws.IsNotFound(err) || aws.ErrorCode(err) != "NoSuchHostedZone" {
+		t.Fatalf("deleting twice got %v", err)
+	}
+	if zones, err = client.ListHostedZones(ctx); err != nil || len(zones) != 2 {
+		t.Fatalf("got %+v, %v", zones, err)
+	}
+}
+
+func TestCleanZoneId(t *testing.T) {
+	for _, id := range []string{"/hostedzone/Z123", "Z123"} {
+		if got := CleanZoneId(id); got != "Z123" {
+			t.Errorf("CleanZoneId(%q) = %q", id, got)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package s3
+
+import (
+	"encoding/xml"
+)
+
+// Tag is a key/value pair used to filter objects by their tags.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// ConfigFilter limits an analytics or metrics configuration to the objects
+// matching a key prefix and/or a set of tags. A zero ConfigFilter matches
+// every object in the bucket.
+type ConfigFilter struct {
+	Prefix string
+	Tags   []Tag
+}
+
+type configFilterXML struct {
+	Prefix string `xml:",omitempty"`
+	Tag    *Tag   `xml:",omitempty"`
+	And    *struct {
+		Prefix string `xml:",omitempty"`
+		Tag    []Tag
+	} `xml:",omitempty"`
+}
+
+// MarshalXML encodes the filter in the Prefix, Tag or And form S3 expects
+// depending on how many conditions it holds.
+func (self ConfigFilter) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	var out configFilterXML
+	switch {
+	case len(self.Tags) == 0:
+		out.Prefix = self.Prefix
+	case len(self.Tags) == 1 && self.Prefix == "":
+		out.Tag = &self.Tags[0]
+	default:
+		out.And = &struct {
+			Prefix string `xml:",omitempty"`
+			Tag    []Tag
+		}{self.Prefix, self.Tags}
+	}
+	return e.EncodeElement(out, start)
+}
+
+// UnmarshalXML decodes any of the filter forms returned by S3.
+func (self *ConfigFilter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var in configFilterXML
+	if err := d.DecodeElement(&in, &start); err != nil {
+		return err
+	}
+	*self = ConfigFilter{Prefix: in.Prefix}
+	if in.Tag != nil {
+		self.Tags = []Tag{*in.Tag}
+	}
+	if in.And != nil {
+		self.Prefix = in.And.Prefix
+		self.Tags = in.And.Tag
+	}
+	return nil
+}
+
+// AnalyticsConfiguration describes a storage class analysis of a bucket.
+type AnalyticsConfiguration struct {
+	XMLName              xml.Name      `xml:"AnalyticsConfiguration"`
+	Id                   string        `xml:"Id"`
+	Filter               *ConfigFilter `xml:"Filter,omitempty"`
+	StorageClassAnalysis StorageClassAnalysis
+}
+
+// StorageClassAnalysis optionally exports the analysis results as CSV
+// files to another bucket.
+type StorageClassAnalysis struct {
+	DataExport *AnalyticsDataExport `xml:",omitempty"`
+}
+
+type AnalyticsDataExport struct {
+	OutputSchemaVersion string                 // always "V_1"
+	Destination         AnalyticsS3Destination `xml:"Destination>S3BucketDestination"`
+}
+
+type AnalyticsS3Destination struct {
+	Format          string // always "CSV"
+	BucketAccountId string `xml:",omitempty"`
+	Bucket          string // ARN of the destination bucket
+	Prefix          string `xml:",omitempty"`
+}
+
+// ListAnalyticsResp holds the results of ListAnalyticsConfigurations.
+type ListAnalyticsResp struct {
+	AnalyticsConfigurations []AnalyticsConfiguration `xml:"AnalyticsConfiguration"`
+	IsTruncated             bool
+	ContinuationToken       string
+	NextContinuationToken   string
+}
+
+// MetricsConfiguration enables CloudWatch request metrics for the objects
+// of a bucket matching its filter.
+type MetricsConfiguration struct {
+	XMLName xml.Name      `xml:"MetricsConfiguration"`
+	Id      string        `xml:"Id"`
+	Filter  *ConfigFilter `xml:"Filter,omitempty"`
+}
+
+// ListMetricsResp holds the results of ListMetricsConfigurations.
+type ListMetricsResp struct {
+	MetricsConfigurations []MetricsConfiguration `xml:"MetricsConfiguration"`
+	IsTruncated           bool
+	ContinuationToken     string
+	NextContinuationToken string
+}
+
+// PutAnalyticsConfiguration creates or replaces the analytics
+// configuration with the Id of config.
+func (self *Bucket) PutAnalyticsConfiguration(config *AnalyticsConfiguration) error {
+	return self.putConfiguration("analytics", config.Id, config)
+}
+
+// GetAnalyticsConfiguration returns the analytics configuration with the
+// given id.
+func (self *Bucket) GetAnalyticsConfiguration(id string) (*AnalyticsConfiguration, error) {
+	config := &AnalyticsConfiguration{}
+	if err := self.getConfiguration("analytics", id, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// DelAnalyticsConfiguration removes the analytics configuration with the
+// given id.
+func (self *Bucket) DelAnalyticsConfiguration(id string) error {
+	return self.delConfiguration("analytics", id)
+}
+
+// ListAnalyticsConfigurations returns a page of the bucket's analytics
+// configurations. Pass the NextContinuationToken of a truncated response
+// as token to fetch the next page.
+func (self *Bucket) ListAnalyticsConfigurations(token string) (*ListAnalyticsResp, error) {
+	result := &ListAnalyticsResp{}
+	if err := self.listConfigurations("analytics", token, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PutMetricsConfiguration creates or replaces the metrics configuration
+// with the Id of config.
+func (self *Bucket) PutMetricsConfiguration(config *MetricsConfiguration) error {
+	return self.putConfiguration("metrics", config.Id, config)
+}
+
+// GetMetricsConfiguration returns the metrics configuration with the given
+// id.
+func (self *Bucket) GetMetricsConfiguration(id string) (*MetricsConfiguration, error) {
+	config := &MetricsConfiguration{}
+	if err := self.getConfiguration("metrics", id, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// DelMetricsConfiguration removes the metrics configuration with the given
+// id.
+func (self *Bucket) DelMetricsConfiguration(id string) error {
+	return self.delConfiguration("metrics", id)
+}
+
+// ListMetricsConfigurations returns a page of the bucket's metrics
+// configurations. Pass the NextContinuationToken of a truncated response
+// as token to fetch the next page.
+func (self *Bucket) ListMetricsConfigurations(token string) (*ListMetricsResp, error) {
+	result := &ListMetricsResp{}
+	if err := self.listConfigurations("metrics", token, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (self *Bucket) putConfiguration(subresource, id string, config interface{}) error {
+	req := &request{
+		method: "PUT",
+		bucket: self.Name,
+		path:   "/",
+		params: map[string][]string{subresource: {""}, "id": {id}},
+	}
+	if err := setXMLPayload(req, config); err != nil {
+		return err
+	}
+	return self.S3.query(req, nil)
+}
+
+func (self *Bucket) getConfiguration(subresource, id string, config interface{}) (err error) {
+	req := &request{
+		bucket: self.Name,
+		path:   "/",
+		params: map[string][]string{subresource: {""}, "id": {id}},
+	}
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
+		err = self.S3.query(req, config)
+		if !shouldRetry(err) {
+			break
+		}
+		self.retryBackoff(req.context(), err, try)
+	}
+	return err
+}
+
+func (self *Bucket) delConfiguration(subresource, id string) error {
+	req := &request{
+		method: "DELETE",
+		bucket: self.Name,
+		path:   "/",
+		params: map[string][]string{subresource: {""}, "id": {id}},
+	}
+	return self.S3.query(req, nil)
+}
+
+func (self *Bucket) listConfigurations(subresource, token string, result interface{}) (err error) {
+	params := map[string][]string{subresource: {""}}
+	if token != "" {
+		params["continuation-token"] = []string{token}
+	}
+	req := &request{
+		bucket: self.Name,
+		path:   "/",
+		params: params,
+	}
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
+		err = self.S3.query(req, result)
+		if !shouldRetry(err) {
+			break
+		}
+		self.retryBackoff(req.context(), err, try)
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dkln/go-aws"
+)
+
+// configServer keeps the analytics and metrics configurations of a
+// bucket, and lists them one per page.
+type configServer struct {
+	mu      sync.Mutex
+	configs map[string]string // bodies by subresource + "/" + id
+}
+
+func (self *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	query := r.URL.Query()
+	subresource := "analytics"
+	if _, ok := query["metrics"]; ok {
+		subresource = "metrics"
+	}
+	name := subresource + "/" + query.Get("id")
+	switch {
+	case r.Method == "PUT":
+		data, _ := ioutil.ReadAll(r.Body)
+		self.configs[name] = string(data)
+	case r.Method == "DELETE":
+		delete(self.configs, name)
+		w.WriteHeader(204)
+	case query.Get("id") != "":
+		config, ok := self.configs[name]
+		if !ok {
+			w.WriteHeader(404)
+			w.Write([]byte("<Error><Code>NoSuchConfiguration</Code></Error>"))
+			return
+		}
+		w.Write([]byte(config))
+	default:
+		var names []string
+		for name := range self.configs {
+			if strings.HasPrefix(name, subresource+"/") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		page, _ := strconv.Atoi(query.Get("continuation-token"))
+		result := "<Result>"
+		if page < len(names) {
+			result += self.configs[names[page]]
+		}
+		if page+1 < len(names) {
+			result += fmt.Sprintf("<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", page+1)
+		}
+		w.Write([]byte(result + "</Result>"))
+	}
+}
+
+func newConfigBucket(t *testing.T) (*Bucket, *configServer) {
+	handler := &configServer{configs: map[string]string{}}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	s, err := NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Bucket("b"), handler
+}
+
+func TestConfigFilterForms(t *testing.T) {
+	for _, test := range []struct {
+		filter ConfigFilter
+		xml    string
+	}{
+		{ConfigFilter{}, "<Filter></Filter>"},
+		{ConfigFilter{Prefix: "logs/"}, "<Filter><Prefix>logs/</Prefix></Filter>"},
+		{ConfigFilter{Tags: []Tag{{"env", "prod"}}}, "<Filter><Tag><Key>env</Key><Value>prod</Value></Tag></Filter>"},
+		{ConfigFilter{Prefix: "logs/", Tags: []Tag{{"env", "prod"}}},
+			"<Filter><And><Prefix>logs/</Prefix><Tag><Key>env</Key><Value>prod</Value></Tag></And></Filter>"},
+		{ConfigFilter{Tags: []Tag{{"env", "prod"}, {"team", "ops"}}},
+			"<Filter><And><Tag><Key>env</Key><Value>prod</Value></Tag><Tag><Key>team</Key><Value>ops</Value></Tag></And></Filter>"},
+	} {
+		var buf bytes.Buffer
+		if err := xml.NewEncoder(&buf).EncodeElement(test.filter, xml.StartElement{Name: xml.Name{Local: "Filter"}}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.xml {
+			t.Errorf("%+v: got %s, want %s", test.filter, buf.String(), test.xml)
+		}
+		var decoded ConfigFilter
+		if err := xml.Unmarshal([]byte(test.xml), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(decoded) != fmt.Sprint(test.filter) {
+			t.Errorf("%s: decoded %+v", test.xml, decoded)
+		}
+	}
+}
+
+func TestAnalyticsConfigurations(t *testing.T) {
+	b, srv := newConfigBucket(t)
+	export := &AnalyticsDataExport{
+		OutputSchemaVersion: "V_1",
+		Destination:         AnalyticsS3Destination{Format: "CSV", Bucket: "arn:aws:s3:::reports", Prefix: "analysis/"},
+	}
+	for _, config := range []*AnalyticsConfiguration{
+		{Id: "all"},
+		{Id: "logs", Filter: &ConfigFilter{Prefix: "logs/"}, StorageClassAnalysis: StorageClassAnalysis{DataExport: export}},
+	} {
+		if err := b.PutAnalyticsConfiguration(config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if body := srv.configs["analytics/logs"]; !strings.Contains(body, "<S3BucketDestination><Format>CSV</Format><Bucket>arn:aws:s3:::reports</Bucket>") {
+		t.Fatalf("put %s", body)
+	}
+
+	config, err := b.GetAnalyticsConfiguration("logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Filter == nil || config.Filter.Prefix != "logs/" || config.StorageClassAnalysis.DataExport == nil ||
+		config.StorageClassAnalysis.DataExport.Destination != export.Destination {
+		t.Fatalf("got %+v", config)
+	}
+
+	var ids []string
+	for token := ""; ; {
+		page, err := b.ListAnalyticsConfigurations(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, config := range page.AnalyticsConfigurations {
+			ids = append(ids, config.Id)
+		}
+		if !page.IsTruncated {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	if fmt.Sprint(ids) != "[all logs]" {
+		t.Fatalf("listed %v", ids)
+	}
+
+	if err := b.DelAnalyticsConfiguration("logs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.GetAnalyticsConfiguration("logs"); aws.ErrorCode(err) != "NoSuchConfiguration" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestMetricsConfigurations(t *testing.T) {
+	b, _ := newConfigBucket(t)
+	config := &MetricsConfiguration{Id: "prod", Filter: &ConfigFilter{Tags: []Tag{{"env", "prod"}}}}
+	if err := b.PutMetricsConfiguration(config); err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.GetMetricsConfiguration("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Id != "prod" || got.Filter == nil || fmt.Sprint(got.Filter.Tags) != "[{env prod}]" {
+		t.Fatalf("got %+v", got)
+	}
+	list, err := b.ListMetricsConfigurations("")
+	if err != nil || len(list.MetricsConfigurations) != 1 || list.IsTruncated {
+		t.Fatalf("got %+v, %v", list, err)
+	}
+	// Analytics configurations are kept apart.
+	if list, err := b.ListAnalyticsConfigurations(""); err != nil || len(list.AnalyticsConfigurations) != 0 {
+		t.Fatalf("got %+v, %v", list, err)
+	}
+	if err := b.DelMetricsConfiguration("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.GetMetricsConfiguration("prod"); !aws.IsNotFound(err) {
+		t.Fatalf("got %v", err)
+	}
+}
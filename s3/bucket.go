@@ -1,8 +1,8 @@
 package s3
 
 import (
+  "context"
   "io"
-  "net/url"
   "net/http"
   "time"
   "io/ioutil"
@@ -20,10 +20,16 @@ type Bucket struct {
 //
 // See http://goo.gl/ndjnR for details.
 func (self *Bucket) PutBucket(perm ACL) error {
+	return self.PutBucketWithContext(context.Background(), perm)
+}
+
+// PutBucketWithContext is like PutBucket but aborts when ctx is done.
+func (self *Bucket) PutBucketWithContext(ctx context.Context, perm ACL) error {
 	headers := map[string][]string{
 		"x-amz-acl": {string(perm)},
 	}
 	req := &request{
+		ctx:     ctx,
 		method:  "PUT",
 		bucket:  self.Name,
 		path:    "/",
@@ -38,16 +44,23 @@ func (self *Bucket) PutBucket(perm ACL) error {
 //
 // See http://goo.gl/GoBrY for details.
 func (self *Bucket) DelBucket() (err error) {
+	return self.DelBucketWithContext(context.Background())
+}
+
+// DelBucketWithContext is like DelBucket but aborts when ctx is done.
+func (self *Bucket) DelBucketWithContext(ctx context.Context) (err error) {
 	req := &request{
+		ctx:    ctx,
 		method: "DELETE",
 		bucket: self.Name,
 		path:   "/",
 	}
-	for attempt := attempts.Start(); attempt.Next(); {
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
 		err = self.S3.query(req, nil)
 		if !shouldRetry(err) {
 			break
 		}
+		self.retryBackoff(req.context(), err, try)
 	}
 	return err
 }
@@ -56,7 +69,12 @@ func (self *Bucket) DelBucket() (err error) {
 //
 // See http://goo.gl/isCO7 for details.
 func (self *Bucket) Get(path string) (data []byte, err error) {
-	body, err := self.GetReader(path)
+	return self.GetWithContext(context.Background(), path)
+}
+
+// GetWithContext is like Get but aborts when ctx is done.
+func (self *Bucket) GetWithContext(ctx context.Context, path string) (data []byte, err error) {
+	body, err := self.GetReaderWithContext(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +87,13 @@ func (self *Bucket) Get(path string) (data []byte, err error) {
 // It is the caller's responsibility to call Close on rc when
 // finished reading.
 func (self *Bucket) GetReader(path string) (rc io.ReadCloser, err error) {
-	resp, err := self.GetResponse(path)
+	return self.GetReaderWithContext(context.Background(), path)
+}
+
+// GetReaderWithContext is like GetReader but aborts when ctx is done,
+// including while the caller reads rc.
+func (self *Bucket) GetReaderWithContext(ctx context.Context, path string) (rc io.ReadCloser, err error) {
+	resp, err := self.GetResponseWithContext(ctx, path)
 	if resp != nil {
 		return resp.Body, err
 	}
@@ -80,7 +104,70 @@ func (self *Bucket) GetReader(path string) (rc io.ReadCloser, err error) {
 // It is the caller's responsibility to call Close on rc when
 // finished reading.
 func (self *Bucket) GetResponse(path string) (*http.Response, error) {
+	return self.getResponse(context.Background(), path, nil)
+}
+
+// GetResponseWithContext is like GetResponse but aborts when ctx is done,
+// including while the caller reads the response body.
+func (self *Bucket) GetResponseWithContext(ctx context.Context, path string) (*http.Response, error) {
+	return self.getResponse(ctx, path, nil)
+}
+
+// getResponse is like GetResponseWithContext but sends the given extra
+// headers, e.g. for conditional or ranged requests.
+func (self *Bucket) getResponse(ctx context.Context, path string, headers http.Header) (*http.Response, error) {
 	req := &request{
+		ctx:      ctx,
+		bucket:   self.Name,
+		path:     path,
+		headers:  headers,
+		transfer: true,
+	}
+	err := self.S3.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
+		resp, err := self.S3.run(req, nil)
+		if shouldRetry(err) && attempt.HasNext() {
+			self.retryBackoff(req.context(), err, try)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	panic("unreachable")
+}
+
+// getResponseOnce is like getResponse but makes a single attempt, for
+// callers that retry on their own.
+func (self *Bucket) getResponseOnce(ctx context.Context, path string, headers http.Header) (*http.Response, error) {
+	req := &request{
+		ctx:      ctx,
+		bucket:   self.Name,
+		path:     path,
+		headers:  headers,
+		transfer: true,
+	}
+	if err := self.S3.prepare(req); err != nil {
+		return nil, err
+	}
+	return self.S3.run(req, nil)
+}
+
+// Head retrieves the metadata of an object without its contents. The
+// response body is empty; the metadata is in the response headers.
+func (self *Bucket) Head(path string) (*http.Response, error) {
+	return self.HeadWithContext(context.Background(), path)
+}
+
+// HeadWithContext is like Head but aborts when ctx is done.
+func (self *Bucket) HeadWithContext(ctx context.Context, path string) (*http.Response, error) {
+	req := &request{
+		ctx:    ctx,
+		method: "HEAD",
 		bucket: self.Name,
 		path:   path,
 	}
@@ -88,14 +175,16 @@ func (self *Bucket) GetResponse(path string) (*http.Response, error) {
 	if err != nil {
 		return nil, err
 	}
-	for attempt := attempts.Start(); attempt.Next(); {
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
 		resp, err := self.S3.run(req, nil)
 		if shouldRetry(err) && attempt.HasNext() {
+			self.retryBackoff(req.context(), err, try)
 			continue
 		}
 		if err != nil {
 			return nil, err
 		}
+		resp.Body.Close()
 		return resp, nil
 	}
 	panic("unreachable")
@@ -105,8 +194,13 @@ func (self *Bucket) GetResponse(path string) (*http.Response, error) {
 //
 // See http://goo.gl/FEBPD for details.
 func (self *Bucket) Put(path string, data []byte, contType string, perm ACL) error {
-	body := bytes.NewBuffer(data)
-	return self.PutReader(path, body, int64(len(data)), contType, perm)
+	return self.PutWithContext(context.Background(), path, data, contType, perm)
+}
+
+// PutWithContext is like Put but aborts when ctx is done.
+func (self *Bucket) PutWithContext(ctx context.Context, path string, data []byte, contType string, perm ACL) error {
+	body := bytes.NewReader(data)
+	return self.PutReaderWithContext(ctx, path, body, int64(len(data)), contType, perm)
 }
 
 /*
@@ -114,26 +208,41 @@ PutHeader - like Put, inserts an object into the S3 bucket.
 Instead of Content-Type string, pass in custom headers to override defaults.
 */
 func (self *Bucket) PutHeader(path string, data []byte, customHeaders map[string][]string, perm ACL) error {
-	body := bytes.NewBuffer(data)
-	return self.PutReaderHeader(path, body, int64(len(data)), customHeaders, perm)
+	return self.PutHeaderWithContext(context.Background(), path, data, customHeaders, perm)
+}
+
+// PutHeaderWithContext is like PutHeader but aborts when ctx is done.
+func (self *Bucket) PutHeaderWithContext(ctx context.Context, path string, data []byte, customHeaders map[string][]string, perm ACL) error {
+	body := bytes.NewReader(data)
+	return self.PutReaderHeaderWithContext(ctx, path, body, int64(len(data)), customHeaders, perm)
 }
 
 // PutReader inserts an object into the S3 bucket by consuming data
-// from r until EOF.
+// from r until EOF. If r is an io.Seeker, such as an *os.File or a
+// *bytes.Reader, failed uploads are retried after rewinding r to the
+// position it had when PutReader was called; other readers are only
+// sent once.
 func (self *Bucket) PutReader(path string, r io.Reader, length int64, contType string, perm ACL) error {
+	return self.PutReaderWithContext(context.Background(), path, r, length, contType, perm)
+}
+
+// PutReaderWithContext is like PutReader but aborts when ctx is done.
+func (self *Bucket) PutReaderWithContext(ctx context.Context, path string, r io.Reader, length int64, contType string, perm ACL) error {
 	headers := map[string][]string{
 		"Content-Length": {strconv.FormatInt(length, 10)},
 		"Content-Type":   {contType},
 		"x-amz-acl":      {string(perm)},
 	}
 	req := &request{
-		method:  "PUT",
-		bucket:  self.Name,
-		path:    path,
-		headers: headers,
-		payload: r,
+		ctx:      ctx,
+		method:   "PUT",
+		bucket:   self.Name,
+		path:     path,
+		headers:  headers,
+		payload:  r,
+		transfer: true,
 	}
-	return self.S3.query(req, nil)
+	return self.putReader(req)
 }
 
 /*
@@ -141,6 +250,12 @@ PutReaderHeader - like PutReader, inserts an object into S3 from a reader.
 Instead of Content-Type string, pass in custom headers to override defaults.
 */
 func (self *Bucket) PutReaderHeader(path string, r io.Reader, length int64, customHeaders map[string][]string, perm ACL) error {
+	return self.PutReaderHeaderWithContext(context.Background(), path, r, length, customHeaders, perm)
+}
+
+// PutReaderHeaderWithContext is like PutReaderHeader but aborts when ctx
+// is done.
+func (self *Bucket) PutReaderHeaderWithContext(ctx context.Context, path string, r io.Reader, length int64, customHeaders map[string][]string, perm ACL) error {
 	// Default headers
 	headers := map[string][]string{
 		"Content-Length": {strconv.FormatInt(length, 10)},
@@ -154,20 +269,52 @@ func (self *Bucket) PutReaderHeader(path string, r io.Reader, length int64, cust
 	}
 
 	req := &request{
-		method:  "PUT",
-		bucket:  self.Name,
-		path:    path,
-		headers: headers,
-		payload: r,
+		ctx:      ctx,
+		method:   "PUT",
+		bucket:   self.Name,
+		path:     path,
+		headers:  headers,
+		payload:  r,
+		transfer: true,
 	}
-	return self.S3.query(req, nil)
+	return self.putReader(req)
+}
+
+// putReader sends req, retrying it as long as its payload can be
+// rewound.
+func (self *Bucket) putReader(req *request) (err error) {
+	seeker, ok := req.payload.(io.Seeker)
+	if !ok {
+		return self.S3.query(req, nil)
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return self.S3.query(req, nil)
+	}
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
+		if _, err = seeker.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		err = self.S3.query(req, nil)
+		if !shouldRetry(err) || !attempt.HasNext() {
+			break
+		}
+		self.retryBackoff(req.context(), err, try)
+	}
+	return err
 }
 
 // Del removes an object from the S3 bucket.
 //
 // See http://goo.gl/APeTt for details.
 func (self *Bucket) Del(path string) error {
+	return self.DelWithContext(context.Background(), path)
+}
+
+// DelWithContext is like Del but aborts when ctx is done.
+func (self *Bucket) DelWithContext(ctx context.Context, path string) error {
 	req := &request{
+		ctx:    ctx,
 		method: "DELETE",
 		bucket: self.Name,
 		path:   path,
@@ -232,6 +379,11 @@ func (self *Bucket) Del(path string) error {
 //
 // See http://goo.gl/YjQTc for details.
 func (self *Bucket) List(prefix, delim, marker string, max int) (result *ListResp, err error) {
+	return self.ListWithContext(context.Background(), prefix, delim, marker, max)
+}
+
+// ListWithContext is like List but aborts when ctx is done.
+func (self *Bucket) ListWithContext(ctx context.Context, prefix, delim, marker string, max int) (result *ListResp, err error) {
 	params := map[string][]string{
 		"prefix":    {prefix},
 		"delimiter": {delim},
@@ -241,15 +393,17 @@ func (self *Bucket) List(prefix, delim, marker string, max int) (result *ListRes
 		params["max-keys"] = []string{strconv.FormatInt(int64(max), 10)}
 	}
 	req := &request{
+		ctx:    ctx,
 		bucket: self.Name,
 		params: params,
 	}
 	result = &ListResp{}
-	for attempt := attempts.Start(); attempt.Next(); {
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
 		err = self.S3.query(req, result)
 		if !shouldRetry(err) {
 			break
 		}
+		self.retryBackoff(req.context(), err, try)
 	}
 	if err != nil {
 		return nil, err
@@ -271,11 +425,11 @@ func (self *Bucket) GetBucketContents() (*map[string]Key, error) {
 		for _, key := range contents.Contents {
 			bucket_contents[key.Key] = key
 		}
-		if contents.IsTruncated {
-			marker = contents.NextMarker
-		} else {
+		if !contents.IsTruncated || len(contents.Contents) == 0 {
 			break
 		}
+		// S3 only returns NextMarker for listings with a delimiter.
+		marker = contents.Contents[len(contents.Contents)-1].Key
 	}
 
 	return &bucket_contents, nil
@@ -307,7 +461,7 @@ func (self *Bucket) SignedURL(path string, expires time.Time) string {
 	req := &request{
 		bucket: self.Name,
 		path:   path,
-		params: url.Values{"Expires": {strconv.FormatInt(expires.Unix(), 10)}},
+		expires: expires,
 	}
 
 	err := self.S3.prepare(req)
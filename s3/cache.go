@@ -0,0 +1,178 @@
+package s3
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachingGetter fetches objects from a bucket through an in-memory cache
+// that follows HTTP caching semantics: objects are served from the cache
+// while they are fresh according to their Cache-Control max-age (or
+// Expires) header, and revalidated with a conditional GET once stale.
+// Objects marked no-store are never cached and no-cache ones are
+// revalidated on every Get.
+//
+// It is meant for semi-static assets such as templates that are fetched
+// over and over again. A CachingGetter is safe for concurrent use.
+type CachingGetter struct {
+	Bucket *Bucket
+
+	// MaxEntries bounds the number of cached objects; when exceeded, the
+	// entry that expires first is evicted. Zero means no limit.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	data         []byte
+	etag         string
+	lastModified string
+	lifetime     time.Duration
+	expires      time.Time
+}
+
+// NewCachingGetter returns a CachingGetter for bucket.
+func NewCachingGetter(bucket *Bucket) *CachingGetter {
+	return &CachingGetter{Bucket: bucket}
+}
+
+// Get returns the contents of the object at path, from the cache if it
+// is still fresh.
+func (self *CachingGetter) Get(path string) ([]byte, error) {
+	now := time.Now()
+
+	self.mu.Lock()
+	entry := self.entries[path]
+	self.mu.Unlock()
+
+	if entry != nil && now.Before(entry.expires) {
+		return entry.data, nil
+	}
+
+	headers := make(http.Header)
+	if entry != nil {
+		if entry.etag != "" {
+			headers.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			headers.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := self.Bucket.getResponse(context.Background(), path, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		// S3 may omit the caching headers on a 304; the object then stays
+		// fresh for as long as it did before.
+		revalidated := *entry
+		if lifetime, noStore, ok := freshness(resp.Header, now); ok {
+			if noStore {
+				self.Invalidate(path)
+				return entry.data, nil
+			}
+			revalidated.lifetime = lifetime
+		}
+		revalidated.expires = now.Add(revalidated.lifetime)
+		self.store(path, &revalidated)
+		return entry.data, nil
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	lifetime, noStore, _ := freshness(resp.Header, now)
+	if noStore {
+		self.Invalidate(path)
+		return data, nil
+	}
+	self.store(path, &cacheEntry{
+		data:         data,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		lifetime:     lifetime,
+		expires:      now.Add(lifetime),
+	})
+	return data, nil
+}
+
+// Invalidate drops path from the cache.
+func (self *CachingGetter) Invalidate(path string) {
+	self.mu.Lock()
+	delete(self.entries, path)
+	self.mu.Unlock()
+}
+
+func (self *CachingGetter) store(path string, entry *cacheEntry) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.entries == nil {
+		self.entries = make(map[string]*cacheEntry)
+	}
+	if _, ok := self.entries[path]; !ok && self.MaxEntries > 0 && len(self.entries) >= self.MaxEntries {
+		self.evict()
+	}
+	self.entries[path] = entry
+}
+
+// evict removes the entry that expires first. Must be called with mu held.
+func (self *CachingGetter) evict() {
+	var victim string
+	var first time.Time
+	for path, entry := range self.entries {
+		if victim == "" || entry.expires.Before(first) {
+			victim, first = path, entry.expires
+		}
+	}
+	delete(self.entries, victim)
+}
+
+// freshness interprets the Cache-Control and Expires headers of a
+// response received at now, returning how long the response may be
+// served from the cache. ok is false if the response has no caching
+// headers at all; such responses are revalidated on every use.
+func freshness(header http.Header, now time.Time) (lifetime time.Duration, noStore, ok bool) {
+	maxAge := -1
+	noCache := false
+	if cc := header.Get("Cache-Control"); cc != "" {
+		ok = true
+		for _, directive := range strings.Split(cc, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			switch {
+			case directive == "no-store":
+				noStore = true
+			case directive == "no-cache":
+				noCache = true
+			case strings.HasPrefix(directive, "max-age="):
+				if n, err := strconv.Atoi(directive[len("max-age="):]); err == nil {
+					maxAge = n
+				}
+			}
+		}
+	}
+	switch {
+	case noCache:
+		return 0, noStore, ok
+	case maxAge >= 0:
+		return time.Duration(maxAge) * time.Second, noStore, ok
+	}
+	if v := header.Get("Expires"); v != "" {
+		ok = true
+		if t, err := http.ParseTime(v); err == nil && t.After(now) {
+			lifetime = t.Sub(now)
+		}
+	}
+	return lifetime, noStore, ok
+}
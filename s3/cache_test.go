@@ -0,0 +1,130 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingTransport counts the requests it passes on, and the
+// conditional ones among them.
+type countingTransport struct {
+	mu                    sync.Mutex
+	requests, conditional int
+}
+
+func (self *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	self.mu.Lock()
+	self.requests++
+	if req.Header.Get("If-None-Match") != "" {
+		self.conditional++
+	}
+	self.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (self *countingTransport) counts() (int, int) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.requests, self.conditional
+}
+
+func TestCachingGetter(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	transport := &countingTransport{}
+	bucket.S3.Client = &http.Client{Transport: transport}
+	put := func(path, data, cacheControl string) {
+		t.Helper()
+		headers := map[string][]string{"Content-Type": {"text/plain"}}
+		if cacheControl != "" {
+			headers["Cache-Control"] = []string{cacheControl}
+		}
+		if err := bucket.PutHeader(path, []byte(data), headers, Private); err != nil {
+			t.Fatal(err)
+		}
+	}
+	getter := NewCachingGetter(bucket)
+	get := func(path, want string, requests, conditional int) {
+		t.Helper()
+		before, beforeConditional := transport.counts()
+		data, err := getter.Get(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		after, afterConditional := transport.counts()
+		if string(data) != want || after-before != requests || afterConditional-beforeConditional != conditional {
+			t.Fatalf("%s: got %q with %d requests, %d conditional; want %q with %d, %d",
+				path, data, after-before, afterConditional-beforeConditional, want, requests, conditional)
+		}
+	}
+
+	put("fresh", "v1", "public, max-age=60")
+	get("fresh", "v1", 1, 0)
+	put("fresh", "v2", "max-age=60")
+	get("fresh", "v1", 0, 0) // still fresh
+	getter.Invalidate("fresh")
+	get("fresh", "v2", 1, 0)
+
+	put("revalidated", "v1", "no-cache")
+	get("revalidated", "v1", 1, 0)
+	get("revalidated", "v1", 1, 1) // not modified
+	put("revalidated", "v2", "no-cache")
+	get("revalidated", "v2", 1, 1)
+
+	put("uncached", "v1", "")
+	get("uncached", "v1", 1, 0)
+	get("uncached", "v1", 1, 1)
+
+	put("private", "v1", "no-store")
+	get("private", "v1", 1, 0)
+	get("private", "v1", 1, 0) // not even revalidated
+
+	if _, err := getter.Get("missing"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestCachingGetterEvictsFirstExpiring(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	getter := &CachingGetter{Bucket: bucket, MaxEntries: 2}
+	for path, maxAge := range map[string]string{"short": "max-age=10", "long": "max-age=1000", "medium": "max-age=100"} {
+		if err := bucket.PutHeader(path, []byte(path), map[string][]string{"Cache-Control": {maxAge}}, Private); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range []string{"short", "long", "medium"} {
+		if _, err := getter.Get(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(getter.entries) != 2 || getter.entries["short"] != nil {
+		t.Fatalf("cached %v", getter.entries)
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		header      http.Header
+		lifetime    time.Duration
+		noStore, ok bool
+	}{
+		{http.Header{}, 0, false, false},
+		{http.Header{"Cache-Control": {"max-age=300"}}, 5 * time.Minute, false, true},
+		{http.Header{"Cache-Control": {"Public, Max-Age=60"}}, time.Minute, false, true},
+		{http.Header{"Cache-Control": {"no-cache, max-age=60"}}, 0, false, true},
+		{http.Header{"Cache-Control": {"no-store"}}, 0, true, true},
+		{http.Header{"Cache-Control": {"max-age=60"}, "Expires": {"Fri, 01 Mar 2024 13:00:00 GMT"}}, time.Minute, false, true},
+		{http.Header{"Expires": {"Fri, 01 Mar 2024 13:00:00 GMT"}}, time.Hour, false, true},
+		{http.Header{"Expires": {"Fri, 01 Mar 2024 11:00:00 GMT"}}, 0, false, true},
+		{http.Header{"Expires": {"0"}}, 0, false, true},
+	} {
+		lifetime, noStore, ok := freshness(test.header, now)
+		if lifetime != test.lifetime || noStore != test.noStore || ok != test.ok {
+			t.Errorf("%v: got %v, %v, %v", test.header, lifetime, noStore, ok)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/x/s3test"
+)
+
+// newTestBucket returns a bucket created on a fake S3 server.
+func newTestBucket(t *testing.T) (*Bucket, *s3test.Server) {
+	srv := s3test.NewServer()
+	t.Cleanup(srv.Close)
+	client, err := NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket := client.Bucket("bucket")
+	if err := bucket.PutBucket(Private); err != nil {
+		t.Fatal(err)
+	}
+	return bucket, srv
+}
+
+func TestDefaultClientConnectionsAreTracked(t *testing.T) {
+	srv := s3test.NewServer()
+	defer srv.Close()
+	client, err := NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Connections of earlier tests close in the background; wait for
+	// them so they don't skew the count.
+	defaultClient.CloseIdleConnections()
+	for deadline := time.Now().Add(2 * time.Second); aws.Diagnostics().OpenConnections > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	before := aws.Diagnostics().OpenConnections
+	if err := client.Bucket("bucket").PutBucket(Private); err != nil {
+		t.Fatal(err)
+	}
+	// The connection is kept alive for the next request.
+	if after := aws.Diagnostics().OpenConnections; after <= before {
+		t.Fatalf("open connections went from %d to %d", before, after)
+	}
+	defaultClient.CloseIdleConnections()
+}
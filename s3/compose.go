@@ -0,0 +1,78 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+const (
+	minPartSize int64 = 5 << 20
+	maxPartSize int64 = 5 << 30
+	maxParts          = 10000
+)
+
+// Compose creates the object dst by concatenating the objects at the
+// given source paths of the bucket, in order. The data is copied within
+// S3 with multipart part copies, so nothing is downloaded, which makes it
+// suitable for stitching log segments together.
+//
+// S3 requires every part but the last to be at least 5 MiB, so all
+// sources except the last one must be at least that large. If anything
+// fails, the multipart upload is aborted so no orphaned parts are left
+// behind.
+func (self *Bucket) Compose(dst string, contType string, perm ACL, sources ...string) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("s3: compose %s: no sources", dst)
+	}
+
+	type span struct {
+		source     string
+		start, end int64
+	}
+	var spans []span
+	for i, path := range sources {
+		resp, err := self.Head(path)
+		if err != nil {
+			return err
+		}
+		size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("s3: compose %s: size of %s: %v", dst, path, err)
+		}
+		if size < minPartSize && i < len(sources)-1 {
+			return fmt.Errorf("s3: compose %s: source %s is smaller than 5 MiB", dst, path)
+		}
+		source := copySource(self.Name, path)
+		if size <= maxPartSize {
+			spans = append(spans, span{source, -1, -1})
+			continue
+		}
+		// Too large for a single part copy: split into even ranges.
+		for _, r := range copyRanges(size) {
+			spans = append(spans, span{source, r[0], r[1]})
+		}
+	}
+	if len(spans) > maxParts {
+		return fmt.Errorf("s3: compose %s: more than %d parts", dst, maxParts)
+	}
+
+	uploadId, err := self.initMulti(context.Background(), dst, contType, perm)
+	if err != nil {
+		return err
+	}
+	parts := make([]completedPart, 0, len(spans))
+	for i, s := range spans {
+		part, err := self.putPartCopy(dst, uploadId, i+1, s.source, s.start, s.end)
+		if err != nil {
+			self.abortMulti(dst, uploadId)
+			return err
+		}
+		parts = append(parts, part)
+	}
+	if err := self.completeMulti(context.Background(), dst, uploadId, parts); err != nil {
+		self.abortMulti(dst, uploadId)
+		return err
+	}
+	return nil
+}
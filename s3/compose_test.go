@@ -0,0 +1,97 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dkln/go-aws"
+)
+
+// denyPart refuses to upload one part number and passes other requests
+// on.
+type denyPart string
+
+func (self denyPart) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Query().Get("partNumber") != string(self) {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	body := "<Error><Code>AccessDenied</Code><Message>denied</Message></Error>"
+	return &http.Response{
+		StatusCode: 403,
+		Status:     "403 Forbidden",
+		Header:     http.Header{"Content-Type": {"application/xml"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestCompose(t *testing.T) {
+	bucket, srv := newTestBucket(t)
+	first := bytes.Repeat([]byte("a"), 5<<20)
+	second := bytes.Repeat([]byte("b"), 5<<20+1)
+	for path, data := range map[string][]byte{"logs/1": first, "logs/2": second, "logs/3": []byte("tail")} {
+		if err := bucket.Put(path, data, "text/plain", Private); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := bucket.Compose("logs/all", "text/plain", Private, "logs/1", "logs/2", "logs/3"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := bucket.Get("logs/all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(append(append([]byte(nil), first...), second...), "tail"...)
+	if !bytes.Equal(data, want) {
+		t.Fatalf("composed %d bytes, want %d", len(data), len(want))
+	}
+
+	// A single source is copied.
+	if err := bucket.Compose("logs/copy", "text/plain", Private, "logs/3"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := bucket.Get("logs/copy"); err != nil || string(data) != "tail" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+
+	// Sources are checked before anything is uploaded.
+	if err := bucket.Compose("logs/bad", "text/plain", Private, "logs/3", "logs/1"); err == nil || !strings.Contains(err.Error(), "smaller than 5 MiB") {
+		t.Fatalf("got %v", err)
+	}
+	if err := bucket.Compose("logs/bad", "text/plain", Private, "logs/1", "logs/missing"); !aws.IsNotFound(err) {
+		t.Fatalf("got %v", err)
+	}
+	if err := bucket.Compose("logs/bad", "text/plain", Private); err == nil {
+		t.Fatal("expected an error without sources")
+	}
+	if uploads := pendingUploads(t, srv); strings.Contains(uploads, "<UploadId>") {
+		t.Fatalf("uploads left behind: %s", uploads)
+	}
+}
+
+func TestComposeAbortsOnFailure(t *testing.T) {
+	bucket, srv := newTestBucket(t)
+	for _, path := range []string{"1", "2"} {
+		if err := bucket.Put(path, bytes.Repeat([]byte(path), 5<<20), "", Private); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bucket.S3.Client = &http.Client{Transport: denyPart("2")}
+
+	err := bucket.Compose("all", "", Private, "1", "2")
+	if aws.ErrorCode(err) != "AccessDenied" {
+		t.Fatalf("got %v", err)
+	}
+	if uploads := pendingUploads(t, srv); strings.Contains(uploads, "<UploadId>") {
+		t.Fatalf("uploads left behind: %s", uploads)
+	}
+	if _, err := bucket.Get("all"); !aws.IsNotFound(err) {
+		t.Fatalf("got %v", err)
+	}
+}
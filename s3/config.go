@@ -0,0 +1,38 @@
+package s3
+
+import (
+	"github.com/dkln/go-aws"
+)
+
+// Configure makes the S3 send its requests as config says. The request
+// timeout of config only applies to metadata operations; transfers keep
+// the Transfer timeout, as they may legitimately take hours.
+func (self *S3) Configure(config *aws.Config) error {
+	region, known := aws.GetRegion(config.Region)
+	if !known {
+		region = aws.Region{Name: config.Region, SigV4Only: true}
+	}
+	self.Region = region
+	self.Resolver = nil
+	if _, ok := config.Endpoints["s3"]; ok || !known {
+		if _, err := config.Resolver().ResolveEndpoint("s3", config.Region); err != nil {
+			return err
+		}
+		self.Resolver = config.Resolver()
+	}
+	if config.Credentials != nil {
+		self.Credentials = config.Credentials
+	}
+	if config.Attempts != nil {
+		self.Attempts = config.Attempts
+	}
+	if client := config.HTTPClient(); client != nil {
+		copy := *client
+		copy.Timeout = 0
+		self.Client = &copy
+	}
+	if config.RequestTimeout != 0 {
+		self.Timeouts.Metadata = config.RequestTimeout
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/x/s3test"
+)
+
+func TestConfigure(t *testing.T) {
+	srv := s3test.NewServer()
+	defer srv.Close()
+
+	client := NewS3(aws.Auth{}, aws.USEast)
+	err := client.Configure(&aws.Config{
+		Region:         "eu-central-1",
+		Endpoints:      map[string]aws.Endpoint{"s3": {URL: srv.URL()}},
+		RequestTimeout: 5 * time.Second,
+		Credentials:    aws.NewCredentials(&aws.StaticProvider{Auth: aws.Auth{AccessKey: "a", SecretKey: "s"}}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Region.Name != "eu-central-1" || client.Timeouts.Metadata != 5*time.Second || client.Client.Timeout != 0 {
+		t.Fatalf("configured %+v", client)
+	}
+
+	bucket := client.Bucket("bucket")
+	if err := bucket.PutBucket(Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := bucket.Put("key", []byte("data"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := bucket.Get("key"); err != nil || string(data) != "data" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+}
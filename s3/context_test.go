@@ -0,0 +1,111 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type contextKey struct{}
+
+// contextTransport records the contextKey value of the requests it
+// passes on.
+type contextTransport struct {
+	mu     sync.Mutex
+	values []interface{}
+}
+
+func (self *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	self.mu.Lock()
+	self.values = append(self.values, req.Context().Value(contextKey{}))
+	self.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// contextOps calls every context-aware bucket operation with ctx.
+func contextOps(bucket *Bucket, ctx context.Context) map[string]error {
+	errs := map[string]error{}
+	other := bucket.S3.Bucket("other")
+	errs["PutBucket"] = other.PutBucketWithContext(ctx, Private)
+	errs["Put"] = bucket.PutWithContext(ctx, "key", []byte("data"), "text/plain", Private)
+	errs["PutHeader"] = bucket.PutHeaderWithContext(ctx, "header", []byte("data"), nil, Private)
+	errs["PutReader"] = bucket.PutReaderWithContext(ctx, "reader", strings.NewReader("data"), 4, "text/plain", Private)
+	errs["PutReaderHeader"] = bucket.PutReaderHeaderWithContext(ctx, "readerheader", bytes.NewReader([]byte("data")), 4, nil, Private)
+	_, errs["Get"] = bucket.GetWithContext(ctx, "key")
+	if rc, err := bucket.GetReaderWithContext(ctx, "key"); err == nil {
+		rc.Close()
+	} else {
+		errs["GetReader"] = err
+	}
+	if resp, err := bucket.GetResponseWithContext(ctx, "key"); err == nil {
+		resp.Body.Close()
+	} else {
+		errs["GetResponse"] = err
+	}
+	_, errs["Head"] = bucket.HeadWithContext(ctx, "key")
+	_, errs["List"] = bucket.ListWithContext(ctx, "", "", "", 0)
+	errs["Del"] = bucket.DelWithContext(ctx, "key")
+	errs["DelBucket"] = other.DelBucketWithContext(ctx)
+	return errs
+}
+
+func TestContextReachesTransport(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	transport := &contextTransport{}
+	bucket.S3.Client = &http.Client{Transport: transport}
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "value")
+	for name, err := range contextOps(bucket, ctx) {
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if len(transport.values) == 0 {
+		t.Fatal("no requests")
+	}
+	for i, value := range transport.values {
+		if value != "value" {
+			t.Errorf("request %d: context value %v", i, value)
+		}
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	if err := bucket.Put("key", []byte("original"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for name, err := range contextOps(bucket, ctx) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: got %v, want context.Canceled", name, err)
+		}
+	}
+	if data, err := bucket.Get("key"); err != nil || string(data) != "original" {
+		t.Errorf("got %q, %v", data, err)
+	}
+}
+
+func TestContextDeadlineStopsRequest(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	bucket.S3.Client = &http.Client{Transport: delayTransport(time.Minute)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := bucket.GetWithContext(ctx, "key")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %v", elapsed)
+	}
+}
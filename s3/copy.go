@@ -0,0 +1,148 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+)
+
+// CopyObjectResult is returned by S3 for copy requests.
+type CopyObjectResult struct {
+	ETag         string
+	LastModified string
+}
+
+// copyResp decodes the body of a copy response, which may be an error
+// even though the status code is 200.
+type copyResp struct {
+	XMLName xml.Name
+	CopyObjectResult
+	Error
+}
+
+// copySource returns the x-amz-copy-source header value for path in the
+// bucket named bucket.
+func copySource(bucket, path string) string {
+	if len(path) > 0 && path[0] == '/' {
+		path = path[1:]
+	}
+	return (&url.URL{Path: "/" + bucket + "/" + path}).EscapedPath()
+}
+
+// copyObject issues a copy request to path with the given headers, which
+// must include x-amz-copy-source, and extra query parameters. Copies move
+// object data within S3, which takes time proportional to its size, so
+// they are subject to the Transfer timeout.
+func (self *Bucket) copyObject(path string, params, headers map[string][]string) (*CopyObjectResult, error) {
+	req := &request{
+		method:   "PUT",
+		bucket:   self.Name,
+		path:     path,
+		params:   params,
+		headers:  headers,
+		transfer: true,
+	}
+	resp := &copyResp{}
+	if err := self.S3.query(req, resp); err != nil {
+		return nil, err
+	}
+	if resp.XMLName.Local == "Error" {
+		resp.Error.StatusCode = 200
+		return nil, &resp.Error
+	}
+	return &resp.CopyObjectResult, nil
+}
+
+// keptOnCopy are the object properties UpdateMetadata carries over from
+// the object unless metadata sets them, as a copy would reset them.
+var keptOnCopy = []string{
+	"x-amz-storage-class",
+	"x-amz-server-side-encryption",
+	"x-amz-server-side-encryption-aws-kms-key-id",
+	"x-amz-server-side-encryption-bucket-key-enabled",
+	"x-amz-website-redirect-location",
+}
+
+// UpdateMetadata replaces the metadata and Content-Type of the object at
+// path without uploading it again, by copying the object onto itself.
+// metadata holds headers such as Cache-Control, Content-Disposition or
+// x-amz-meta-* user metadata. All metadata not given is removed, and the
+// object's ACL is reset to perm. The storage class, server-side
+// encryption and website redirect of the object are kept unless metadata
+// sets them.
+//
+// Objects larger than 5 GiB, which a single copy cannot handle, are
+// copied onto themselves with a multipart upload.
+func (self *Bucket) UpdateMetadata(path string, metadata map[string][]string, contType string, perm ACL) error {
+	resp, err := self.Head(path)
+	if err != nil {
+		return err
+	}
+	headers := map[string][]string{
+		"Content-Type": {contType},
+		"x-amz-acl":    {string(perm)},
+	}
+	given := http.Header{}
+	for key, value := range metadata {
+		headers[key] = value
+		given[http.CanonicalHeaderKey(key)] = value
+	}
+	for _, key := range keptOnCopy {
+		if value := resp.Header.Get(key); value != "" && given.Get(key) == "" {
+			headers[key] = []string{value}
+		}
+	}
+
+	source := copySource(self.Name, path)
+	if resp.ContentLength > maxPartSize {
+		return self.multipartCopy(path, source, resp.ContentLength, headers)
+	}
+	headers["x-amz-copy-source"] = []string{source}
+	headers["x-amz-metadata-directive"] = []string{"REPLACE"}
+	_, err = self.copyObject(path, nil, headers)
+	return err
+}
+
+// multipartCopy copies the size bytes of source to path with part copies,
+// creating the object with the given headers. The upload is aborted if
+// anything fails.
+func (self *Bucket) multipartCopy(path, source string, size int64, headers map[string][]string) error {
+	uploadId, err := self.initMultiHeaders(context.Background(), path, headers)
+	if err != nil {
+		return err
+	}
+	var parts []completedPart
+	for i, r := range copyRanges(size) {
+		part, err := self.putPartCopy(path, uploadId, i+1, source, r[0], r[1])
+		if err != nil {
+			self.abortMulti(path, uploadId)
+			return err
+		}
+		parts = append(parts, part)
+	}
+	if err := self.completeMulti(context.Background(), path, uploadId, parts); err != nil {
+		self.abortMulti(path, uploadId)
+		return err
+	}
+	return nil
+}
+
+// copyRanges splits a source of size bytes into even byte ranges, first
+// and last byte included, small enough for part copies.
+func copyRanges(size int64) [][2]int64 {
+	n := (size + maxPartSize - 1) / maxPartSize
+	if n == 0 {
+		n = 1
+	}
+	partSize := (size + n - 1) / n
+	var ranges [][2]int64
+	for start := int64(0); start < size; start += partSize {
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		ranges = append(ranges, [2]int64{start, end})
+	}
+	return ranges
+}
@@ -0,0 +1,134 @@
+package s3
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// copyServer serves HEAD requests for an object of the given size and
+// headers, and records the copy, multipart and part copy requests.
+type copyServer struct {
+	size   int64
+	header http.Header
+	delay  time.Duration // of copies
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (self *copyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	self.mu.Lock()
+	self.requests = append(self.requests, r)
+	self.mu.Unlock()
+	query := r.URL.Query()
+	switch {
+	case r.Method == "HEAD":
+		for key, values := range self.header {
+			w.Header()[key] = values
+		}
+		w.Header().Set("Content-Length", strconv.FormatInt(self.size, 10))
+	case r.Method == "POST" && query.Get("uploadId") == "":
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>up</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == "POST":
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"x"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == "PUT":
+		time.Sleep(self.delay)
+		fmt.Fprintf(w, `<CopyObjectResult><ETag>"%s"</ETag></CopyObjectResult>`, query.Get("partNumber"))
+	}
+}
+
+func newCopyBucket(t *testing.T, handler *copyServer) *Bucket {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	s, err := NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Bucket("b")
+}
+
+func TestUpdateMetadataKeepsStorageClassAndEncryption(t *testing.T) {
+	handler := &copyServer{size: 10, header: http.Header{
+		"X-Amz-Storage-Class":                         {"STANDARD_IA"},
+		"X-Amz-Server-Side-Encryption":                {"aws:kms"},
+		"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": {"key"},
+	}}
+	b := newCopyBucket(t, handler)
+	err := b.UpdateMetadata("obj", map[string][]string{
+		"Cache-Control":                {"max-age=60"},
+		"x-amz-server-side-encryption": {"AES256"},
+	}, "text/plain", Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy := handler.requests[len(handler.requests)-1]
+	for key, want := range map[string]string{
+		"x-amz-copy-source":                           "/b/obj",
+		"x-amz-metadata-directive":                    "REPLACE",
+		"x-amz-storage-class":                         "STANDARD_IA",
+		"x-amz-server-side-encryption":                "AES256",
+		"x-amz-server-side-encryption-aws-kms-key-id": "key",
+		"Cache-Control":                               "max-age=60",
+		"Content-Type":                                "text/plain",
+	} {
+		if got := copy.Header.Get(key); got != want {
+			t.Errorf("%s: got %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestUpdateMetadataCopiesLargeObjectsInParts(t *testing.T) {
+	handler := &copyServer{size: 2*maxPartSize + 1, header: http.Header{"X-Amz-Storage-Class": {"GLACIER_IR"}}}
+	b := newCopyBucket(t, handler)
+	if err := b.UpdateMetadata("obj", nil, "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	var ranges []string
+	for _, r := range handler.requests {
+		switch {
+		case r.Method == "POST" && r.URL.Query().Get("uploadId") == "":
+			if r.Header.Get("x-amz-storage-class") != "GLACIER_IR" || r.Header.Get("Content-Type") != "text/plain" {
+				t.Errorf("multipart upload initiated with headers %v", r.Header)
+			}
+		case r.Method == "PUT":
+			ranges = append(ranges, r.Header.Get("x-amz-copy-source-range"))
+		}
+	}
+	if len(ranges) != 3 || ranges[0] != "bytes=0-3579139413" || ranges[2] != "bytes=7158278828-10737418240" {
+		t.Fatalf("unexpected part ranges %v", ranges)
+	}
+}
+
+func TestCopiesUseTransferTimeout(t *testing.T) {
+	handler := &copyServer{size: 10, delay: 100 * time.Millisecond}
+	b := newCopyBucket(t, handler)
+	b.Timeouts = Timeouts{Metadata: 20 * time.Millisecond}
+	if err := b.UpdateMetadata("obj", nil, "text/plain", Private); err != nil {
+		t.Fatalf("copy hit the metadata timeout: %v", err)
+	}
+}
+
+func TestComposeUsesTransferTimeout(t *testing.T) {
+	handler := &copyServer{size: minPartSize, delay: 100 * time.Millisecond}
+	b := newCopyBucket(t, handler)
+	b.Timeouts = Timeouts{Metadata: 20 * time.Millisecond}
+	if err := b.Compose("dst", "text/plain", Private, "a", "b"); err != nil {
+		t.Fatalf("part copy hit the metadata timeout: %v", err)
+	}
+	parts := 0
+	for _, r := range handler.requests {
+		if r.Method == "PUT" && r.URL.Query().Get("uploadId") == "up" {
+			parts++
+		}
+	}
+	if parts != 2 {
+		t.Fatalf("got %d part copies, want 2", parts)
+	}
+}
@@ -0,0 +1,96 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// GetToWriter streams the object at path into w without holding it in
+// memory. If the connection fails midway, the download is resumed with a
+// ranged request for the remaining bytes, pinned to the ETag of the
+// original response so a concurrent overwrite of the object is detected
+// rather than producing a mix of two versions. It returns the number of
+// bytes written to w.
+func (self *Bucket) GetToWriter(path string, w io.Writer) (written int64, err error) {
+	return self.GetToWriterWithContext(context.Background(), path, w)
+}
+
+// GetToWriterWithContext is like GetToWriter but aborts when ctx is done.
+func (self *Bucket) GetToWriterWithContext(ctx context.Context, path string, w io.Writer) (written int64, err error) {
+	headers := make(http.Header)
+	// Failed requests and broken off bodies are retried by the same loop,
+	// so the attempts of the strategy bound the requests made in total.
+	for attempt, try := self.attempts().StartWithContext(ctx), 0; attempt.Next(); try++ {
+		var resp *http.Response
+		resp, err = self.getResponseOnce(ctx, path, headers)
+		if err == nil {
+			if written > 0 && resp.StatusCode != http.StatusPartialContent {
+				resp.Body.Close()
+				return written, &Error{
+					StatusCode: resp.StatusCode,
+					Code:       "RangeIgnored",
+					Message:    "s3: range request for " + path + " returned the whole object",
+				}
+			}
+			if written == 0 {
+				if etag := resp.Header.Get("ETag"); etag != "" {
+					headers.Set("If-Match", etag)
+				}
+			}
+			var n int64
+			n, err = io.Copy(errWriter{w}, resp.Body)
+			resp.Body.Close()
+			written += n
+			if err == nil {
+				return written, nil
+			}
+			if werr, ok := err.(writeError); ok {
+				return written, werr.error
+			}
+		}
+		if !shouldRetry(err) || !attempt.HasNext() {
+			return written, err
+		}
+		self.retryBackoff(ctx, err, try)
+		if written > 0 {
+			headers.Set("Range", "bytes="+strconv.FormatInt(written, 10)+"-")
+		}
+	}
+	return written, err
+}
+
+// GetToFile downloads the object at path into the file localFile,
+// creating or truncating it.
+func (self *Bucket) GetToFile(path, localFile string) error {
+	file, err := os.Create(localFile)
+	if err != nil {
+		return err
+	}
+	_, err = self.GetToWriter(path, file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeError marks errors returned by the destination writer, which must
+// not be retried.
+type writeError struct {
+	error
+}
+
+// errWriter wraps the errors of its writer in writeError.
+type errWriter struct {
+	w io.Writer
+}
+
+func (self errWriter) Write(p []byte) (int, error) {
+	n, err := self.w.Write(p)
+	if err != nil {
+		err = writeError{err}
+	}
+	return n, err
+}
@@ -0,0 +1,197 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// cuttingTransport fails the first GETs with a 500 and breaks off the
+// bodies of the following ones after a few bytes, calling cut each time,
+// and records the headers of the GETs.
+type cuttingTransport struct {
+	mu       sync.Mutex
+	failures int
+	cuts     int
+	after    int64
+	cut      func()
+	headers  []http.Header
+}
+
+func (self *cuttingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil || req.Method != "GET" {
+		return resp, err
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.headers = append(self.headers, req.Header.Clone())
+	if self.failures > 0 {
+		self.failures--
+		resp.Body.Close()
+		return &http.Response{
+			StatusCode: 500,
+			Status:     "500 Internal Server Error",
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("<Error><Code>InternalError</Code></Error>")),
+			Request:    req,
+		}, nil
+	}
+	if self.cuts > 0 && resp.StatusCode/100 == 2 {
+		self.cuts--
+		if self.cut != nil {
+			self.cut()
+		}
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(io.LimitReader(resp.Body, self.after), errReader{io.ErrUnexpectedEOF}), resp.Body}
+	}
+	return resp, nil
+}
+
+type errReader struct {
+	err error
+}
+
+func (self errReader) Read(p []byte) (int, error) {
+	return 0, self.err
+}
+
+func newDownloadBucket(t *testing.T, transport *cuttingTransport) *Bucket {
+	bucket, _ := newTestBucket(t)
+	if err := bucket.Put("key", []byte("0123456789"), "", Private); err != nil {
+		t.Fatal(err)
+	}
+	bucket.S3.Client = &http.Client{Transport: transport}
+	bucket.S3.Attempts = &aws.AttemptStrategy{Min: 4, Delay: time.Millisecond}
+	return bucket
+}
+
+func TestGetToWriterResumes(t *testing.T) {
+	transport := &cuttingTransport{cuts: 2, after: 3}
+	bucket := newDownloadBucket(t, transport)
+	var buf bytes.Buffer
+	n, err := bucket.GetToWriter("key", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 || buf.String() != "0123456789" {
+		t.Fatalf("wrote %d bytes: %q", n, buf.String())
+	}
+	if len(transport.headers) != 3 {
+		t.Fatalf("sent %d GETs", len(transport.headers))
+	}
+	etag := transport.headers[1].Get("If-Match")
+	if transport.headers[0].Get("Range") != "" || transport.headers[1].Get("Range") != "bytes=3-" ||
+		transport.headers[2].Get("Range") != "bytes=6-" || etag == "" || transport.headers[2].Get("If-Match") != etag {
+		t.Fatalf("sent %v", transport.headers)
+	}
+}
+
+func TestGetToWriterDetectsOverwrites(t *testing.T) {
+	transport := &cuttingTransport{cuts: 1, after: 5}
+	bucket := newDownloadBucket(t, transport)
+	other, err := NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, bucket.S3.Region.S3Endpoint, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport.cut = func() {
+		// Overwrite the object while the first response is cut.
+		if err := other.Bucket(bucket.Name).Put("key", []byte("abcdefghij"), "", Private); err != nil {
+			t.Error(err)
+		}
+	}
+	var buf bytes.Buffer
+	_, err = bucket.GetToWriter("key", &buf)
+	if aws.ErrorCode(err) != "PreconditionFailed" || buf.String() != "01234" {
+		t.Fatalf("got %v after %q", err, buf.String())
+	}
+}
+
+// failingWriter fails after accepting a few bytes.
+type failingWriter struct {
+	n int
+}
+
+func (self *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > self.n {
+		p = p[:self.n]
+	}
+	self.n -= len(p)
+	if self.n == 0 {
+		return len(p), errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func TestGetToWriterDoesNotRetryWriteErrors(t *testing.T) {
+	transport := &cuttingTransport{}
+	bucket := newDownloadBucket(t, transport)
+	n, err := bucket.GetToWriter("key", &failingWriter{n: 4})
+	if err == nil || err.Error() != "disk full" || n != 4 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	if len(transport.headers) != 1 {
+		t.Fatalf("sent %d GETs", len(transport.headers))
+	}
+}
+
+func TestGetToFile(t *testing.T) {
+	transport := &cuttingTransport{cuts: 1, after: 4}
+	bucket := newDownloadBucket(t, transport)
+	local := filepath.Join(t.TempDir(), "key")
+	ioutil.WriteFile(local, []byte("previous contents, longer than the object"), 0666)
+	if err := bucket.GetToFile("key", local); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(local); string(data) != "0123456789" {
+		t.Fatalf("got %q", data)
+	}
+	if err := bucket.GetToFile("missing", local); !aws.IsNotFound(err) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestGetToWriterBoundsRequests(t *testing.T) {
+	transport := &cuttingTransport{failures: 100}
+	bucket := newDownloadBucket(t, transport)
+
+	var buf bytes.Buffer
+	if _, err := bucket.GetToWriter("key", &buf); err == nil {
+		t.Fatal("got no error")
+	}
+	// Failed requests use up the attempts of the download rather than
+	// being retried on their own.
+	if len(transport.headers) != 4 {
+		t.Fatalf("got %d GETs, want 4", len(transport.headers))
+	}
+}
+
+func TestGetToWriterRetriesRequestsAndBodies(t *testing.T) {
+	transport := &cuttingTransport{failures: 1, cuts: 1, after: 4}
+	bucket := newDownloadBucket(t, transport)
+
+	var buf bytes.Buffer
+	n, err := bucket.GetToWriter("key", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 || buf.String() != "0123456789" {
+		t.Fatalf("got %d bytes %q", n, buf.String())
+	}
+	if len(transport.headers) != 3 || transport.headers[2].Get("Range") != "bytes=4-" {
+		t.Fatalf("got GETs %v", transport.headers)
+	}
+}
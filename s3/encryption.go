@@ -0,0 +1,219 @@
+package s3
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/dkln/go-aws/kms"
+)
+
+// Object metadata headers of client-side encrypted objects, as written by
+// the AWS SDK encryption clients (V2 format).
+const (
+	cryptoKeyHeader       = "x-amz-meta-x-amz-key-v2"
+	cryptoIVHeader        = "x-amz-meta-x-amz-iv"
+	cryptoMatDescHeader   = "x-amz-meta-x-amz-matdesc"
+	cryptoWrapAlgHeader   = "x-amz-meta-x-amz-wrap-alg"
+	cryptoCEKAlgHeader    = "x-amz-meta-x-amz-cek-alg"
+	cryptoTagLenHeader    = "x-amz-meta-x-amz-tag-len"
+	cryptoPlainLenHeader  = "x-amz-meta-x-amz-unencrypted-content-length"
+	cryptoCEKAlg          = "AES/GCM/NoPadding"
+	cryptoTagLen          = 128
+	cryptoDefaultMatDesc  = "{}"
+	cryptoDataKeySize     = 32
+	cryptoAESGCMWrapAlg   = "AES/GCM"
+	cryptoKMSWrapAlg      = "kms"
+	cryptoKMSKeyIdMatDesc = "kms_cmk_id"
+)
+
+// KeyWrapper encrypts and decrypts the per-object data keys of an
+// EncryptionClient with a master key, which never leaves the wrapper.
+// Implementations can keep the master key locally or delegate to a key
+// management service.
+type KeyWrapper interface {
+	// WrapAlgorithm is recorded in the object metadata, e.g. "AES/GCM"
+	// or "kms+context".
+	WrapAlgorithm() string
+	// MaterialDescription is recorded in the object metadata as JSON
+	// and passed back to UnwrapKey.
+	MaterialDescription() string
+	WrapKey(key []byte) ([]byte, error)
+	UnwrapKey(wrapped []byte, materialDescription string) ([]byte, error)
+}
+
+// NewAESGCMKeyWrapper returns a KeyWrapper that wraps data keys with a
+// local 256 bit AES master key using AES-GCM. The wrapped key is stored as
+// the nonce followed by the sealed key, authenticated with the content
+// encryption algorithm name, as done by the AWS SDK encryption clients.
+func NewAESGCMKeyWrapper(masterKey []byte) (KeyWrapper, error) {
+	if len(masterKey) != 32 {
+		return nil, errors.New("s3: AES master key must be 32 bytes")
+	}
+	gcm, err := newAESGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &aesGCMKeyWrapper{gcm}, nil
+}
+
+type aesGCMKeyWrapper struct {
+	gcm cipher.AEAD
+}
+
+func (self *aesGCMKeyWrapper) WrapAlgorithm() string       { return cryptoAESGCMWrapAlg }
+func (self *aesGCMKeyWrapper) MaterialDescription() string { return cryptoDefaultMatDesc }
+
+func (self *aesGCMKeyWrapper) WrapKey(key []byte) ([]byte, error) {
+	return sealWithNonce(self.gcm, key, []byte(cryptoCEKAlg))
+}
+
+func (self *aesGCMKeyWrapper) UnwrapKey(wrapped []byte, materialDescription string) ([]byte, error) {
+	return openWithNonce(self.gcm, wrapped, []byte(cryptoCEKAlg))
+}
+
+// NewKMSKeyWrapper returns a KeyWrapper that has KMS encrypt data keys
+// with the customer master key keyId, a key ID, key ARN or alias. Objects
+// are stored as the AWS SDK encryption clients do with the "kms" wrap
+// algorithm: the material description {"kms_cmk_id": keyId} doubles as
+// the KMS encryption context, so either can decrypt what the other wrote.
+func NewKMSKeyWrapper(client *kms.KMS, keyId string) KeyWrapper {
+	return &kmsKeyWrapper{client, keyId}
+}
+
+type kmsKeyWrapper struct {
+	client *kms.KMS
+	keyId  string
+}
+
+func (self *kmsKeyWrapper) WrapAlgorithm() string { return cryptoKMSWrapAlg }
+
+func (self *kmsKeyWrapper) MaterialDescription() string {
+	desc, _ := json.Marshal(self.context())
+	return string(desc)
+}
+
+func (self *kmsKeyWrapper) context() map[string]string {
+	return map[string]string{cryptoKMSKeyIdMatDesc: self.keyId}
+}
+
+func (self *kmsKeyWrapper) WrapKey(key []byte) ([]byte, error) {
+	resp, err := self.client.Encrypt(context.Background(), self.keyId, key, self.context())
+	if err != nil {
+		return nil, err
+	}
+	return resp.CiphertextBlob, nil
+}
+
+func (self *kmsKeyWrapper) UnwrapKey(wrapped []byte, materialDescription string) ([]byte, error) {
+	var encryptionContext map[string]string
+	if err := json.Unmarshal([]byte(materialDescription), &encryptionContext); err != nil {
+		return nil, fmt.Errorf("bad material description: %v", err)
+	}
+	resp, err := self.client.Decrypt(context.Background(), wrapped, encryptionContext)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+// EncryptionClient stores objects encrypted on the client side, so S3
+// never sees their plaintext. Every object is encrypted with its own
+// random data key using AES-GCM; the data key is wrapped with the master
+// key of Wrapper and stored, along with the IV, in the object's metadata
+// using the same headers as the AWS SDK encryption clients.
+type EncryptionClient struct {
+	Bucket  *Bucket
+	Wrapper KeyWrapper
+}
+
+// NewEncryptionClient returns an EncryptionClient for bucket.
+func NewEncryptionClient(bucket *Bucket, wrapper KeyWrapper) *EncryptionClient {
+	return &EncryptionClient{bucket, wrapper}
+}
+
+// Put encrypts data and stores it at path.
+func (self *EncryptionClient) Put(path string, data []byte, contType string, perm ACL) error {
+	key := make([]byte, cryptoDataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return err
+	}
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return err
+	}
+	wrapped, err := self.Wrapper.WrapKey(key)
+	if err != nil {
+		return fmt.Errorf("s3: wrapping data key: %v", err)
+	}
+	headers := map[string][]string{
+		"Content-Type":       {contType},
+		cryptoKeyHeader:      {base64.StdEncoding.EncodeToString(wrapped)},
+		cryptoIVHeader:       {base64.StdEncoding.EncodeToString(iv)},
+		cryptoMatDescHeader:  {self.Wrapper.MaterialDescription()},
+		cryptoWrapAlgHeader:  {self.Wrapper.WrapAlgorithm()},
+		cryptoCEKAlgHeader:   {cryptoCEKAlg},
+		cryptoTagLenHeader:   {strconv.Itoa(cryptoTagLen)},
+		cryptoPlainLenHeader: {strconv.Itoa(len(data))},
+	}
+	return self.Bucket.PutHeader(path, gcm.Seal(nil, iv, data, nil), headers, perm)
+}
+
+// Get retrieves and decrypts the object at path.
+func (self *EncryptionClient) Get(path string) ([]byte, error) {
+	resp, err := self.Bucket.GetResponse(path)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header
+	if header.Get(cryptoKeyHeader) == "" {
+		return nil, fmt.Errorf("s3: %s is not client-side encrypted", path)
+	}
+	if alg := header.Get(cryptoCEKAlgHeader); alg != cryptoCEKAlg {
+		return nil, fmt.Errorf("s3: %s: unsupported content encryption %q", path, alg)
+	}
+	if alg := header.Get(cryptoWrapAlgHeader); alg != self.Wrapper.WrapAlgorithm() {
+		return nil, fmt.Errorf("s3: %s: key wrapped with %q, not %q", path, alg, self.Wrapper.WrapAlgorithm())
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(header.Get(cryptoKeyHeader))
+	if err != nil {
+		return nil, fmt.Errorf("s3: %s: bad wrapped key: %v", path, err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(header.Get(cryptoIVHeader))
+	if err != nil {
+		return nil, fmt.Errorf("s3: %s: bad IV: %v", path, err)
+	}
+	key, err := self.Wrapper.UnwrapKey(wrapped, header.Get(cryptoMatDescHeader))
+	if err != nil {
+		return nil, fmt.Errorf("s3: %s: unwrapping data key: %v", path, err)
+	}
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != gcm.NonceSize() {
+		return nil, fmt.Errorf("s3: %s: bad IV length %d", path, len(iv))
+	}
+	data, err := gcm.Open(nil, iv, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("s3: %s: decrypting: %v", path, err)
+	}
+	return data, nil
+}
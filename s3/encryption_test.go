@@ -0,0 +1,102 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/kms"
+)
+
+func TestEncryptionClientAESGCM(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	wrapper, err := NewAESGCMKeyWrapper(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewEncryptionClient(bucket, wrapper)
+	if err := client.Put("secret", []byte("plaintext"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := bucket.Get("secret"); bytes.Contains(raw, []byte("plaintext")) {
+		t.Fatal("object stored in plaintext")
+	}
+	if data, err := client.Get("secret"); err != nil || string(data) != "plaintext" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+
+	other, _ := NewAESGCMKeyWrapper(bytes.Repeat([]byte{8}, 32))
+	if _, err := NewEncryptionClient(bucket, other).Get("secret"); err == nil {
+		t.Fatal("decrypted with the wrong master key")
+	}
+}
+
+// fakeKMS "encrypts" by prefixing the plaintext with the key ID and the
+// encryption context, and checks both again on Decrypt.
+func fakeKMS(t *testing.T) *kms.KMS {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			KeyId             string
+			Plaintext         []byte
+			CiphertextBlob    []byte
+			EncryptionContext map[string]string
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &params)
+		context, _ := json.Marshal(params.EncryptionContext)
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			sealed := append([]byte(params.KeyId+string(context)), params.Plaintext...)
+			json.NewEncoder(w).Encode(map[string][]byte{"CiphertextBlob": sealed})
+		case "TrentService.Decrypt":
+			prefix := []byte(params.EncryptionContext["kms_cmk_id"] + string(context))
+			if !bytes.HasPrefix(params.CiphertextBlob, prefix) {
+				w.WriteHeader(400)
+				w.Write([]byte(`{"__type":"InvalidCiphertextException"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string][]byte{"Plaintext": params.CiphertextBlob[len(prefix):]})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client := kms.New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.USEast)
+	client.Endpoint.URL = srv.URL
+	return client
+}
+
+func TestEncryptionClientKMS(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	client := NewEncryptionClient(bucket, NewKMSKeyWrapper(fakeKMS(t), "alias/uploads"))
+	if err := client.Put("secret", []byte("plaintext"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := bucket.Head("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alg := resp.Header.Get(cryptoWrapAlgHeader); alg != "kms" {
+		t.Fatalf("wrap algorithm %q", alg)
+	}
+	if desc := resp.Header.Get(cryptoMatDescHeader); desc != `{"kms_cmk_id":"alias/uploads"}` {
+		t.Fatalf("material description %q", desc)
+	}
+	if data, err := client.Get("secret"); err != nil || string(data) != "plaintext" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+
+	// Objects wrapped locally are not handed to KMS.
+	local, _ := NewAESGCMKeyWrapper(make([]byte, 32))
+	if err := NewEncryptionClient(bucket, local).Put("local", []byte("x"), "", Private); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get("local"); err == nil {
+		t.Fatal("decrypted an AES/GCM wrapped key with KMS")
+	}
+}
@@ -0,0 +1,100 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dkln/go-aws"
+)
+
+func TestNewS3EndpointValidation(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		ok       bool
+	}{
+		{"http://localhost:9000", true},
+		{"https://s3.example.com", true},
+		{"https://s3.example.com/", true},
+		{"localhost:9000", false},
+		{"ftp://localhost", false},
+		{"http://", false},
+		{"http://localhost:9000/minio", false},
+		{"http://localhost:9000?x=1", false},
+		{"http://[::1", false},
+	}
+	for _, test := range tests {
+		_, err := NewS3Endpoint(aws.Auth{}, test.endpoint, "")
+		if ok := err == nil; ok != test.ok {
+			t.Errorf("NewS3Endpoint(%q): got error %v, want ok %v", test.endpoint, err, test.ok)
+		}
+	}
+}
+
+func TestNewS3EndpointRegion(t *testing.T) {
+	client, err := NewS3Endpoint(aws.Auth{}, "https://s3.example.com/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Region.Name != aws.USEast.Name || client.Region.S3LocationConstraint {
+		t.Errorf("default region = %+v", client.Region)
+	}
+	if client.Region.S3Endpoint != "https://s3.example.com" {
+		t.Errorf("S3Endpoint = %q", client.Region.S3Endpoint)
+	}
+	if got, want := client.Bucket("bucket").URL("key"), "https://s3.example.com/bucket/key"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+
+	client, err = NewS3Endpoint(aws.Auth{}, "https://s3.example.com", "eu-west-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Region.Name != "eu-west-1" || !client.Region.S3LocationConstraint {
+		t.Errorf("region = %+v", client.Region)
+	}
+}
+
+func TestNewS3EndpointRequests(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.Host+r.URL.Path+" "+string(body))
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL, "eu-west-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket := client.Bucket("bucket")
+	if err := bucket.PutBucket(Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := bucket.Put("dir/key", []byte("data"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	if len(requests) != 2 {
+		t.Fatalf("got requests %q", requests)
+	}
+	// Buckets are addressed path-style, on the endpoint's host.
+	if want := "PUT " + host + "/bucket/ "; !strings.HasPrefix(requests[0], want) {
+		t.Errorf("got %q, want prefix %q", requests[0], want)
+	}
+	if !strings.Contains(requests[0], "<LocationConstraint>eu-west-1</LocationConstraint>") {
+		t.Errorf("bucket creation lacks the location constraint: %q", requests[0])
+	}
+	if want := "PUT " + host + "/bucket/dir/key data"; requests[1] != want {
+		t.Errorf("got %q, want %q", requests[1], want)
+	}
+}
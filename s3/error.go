@@ -1,15 +1,38 @@
 package s3
 
+import (
+	"github.com/dkln/go-aws"
+	"time"
+)
+
 // Error represents an error in an operation with S3.
 type Error struct {
 	StatusCode int    // HTTP status code (200, 403, ...)
 	Code       string // EC2 error code ("UnsupportedOperation", ...)
 	Message    string // The human-oriented error message
 	BucketName string
-	RequestId  string
-	HostId     string
+	RequestId  string // x-amz-request-id, needed by AWS support
+	HostId     string // x-amz-id-2, needed by AWS support
+
+	retryAfter time.Duration // wait requested with a Retry-After header
 }
 
 func (self *Error) Error() string {
 	return self.Message
 }
+
+// ErrorCode returns the S3 error code, e.g. "NoSuchKey".
+func (self *Error) ErrorCode() string {
+	return self.Code
+}
+
+// HTTPStatusCode returns the HTTP status of the error response.
+func (self *Error) HTTPStatusCode() int {
+	return self.StatusCode
+}
+
+// IsNotFound reports whether err is S3 saying the bucket, key, version
+// or multipart upload a request was about does not exist.
+func IsNotFound(err error) bool {
+	return aws.IsNotFound(err)
+}
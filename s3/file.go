@@ -0,0 +1,31 @@
+package s3
+
+import (
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// PutFile uploads the file localFile to path. The Content-Type is derived
+// from the file's extension, falling back to application/octet-stream.
+func (self *Bucket) PutFile(path, localFile string, perm ACL) error {
+	file, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return self.PutReader(path, file, info.Size(), ContentTypeOf(localFile), perm)
+}
+
+// ContentTypeOf returns the Content-Type matching the extension of name.
+func ContentTypeOf(name string) string {
+	if ctype := mime.TypeByExtension(filepath.Ext(name)); ctype != "" {
+		return ctype
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,43 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPutFile(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	dir := t.TempDir()
+	for name, ctype := range map[string]string{
+		"page.html": "text/html; charset=utf-8",
+		"data.json": "application/json",
+		"blob":      "application/octet-stream",
+		"BLOB.XYZ":  "application/octet-stream",
+	} {
+		local := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(local, []byte("contents of "+name), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := bucket.PutFile("files/"+name, local, Private); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := bucket.GetResponse("files/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(data) != "contents of "+name || resp.Header.Get("Content-Type") != ctype {
+			t.Errorf("%s: got %q as %s, want %s", name, data, resp.Header.Get("Content-Type"), ctype)
+		}
+	}
+
+	err := bucket.PutFile("missing", filepath.Join(dir, "missing"), Private)
+	if !os.IsNotExist(err) {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package s3
+
+import (
+	"context"
+
+	"github.com/dkln/go-aws"
+)
+
+// Keys lists the keys under prefix in the background, one page at a time,
+// and sends them on the returned channel in lexical order. Memory use is
+// bounded by a single listing page regardless of the size of the bucket.
+//
+// Both channels are closed when the listing ends. If listing fails the
+// error is sent on the error channel after the keys channel is closed,
+// so range over the keys and then receive from the error channel.
+//
+// A consumer that stops before the listing ends must use KeysWithContext
+// instead and cancel the context, otherwise the listing goroutine blocks
+// forever.
+func (self *Bucket) Keys(prefix string) (<-chan Key, <-chan error) {
+	return self.KeysWithContext(context.Background(), prefix)
+}
+
+// KeysWithContext is like Keys but stops listing as soon as ctx is done,
+// both between pages and while waiting for the consumer, and then sends
+// the error of ctx on the error channel. Cancel ctx when abandoning the
+// keys channel to release the listing goroutine.
+func (self *Bucket) KeysWithContext(ctx context.Context, prefix string) (<-chan Key, <-chan error) {
+	keys := make(chan Key)
+	errs := make(chan error, 1)
+	aws.Go(func() {
+		defer close(errs)
+		err := self.listKeys(ctx, prefix, keys)
+		close(keys)
+		if err != nil {
+			errs <- err
+		}
+	})
+	return keys, errs
+}
+
+func (self *Bucket) listKeys(ctx context.Context, prefix string, keys chan<- Key) error {
+	marker := ""
+	for {
+		resp, err := self.ListWithContext(ctx, prefix, "", marker, 1000)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		for _, key := range resp.Contents {
+			select {
+			case keys <- key:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if !resp.IsTruncated || len(resp.Contents) == 0 {
+			return nil
+		}
+		marker = resp.NextMarker
+		if marker == "" {
+			marker = resp.Contents[len(resp.Contents)-1].Key
+		}
+	}
+}
@@ -0,0 +1,60 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+func TestKeysWithContextReleasesGoroutine(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	for i := 0; i < 5; i++ {
+		if err := bucket.Put(fmt.Sprintf("k%d", i), nil, "", Private); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before := aws.Diagnostics().Goroutines
+	ctx, cancel := context.WithCancel(context.Background())
+	keys, errs := bucket.KeysWithContext(ctx, "")
+	if key := <-keys; key.Key != "k0" {
+		t.Fatalf("got %q", key.Key)
+	}
+	cancel()
+	for range keys {
+	}
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	for deadline := time.Now().Add(time.Second); aws.Diagnostics().Goroutines > before; {
+		if time.Now().After(deadline) {
+			t.Fatal("listing goroutine still running")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestKeysListsAll(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	for i := 0; i < 3; i++ {
+		if err := bucket.Put(fmt.Sprintf("p/%d", i), []byte("x"), "", Private); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, errs := bucket.Keys("p/")
+	var got []string
+	for key := range keys {
+		got = append(got, key.Key)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[p/0 p/1 p/2]" {
+		t.Fatalf("got %v", got)
+	}
+}
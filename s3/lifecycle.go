@@ -0,0 +1,369 @@
+package s3
+
+import (
+	"encoding/xml"
+	"errors"
+	"time"
+)
+
+// LifecycleConfiguration holds the lifecycle rules of a bucket.
+type LifecycleConfiguration struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Rules   []LifecycleRule `xml:"Rule"`
+}
+
+// LifecycleRule applies actions to the objects matching its filter.
+// Elements of a rule the type does not model are kept in Other, so rules
+// read with GetLifecycle are written back by PutLifecycle unchanged.
+type LifecycleRule struct {
+	ID     string           `xml:",omitempty"`
+	Filter *LifecycleFilter `xml:",omitempty"`
+	Prefix string           `xml:",omitempty"` // legacy form of Filter
+	Status string           // "Enabled" or "Disabled"
+
+	Expiration                     *LifecycleExpiration            `xml:",omitempty"`
+	Transitions                    []LifecycleTransition           `xml:"Transition,omitempty"`
+	NoncurrentVersionExpiration    *NoncurrentVersionExpiration    `xml:",omitempty"`
+	NoncurrentVersionTransitions   []NoncurrentVersionTransition   `xml:"NoncurrentVersionTransition,omitempty"`
+	AbortIncompleteMultipartUpload *AbortIncompleteMultipartUpload `xml:",omitempty"`
+
+	Other []RawElement `xml:",any"`
+}
+
+// LifecycleFilter selects the objects a lifecycle rule applies to. A zero
+// LifecycleFilter matches every object of the bucket.
+type LifecycleFilter struct {
+	Prefix                string
+	Tags                  []Tag
+	ObjectSizeGreaterThan int64 // in bytes
+	ObjectSizeLessThan    int64
+}
+
+type lifecycleFilterXML struct {
+	Prefix                *string `xml:",omitempty"`
+	Tag                   *Tag    `xml:",omitempty"`
+	ObjectSizeGreaterThan int64   `xml:",omitempty"`
+	ObjectSizeLessThan    int64   `xml:",omitempty"`
+	And                   *struct {
+		Prefix                string `xml:",omitempty"`
+		Tag                   []Tag
+		ObjectSizeGreaterThan int64 `xml:",omitempty"`
+		ObjectSizeLessThan    int64 `xml:",omitempty"`
+	} `xml:",omitempty"`
+}
+
+// MarshalXML encodes the filter as a single condition or as an And of
+// several, as S3 expects.
+func (self LifecycleFilter) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	var out lifecycleFilterXML
+	conditions := len(self.Tags)
+	for _, set := range []bool{self.Prefix != "", self.ObjectSizeGreaterThan != 0, self.ObjectSizeLessThan != 0} {
+		if set {
+			conditions++
+		}
+	}
+	switch {
+	case conditions > 1:
+		out.And = &struct {
+			Prefix                string `xml:",omitempty"`
+			Tag                   []Tag
+			ObjectSizeGreaterThan int64 `xml:",omitempty"`
+			ObjectSizeLessThan    int64 `xml:",omitempty"`
+		}{self.Prefix, self.Tags, self.ObjectSizeGreaterThan, self.ObjectSizeLessThan}
+	case len(self.Tags) == 1:
+		out.Tag = &self.Tags[0]
+	default:
+		// An empty Prefix element, the usual form of "every object".
+		out.Prefix = &self.Prefix
+		out.ObjectSizeGreaterThan = self.ObjectSizeGreaterThan
+		out.ObjectSizeLessThan = self.ObjectSizeLessThan
+		if out.ObjectSizeGreaterThan != 0 || out.ObjectSizeLessThan != 0 {
+			out.Prefix = nil
+		}
+	}
+	return e.EncodeElement(out, start)
+}
+
+// UnmarshalXML decodes any of the filter forms returned by S3.
+func (self *LifecycleFilter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var in lifecycleFilterXML
+	if err := d.DecodeElement(&in, &start); err != nil {
+		return err
+	}
+	*self = LifecycleFilter{
+		ObjectSizeGreaterThan: in.ObjectSizeGreaterThan,
+		ObjectSizeLessThan:    in.ObjectSizeLessThan,
+	}
+	if in.Prefix != nil {
+		self.Prefix = *in.Prefix
+	}
+	if in.Tag != nil {
+		self.Tags = []Tag{*in.Tag}
+	}
+	if in.And != nil {
+		self.Prefix = in.And.Prefix
+		self.Tags = in.And.Tag
+		self.ObjectSizeGreaterThan = in.And.ObjectSizeGreaterThan
+		self.ObjectSizeLessThan = in.And.ObjectSizeLessThan
+	}
+	return nil
+}
+
+// LifecycleExpiration expires objects a number of days after their
+// creation or at a fixed date (midnight UTC, ISO 8601 format). In
+// versioned buckets expiring adds a delete marker; set
+// ExpiredObjectDeleteMarker, without Days or Date, to remove delete
+// markers left without versions.
+type LifecycleExpiration struct {
+	Days                      int    `xml:",omitempty"`
+	Date                      string `xml:",omitempty"`
+	ExpiredObjectDeleteMarker bool   `xml:",omitempty"`
+}
+
+// LifecycleTransition moves objects to another storage class a number of
+// days after their creation or at a fixed date.
+type LifecycleTransition struct {
+	Days         int    `xml:",omitempty"`
+	Date         string `xml:",omitempty"`
+	StorageClass string // e.g. "STANDARD_IA" or "GLACIER"
+}
+
+// NoncurrentVersionExpiration deletes versions a number of days after
+// they stopped being the current version, keeping the newest
+// NewerNoncurrentVersions of them if set.
+type NoncurrentVersionExpiration struct {
+	NoncurrentDays          int
+	NewerNoncurrentVersions int `xml:",omitempty"`
+}
+
+// NoncurrentVersionTransition moves versions to another storage class a
+// number of days after they stopped being the current version.
+type NoncurrentVersionTransition struct {
+	NoncurrentDays          int
+	StorageClass            string
+	NewerNoncurrentVersions int `xml:",omitempty"`
+}
+
+// AbortIncompleteMultipartUpload aborts multipart uploads that are not
+// completed within a number of days after they were initiated.
+type AbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int
+}
+
+// RawElement is an XML element kept verbatim, for elements of S3
+// documents the package does not model.
+type RawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+// MarshalXML writes the element as it was read, in the namespace of its
+// parent.
+func (self RawElement) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Local: self.XMLName.Local}}
+	for _, attr := range self.Attrs {
+		if attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" {
+			start.Attr = append(start.Attr, attr)
+		}
+	}
+	return e.EncodeElement(struct {
+		Inner []byte `xml:",innerxml"`
+	}{self.Inner}, start)
+}
+
+// GetLifecycle returns the lifecycle configuration of the bucket. A bucket
+// without one yields an empty configuration.
+func (self *Bucket) GetLifecycle() (config *LifecycleConfiguration, err error) {
+	req := &request{
+		bucket: self.Name,
+		path:   "/",
+		params: map[string][]string{"lifecycle": {""}},
+	}
+	config = &LifecycleConfiguration{}
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
+		err = self.S3.query(req, config)
+		if !shouldRetry(err) {
+			break
+		}
+		self.retryBackoff(req.context(), err, try)
+	}
+	if hasCode(err, "NoSuchLifecycleConfiguration") {
+		return &LifecycleConfiguration{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// PutLifecycle replaces the lifecycle configuration of the bucket.
+func (self *Bucket) PutLifecycle(config *LifecycleConfiguration) error {
+	if len(config.Rules) == 0 {
+		return self.DelLifecycle()
+	}
+	req := &request{
+		method: "PUT",
+		bucket: self.Name,
+		path:   "/",
+		params: map[string][]string{"lifecycle": {""}},
+	}
+	if err := setXMLPayload(req, config); err != nil {
+		return err
+	}
+	return self.S3.query(req, nil)
+}
+
+// DelLifecycle removes the lifecycle configuration of the bucket.
+func (self *Bucket) DelLifecycle() error {
+	req := &request{
+		method: "DELETE",
+		bucket: self.Name,
+		path:   "/",
+		params: map[string][]string{"lifecycle": {""}},
+	}
+	return self.S3.query(req, nil)
+}
+
+// ExpirePrefix installs a lifecycle rule that makes S3 delete every object
+// under prefix once it is older than after, rounded up to whole days. It
+// is by far the cheapest way to delete large numbers of objects: S3 does
+// the work in the background and does not charge for the deletes, while
+// deleting millions of keys one by one takes days of requests.
+//
+// In versioned buckets the rule also deletes noncurrent versions the
+// same number of days after they became noncurrent. Delete markers are
+// left behind.
+//
+// Other lifecycle rules of the bucket are kept as they are; an earlier
+// ExpirePrefix rule for the same prefix is replaced. Use
+// ExpirePrefixStatus to follow progress. S3 applies lifecycle rules
+// asynchronously, usually within a day or two of objects becoming
+// eligible.
+//
+// The prefix must not be empty: expiring a whole bucket is better done
+// with a rule of its own, written with PutLifecycle.
+func (self *Bucket) ExpirePrefix(prefix string, after time.Duration) error {
+	if prefix == "" {
+		return ErrEmptyExpirePrefix
+	}
+	days := int((after + 24*time.Hour - 1) / (24 * time.Hour))
+	if days < 1 {
+		days = 1
+	}
+	config, err := self.GetLifecycle()
+	if err != nil {
+		return err
+	}
+	rule := LifecycleRule{
+		ID:         expirePrefixRuleID(prefix),
+		Filter:     &LifecycleFilter{Prefix: prefix},
+		Status:     "Enabled",
+		Expiration: &LifecycleExpiration{Days: days},
+		NoncurrentVersionExpiration: &NoncurrentVersionExpiration{
+			NoncurrentDays: days,
+		},
+	}
+	replaced := false
+	for i := range config.Rules {
+		if config.Rules[i].ID == rule.ID {
+			config.Rules[i] = rule
+			replaced = true
+		}
+	}
+	if !replaced {
+		config.Rules = append(config.Rules, rule)
+	}
+	return self.PutLifecycle(config)
+}
+
+// ExpirationStatus reports the progress of an ExpirePrefix call.
+type ExpirationStatus struct {
+	RuleInstalled bool // the expiration rule for the prefix is in place
+	Days          int  // age in days after which objects expire
+	Done          bool // no objects or versions are left under the prefix
+}
+
+// ExpirePrefixStatus reports whether the rule installed by ExpirePrefix for
+// prefix is in place and whether any objects, including noncurrent
+// versions, remain under prefix. Delete markers are not counted.
+func (self *Bucket) ExpirePrefixStatus(prefix string) (*ExpirationStatus, error) {
+	if prefix == "" {
+		return nil, ErrEmptyExpirePrefix
+	}
+	config, err := self.GetLifecycle()
+	if err != nil {
+		return nil, err
+	}
+	status := &ExpirationStatus{}
+	id := expirePrefixRuleID(prefix)
+	for _, rule := range config.Rules {
+		if rule.ID == id && rule.Status == "Enabled" && rule.Expiration != nil {
+			status.RuleInstalled = true
+			status.Days = rule.Expiration.Days
+		}
+	}
+	resp, err := self.List(prefix, "", "", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Contents) > 0 {
+		return status, nil
+	}
+	// Versions of deleted objects linger behind their delete markers.
+	keyMarker, versionIdMarker := "", ""
+	for {
+		versions, err := self.ListVersions(prefix, "", keyMarker, versionIdMarker, 1000)
+		if hasCode(err, "NotImplemented") {
+			// Services without versioning have nothing more to expire.
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions.Versions {
+			if !version.IsDeleteMarker {
+				return status, nil
+			}
+		}
+		if !versions.IsTruncated {
+			break
+		}
+		keyMarker, versionIdMarker = versions.NextKeyMarker, versions.NextVersionIdMarker
+	}
+	status.Done = true
+	return status, nil
+}
+
+// RemoveExpirePrefix removes the rule installed by ExpirePrefix for
+// prefix, keeping the other lifecycle rules of the bucket.
+func (self *Bucket) RemoveExpirePrefix(prefix string) error {
+	if prefix == "" {
+		return ErrEmptyExpirePrefix
+	}
+	config, err := self.GetLifecycle()
+	if err != nil {
+		return err
+	}
+	id := expirePrefixRuleID(prefix)
+	rules := config.Rules[:0]
+	for _, rule := range config.Rules {
+		if rule.ID != id {
+			rules = append(rules, rule)
+		}
+	}
+	config.Rules = rules
+	return self.PutLifecycle(config)
+}
+
+// ErrEmptyExpirePrefix is returned by ExpirePrefix and its companions
+// for an empty prefix, which would expire the whole bucket.
+var ErrEmptyExpirePrefix = errors.New("s3: ExpirePrefix needs a non-empty prefix")
+
+func expirePrefixRuleID(prefix string) string {
+	id := "expire-prefix:" + prefix
+	if len(id) > 255 {
+		id = id[:255]
+	}
+	return id
+}
@@ -0,0 +1,165 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+const legacyLifecycle = `<?xml version="1.0" encoding="UTF-8"?>
+<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Rule>
+    <ID>archive</ID>
+    <Prefix>logs/</Prefix>
+    <Status>Enabled</Status>
+    <Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>
+    <NoncurrentVersionExpiration><NoncurrentDays>90</NoncurrentDays></NoncurrentVersionExpiration>
+    <AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload>
+    <FutureAction><Days>3</Days></FutureAction>
+  </Rule>
+</LifecycleConfiguration>`
+
+// lifecycleServer serves the lifecycle configuration of a single bucket.
+type lifecycleServer struct {
+	mu       sync.Mutex
+	config   string
+	versions string // inner XML of the ListVersionsResult
+}
+
+func (self *lifecycleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	switch r.Method {
+	case "GET":
+		if _, ok := r.URL.Query()["versions"]; ok {
+			w.Write([]byte("<ListVersionsResult>" + self.versions + "</ListVersionsResult>"))
+			return
+		}
+		if _, ok := r.URL.Query()["lifecycle"]; !ok {
+			w.Write([]byte("<ListBucketResult></ListBucketResult>"))
+			return
+		}
+		if self.config == "" {
+			w.WriteHeader(404)
+			w.Write([]byte(`<Error><Code>NoSuchLifecycleConfiguration</Code></Error>`))
+			return
+		}
+		w.Write([]byte(self.config))
+	case "PUT":
+		data, _ := ioutil.ReadAll(r.Body)
+		self.config = string(data)
+	case "DELETE":
+		self.config = ""
+		w.WriteHeader(204)
+	}
+}
+
+func newLifecycleBucket(t *testing.T, config string) (*Bucket, *lifecycleServer) {
+	handler := &lifecycleServer{config: config}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	s, err := NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Bucket("b"), handler
+}
+
+func TestExpirePrefixKeepsOtherRules(t *testing.T) {
+	b, srv := newLifecycleBucket(t, legacyLifecycle)
+	if err := b.ExpirePrefix("tmp/", 36*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<Prefix>logs/</Prefix>",
+		"<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>",
+		"<NoncurrentVersionExpiration><NoncurrentDays>90</NoncurrentDays></NoncurrentVersionExpiration>",
+		"<AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload>",
+		"<FutureAction><Days>3</Days></FutureAction>",
+		"<Filter><Prefix>tmp/</Prefix></Filter>",
+		"<Expiration><Days>2</Days></Expiration>",
+	} {
+		if !strings.Contains(srv.config, want) {
+			t.Errorf("configuration lacks %s:\n%s", want, srv.config)
+		}
+	}
+	if strings.Contains(srv.config, "<Filter><Prefix></Prefix></Filter>") {
+		t.Errorf("legacy rule was widened to the whole bucket:\n%s", srv.config)
+	}
+
+	if err := b.RemoveExpirePrefix("tmp/"); err != nil {
+		t.Fatal(err)
+	}
+	config, err := b.GetLifecycle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Rules) != 1 || config.Rules[0].ID != "archive" || len(config.Rules[0].Other) != 1 {
+		t.Fatalf("unexpected rules after removal: %+v", config.Rules)
+	}
+}
+
+func TestExpirePrefixRejectsEmptyPrefix(t *testing.T) {
+	b, srv := newLifecycleBucket(t, "")
+	if err := b.ExpirePrefix("", time.Hour); err != ErrEmptyExpirePrefix {
+		t.Fatalf("got %v, want ErrEmptyExpirePrefix", err)
+	}
+	if srv.config != "" {
+		t.Fatalf("configuration was written: %s", srv.config)
+	}
+}
+
+func TestLifecycleFilterForms(t *testing.T) {
+	for _, filter := range []LifecycleFilter{
+		{},
+		{Prefix: "a/"},
+		{Tags: []Tag{{Key: "k", Value: "v"}}},
+		{Prefix: "a/", ObjectSizeGreaterThan: 10},
+		{ObjectSizeLessThan: 10},
+	} {
+		config := &LifecycleConfiguration{Rules: []LifecycleRule{{ID: "r", Filter: &filter, Status: "Enabled"}}}
+		b, srv := newLifecycleBucket(t, "")
+		if err := b.PutLifecycle(config); err != nil {
+			t.Fatal(err)
+		}
+		got, err := b.GetLifecycle()
+		if err != nil {
+			t.Fatal(err)
+		}
+		back := got.Rules[0].Filter
+		if back == nil || back.Prefix != filter.Prefix || len(back.Tags) != len(filter.Tags) ||
+			back.ObjectSizeGreaterThan != filter.ObjectSizeGreaterThan || back.ObjectSizeLessThan != filter.ObjectSizeLessThan {
+			t.Errorf("filter %+v came back as %+v from %s", filter, back, srv.config)
+		}
+	}
+}
+
+func TestExpirePrefixStatusCountsVersions(t *testing.T) {
+	b, srv := newLifecycleBucket(t, "")
+	if err := b.ExpirePrefix("tmp/", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	srv.versions = `<DeleteMarker><Key>tmp/a</Key><VersionId>2</VersionId><IsLatest>true</IsLatest></DeleteMarker>` +
+		`<Version><Key>tmp/a</Key><VersionId>1</VersionId><IsLatest>false</IsLatest></Version>`
+	status, err := b.ExpirePrefixStatus("tmp/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.RuleInstalled || status.Days != 1 || status.Done {
+		t.Fatalf("with a noncurrent version left: %+v", status)
+	}
+
+	srv.versions = `<DeleteMarker><Key>tmp/a</Key><VersionId>2</VersionId><IsLatest>true</IsLatest></DeleteMarker>`
+	if status, err = b.ExpirePrefixStatus("tmp/"); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Done {
+		t.Fatalf("with only a delete marker left: %+v", status)
+	}
+}
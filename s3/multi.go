@@ -0,0 +1,175 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// multipart uploads are only used internally, by Compose, UpdateMetadata
+// and StartPutReader.
+
+type initiateMultipartResp struct {
+	UploadId string
+}
+
+type completedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completedParts []completedPart
+
+func (self completedParts) Len() int           { return len(self) }
+func (self completedParts) Less(i, j int) bool { return self[i].PartNumber < self[j].PartNumber }
+func (self completedParts) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+
+// initMulti starts a multipart upload to path and returns its upload id.
+func (self *Bucket) initMulti(ctx context.Context, path string, contType string, perm ACL) (string, error) {
+	return self.initMultiHeaders(ctx, path, map[string][]string{
+		"Content-Type": {contType},
+		"x-amz-acl":    {string(perm)},
+	})
+}
+
+// initMultiHeaders starts a multipart upload to path creating an object
+// with the given headers, such as Content-Type and metadata, and returns
+// its upload id.
+func (self *Bucket) initMultiHeaders(ctx context.Context, path string, headers map[string][]string) (string, error) {
+	copy := map[string][]string{"Content-Length": {"0"}}
+	for key, value := range headers {
+		copy[key] = value
+	}
+	req := &request{
+		ctx:     ctx,
+		method:  "POST",
+		bucket:  self.Name,
+		path:    path,
+		params:  map[string][]string{"uploads": {""}},
+		headers: copy,
+	}
+	resp := &initiateMultipartResp{}
+	if err := self.S3.query(req, resp); err != nil {
+		return "", err
+	}
+	return resp.UploadId, nil
+}
+
+// completeMulti assembles the uploaded parts into the final object. For
+// large objects S3 may take minutes to answer, so completion is subject
+// to the Transfer timeout.
+func (self *Bucket) completeMulti(ctx context.Context, path, uploadId string, parts []completedPart) error {
+	sort.Sort(completedParts(parts))
+	req := &request{
+		ctx:      ctx,
+		method:   "POST",
+		bucket:   self.Name,
+		path:     path,
+		params:   map[string][]string{"uploadId": {uploadId}},
+		transfer: true,
+	}
+	if err := setXMLPayload(req, &completeMultipartUpload{Parts: parts}); err != nil {
+		return err
+	}
+	// Like copies, completion may fail after S3 already answered 200.
+	resp := &copyResp{}
+	if err := self.S3.query(req, resp); err != nil {
+		return err
+	}
+	if resp.XMLName.Local == "Error" {
+		resp.Error.StatusCode = 200
+		return &resp.Error
+	}
+	return nil
+}
+
+// abortMulti cancels a multipart upload, freeing the storage of all parts
+// uploaded so far.
+func (self *Bucket) abortMulti(path, uploadId string) error {
+	req := &request{
+		method: "DELETE",
+		bucket: self.Name,
+		path:   path,
+		params: map[string][]string{"uploadId": {uploadId}},
+	}
+	var err error
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
+		err = self.S3.query(req, nil)
+		if !shouldRetry(err) || hasCode(err, "NoSuchUpload") {
+			break
+		}
+		self.retryBackoff(req.context(), err, try)
+	}
+	return err
+}
+
+// putPart uploads data as part n of a multipart upload, retrying it as
+// PutReader does.
+func (self *Bucket) putPart(ctx context.Context, path, uploadId string, n int, data []byte) (completedPart, error) {
+	body := bytes.NewReader(data)
+	req := &request{
+		ctx:    ctx,
+		method: "PUT",
+		bucket: self.Name,
+		path:   path,
+		params: map[string][]string{
+			"partNumber": {strconv.Itoa(n)},
+			"uploadId":   {uploadId},
+		},
+		headers:  map[string][]string{"Content-Length": {strconv.Itoa(len(data))}},
+		payload:  body,
+		transfer: true,
+	}
+	var err error
+	for attempt, try := self.attempts().StartWithContext(ctx), 0; attempt.Next(); try++ {
+		if _, err = body.Seek(0, io.SeekStart); err != nil {
+			return completedPart{}, err
+		}
+		if err = self.S3.prepare(req); err != nil {
+			return completedPart{}, err
+		}
+		var resp *http.Response
+		if resp, err = self.S3.run(req, nil); err == nil {
+			closeBody(resp)
+			return completedPart{n, resp.Header.Get("ETag")}, nil
+		}
+		if !shouldRetry(err) || !attempt.HasNext() {
+			return completedPart{}, err
+		}
+		self.retryBackoff(ctx, err, try)
+	}
+	return completedPart{}, err
+}
+
+// putPartCopy copies the byte range [start, end] of source into part n of
+// a multipart upload. A negative start copies the whole source. Parts of
+// up to 5 GiB take a while, so, like all copies, it is subject to the
+// Transfer timeout rather than the Metadata one.
+func (self *Bucket) putPartCopy(path, uploadId string, n int, source string, start, end int64) (completedPart, error) {
+	headers := map[string][]string{
+		"x-amz-copy-source": {source},
+	}
+	if start >= 0 {
+		headers["x-amz-copy-source-range"] = []string{
+			"bytes=" + strconv.FormatInt(start, 10) + "-" + strconv.FormatInt(end, 10),
+		}
+	}
+	params := map[string][]string{
+		"partNumber": {strconv.Itoa(n)},
+		"uploadId":   {uploadId},
+	}
+	result, err := self.copyObject(path, params, headers)
+	if err != nil {
+		return completedPart{}, err
+	}
+	return completedPart{n, result.ETag}, nil
+}
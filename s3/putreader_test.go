@@ -0,0 +1,87 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// failingPuts consumes the body of the first n PUT requests and answers
+// them with an InternalError, passing everything else on.
+type failingPuts struct {
+	mu   sync.Mutex
+	n    int
+	puts int
+}
+
+func (self *failingPuts) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "PUT" {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	self.mu.Lock()
+	self.puts++
+	fail := self.puts <= self.n
+	self.mu.Unlock()
+	if !fail {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	if req.Body != nil {
+		io.Copy(ioutil.Discard, req.Body)
+		req.Body.Close()
+	}
+	body := "<Error><Code>InternalError</Code><Message>We encountered an internal error.</Message></Error>"
+	return &http.Response{
+		StatusCode: 500,
+		Status:     "500 Internal Server Error",
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestPutReaderRewindsOnRetry(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	transport := &failingPuts{n: 2}
+	bucket.S3.Client = &http.Client{Transport: transport}
+	bucket.S3.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+
+	// The upload starts where the reader is, not at its beginning.
+	r := strings.NewReader("skipped-data")
+	r.Seek(int64(len("skipped-")), io.SeekStart)
+	if err := bucket.PutReader("key", r, int64(r.Len()), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if transport.puts != 3 {
+		t.Errorf("got %d PUT requests, want 3", transport.puts)
+	}
+	if data, err := bucket.Get("key"); err != nil || string(data) != "data" {
+		t.Errorf("got %q, %v", data, err)
+	}
+}
+
+func TestPutReaderNotSeekable(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	transport := &failingPuts{n: 1}
+	bucket.S3.Client = &http.Client{Transport: transport}
+	bucket.S3.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+
+	// A consumed reader that cannot be rewound must not be retried, or
+	// the retry would upload whatever is left of it.
+	r := struct{ io.Reader }{bytes.NewReader([]byte("data"))}
+	err := bucket.PutReader("key", r, 4, "text/plain", Private)
+	if code := aws.ErrorCode(err); code != "InternalError" {
+		t.Fatalf("got %v, want InternalError", err)
+	}
+	if transport.puts != 1 {
+		t.Errorf("got %d PUT requests, want 1", transport.puts)
+	}
+}
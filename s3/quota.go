@@ -0,0 +1,340 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// QuotaExceededError is returned when an upload would take a prefix over
+// its quota.
+type QuotaExceededError struct {
+	Prefix    string
+	Limit     int64
+	Used      int64
+	Requested int64
+}
+
+func (self *QuotaExceededError) Error() string {
+	return fmt.Sprintf("s3: quota of %q exceeded: %d of %d bytes used, %d more requested",
+		self.Prefix, self.Used, self.Limit, self.Requested)
+}
+
+// QuotaBucket enforces per-prefix storage quotas on uploads, for platforms
+// storing the data of their own tenants under one prefix each.
+//
+// The bytes stored under every quota prefix are tracked in a JSON
+// document kept in the bucket itself, at UsagePath. Only the writes of a
+// QuotaBucket are accounted for, which is why it wraps rather than
+// extends Bucket; Recalculate rebuilds the usage from a full listing.
+//
+// Several processes may write to the same prefixes. Every change to the
+// usage re-reads the document and writes it back conditionally on its
+// ETag, retrying on conflicts, so no update is lost. Quotas are checked
+// against a copy of the document cached for ReloadInterval plus the
+// uploads in progress in this process, so uploads of other processes
+// racing each other can still overshoot a quota, by at most what they
+// upload in an interval. A QuotaBucket is safe for concurrent use.
+type QuotaBucket struct {
+	Quotas    map[string]int64 // byte limit per key prefix
+	UsagePath string           // object holding the usage document
+
+	// ReloadInterval is how long the usage document is cached. If zero,
+	// DefaultUsageReloadInterval.
+	ReloadInterval time.Duration
+
+	bucket *Bucket
+
+	mu       sync.Mutex
+	usage    map[string]int64 // never modified once loaded
+	loadedAt time.Time
+	pending  map[string]int64 // growth of the uploads in progress by prefix
+}
+
+// DefaultUsagePath is the object WithQuotas keeps usage in by default.
+const DefaultUsagePath = ".quota-usage.json"
+
+// DefaultUsageReloadInterval is how long a QuotaBucket caches the usage
+// document unless set otherwise.
+const DefaultUsageReloadInterval = time.Minute
+
+// WithQuotas returns a view of the bucket enforcing quotas, a map from key
+// prefix to the maximum number of bytes stored under it. Keys matching no
+// prefix are not limited; keys matching several count against the longest.
+func (self *Bucket) WithQuotas(quotas map[string]int64) *QuotaBucket {
+	return &QuotaBucket{bucket: self, Quotas: quotas, UsagePath: DefaultUsagePath}
+}
+
+// Name returns the name of the bucket.
+func (self *QuotaBucket) Name() string {
+	return self.bucket.Name
+}
+
+// Get retrieves an object.
+func (self *QuotaBucket) Get(path string) ([]byte, error) {
+	return self.bucket.Get(path)
+}
+
+// Stat retrieves the metadata of an object.
+func (self *QuotaBucket) Stat(path string) (*ObjectInfo, error) {
+	return self.bucket.Stat(path)
+}
+
+// Put inserts an object if its prefix has enough quota left.
+func (self *QuotaBucket) Put(path string, data []byte, contType string, perm ACL) error {
+	return self.PutReader(path, bytes.NewReader(data), int64(len(data)), contType, perm)
+}
+
+// PutReader inserts an object if its prefix has enough quota left. When
+// path is overwritten, only the growth in size counts against the quota.
+func (self *QuotaBucket) PutReader(path string, r io.Reader, length int64, contType string, perm ACL) error {
+	return self.put(path, length, func() error {
+		return self.bucket.PutReader(path, r, length, contType, perm)
+	})
+}
+
+// PutReaderHeader is like PutReader, with custom headers as in
+// Bucket.PutReaderHeader.
+func (self *QuotaBucket) PutReaderHeader(path string, r io.Reader, length int64, customHeaders map[string][]string, perm ACL) error {
+	return self.put(path, length, func() error {
+		return self.bucket.PutReaderHeader(path, r, length, customHeaders, perm)
+	})
+}
+
+// put runs upload, which writes length bytes to path, if the prefix of
+// path has enough quota left.
+func (self *QuotaBucket) put(path string, length int64, upload func() error) error {
+	prefix, limited := self.prefix(path)
+	if !limited {
+		return upload()
+	}
+
+	old, err := self.size(path)
+	if err != nil {
+		return err
+	}
+	growth := length - old
+	if err := self.reserve(prefix, growth); err != nil {
+		return err
+	}
+	// The reservation is only released once the usage includes the
+	// upload, so that concurrent uploads never miss it.
+	defer self.release(prefix, growth)
+	if err := upload(); err != nil {
+		return err
+	}
+	return self.update(func(usage map[string]int64) {
+		usage[prefix] += growth
+	})
+}
+
+// reserve counts growth bytes against the quota of prefix, failing if
+// that exceeds it.
+func (self *QuotaBucket) reserve(prefix string, growth int64) error {
+	usage, err := self.load()
+	if err != nil {
+		return err
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	used := usage[prefix] + self.pending[prefix]
+	if used+growth > self.Quotas[prefix] {
+		return &QuotaExceededError{prefix, self.Quotas[prefix], used, growth}
+	}
+	if self.pending == nil {
+		self.pending = make(map[string]int64)
+	}
+	self.pending[prefix] += growth
+	return nil
+}
+
+// release gives back a reservation made by reserve.
+func (self *QuotaBucket) release(prefix string, growth int64) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.pending[prefix] -= growth
+	if self.pending[prefix] == 0 {
+		delete(self.pending, prefix)
+	}
+}
+
+// Del removes an object, returning its size to the quota of its prefix.
+func (self *QuotaBucket) Del(path string) error {
+	prefix, limited := self.prefix(path)
+	if !limited {
+		return self.bucket.Del(path)
+	}
+
+	old, err := self.size(path)
+	if err != nil {
+		return err
+	}
+	if err := self.bucket.Del(path); err != nil {
+		return err
+	}
+	return self.update(func(usage map[string]int64) {
+		usage[prefix] -= old
+	})
+}
+
+// Usage returns the number of bytes stored under a quota prefix.
+func (self *QuotaBucket) Usage(prefix string) (int64, error) {
+	usage, err := self.load()
+	if err != nil {
+		return 0, err
+	}
+	return usage[prefix], nil
+}
+
+// Recalculate rebuilds the usage of every quota prefix by listing the
+// objects under it, e.g. after objects were written around the QuotaBucket.
+func (self *QuotaBucket) Recalculate() error {
+	rebuilt := make(map[string]int64)
+	for prefix := range self.Quotas {
+		rebuilt[prefix] = 0
+		keys, errs := self.bucket.Keys(prefix)
+		for key := range keys {
+			if owner, _ := self.prefix(key.Key); owner == prefix {
+				rebuilt[prefix] += key.Size
+			}
+		}
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return self.update(func(usage map[string]int64) {
+		for prefix, used := range rebuilt {
+			usage[prefix] = used
+		}
+	})
+}
+
+// prefix returns the longest quota prefix of path.
+func (self *QuotaBucket) prefix(path string) (prefix string, ok bool) {
+	path = strings.TrimPrefix(path, "/")
+	for p := range self.Quotas {
+		if strings.HasPrefix(path, p) && (!ok || len(p) > len(prefix)) {
+			prefix, ok = p, true
+		}
+	}
+	return prefix, ok
+}
+
+// size returns the size of the object at path, zero if it does not exist.
+func (self *QuotaBucket) size(path string) (int64, error) {
+	info, err := self.bucket.Stat(path)
+	if hasCode(err, "NoSuchKey") {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// load returns the usage document, reading it again if it was read
+// ReloadInterval ago or earlier. The returned map must not be modified.
+func (self *QuotaBucket) load() (map[string]int64, error) {
+	interval := self.ReloadInterval
+	if interval == 0 {
+		interval = DefaultUsageReloadInterval
+	}
+	self.mu.Lock()
+	usage, loadedAt := self.usage, self.loadedAt
+	self.mu.Unlock()
+	if usage != nil && time.Since(loadedAt) < interval {
+		return usage, nil
+	}
+
+	usage, _, err := self.read()
+	if err != nil {
+		return nil, err
+	}
+	self.cache(usage)
+	return usage, nil
+}
+
+// cache makes usage, just read or written, the cached usage document.
+func (self *QuotaBucket) cache(usage map[string]int64) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.usage, self.loadedAt = usage, time.Now()
+}
+
+// read reads the usage document and its ETag, which is empty if the
+// document does not exist yet.
+func (self *QuotaBucket) read() (map[string]int64, string, error) {
+	usage := make(map[string]int64)
+	resp, err := self.bucket.GetResponse(self.UsagePath)
+	if hasCode(err, "NoSuchKey") {
+		return usage, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, "", fmt.Errorf("s3: bad quota usage document %s: %v", self.UsagePath, err)
+	}
+	return usage, resp.Header.Get("ETag"), nil
+}
+
+// usageUpdateAttempts is how often an update of the usage document is
+// tried when it keeps conflicting with updates of other processes.
+var usageUpdateAttempts = aws.AttemptStrategy{
+	Min:    10,
+	Total:  10 * time.Second,
+	Delay:  20 * time.Millisecond,
+	Jitter: true,
+}
+
+// update applies change to the latest usage document and writes it back,
+// unless the document was changed in the meantime, in which case it
+// starts over.
+func (self *QuotaBucket) update(change func(usage map[string]int64)) error {
+	var conflict error
+	for attempt := usageUpdateAttempts.Start(); attempt.Next(); {
+		usage, etag, err := self.read()
+		if err != nil {
+			return err
+		}
+		change(usage)
+		data, err := json.Marshal(usage)
+		if err != nil {
+			return err
+		}
+		headers := map[string][]string{
+			"Content-Type": {"application/json"},
+		}
+		if etag == "" {
+			headers["If-None-Match"] = []string{"*"}
+		} else {
+			headers["If-Match"] = []string{etag}
+		}
+		err = self.bucket.PutHeader(self.UsagePath, data, headers, Private)
+		if err == nil {
+			self.cache(usage)
+			return nil
+		}
+		if !hasCode(err, "PreconditionFailed") && !hasCode(err, "ConditionalRequestConflict") {
+			return err
+		}
+		conflict = err
+	}
+	return conflict
+}
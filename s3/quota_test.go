@@ -0,0 +1,134 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQuotaBucketEnforcesQuota(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	quota := bucket.WithQuotas(map[string]int64{"tenant/": 10})
+
+	if err := quota.Put("tenant/a", []byte("123456"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	err := quota.Put("tenant/b", []byte("123456"), "text/plain", Private)
+	if e, ok := err.(*QuotaExceededError); !ok || e.Used != 6 || e.Requested != 6 {
+		t.Fatalf("got %v", err)
+	}
+	// Overwriting only counts the growth.
+	if err := quota.Put("tenant/a", []byte("1234567890"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := quota.Put("other/b", []byte("unlimited"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := quota.Del("tenant/a"); err != nil {
+		t.Fatal(err)
+	}
+	if used, err := quota.Usage("tenant/"); err != nil || used != 0 {
+		t.Fatalf("got %d, %v", used, err)
+	}
+}
+
+func TestQuotaBucketReloadsUsage(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	quotas := map[string]int64{"tenant/": 10}
+	first := bucket.WithQuotas(quotas)
+	second := bucket.WithQuotas(quotas)
+	second.ReloadInterval = time.Nanosecond
+
+	// Load the usage before the first writes.
+	if used, err := second.Usage("tenant/"); err != nil || used != 0 {
+		t.Fatalf("got %d, %v", used, err)
+	}
+	if err := first.Put("tenant/a", []byte("123456"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := second.Put("tenant/b", []byte("123456"), "text/plain", Private).(*QuotaExceededError); !ok {
+		t.Fatal("second QuotaBucket did not see the usage of the first")
+	}
+}
+
+func TestQuotaBucketKeepsUpdatesOfOtherProcesses(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	quotas := map[string]int64{"tenant/": 100}
+	first := bucket.WithQuotas(quotas)
+	second := bucket.WithQuotas(quotas)
+
+	// Both cache the usage before either writes.
+	for _, quota := range []*QuotaBucket{first, second} {
+		if _, err := quota.Usage("tenant/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := first.Put("tenant/a", []byte("123456"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Put("tenant/b", []byte("1234"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Del("tenant/a"); err != nil {
+		t.Fatal(err)
+	}
+	if used, err := bucket.WithQuotas(quotas).Usage("tenant/"); err != nil || used != 4 {
+		t.Fatalf("got %d, %v", used, err)
+	}
+}
+
+// gatedTransport holds back requests to paths ending in suffix until
+// released, and signals the first one.
+type gatedTransport struct {
+	suffix   string
+	waiting  chan struct{}
+	released chan struct{}
+	once     sync.Once
+}
+
+func (self *gatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == "PUT" && strings.HasSuffix(req.URL.Path, self.suffix) {
+		self.once.Do(func() { close(self.waiting) })
+		<-self.released
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestQuotaBucketUploadsConcurrently(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	transport := &gatedTransport{
+		suffix:   "/slow",
+		waiting:  make(chan struct{}),
+		released: make(chan struct{}),
+	}
+	bucket.S3.Client = &http.Client{Transport: transport}
+	quota := bucket.WithQuotas(map[string]int64{"tenant/": 10})
+
+	done := make(chan error)
+	go func() {
+		done <- quota.Put("tenant/slow", []byte("123456"), "text/plain", Private)
+	}()
+	<-transport.waiting
+
+	// Other uploads proceed while the slow one is in progress, and
+	// count it against the quota.
+	if err := quota.Put("tenant/fast", []byte("1234"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	err := quota.Put("tenant/more", []byte("1"), "text/plain", Private)
+	if e, ok := err.(*QuotaExceededError); !ok || e.Used != 10 {
+		t.Fatalf("got %v", err)
+	}
+
+	close(transport.released)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if used, err := quota.Usage("tenant/"); err != nil || used != 10 {
+		t.Fatalf("got %d, %v", used, err)
+	}
+}
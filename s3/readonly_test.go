@@ -0,0 +1,53 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReadOnly(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	if err := bucket.Put("key", []byte("data"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	transport := &countingTransport{}
+	bucket.S3.Client = &http.Client{Transport: transport}
+	bucket.S3.ReadOnly = true
+
+	mutations := map[string]func() error{
+		"Put":       func() error { return bucket.Put("key", []byte("changed"), "text/plain", Private) },
+		"Del":       func() error { return bucket.Del("key") },
+		"PutBucket": func() error { return bucket.PutBucket(Private) },
+		"DelBucket": func() error { return bucket.DelBucket() },
+	}
+	for name, mutate := range mutations {
+		if err := mutate(); err != ErrReadOnly {
+			t.Errorf("%s: got %v, want ErrReadOnly", name, err)
+		}
+	}
+	if requests, _ := transport.counts(); requests != 0 {
+		t.Fatalf("refused requests were sent: %d", requests)
+	}
+
+	if data, err := bucket.Get("key"); err != nil || string(data) != "data" {
+		t.Errorf("Get: got %q, %v", data, err)
+	}
+	if _, err := bucket.Head("key"); err != nil {
+		t.Errorf("Head: %v", err)
+	}
+	if result, err := bucket.List("", "", "", 0); err != nil || len(result.Contents) != 1 {
+		t.Errorf("List: got %+v, %v", result, err)
+	}
+	// Signed URLs are for reading and still work.
+	resp, err := http.Get(bucket.SignedURL("key", time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("GET of the signed URL: %s", resp.Status)
+	}
+}
@@ -1,13 +1,17 @@
 package s3
 
 import (
+  "context"
   "net/url"
+  "time"
   "net/http"
   "io"
   "fmt"
+  "strings"
 )
 
 type request struct {
+	ctx      context.Context // nil means context.Background()
 	method   string
 	bucket   string
 	path     string
@@ -16,7 +20,96 @@ type request struct {
 	headers  http.Header
 	baseurl  string
 	payload  io.Reader
+	expires  time.Time // if set, the request is presigned until then
 	prepared bool
+	transfer bool // uploads or downloads object data; see Timeouts
+
+	operation string // name of the S3 API operation, for metrics
+	attempts  int    // number of times the request has been sent
+}
+
+// context returns the context the request is made in.
+func (self *request) context() context.Context {
+	if self.ctx == nil {
+		return context.Background()
+	}
+	return self.ctx
+}
+
+// subresources name operations other than the plain object and bucket
+// ones, in order of precedence.
+var subresources = []string{
+	"uploads", "uploadId", "delete", "acl", "versions", "versioning",
+	"location", "lifecycle", "policy", "website", "logging", "notification",
+	"analytics", "metrics", "tagging", "cors",
+}
+
+// operationName derives the name of the S3 API operation req performs
+// from its method, path and query, e.g. "PutObject" or
+// "GetBucketLifecycle". It must be called before the bucket is added to
+// the path.
+func operationName(req *request) string {
+	sub := ""
+	for _, name := range subresources {
+		if _, ok := req.params[name]; ok {
+			sub = name
+			break
+		}
+	}
+	object := req.path != "/"
+	_, copy := req.headers["x-amz-copy-source"]
+
+	switch {
+	case sub == "uploads" && req.method == "GET":
+		return "ListMultipartUploads"
+	case sub == "uploads":
+		return "CreateMultipartUpload"
+	case sub == "uploadId":
+		switch req.method {
+		case "PUT":
+			if copy {
+				return "UploadPartCopy"
+			}
+			return "UploadPart"
+		case "POST":
+			return "CompleteMultipartUpload"
+		case "DELETE":
+			return "AbortMultipartUpload"
+		}
+		return "ListParts"
+	case sub == "delete" && req.method == "POST":
+		return "DeleteObjects"
+	case sub == "versions":
+		return "ListObjectVersions"
+	case sub == "location":
+		return "GetBucketLocation"
+	}
+
+	verb := map[string]string{
+		"GET":    "Get",
+		"HEAD":   "Head",
+		"PUT":    "Put",
+		"POST":   "Post",
+		"DELETE": "Delete",
+	}[req.method]
+	if sub != "" {
+		sub = strings.ToUpper(sub[:1]) + sub[1:]
+		if object {
+			return verb + "Object" + sub
+		}
+		return verb + "Bucket" + sub
+	}
+	switch {
+	case object && copy:
+		return "CopyObject"
+	case object:
+		return verb + "Object"
+	case req.method == "GET":
+		return "ListObjects"
+	case req.method == "PUT":
+		return "CreateBucket"
+	}
+	return verb + "Bucket"
 }
 
 /**
@@ -0,0 +1,83 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// ResumeGet downloads the object at path into the file localFile. If
+// localFile holds a partial download of the same object left behind by an
+// earlier, interrupted ResumeGet, only the missing bytes are fetched.
+//
+// The ETag of the object being downloaded is kept next to localFile in
+// localFile + ".etag" while the download is incomplete. Resuming sends it
+// in an If-Range header, so if the object changed in the meantime S3
+// returns the whole new object and the local file is rewritten from the
+// start.
+func (self *Bucket) ResumeGet(path, localFile string) error {
+	etagFile := localFile + ".etag"
+
+	file, err := os.OpenFile(localFile, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	offset := info.Size()
+
+	headers := make(http.Header)
+	etag, err := ioutil.ReadFile(etagFile)
+	if err == nil && len(etag) > 0 && offset > 0 {
+		headers.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		headers.Set("If-Range", string(etag))
+	} else {
+		offset = 0
+	}
+
+	resp, err := self.getResponse(context.Background(), path, headers)
+	if hasCode(err, "InvalidRange") {
+		// Nothing left to fetch: the partial file is already complete.
+		return os.Remove(etagFile)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		// Full content, either because nothing was downloaded yet or
+		// because the object changed since the partial download.
+		offset = 0
+		if err := file.Truncate(0); err != nil {
+			return err
+		}
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		if err := ioutil.WriteFile(etagFile, []byte(etag), 0666); err != nil {
+			return err
+		}
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	err = os.Remove(etagFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
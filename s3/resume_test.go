@@ -0,0 +1,73 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResumeGet(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	if err := bucket.Put("key", []byte("0123456789"), "", Private); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := bucket.GetResponse("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	dir := t.TempDir()
+	local := filepath.Join(dir, "key")
+	check := func(want string) {
+		t.Helper()
+		data, err := ioutil.ReadFile(local)
+		if err != nil || string(data) != want {
+			t.Fatalf("got %q, %v, want %q", data, err, want)
+		}
+		if _, err := os.Stat(local + ".etag"); !os.IsNotExist(err) {
+			t.Fatalf("etag file left behind: %v", err)
+		}
+	}
+
+	// A fresh download.
+	if err := bucket.ResumeGet("key", local); err != nil {
+		t.Fatal(err)
+	}
+	check("0123456789")
+
+	// A partial download of the same object is completed, which only
+	// works with a ranged request, as the local bytes differ.
+	ioutil.WriteFile(local, []byte("abcd"), 0666)
+	ioutil.WriteFile(local+".etag", []byte(etag), 0666)
+	if err := bucket.ResumeGet("key", local); err != nil {
+		t.Fatal(err)
+	}
+	check("abcd456789")
+
+	// A partial download of a since changed object is started over.
+	ioutil.WriteFile(local, []byte("abcdefghijklmnop"), 0666)
+	ioutil.WriteFile(local+".etag", []byte(`"stale"`), 0666)
+	if err := bucket.ResumeGet("key", local); err != nil {
+		t.Fatal(err)
+	}
+	check("0123456789")
+
+	// A complete download that was not cleaned up is left alone.
+	ioutil.WriteFile(local, []byte("abcdefghij"), 0666)
+	ioutil.WriteFile(local+".etag", []byte(etag), 0666)
+	if err := bucket.ResumeGet("key", local); err != nil {
+		t.Fatal(err)
+	}
+	check("abcdefghij")
+
+	// Without an ETag there is nothing to resume from.
+	ioutil.WriteFile(local, []byte("abc"), 0666)
+	if err := bucket.ResumeGet("key", local); err != nil {
+		t.Fatal(err)
+	}
+	check("0123456789")
+}
@@ -12,38 +12,139 @@ package s3
 import (
   "github.com/dkln/go-aws"
 	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
+	"math/rand"
 	"net"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 )
 
-const debug = false
-
 // The S3 type encapsulates operations with an S3 region.
 type S3 struct {
 	aws.Auth
 	aws.Region
 	private byte // Reserve the right of using private data.
+
+	// Timeouts bounds the duration of requests by operation class.
+	Timeouts Timeouts
+
+	// Client sends the requests. If nil, a client shared by all S3 values
+	// is used, which keeps connections alive between requests. A
+	// custom client controls timeouts, proxies and instrumentation; to
+	// only change the transport, set a client with that RoundTripper.
+	Client *http.Client
+
+	// Signer authenticates the requests. If nil, an aws.V4Signer is used
+	// in regions that only accept Signature Version 4 and V2Signer
+	// elsewhere.
+	Signer aws.Signer
+
+	// ReadOnly makes every request other than GET and HEAD fail with
+	// ErrReadOnly before anything is sent, so tooling can be pointed at
+	// production data without any risk of modifying it.
+	ReadOnly bool
+
+	// Resolver, if set, finds the endpoint requests are sent to instead
+	// of the S3 endpoints of Region. Buckets are then addressed by path.
+	Resolver aws.EndpointResolver
+
+	// Attempts, if set, replaces DefaultAttempts as the strategy for
+	// retrying failed requests.
+	Attempts *aws.AttemptStrategy
+
+	// Limiter, if set, limits the rate of requests, retries included.
+	Limiter *aws.RateLimiter
+
+	// Logger, if set, receives a log of the requests made, in as much
+	// detail as LogLevel asks for. Both may be changed at any time.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Metrics, if set, receives the metrics of every request. With
+	// TraceTimings set they include where the time of the request went.
+	Metrics      aws.MetricsCollector
+	TraceTimings bool
+
+	// Hooks are called at fixed points of every request.
+	Hooks *aws.Hooks
+
+	// Credentials, if set, supply the keys every request is signed with
+	// instead of Auth. Credentials that expire, such as those of an
+	// instance role or an assumed role, are then refreshed as needed
+	// without recreating the S3 value.
+	Credentials *aws.Credentials
+}
+
+// ErrReadOnly is returned for mutating requests made through an S3 with
+// ReadOnly set.
+var ErrReadOnly = errors.New("s3: mutating request refused by read-only client")
+
+// Timeouts holds the maximum duration of a single request, including
+// reading the response body, per class of operation. Metadata operations
+// (HEAD, listings, deletes, bucket configuration) should complete
+// quickly, while transferring object data may legitimately take hours.
+// A zero duration means no timeout.
+type Timeouts struct {
+	Metadata time.Duration
+	Transfer time.Duration
+}
+
+// DefaultTimeouts are the timeouts of S3 values created with NewS3.
+var DefaultTimeouts = Timeouts{
+	Metadata: 30 * time.Second,
 }
 
-var attempts = aws.AttemptStrategy{
-	Min:   5,
-	Total: 5 * time.Second,
-	Delay: 200 * time.Millisecond,
+// DefaultAttempts is the retry strategy of S3 values without Attempts.
+var DefaultAttempts = aws.AttemptStrategy{
+	Min:        5,
+	Total:      5 * time.Second,
+	Delay:      200 * time.Millisecond,
+	Multiplier: 2,
+	MaxDelay:   2 * time.Second,
+	Jitter:     true,
 }
 
 // New creates a new S3.
 func NewS3(auth aws.Auth, region aws.Region) *S3 {
-	return &S3{auth, region, 0}
+	return &S3{Auth: auth, Region: region, Timeouts: DefaultTimeouts}
+}
+
+// NewS3Endpoint creates a new S3 for an S3 compatible service, such as
+// MinIO, Ceph RGW or localstack, listening at endpoint. The endpoint is a
+// URL with a scheme and host and optionally a port, for example
+// "http://localhost:9000"; it must not have a path. Buckets are always
+// addressed path-style. regionName is the region the service considers
+// itself to be in and defaults to "us-east-1".
+func NewS3Endpoint(auth aws.Auth, endpoint, regionName string) (*S3, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("bad S3 endpoint URL %q: %v", endpoint, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("bad S3 endpoint URL %q: want http(s)://host[:port]", endpoint)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		return nil, fmt.Errorf("bad S3 endpoint URL %q: must not have a path or query", endpoint)
+	}
+	if regionName == "" {
+		regionName = aws.USEast.Name
+	}
+	region := aws.Region{
+		Name:                 regionName,
+		S3Endpoint:           u.Scheme + "://" + u.Host,
+		S3LocationConstraint: regionName != aws.USEast.Name,
+	}
+	return NewS3(auth, region), nil
 }
 
 // Bucket returns a Bucket with the given name.
@@ -81,12 +182,30 @@ func (self *S3) query(req *request, resp interface{}) error {
 		var httpResponse *http.Response
 		httpResponse, err = self.run(req, resp)
 		if resp == nil && httpResponse != nil {
-			httpResponse.Body.Close()
+			closeBody(httpResponse)
 		}
 	}
 	return err
 }
 
+// setXMLPayload marshals v as the XML payload of req, adding the
+// Content-Length and Content-MD5 headers S3 requires for configuration
+// documents.
+func setXMLPayload(req *request, v interface{}) error {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(data)
+	if req.headers == nil {
+		req.headers = make(http.Header)
+	}
+	req.headers["Content-Length"] = []string{strconv.Itoa(len(data))}
+	req.headers["Content-MD5"] = []string{base64.StdEncoding.EncodeToString(sum[:])}
+	req.payload = bytes.NewReader(data)
+	return nil
+}
+
 // prepare sets up req to be delivered to S3.
 func (self *S3) prepare(req *request) error {
 	if !req.prepared {
@@ -109,7 +228,16 @@ func (self *S3) prepare(req *request) error {
 			req.path = "/" + req.path
 		}
 		req.signpath = req.path
-		if req.bucket != "" {
+		req.operation = operationName(req)
+		if req.bucket != "" && self.Resolver != nil {
+			endpoint, err := self.Resolver.ResolveEndpoint("s3", self.Region.Name)
+			if err != nil {
+				return err
+			}
+			req.baseurl = endpoint.URL
+			req.path = "/" + req.bucket + req.path
+			req.signpath = "/" + req.bucket + req.signpath
+		} else if req.bucket != "" {
 			req.baseurl = self.Region.S3BucketEndpoint
 			if req.baseurl == "" {
 				// Use the path method to address the bucket.
@@ -126,90 +254,311 @@ func (self *S3) prepare(req *request) error {
 		}
 	}
 
+	if self.ReadOnly && req.method != "GET" && req.method != "HEAD" {
+		return ErrReadOnly
+	}
+
 	// Always sign again as it's not clear how far the
 	// server has handled a previous attempt.
-	u, err := url.Parse(req.baseurl)
+	u, err := req.url()
 	if err != nil {
-		return fmt.Errorf("bad S3 endpoint URL %q: %v", req.baseurl, err)
+		return err
 	}
 	req.headers["Host"] = []string{u.Host}
 	req.headers["Date"] = []string{time.Now().In(time.UTC).Format(time.RFC1123)}
-	sign(self.Auth, req.method, req.signpath, req.params, req.headers)
+
+	hreq := &http.Request{
+		Method: req.method,
+		URL:    u,
+		Header: req.headers,
+		Host:   u.Host,
+	}
+	hreq = hreq.WithContext(context.WithValue(req.context(), canonicalResourceKey{}, req.signpath))
+	if err := self.Hooks.RunBeforeSign(hreq); err != nil {
+		return err
+	}
+	auth, err := self.auth(req.context())
+	if err != nil {
+		return err
+	}
+	if req.expires.IsZero() {
+		err = self.signer().Sign(hreq, auth)
+	} else {
+		err = self.signer().Presign(hreq, auth, req.expires)
+	}
+	if err != nil {
+		return err
+	}
+	req.params = hreq.URL.Query()
+	req.headers = hreq.Header
 	return nil
 }
 
+// auth returns the keys to sign requests with.
+func (self *S3) auth(ctx context.Context) (aws.Auth, error) {
+	if self.Credentials != nil {
+		return self.Credentials.GetWithContext(ctx)
+	}
+	return self.Auth, nil
+}
+
+func (self *S3) attempts() aws.AttemptStrategy {
+	if self.Attempts != nil {
+		return *self.Attempts
+	}
+	return DefaultAttempts
+}
+
+// logf logs a message at level, if the LogLevel asks for it.
+func (self *S3) logf(level aws.LogLevel, format string, args ...interface{}) {
+	aws.Logf(self.Logger, self.LogLevel, level, format, args...)
+}
+
+func (self *S3) signer() aws.Signer {
+	if self.Signer != nil {
+		return self.Signer
+	}
+	if self.Region.SigV4Only {
+		signer := &aws.V4Signer{Region: self.Region.Name, Service: "s3"}
+		if self.Resolver != nil {
+			endpoint, err := self.Resolver.ResolveEndpoint("s3", self.Region.Name)
+			if err == nil && endpoint.SigningRegion != "" {
+				signer.Region = endpoint.SigningRegion
+			}
+		}
+		return signer
+	}
+	signer := V2Signer{}
+	if self.LogLevel >= aws.LogDebug {
+		signer.Logger = self.Logger
+	}
+	return signer
+}
+
 // run sends req and returns the http response from the server.
 // If resp is not nil, the XML data contained in the response
 // body will be unmarshalled on it.
 func (self *S3) run(req *request, resp interface{}) (*http.Response, error) {
-	if debug {
-		log.Printf("Running S3 request: %#v", req)
-	}
-
 	u, err := req.url()
 	if err != nil {
 		return nil, err
 	}
 
-	hreq := http.Request{
-		URL:        u,
-		Method:     req.method,
-		ProtoMajor: 1,
-		ProtoMinor: 1,
-		Close:      true,
-		Header:     req.headers,
+	var body io.Reader
+	if req.payload != nil {
+		// Hide the payload's concrete type, so the http package neither
+		// closes it nor replays it behind our back.
+		body = ioutil.NopCloser(req.payload)
 	}
+	hreq, err := http.NewRequest(req.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	hreq.Header = req.headers
 
+	known := true
 	if v, ok := req.headers["Content-Length"]; ok {
+		// Kept in req.headers so a retried request has it too; the
+		// http package takes the length from ContentLength only.
 		hreq.ContentLength, _ = strconv.ParseInt(v[0], 10, 64)
-		delete(req.headers, "Content-Length")
+	} else if sized, ok := req.payload.(interface {
+		Len() int
+	}); ok {
+		hreq.ContentLength = int64(sized.Len())
+	} else {
+		known = req.payload == nil
 	}
-	if req.payload != nil {
-		hreq.Body = ioutil.NopCloser(req.payload)
+	if known && hreq.ContentLength == 0 {
+		hreq.Body = nil
+	}
+
+	timeout := self.Timeouts.Metadata
+	if req.transfer {
+		timeout = self.Timeouts.Transfer
+	}
+	ctx := req.context()
+	cancel := func() {}
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	hreq = hreq.WithContext(ctx)
+
+	aws.SetUserAgent(hreq)
+	if err := self.Hooks.RunBeforeSend(hreq); err != nil {
+		cancel()
+		return nil, err
 	}
+	if err := self.Limiter.Wait(req.context()); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	self.logf(aws.LogRequest, "S3 %s %s", req.method, aws.RedactURL(u))
+	self.logf(aws.LogWire, "S3 request headers:%s", aws.FormatHeader(hreq.Header))
+
+	metrics := aws.Metrics{
+		Service:   "s3",
+		Operation: req.operation,
+		Attempt:   req.attempts,
+		BytesOut:  hreq.ContentLength,
+	}
+	if !known {
+		metrics.BytesOut = -1
+	}
+	if self.TraceTimings {
+		var traced context.Context
+		traced, metrics.Timings = aws.WithTimings(hreq.Context())
+		hreq = hreq.WithContext(traced)
+	}
+	req.attempts++
 
-	hresp, err := http.DefaultClient.Do(&hreq)
+	start := time.Now()
+	done := aws.TrackRequest()
+	hresp, err := self.httpClient().Do(hreq)
+	self.Hooks.RunAfterResponse(hreq, hresp, err)
 	if err != nil {
+		self.logf(aws.LogRequest, "S3 %s %s -> %v", req.method, aws.RedactURL(u), err)
+		cancel()
+		done()
+		metrics.Latency = time.Since(start)
+		metrics.Err = err
+		self.observe(metrics)
 		return nil, err
 	}
-	if debug {
-		dump, _ := httputil.DumpResponse(hresp, true)
-		log.Printf("} -> %s\n", dump)
+	// The timeout covers reading the body, so it may only be released
+	// once the body has been closed.
+	metrics.StatusCode = hresp.StatusCode
+	counter := &countingReader{ReadCloser: hresp.Body}
+	hresp.Body = &cancelReadCloser{counter, func() {
+		cancel()
+		done()
+		metrics.Latency = time.Since(start)
+		metrics.BytesIn = counter.n
+		self.observe(metrics)
+	}}
+	self.logf(aws.LogRequest, "S3 %s %s -> %s in %v", req.method, aws.RedactURL(u), hresp.Status, time.Since(start))
+	if self.Logger != nil && self.LogLevel >= aws.LogWire {
+		msg := "S3 response headers:" + aws.FormatHeader(hresp.Header)
+		// Object data is not logged, but errors returned for it are.
+		if !req.transfer || hresp.StatusCode >= 300 {
+			data, err := ioutil.ReadAll(hresp.Body)
+			if err != nil {
+				closeBody(hresp)
+				return nil, err
+			}
+			hresp.Body = &bufferedBody{bytes.NewReader(data), hresp.Body}
+			msg += "\n" + string(data)
+		}
+		self.Logger.Log(aws.LogWire, msg)
 	}
-	if hresp.StatusCode != 200 && hresp.StatusCode != 204 {
-		hresp.Body.Close()
-		return nil, buildError(hresp)
+	switch hresp.StatusCode {
+	case 200, 204, 206:
+	case 304:
+		// Only returned for conditional requests, to which it is
+		// a successful answer.
+		return hresp, nil
+	default:
+		err = buildError(hresp)
+		metrics.Err = err
+		if e := err.(*Error); e.RequestId != "" {
+			self.logf(aws.LogRequest, "S3 %s %s failed: %s (request id %s, host id %s)", req.method, aws.RedactURL(u), e.Code, e.RequestId, e.HostId)
+		}
+		closeBody(hresp)
+		if hasCode(err, "ExpiredToken") && self.Credentials != nil {
+			// Refresh early rather than wait for the expiry time we
+			// were told, which the clocks may disagree on.
+			self.Credentials.Expire()
+		}
+		return nil, err
 	}
 	if resp != nil {
 		err = xml.NewDecoder(hresp.Body).Decode(resp)
-		hresp.Body.Close()
+		closeBody(hresp)
 	}
 	return hresp, err
 }
 
-func buildError(r *http.Response) error {
-	if debug {
-		log.Printf("got error (status code %v)", r.StatusCode)
-		data, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			log.Printf("\tread error: %v", err)
-		} else {
-			log.Printf("\tdata:\n%s\n\n", data)
-		}
-		r.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+// defaultClient is shared by all S3 values without a Client of their
+// own, so connections to S3 are kept alive and reused across requests.
+// Its connections are counted in aws.Diagnostics; retries are left to
+// the S3 methods.
+var defaultClient = aws.NewSingleTryClient(30*time.Second,
+	aws.WithMaxIdleConns(100),
+	aws.WithMaxIdleConnsPerHost(32),
+	aws.WithIdleConnTimeout(90*time.Second))
+
+func (self *S3) httpClient() *http.Client {
+	if self.Client != nil {
+		return self.Client
+	}
+	return defaultClient
+}
+
+// closeBody drains what is left of the response body before closing it,
+// which allows the connection to be reused.
+func closeBody(resp *http.Response) {
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+func (self *S3) observe(metrics aws.Metrics) {
+	if self.Metrics != nil {
+		self.Metrics.Observe(metrics)
 	}
+}
+
+// countingReader counts the bytes read from a response body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
 
+func (self *countingReader) Read(p []byte) (int, error) {
+	n, err := self.ReadCloser.Read(p)
+	self.n += int64(n)
+	return n, err
+}
+
+// bufferedBody replays a response body that has been read for logging
+// and closes the original.
+type bufferedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// cancelReadCloser calls cancel once the wrapped body has been closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (self *cancelReadCloser) Close() error {
+	err := self.ReadCloser.Close()
+	if self.cancel != nil {
+		self.cancel()
+		self.cancel = nil
+	}
+	return err
+}
+
+// buildError returns the error described by the body of r, which the
+// caller must close.
+func buildError(r *http.Response) error {
 	err := Error{}
 	// TODO return error if Unmarshal fails?
 	xml.NewDecoder(r.Body).Decode(&err)
-	r.Body.Close()
 	err.StatusCode = r.StatusCode
+	err.retryAfter, _ = aws.RetryAfter(r)
+	// Responses to HEAD requests have no body to take these from.
+	if err.RequestId == "" {
+		err.RequestId = r.Header.Get("x-amz-request-id")
+	}
+	if err.HostId == "" {
+		err.HostId = r.Header.Get("x-amz-id-2")
+	}
 	if err.Message == "" {
 		err.Message = r.Status
 	}
-	if debug {
-		log.Printf("err: %#v\n", err)
-	}
 	return &err
 }
 
@@ -234,10 +583,62 @@ func shouldRetry(err error) bool {
 		case "InternalError", "NoSuchUpload", "NoSuchBucket":
 			return true
 		}
+		if isThrottle(err) {
+			return true
+		}
 	}
 	return false
 }
 
+// isThrottle reports whether err is S3 asking the client to slow down.
+func isThrottle(err error) bool {
+	e, ok := err.(*Error)
+	if !ok {
+		return false
+	}
+	if aws.IsThrottleCode(e.Code) {
+		return true
+	}
+	return e.StatusCode == 503 || e.StatusCode == 429
+}
+
+const (
+	throttleBaseDelay = 200 * time.Millisecond
+	throttleMaxDelay  = 20 * time.Second
+)
+
+// retryBackoff must be called before retrying a request that failed with
+// err. Throttled requests sleep for a delay that grows exponentially with
+// try, the zero based number of the attempt that failed, and is fully
+// jittered so parallel clients spread out instead of retrying in lockstep.
+// The sleep ends early when ctx is done. Other errors return immediately;
+// the regular attempt strategy delay applies to those.
+func (self *S3) retryBackoff(ctx context.Context, err error, try int) {
+	aws.TrackRetry()
+	self.Hooks.RunOnRetry(ctx, err, try)
+	if !isThrottle(err) {
+		return
+	}
+	if e := err.(*Error); e.retryAfter > 0 {
+		// Wait as long as S3 asked, within reason.
+		delay := e.retryAfter
+		if delay > throttleMaxDelay {
+			delay = throttleMaxDelay
+		}
+		aws.SleepWithContext(ctx, delay)
+		return
+	}
+	aws.SleepWithContext(ctx, throttleDelay(try))
+}
+
+func throttleDelay(try int) time.Duration {
+	max := throttleMaxDelay
+	if try < 16 && throttleBaseDelay<<uint(try) < max {
+		max = throttleBaseDelay << uint(try)
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
 func hasCode(err error, code string) bool {
 	s3err, ok := err.(*Error)
 	return ok && s3err.Code == code
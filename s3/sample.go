@@ -0,0 +1,99 @@
+package s3
+
+import (
+	"math/rand"
+	"sort"
+	"unicode/utf8"
+)
+
+// SampleKeys returns up to n keys under prefix picked approximately
+// uniformly at random, without listing the whole bucket.
+//
+// Small listings are sampled exactly. For larger ones, random markers are
+// generated from the characters seen in the first page of keys and the
+// key following each marker is taken, one listing request per sample.
+// Keys that follow long runs of unused key space are picked more often,
+// so the result suits quick profiling and spot checks, not statistics.
+// The keys are returned in lexical order.
+func (self *Bucket) SampleKeys(prefix string, n int) ([]Key, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	first, err := self.List(prefix, "", "", 1000)
+	if err != nil {
+		return nil, err
+	}
+	if !first.IsTruncated {
+		keys := first.Contents
+		rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		if len(keys) > n {
+			keys = keys[:n]
+		}
+		sortKeys(keys)
+		return keys, nil
+	}
+
+	// Gather the alphabet and length of key suffixes seen so far. Both are
+	// counted in runes, so that markers are valid UTF-8 like the keys.
+	var lo, hi rune = utf8.MaxRune, 0
+	maxLen := 0
+	for _, key := range first.Contents {
+		suffix := []rune(key.Key[len(prefix):])
+		if len(suffix) > maxLen {
+			maxLen = len(suffix)
+		}
+		for _, r := range suffix {
+			if r < lo {
+				lo = r
+			}
+			if r > hi {
+				hi = r
+			}
+		}
+	}
+	if lo > hi {
+		lo, hi = 0, 0
+	}
+	markerLen := maxLen
+	if markerLen > 16 {
+		markerLen = 16
+	}
+
+	seen := make(map[string]bool)
+	var keys []Key
+	for tries := 0; len(keys) < n && tries < 3*n; tries++ {
+		suffix := make([]rune, markerLen)
+		for i := range suffix {
+			suffix[i] = randomRune(lo, hi)
+		}
+		resp, err := self.List(prefix, "", prefix+string(suffix), 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Contents) == 0 {
+			// Past the last key; wrap around to the first one.
+			resp.Contents = first.Contents[:1]
+		}
+		key := resp.Contents[0]
+		if !seen[key.Key] {
+			seen[key.Key] = true
+			keys = append(keys, key)
+		}
+	}
+	sortKeys(keys)
+	return keys, nil
+}
+
+// randomRune returns a valid rune between lo and hi. Surrogate halves,
+// which cannot be encoded in UTF-8, are replaced by the next valid rune.
+func randomRune(lo, hi rune) rune {
+	r := lo + rune(rand.Intn(int(hi-lo)+1))
+	if !utf8.ValidRune(r) {
+		r = 0xe000
+	}
+	return r
+}
+
+func sortKeys(keys []Key) {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+}
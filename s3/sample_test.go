@@ -0,0 +1,42 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"fmt"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRandomRuneIsValidUTF8(t *testing.T) {
+	for _, bounds := range [][2]rune{{'a', 'z'}, {'é', '日'}, {0xd000, 0xe100}} {
+		for i := 0; i < 1000; i++ {
+			r := randomRune(bounds[0], bounds[1])
+			if !utf8.ValidString(string(r)) || string(r) == "�" {
+				t.Fatalf("invalid rune %U for %U-%U", r, bounds[0], bounds[1])
+			}
+		}
+	}
+}
+
+func TestSampleKeysMultibyte(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	for i := 0; i < 1100; i++ {
+		key := fmt.Sprintf("ключ/%c%04d", 'а'+rune(i%32), i)
+		if err := bucket.Put(key, nil, "", Private); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, err := bucket.SampleKeys("ключ/", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) == 0 {
+		t.Fatal("no keys sampled")
+	}
+	for _, key := range keys {
+		if !utf8.ValidString(key.Key) {
+			t.Fatalf("sampled invalid key %q", key.Key)
+		}
+	}
+}
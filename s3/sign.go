@@ -5,9 +5,11 @@ import (
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
-	"log"
+	"net/http"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var b64 = base64.StdEncoding
@@ -17,8 +19,11 @@ var b64 = base64.StdEncoding
 
 var s3ParamsToSign = map[string]bool{
 	"acl":                          true,
+	"analytics":                    true,
+	"lifecycle":                    true,
 	"location":                     true,
 	"logging":                      true,
+	"metrics":                      true,
 	"notification":                 true,
 	"partNumber":                   true,
 	"policy":                       true,
@@ -37,16 +42,55 @@ var s3ParamsToSign = map[string]bool{
 	"response-content-encoding":    true,
 }
 
-func sign(auth aws.Auth, method, canonicalPath string, params, headers map[string][]string) {
+// V2Signer signs requests with the S3 flavor of AWS Signature Version 2.
+// It is the default Signer of S3 values.
+type V2Signer struct {
+	// Logger, if set, receives the string to sign of every request at
+	// aws.LogDebug, to troubleshoot signature mismatches.
+	Logger aws.Logger
+}
+
+// canonicalResourceKey is the context key under which S3 passes the
+// bucket-qualified resource path that Signature Version 2 signs, which
+// differs from the URL path for virtual hosted buckets.
+type canonicalResourceKey struct{}
+
+func canonicalResource(req *http.Request) string {
+	if resource, ok := req.Context().Value(canonicalResourceKey{}).(string); ok {
+		return resource
+	}
+	return req.URL.Path
+}
+
+// Sign adds an Authorization header to req.
+func (self V2Signer) Sign(req *http.Request, auth aws.Auth) error {
+	if auth.Token != "" {
+		// Temporary credentials must present their session token.
+		req.Header["X-Amz-Security-Token"] = []string{auth.Token}
+	}
+	payload := sign(auth, req.Method, canonicalResource(req), req.URL.Query(), req.Header)
+	aws.Logf(self.Logger, aws.LogDebug, aws.LogDebug, "S3 string to sign: %q", payload)
+	return nil
+}
+
+// Presign adds query string authentication to req, valid until expires.
+func (self V2Signer) Presign(req *http.Request, auth aws.Auth, expires time.Time) error {
+	params := req.URL.Query()
+	params["Expires"] = []string{strconv.FormatInt(expires.Unix(), 10)}
+	if auth.Token != "" {
+		params["x-amz-security-token"] = []string{auth.Token}
+	}
+	payload := sign(auth, req.Method, canonicalResource(req), params, req.Header)
+	aws.Logf(self.Logger, aws.LogDebug, aws.LogDebug, "S3 string to sign: %q", payload)
+	req.URL.RawQuery = params.Encode()
+	return nil
+}
+
+func sign(auth aws.Auth, method, canonicalPath string, params, headers map[string][]string) string {
 	var md5, ctype, date, xamz string
 	var xamzDate bool
 	var sarray []string
 
-  // add security token
-  if auth.Token != "" {
-    headers["x-amz-security-token"] = []string{auth.Token}
-  }
-
 	for k, v := range headers {
 		k = strings.ToLower(k)
 		switch k {
@@ -69,17 +113,25 @@ func sign(auth aws.Auth, method, canonicalPath string, params, headers map[strin
 			}
 		}
 	}
-	if len(sarray) > 0 {
-		sort.StringSlice(sarray).Sort()
-		xamz = strings.Join(sarray, "\n") + "\n"
-	}
-
 	expires := false
 	if v, ok := params["Expires"]; ok {
 		// Query string request authentication alternative.
 		expires = true
 		date = v[0]
 		params["AWSAccessKeyId"] = []string{auth.AccessKey}
+
+		// x-amz-* query parameters (such as the session token) are
+		// signed as if they were headers.
+		for k, v := range params {
+			k = strings.ToLower(k)
+			if strings.HasPrefix(k, "x-amz-") {
+				sarray = append(sarray, k+":"+strings.Join(v, ","))
+			}
+		}
+	}
+	if len(sarray) > 0 {
+		sort.StringSlice(sarray).Sort()
+		xamz = strings.Join(sarray, "\n") + "\n"
 	}
 
 	sarray = sarray[0:0]
@@ -111,8 +163,5 @@ func sign(auth aws.Auth, method, canonicalPath string, params, headers map[strin
 	} else {
 		headers["Authorization"] = []string{"AWS " + auth.AccessKey + ":" + string(signature)}
 	}
-	if debug {
-		log.Printf("Signature payload: %q", payload)
-		log.Printf("Signature: %q", signature)
-	}
+	return payload
 }
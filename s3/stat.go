@@ -0,0 +1,70 @@
+package s3
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ObjectInfo describes an object, as returned by Stat.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	// ETag gives the hex-encoded MD5 sum of the contents,
+	// surrounded with double-quotes.
+	ETag         string
+	ContentType  string
+	StorageClass string
+	// Metadata holds the user metadata of the object, keyed by the
+	// lower case name without the x-amz-meta- prefix.
+	Metadata map[string]string
+}
+
+// Stat returns information about the object at path without retrieving
+// its contents. If the object does not exist the error is an *Error with
+// StatusCode 404 and Code "NoSuchKey".
+func (self *Bucket) Stat(path string) (*ObjectInfo, error) {
+	resp, err := self.Head(path)
+	if err != nil {
+		// Responses to HEAD have no body, so the code is missing.
+		if s3err, ok := err.(*Error); ok && s3err.StatusCode == 404 && s3err.Code == "" {
+			s3err.Code = "NoSuchKey"
+		}
+		return nil, err
+	}
+	return newObjectInfo(path, resp), nil
+}
+
+// Exists reports whether an object exists at path.
+func (self *Bucket) Exists(path string) (bool, error) {
+	_, err := self.Stat(path)
+	if hasCode(err, "NoSuchKey") {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func newObjectInfo(path string, resp *http.Response) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:          strings.TrimPrefix(path, "/"),
+		Size:         resp.ContentLength,
+		ETag:         resp.Header.Get("ETag"),
+		ContentType:  resp.Header.Get("Content-Type"),
+		StorageClass: resp.Header.Get("x-amz-storage-class"),
+		Metadata:     make(map[string]string),
+	}
+	if info.StorageClass == "" {
+		info.StorageClass = "STANDARD"
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = t
+	}
+	for name, values := range resp.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-meta-") && len(values) > 0 {
+			info.Metadata[name[len("x-amz-meta-"):]] = values[0]
+		}
+	}
+	return info
+}
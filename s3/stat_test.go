@@ -0,0 +1,70 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// statusTransport answers every request with its status and no body.
+type statusTransport int
+
+func (self statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: int(self),
+		Status:     http.StatusText(int(self)),
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestStat(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	headers := map[string][]string{
+		"Content-Type":     {"text/plain"},
+		"X-Amz-Meta-Owner": {"alice"},
+	}
+	before := time.Now().Add(-time.Second)
+	if err := bucket.PutHeader("dir/key", []byte("0123456789"), headers, Private); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := bucket.Stat("/dir/key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Key != "dir/key" || info.Size != 10 || info.ContentType != "text/plain" || info.StorageClass != "STANDARD" ||
+		info.ETag != `"781e5e245d69b566979b86e28d23f2c7"` || info.LastModified.Before(before.Truncate(time.Second)) ||
+		len(info.Metadata) != 1 || info.Metadata["owner"] != "alice" {
+		t.Fatalf("got %+v", info)
+	}
+
+	_, err = bucket.Stat("missing")
+	if s3err, ok := err.(*Error); !ok || s3err.StatusCode != 404 || s3err.Code != "NoSuchKey" {
+		t.Fatalf("got %#v", err)
+	}
+}
+
+func TestExists(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	if err := bucket.Put("key", nil, "", Private); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{"key": true, "missing": false} {
+		if exists, err := bucket.Exists(path); err != nil || exists != want {
+			t.Errorf("%s: got %v, %v", path, exists, err)
+		}
+	}
+
+	// Other errors are not taken for a missing object.
+	bucket.S3.Client = &http.Client{Transport: statusTransport(403)}
+	exists, err := bucket.Exists("key")
+	if exists || err == nil {
+		t.Fatalf("got %v, %v", exists, err)
+	}
+}
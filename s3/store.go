@@ -0,0 +1,153 @@
+package s3
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/dkln/go-aws"
+)
+
+// BucketResolver maps the logical bucket names an application uses to the
+// physical bucket, and the region it lives in, that holds the data.
+type BucketResolver interface {
+	ResolveBucket(logical string) (BucketLocation, error)
+}
+
+// BucketLocation identifies a physical bucket.
+type BucketLocation struct {
+	Name   string
+	Region aws.Region
+}
+
+// StaticResolver is a BucketResolver backed by a fixed mapping, typically
+// loaded from the application's configuration.
+type StaticResolver map[string]BucketLocation
+
+// ResolveBucket returns the location configured for logical.
+func (self StaticResolver) ResolveBucket(logical string) (BucketLocation, error) {
+	location, ok := self[logical]
+	if !ok {
+		return BucketLocation{}, fmt.Errorf("s3: no bucket configured for %q", logical)
+	}
+	return location, nil
+}
+
+// Store accesses objects by logical bucket name, resolving the physical
+// bucket on every call. Moving data to another bucket or region then only
+// requires changing the resolver's configuration, not the call sites.
+type Store struct {
+	Auth     aws.Auth
+	Resolver BucketResolver
+
+	// S3, if set, is the client the clients of every region are derived
+	// from, so they share its HTTP client, timeouts, credentials and
+	// instrumentation. If nil, the clients are created with NewS3 and
+	// Auth.
+	S3 *S3
+
+	mu      sync.Mutex
+	clients map[string]*S3 // by region name
+}
+
+// NewStore returns a Store resolving logical buckets with resolver.
+func NewStore(auth aws.Auth, resolver BucketResolver) *Store {
+	return &Store{Auth: auth, Resolver: resolver}
+}
+
+// NewStoreWithS3 returns a Store resolving logical buckets with resolver
+// and accessing them with clients derived from client.
+func NewStoreWithS3(client *S3, resolver BucketResolver) *Store {
+	return &Store{Auth: client.Auth, Resolver: resolver, S3: client}
+}
+
+// Bucket returns the physical bucket for logical.
+func (self *Store) Bucket(logical string) (*Bucket, error) {
+	location, err := self.Resolver.ResolveBucket(logical)
+	if err != nil {
+		return nil, err
+	}
+	return self.client(location.Region).Bucket(location.Name), nil
+}
+
+// client returns the client for region, creating it on first use.
+func (self *Store) client(region aws.Region) *S3 {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if client, ok := self.clients[region.Name]; ok {
+		return client
+	}
+	var client *S3
+	if self.S3 == nil {
+		client = NewS3(self.Auth, region)
+	} else {
+		copy := *self.S3
+		copy.Region = region
+		if signer, ok := copy.Signer.(*aws.V4Signer); ok {
+			copy.Signer = &aws.V4Signer{Region: region.Name, Service: signer.Service}
+		}
+		client = &copy
+	}
+	if self.clients == nil {
+		self.clients = make(map[string]*S3)
+	}
+	self.clients[region.Name] = client
+	return client
+}
+
+// Get retrieves an object from the bucket logical resolves to.
+func (self *Store) Get(logical, path string) ([]byte, error) {
+	bucket, err := self.Bucket(logical)
+	if err != nil {
+		return nil, err
+	}
+	return bucket.Get(path)
+}
+
+// GetReader retrieves an object from the bucket logical resolves to. It is
+// the caller's responsibility to call Close on rc when finished reading.
+func (self *Store) GetReader(logical, path string) (rc io.ReadCloser, err error) {
+	bucket, err := self.Bucket(logical)
+	if err != nil {
+		return nil, err
+	}
+	return bucket.GetReader(path)
+}
+
+// Put inserts an object into the bucket logical resolves to.
+func (self *Store) Put(logical, path string, data []byte, contType string, perm ACL) error {
+	bucket, err := self.Bucket(logical)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(path, data, contType, perm)
+}
+
+// PutReader inserts an object into the bucket logical resolves to by
+// consuming data from r until EOF.
+func (self *Store) PutReader(logical, path string, r io.Reader, length int64, contType string, perm ACL) error {
+	bucket, err := self.Bucket(logical)
+	if err != nil {
+		return err
+	}
+	return bucket.PutReader(path, r, length, contType, perm)
+}
+
+// Del removes an object from the bucket logical resolves to.
+func (self *Store) Del(logical, path string) error {
+	bucket, err := self.Bucket(logical)
+	if err != nil {
+		return err
+	}
+	return bucket.Del(path)
+}
+
+// List lists the objects of the bucket logical resolves to. See
+// Bucket.List for the meaning of the parameters.
+func (self *Store) List(logical, prefix, delim, marker string, max int) (*ListResp, error) {
+	bucket, err := self.Bucket(logical)
+	if err != nil {
+		return nil, err
+	}
+	return bucket.List(prefix, delim, marker, max)
+}
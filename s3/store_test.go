@@ -0,0 +1,59 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/x/s3test"
+)
+
+func TestStoreDerivesAndCachesRegionClients(t *testing.T) {
+	srv := s3test.NewServer()
+	defer srv.Close()
+
+	west := aws.Region{Name: "eu-west-1", S3Endpoint: srv.URL()}
+	east := aws.Region{Name: "us-east-1", S3Endpoint: srv.URL()}
+	template := NewS3(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.Region{})
+	template.Client = &http.Client{}
+	template.Signer = &aws.V4Signer{Region: "ap-south-1", Service: "s3"}
+	store := NewStoreWithS3(template, StaticResolver{
+		"uploads": {Name: "uploads-eu", Region: west},
+		"reports": {Name: "reports-eu", Region: west},
+		"archive": {Name: "archive-us", Region: east},
+	})
+
+	uploads, err := store.Bucket("uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports, _ := store.Bucket("reports")
+	archive, _ := store.Bucket("archive")
+	if uploads.S3 != reports.S3 {
+		t.Fatal("buckets of the same region got different clients")
+	}
+	if uploads.S3 == archive.S3 || archive.S3.Region.Name != "us-east-1" {
+		t.Fatal("buckets of different regions share a client")
+	}
+	if uploads.S3.Client != template.Client {
+		t.Fatal("derived client does not use the template's HTTP client")
+	}
+	if signer := archive.S3.Signer.(*aws.V4Signer); signer.Region != "us-east-1" {
+		t.Fatalf("derived client signs for %s", signer.Region)
+	}
+	if template.Region.Name != "" {
+		t.Fatal("template modified")
+	}
+
+	if err := uploads.PutBucket(Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("uploads", "a", []byte("data"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := store.Get("uploads", "a"); err != nil || string(data) != "data" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,122 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// slowDownTransport answers the first n requests with a SlowDown error
+// and passes the others on.
+type slowDownTransport struct {
+	mu       sync.Mutex
+	n        int
+	requests int
+}
+
+func (self *slowDownTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	self.mu.Lock()
+	self.requests++
+	throttled := self.requests <= self.n
+	self.mu.Unlock()
+	if !throttled {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	body := "<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>"
+	return &http.Response{
+		StatusCode: 503,
+		Status:     "503 Slow Down",
+		Header:     http.Header{"Content-Type": {"application/xml"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestIsThrottle(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&Error{StatusCode: 503, Code: "SlowDown"}, true},
+		{&Error{StatusCode: 400, Code: "RequestLimitExceeded"}, true},
+		{&Error{StatusCode: 400, Code: "Throttling"}, true},
+		{&Error{StatusCode: 503}, true},
+		{&Error{StatusCode: 500, Code: "InternalError"}, false},
+		{&Error{StatusCode: 404, Code: "NoSuchKey"}, false},
+		{errors.New("SlowDown"), false},
+	}
+	for _, test := range tests {
+		if got := isThrottle(test.err); got != test.want {
+			t.Errorf("isThrottle(%v) = %v, want %v", test.err, got, test.want)
+		}
+		if test.want && !shouldRetry(test.err) {
+			t.Errorf("shouldRetry(%v) = false for a throttling error", test.err)
+		}
+	}
+}
+
+func TestThrottleDelay(t *testing.T) {
+	for try := 0; try < 100; try++ {
+		max := throttleMaxDelay
+		if try < 16 && throttleBaseDelay<<uint(try) < max {
+			max = throttleBaseDelay << uint(try)
+		}
+		for i := 0; i < 20; i++ {
+			if d := throttleDelay(try); d < 0 || d >= max {
+				t.Fatalf("throttleDelay(%d) = %v, want within [0, %v)", try, d, max)
+			}
+		}
+	}
+	// The delays are jittered rather than fixed.
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		seen[throttleDelay(5)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("throttleDelay(5) returned the same delay 20 times: %v", seen)
+	}
+}
+
+func TestSlowDownIsRetried(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	if err := bucket.Put("key", []byte("data"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	transport := &slowDownTransport{n: 1}
+	bucket.S3.Client = &http.Client{Transport: transport}
+	bucket.S3.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+
+	data, err := bucket.Get("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "data" {
+		t.Errorf("got %q, want %q", data, "data")
+	}
+	if transport.requests != 2 {
+		t.Errorf("got %d requests, want 2", transport.requests)
+	}
+}
+
+func TestSlowDownGivesUp(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	transport := &slowDownTransport{n: 1 << 30}
+	bucket.S3.Client = &http.Client{Transport: transport}
+	bucket.S3.Attempts = &aws.AttemptStrategy{Min: 2, Delay: time.Millisecond}
+
+	_, err := bucket.Get("key")
+	if code := aws.ErrorCode(err); code != "SlowDown" {
+		t.Fatalf("got error %v, want SlowDown", err)
+	}
+	if transport.requests != 2 {
+		t.Errorf("got %d requests, want 2", transport.requests)
+	}
+}
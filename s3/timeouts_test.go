@@ -0,0 +1,91 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// delayTransport passes requests on after a delay, unless their context
+// is done first.
+type delayTransport time.Duration
+
+func (self delayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case <-time.After(time.Duration(self)):
+		return http.DefaultTransport.RoundTrip(req)
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+func TestTimeoutsByClass(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	if err := bucket.Put("key", []byte("data"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	bucket.S3.Client = &http.Client{Transport: delayTransport(100 * time.Millisecond)}
+	bucket.S3.Attempts = &aws.AttemptStrategy{Min: 1}
+
+	bucket.S3.Timeouts = Timeouts{Metadata: 20 * time.Millisecond}
+	if _, err := bucket.List("", "", "", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("List with a short metadata timeout: got %v", err)
+	}
+	if data, err := bucket.Get("key"); err != nil || string(data) != "data" {
+		t.Errorf("Get without a transfer timeout: got %q, %v", data, err)
+	}
+
+	bucket.S3.Timeouts = Timeouts{Transfer: 20 * time.Millisecond}
+	if _, err := bucket.List("", "", "", 0); err != nil {
+		t.Errorf("List without a metadata timeout: %v", err)
+	}
+	if _, err := bucket.Get("key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get with a short transfer timeout: got %v", err)
+	}
+}
+
+func TestTransferTimeoutCoversBody(t *testing.T) {
+	// The server sends the headers at once but the body only later.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(time.Second):
+			w.Write([]byte("data"))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client, err := NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Timeouts = Timeouts{Transfer: 50 * time.Millisecond}
+
+	rc, err := client.Bucket("bucket").GetReader("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if _, err := ioutil.ReadAll(rc); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("reading a stalled body: got %v", err)
+	}
+}
+
+func TestDefaultTimeouts(t *testing.T) {
+	client := NewS3(aws.Auth{}, aws.USEast)
+	if client.Timeouts != DefaultTimeouts {
+		t.Errorf("NewS3 timeouts = %+v, want %+v", client.Timeouts, DefaultTimeouts)
+	}
+	if DefaultTimeouts.Transfer != 0 {
+		t.Errorf("transfers time out after %v by default", DefaultTimeouts.Transfer)
+	}
+}
@@ -0,0 +1,89 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// tokenTransport records the session token of the requests it passes on.
+type tokenTransport struct {
+	mu     sync.Mutex
+	tokens []string
+}
+
+func (self *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	self.mu.Lock()
+	self.tokens = append(self.tokens, req.Header.Get("X-Amz-Security-Token"))
+	self.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSessionTokenHeader(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	bucket.S3.Auth.Token = "token"
+	transport := &tokenTransport{}
+	bucket.S3.Client = &http.Client{Transport: transport}
+
+	if err := bucket.Put("key", []byte("data"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bucket.Get("key"); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.tokens) != 2 {
+		t.Fatalf("got %d requests, want 2", len(transport.tokens))
+	}
+	for i, token := range transport.tokens {
+		if token != "token" {
+			t.Errorf("request %d: X-Amz-Security-Token = %q, want %q", i, token, "token")
+		}
+	}
+}
+
+func TestSessionTokenIsSigned(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	expires := time.Now().Add(time.Hour)
+
+	signature := func(token string) url.Values {
+		bucket.S3.Auth.Token = token
+		u, err := url.Parse(bucket.SignedURL("key", expires))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.Query()
+	}
+	plain := signature("")
+	if _, ok := plain["x-amz-security-token"]; ok {
+		t.Errorf("signed URL without a token has x-amz-security-token: %v", plain)
+	}
+	first, second := signature("first"), signature("second")
+	if got := first.Get("x-amz-security-token"); got != "first" {
+		t.Errorf("x-amz-security-token = %q, want %q", got, "first")
+	}
+	if first.Get("Signature") == second.Get("Signature") {
+		t.Error("signature does not depend on the session token")
+	}
+	if first.Get("Signature") == plain.Get("Signature") {
+		t.Error("signature with a token equals the one without")
+	}
+}
+
+func TestSessionTokenStringToSign(t *testing.T) {
+	auth := aws.Auth{AccessKey: "a", SecretKey: "s", Token: "token"}
+	params := map[string][]string{
+		"Expires":              {"1175139620"},
+		"x-amz-security-token": {auth.Token},
+	}
+	payload := sign(auth, "GET", "/bucket/key", params, map[string][]string{})
+	if !strings.Contains(payload, "\nx-amz-security-token:token\n/bucket/key") {
+		t.Errorf("string to sign %q lacks the session token", payload)
+	}
+}
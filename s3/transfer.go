@@ -0,0 +1,148 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// MultipartThreshold is the size from which StartPutReader uploads
+// objects in parts of UploadPartSize bytes. Smaller objects are sent in a
+// single request, like PutReader does.
+var MultipartThreshold int64 = 64 << 20
+
+// UploadPartSize is the size of the parts of multipart uploads started by
+// StartPutReader. S3 requires at least 5 MiB for all but the last part.
+var UploadPartSize int64 = 16 << 20
+
+// ErrAborted is returned by Transfer.Wait after Transfer.Abort.
+var ErrAborted = errors.New("s3: transfer aborted")
+
+// Transfer is an upload or download running in the background, as
+// started by StartPutReader and StartGetToWriter.
+type Transfer struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	aborted  bool
+	err      error
+	abortErr error
+}
+
+// startTransfer runs fn in the background under a context derived from
+// ctx.
+func startTransfer(ctx context.Context, fn func(ctx context.Context, t *Transfer) error) *Transfer {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &Transfer{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		err := fn(ctx, t)
+		t.mu.Lock()
+		if t.aborted && err != nil {
+			err = ErrAborted
+		}
+		t.err = err
+		t.mu.Unlock()
+		cancel()
+		close(t.done)
+	}()
+	return t
+}
+
+// Done is closed when the transfer is over.
+func (self *Transfer) Done() <-chan struct{} {
+	return self.done
+}
+
+// Wait waits for the transfer to be over and returns its error, or
+// ErrAborted if it was aborted before it completed.
+func (self *Transfer) Wait() error {
+	<-self.done
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.err
+}
+
+// Abort stops the transfer and waits for it to be over, including a Read
+// of the source of an upload in progress. Unlike cancelling the context
+// of the transfer, it also aborts a multipart upload in S3 before
+// returning, so no parts are left behind to be billed; the returned error
+// tells if that failed. A transfer that completed before Abort is not
+// undone.
+func (self *Transfer) Abort() error {
+	self.mu.Lock()
+	select {
+	case <-self.done:
+	default:
+		self.aborted = true
+	}
+	self.mu.Unlock()
+	self.cancel()
+	<-self.done
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.abortErr
+}
+
+// StartPutReader uploads the length bytes read from r to path in the
+// background, like PutReaderWithContext. Objects of MultipartThreshold
+// bytes or more are uploaded in parts, and the upload is aborted in S3
+// if it fails or is aborted.
+func (self *Bucket) StartPutReader(ctx context.Context, path string, r io.Reader, length int64, contType string, perm ACL) *Transfer {
+	return startTransfer(ctx, func(ctx context.Context, t *Transfer) error {
+		if length < MultipartThreshold {
+			return self.PutReaderWithContext(ctx, path, r, length, contType, perm)
+		}
+		uploadId, err := self.initMulti(ctx, path, contType, perm)
+		if err != nil {
+			return err
+		}
+		if err = self.putParts(ctx, path, uploadId, r, length); err != nil {
+			// Parts are billed until the upload is aborted.
+			if abortErr := self.abortMulti(path, uploadId); abortErr != nil {
+				t.mu.Lock()
+				t.abortErr = abortErr
+				t.mu.Unlock()
+			}
+		}
+		return err
+	})
+}
+
+// putParts uploads the length bytes read from r in parts and completes
+// the multipart upload.
+func (self *Bucket) putParts(ctx context.Context, path, uploadId string, r io.Reader, length int64) error {
+	var parts []completedPart
+	buf := make([]byte, UploadPartSize)
+	for n, left := 1, length; left > 0; n++ {
+		size := UploadPartSize
+		if left < size {
+			size = left
+		}
+		if _, err := io.ReadFull(r, buf[:size]); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		part, err := self.putPart(ctx, path, uploadId, n, buf[:size])
+		if err != nil {
+			return err
+		}
+		parts = append(parts, part)
+		left -= size
+	}
+	return self.completeMulti(ctx, path, uploadId, parts)
+}
+
+// StartGetToWriter downloads the object at path into w in the
+// background, like GetToWriterWithContext.
+func (self *Bucket) StartGetToWriter(ctx context.Context, path string, w io.Writer) *Transfer {
+	return startTransfer(ctx, func(ctx context.Context, t *Transfer) error {
+		_, err := self.GetToWriterWithContext(ctx, path, w)
+		return err
+	})
+}
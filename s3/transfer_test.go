@@ -0,0 +1,145 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dkln/go-aws/x/s3test"
+)
+
+func setPartSizes(t *testing.T, threshold, part int64) {
+	oldThreshold, oldPart := MultipartThreshold, UploadPartSize
+	MultipartThreshold, UploadPartSize = threshold, part
+	t.Cleanup(func() { MultipartThreshold, UploadPartSize = oldThreshold, oldPart })
+}
+
+// pendingUploads lists the multipart uploads of the test bucket.
+func pendingUploads(t *testing.T, srv *s3test.Server) string {
+	resp, err := http.Get(srv.URL() + "/bucket?uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	return string(data)
+}
+
+func TestStartPutReaderMultipart(t *testing.T) {
+	setPartSizes(t, 5<<20, 5<<20)
+	bucket, srv := newTestBucket(t)
+
+	data := bytes.Repeat([]byte("0123456789"), 1<<20+7)
+	transfer := bucket.StartPutReader(context.Background(), "big", bytes.NewReader(data), int64(len(data)), "application/octet-stream", Private)
+	if err := transfer.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := bucket.Get("big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("got %d bytes, want %d", len(got), len(data))
+	}
+	if uploads := pendingUploads(t, srv); strings.Contains(uploads, "<Upload>") {
+		t.Fatalf("uploads left behind: %s", uploads)
+	}
+	if err := transfer.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer.Wait(); err != nil {
+		t.Fatalf("completed transfer reported %v after Abort", err)
+	}
+}
+
+// blockingReader returns its data, then blocks until released.
+type blockingReader struct {
+	data     io.Reader
+	reading  chan struct{}
+	released chan struct{}
+}
+
+func (self *blockingReader) Read(p []byte) (int, error) {
+	n, err := self.data.Read(p)
+	if err == io.EOF {
+		close(self.reading)
+		<-self.released
+		return 0, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func TestTransferAbortRemovesParts(t *testing.T) {
+	setPartSizes(t, 5<<20, 5<<20)
+	bucket, srv := newTestBucket(t)
+
+	r := &blockingReader{
+		data:     bytes.NewReader(make([]byte, 5<<20)),
+		reading:  make(chan struct{}),
+		released: make(chan struct{}),
+	}
+	transfer := bucket.StartPutReader(context.Background(), "big", r, 20<<20, "", Private)
+	<-r.reading
+	if uploads := pendingUploads(t, srv); !strings.Contains(uploads, "<Key>big</Key>") {
+		t.Fatalf("no upload in progress: %s", uploads)
+	}
+
+	aborted := make(chan error)
+	go func() { aborted <- transfer.Abort() }()
+	close(r.released)
+	if err := <-aborted; err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer.Wait(); err != ErrAborted {
+		t.Fatalf("got %v, want ErrAborted", err)
+	}
+	if uploads := pendingUploads(t, srv); strings.Contains(uploads, "<Upload>") {
+		t.Fatalf("uploads left behind: %s", uploads)
+	}
+	if ok, _ := bucket.Exists("big"); ok {
+		t.Fatal("aborted upload created the object")
+	}
+}
+
+func TestStartGetToWriter(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	if err := bucket.Put("small", []byte("hello"), "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := bucket.StartGetToWriter(context.Background(), "small", &buf).Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "hello" {
+		t.Fatalf("got %q", buf.String())
+	}
+}
+
+func TestStartPutReaderPassesContext(t *testing.T) {
+	setPartSizes(t, 5<<20, 5<<20)
+	bucket, _ := newTestBucket(t)
+	transport := &contextTransport{}
+	bucket.S3.Client = &http.Client{Transport: transport}
+
+	// Initiation, parts and completion all run under the transfer's
+	// context.
+	ctx := context.WithValue(context.Background(), contextKey{}, "transfer")
+	data := bytes.Repeat([]byte("x"), 6<<20)
+	if err := bucket.StartPutReader(ctx, "big", bytes.NewReader(data), int64(len(data)), "", Private).Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.values) != 4 {
+		t.Fatalf("got %d requests, want 4", len(transport.values))
+	}
+	for i, value := range transport.values {
+		if value != "transfer" {
+			t.Errorf("request %d sent without the transfer's context", i)
+		}
+	}
+}
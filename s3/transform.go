@@ -0,0 +1,239 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// TransformsHeader is the object metadata header recording, in order, the
+// names of the transformers applied to an object's data on upload.
+const TransformsHeader = "x-amz-meta-transforms"
+
+// Transformer is one stage of a payload pipeline, such as compression or
+// encryption. Encode is applied on upload and Decode on download.
+type Transformer interface {
+	// Name identifies the transformer in object metadata. It must not
+	// contain commas.
+	Name() string
+	Encode(data []byte) ([]byte, error)
+	Decode(data []byte) ([]byte, error)
+}
+
+// AuthenticatingTransformer is a Transformer that authenticates the data
+// it decodes, such as the one returned by NewAESGCM. TransformedBucket
+// binds its output to the object's key with EncodeAt and DecodeAt, so that
+// it cannot be copied to another key, and refuses objects that do not
+// record it, so that it cannot be bypassed by storing plain data.
+type AuthenticatingTransformer interface {
+	Transformer
+	// EncodeAt is like Encode, additionally authenticating key.
+	EncodeAt(key string, data []byte) ([]byte, error)
+	// DecodeAt reverses EncodeAt and fails unless data was encoded for
+	// key.
+	DecodeAt(key string, data []byte) ([]byte, error)
+}
+
+// TransformedBucket is a Bucket whose Put and Get run object data through
+// a pipeline of transformers. Put applies them in order and records their
+// names in the object's metadata; Get reverses exactly the transforms
+// recorded on the object, so objects written with a different pipeline, or
+// none at all, remain readable as long as the transformers are known.
+// Objects not recorded as encoded by every authenticating transformer of
+// the pipeline are refused, however.
+type TransformedBucket struct {
+	*Bucket
+	Transformers []Transformer
+}
+
+// WithTransformers returns a view of the bucket applying transformers, in
+// order, to uploaded data, e.g. b.WithTransformers(Gzip, aesTransformer).
+func (self *Bucket) WithTransformers(transformers ...Transformer) *TransformedBucket {
+	return &TransformedBucket{self, transformers}
+}
+
+// Put transforms data and stores it at path.
+func (self *TransformedBucket) Put(path string, data []byte, contType string, perm ACL) error {
+	key := strings.TrimPrefix(path, "/")
+	names := make([]string, 0, len(self.Transformers))
+	for _, t := range self.Transformers {
+		var err error
+		if data, err = encode(t, key, data); err != nil {
+			return fmt.Errorf("s3: %s transform of %s: %v", t.Name(), path, err)
+		}
+		names = append(names, t.Name())
+	}
+	headers := map[string][]string{
+		"Content-Type": {contType},
+	}
+	if len(names) > 0 {
+		headers[TransformsHeader] = []string{strings.Join(names, ",")}
+	}
+	return self.Bucket.PutHeader(path, data, headers, perm)
+}
+
+// Get retrieves the object at path and undoes the transforms recorded on
+// it.
+func (self *TransformedBucket) Get(path string) ([]byte, error) {
+	resp, err := self.Bucket.GetResponse(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	// The header is not authenticated, so it cannot vouch for the
+	// transforms it leaves out.
+	var names []string
+	if applied := resp.Header.Get(TransformsHeader); applied != "" {
+		names = strings.Split(applied, ",")
+	}
+	for _, t := range self.Transformers {
+		if _, ok := t.(AuthenticatingTransformer); ok && !contains(names, t.Name()) {
+			return nil, fmt.Errorf("s3: %s was not stored with required transform %q", path, t.Name())
+		}
+	}
+	key := strings.TrimPrefix(path, "/")
+	for i := len(names) - 1; i >= 0; i-- {
+		t := self.transformer(names[i])
+		if t == nil {
+			return nil, fmt.Errorf("s3: %s was stored with unknown transform %q", path, names[i])
+		}
+		if data, err = decode(t, key, data); err != nil {
+			return nil, fmt.Errorf("s3: %s transform of %s: %v", t.Name(), path, err)
+		}
+	}
+	return data, nil
+}
+
+func (self *TransformedBucket) transformer(name string) Transformer {
+	for _, t := range self.Transformers {
+		if t.Name() == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// encode applies t to the data of the object at key.
+func encode(t Transformer, key string, data []byte) ([]byte, error) {
+	if a, ok := t.(AuthenticatingTransformer); ok {
+		return a.EncodeAt(key, data)
+	}
+	return t.Encode(data)
+}
+
+// decode reverses encode.
+func decode(t Transformer, key string, data []byte) ([]byte, error) {
+	if a, ok := t.(AuthenticatingTransformer); ok {
+		return a.DecodeAt(key, data)
+	}
+	return t.Decode(data)
+}
+
+// Gzip compresses object data with gzip.
+var Gzip Transformer = gzipTransformer{}
+
+type gzipTransformer struct{}
+
+func (gzipTransformer) Name() string { return "gzip" }
+
+func (gzipTransformer) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipTransformer) Decode(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// NewAESGCM returns a Transformer encrypting object data with AES-GCM
+// under key, which must be 16, 24 or 32 bytes long. A random nonce is
+// prepended to each encrypted object. The transformer is an
+// AuthenticatingTransformer.
+func NewAESGCM(key []byte) (Transformer, error) {
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return aesGCMTransformer{gcm}, nil
+}
+
+type aesGCMTransformer struct {
+	gcm cipher.AEAD
+}
+
+func (aesGCMTransformer) Name() string { return "aes-gcm" }
+
+func (self aesGCMTransformer) Encode(data []byte) ([]byte, error) {
+	return sealWithNonce(self.gcm, data, nil)
+}
+
+func (self aesGCMTransformer) Decode(data []byte) ([]byte, error) {
+	return openWithNonce(self.gcm, data, nil)
+}
+
+func (self aesGCMTransformer) EncodeAt(key string, data []byte) ([]byte, error) {
+	return sealWithNonce(self.gcm, data, []byte(key))
+}
+
+func (self aesGCMTransformer) DecodeAt(key string, data []byte) ([]byte, error) {
+	return openWithNonce(self.gcm, data, []byte(key))
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealWithNonce encrypts and authenticates data and additional under a
+// random nonce, which is prepended to the result.
+func sealWithNonce(gcm cipher.AEAD, data, additional []byte) ([]byte, error) {
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, additional), nil
+}
+
+// openWithNonce reverses sealWithNonce.
+func openWithNonce(gcm cipher.AEAD, data, additional []byte) ([]byte, error) {
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, additional)
+}
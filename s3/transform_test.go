@@ -0,0 +1,147 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestTransformedBucket(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	aes, err := NewAESGCM(bytes.Repeat([]byte("k"), 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := bytes.Repeat([]byte("compressible "), 100)
+
+	pipeline := bucket.WithTransformers(Gzip, aes)
+	if err := pipeline.Put("both", plain, "text/plain", Private); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := bucket.GetResponse("both")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get(TransformsHeader) != "gzip,aes-gcm" || resp.ContentLength >= int64(len(plain)) {
+		t.Fatalf("stored %d bytes with %v", resp.ContentLength, resp.Header)
+	}
+	stored, err := bucket.Get("both")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored, []byte("compressible")) {
+		t.Fatal("stored in the clear")
+	}
+	if data, err := pipeline.Get("both"); err != nil || !bytes.Equal(data, plain) {
+		t.Fatalf("got %q, %v", data, err)
+	}
+
+	// Objects are decoded by the transforms recorded on them, whatever
+	// the pipeline that reads them.
+	if err := bucket.WithTransformers(Gzip).Put("gzipped", plain, "", Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := bucket.Put("plain", plain, "", Private); err != nil {
+		t.Fatal(err)
+	}
+	reader := bucket.WithTransformers(Gzip)
+	for _, path := range []string{"gzipped", "plain"} {
+		if data, err := reader.Get(path); err != nil || !bytes.Equal(data, plain) {
+			t.Errorf("%s: got %q, %v", path, data, err)
+		}
+	}
+	if data, err := bucket.WithTransformers(aes, Gzip).Get("both"); err != nil || !bytes.Equal(data, plain) {
+		t.Errorf("both: got %q, %v", data, err)
+	}
+
+	// A pipeline that encrypts only accepts encrypted objects.
+	for _, path := range []string{"gzipped", "plain"} {
+		if _, err := pipeline.Get(path); err == nil || !strings.Contains(err.Error(), `required transform "aes-gcm"`) {
+			t.Errorf("%s: got %v", path, err)
+		}
+	}
+
+	if _, err := bucket.WithTransformers(Gzip).Get("both"); err == nil || !strings.Contains(err.Error(), `unknown transform "aes-gcm"`) {
+		t.Fatalf("got %v", err)
+	}
+	other, _ := NewAESGCM(bytes.Repeat([]byte("o"), 32))
+	if _, err := bucket.WithTransformers(Gzip, other).Get("both"); err == nil || !strings.Contains(err.Error(), "aes-gcm transform of both") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestTransformedBucketBindsKey(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	aes, err := NewAESGCM(bytes.Repeat([]byte("k"), 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := bucket.WithTransformers(aes)
+	if err := pipeline.Put("/dir/key", []byte("secret"), "", Private); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := pipeline.Get("dir/key"); err != nil || string(data) != "secret" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+
+	// A ciphertext copied to another key, header and all, is refused.
+	resp, err := bucket.GetResponse("dir/key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := map[string][]string{TransformsHeader: {resp.Header.Get(TransformsHeader)}}
+	if err := bucket.PutHeader("dir/other", stored, headers, Private); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pipeline.Get("dir/other"); err == nil {
+		t.Fatal("ciphertext moved to another key was accepted")
+	}
+
+	// So is one whose header no longer records the encryption.
+	if err := bucket.PutHeader("dir/key", stored, nil, Private); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pipeline.Get("dir/key"); err == nil {
+		t.Fatal("object without the aes-gcm transform was accepted")
+	}
+}
+
+func TestAESGCM(t *testing.T) {
+	if _, err := NewAESGCM([]byte("short")); err == nil {
+		t.Fatal("expected an invalid key to fail")
+	}
+	aes, err := NewAESGCM(bytes.Repeat([]byte("k"), 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := aes.Encode([]byte("data"))
+	second, _ := aes.Encode([]byte("data"))
+	if bytes.Equal(first, second) {
+		t.Fatal("nonces are reused")
+	}
+	first[len(first)-1] ^= 1
+	if _, err := aes.Decode(first); err == nil {
+		t.Fatal("tampering went unnoticed")
+	}
+	if _, err := aes.Decode([]byte("tiny")); err == nil {
+		t.Fatal("expected a truncated ciphertext to fail")
+	}
+
+	at := aes.(AuthenticatingTransformer)
+	sealed, _ := at.EncodeAt("key", []byte("data"))
+	if data, err := at.DecodeAt("key", sealed); err != nil || string(data) != "data" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+	if _, err := at.DecodeAt("other", sealed); err == nil {
+		t.Fatal("decoded for another key")
+	}
+}
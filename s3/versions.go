@@ -0,0 +1,184 @@
+package s3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Version is one version of an object in a versioned bucket. Deleting an
+// object in a versioned bucket adds a delete marker, a version without
+// data, on top of the existing versions.
+type Version struct {
+	Key            string
+	VersionId      string
+	IsLatest       bool
+	IsDeleteMarker bool `xml:"-"`
+	LastModified   string
+	// ETag gives the hex-encoded MD5 sum of the contents,
+	// surrounded with double-quotes. Empty for delete markers.
+	ETag         string
+	Size         int64
+	StorageClass string
+	Owner        Owner
+}
+
+// The ListVersionsResp type holds the results of a ListVersions operation.
+// Versions and delete markers are listed together, by key and from the
+// newest to the oldest version of each key.
+type ListVersionsResp struct {
+	Name                string
+	Prefix              string
+	Delimiter           string
+	KeyMarker           string
+	VersionIdMarker     string
+	NextKeyMarker       string
+	NextVersionIdMarker string
+	MaxKeys             int
+	IsTruncated         bool
+	Versions            []Version
+	CommonPrefixes      []string
+}
+
+// UnmarshalXML decodes a ListVersionsResult, keeping the order of the
+// interleaved Version and DeleteMarker elements.
+func (self *ListVersionsResp) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			var field interface{}
+			switch t.Name.Local {
+			case "Version", "DeleteMarker":
+				var v Version
+				if err := d.DecodeElement(&v, &t); err != nil {
+					return err
+				}
+				v.IsDeleteMarker = t.Name.Local == "DeleteMarker"
+				self.Versions = append(self.Versions, v)
+				continue
+			case "CommonPrefixes":
+				var p struct{ Prefix string }
+				if err := d.DecodeElement(&p, &t); err != nil {
+					return err
+				}
+				self.CommonPrefixes = append(self.CommonPrefixes, p.Prefix)
+				continue
+			case "Name":
+				field = &self.Name
+			case "Prefix":
+				field = &self.Prefix
+			case "Delimiter":
+				field = &self.Delimiter
+			case "KeyMarker":
+				field = &self.KeyMarker
+			case "VersionIdMarker":
+				field = &self.VersionIdMarker
+			case "NextKeyMarker":
+				field = &self.NextKeyMarker
+			case "NextVersionIdMarker":
+				field = &self.NextVersionIdMarker
+			case "MaxKeys":
+				field = &self.MaxKeys
+			case "IsTruncated":
+				field = &self.IsTruncated
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.DecodeElement(field, &t); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ListVersions returns the versions and delete markers of the objects in
+// a versioned bucket. The prefix, delim and max parameters work as in
+// List; listings continue from keyMarker and versionIdMarker, taken from
+// the NextKeyMarker and NextVersionIdMarker of a truncated response.
+func (self *Bucket) ListVersions(prefix, delim, keyMarker, versionIdMarker string, max int) (result *ListVersionsResp, err error) {
+	params := map[string][]string{
+		"versions":  {""},
+		"prefix":    {prefix},
+		"delimiter": {delim},
+	}
+	if keyMarker != "" {
+		params["key-marker"] = []string{keyMarker}
+	}
+	if versionIdMarker != "" {
+		params["version-id-marker"] = []string{versionIdMarker}
+	}
+	if max != 0 {
+		params["max-keys"] = []string{strconv.FormatInt(int64(max), 10)}
+	}
+	req := &request{
+		bucket: self.Name,
+		params: params,
+	}
+	result = &ListVersionsResp{}
+	for attempt, try := self.attempts().StartWithContext(req.context()), 0; attempt.Next(); try++ {
+		err = self.S3.query(req, result)
+		if !shouldRetry(err) {
+			break
+		}
+		self.retryBackoff(req.context(), err, try)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DelVersion permanently removes one version or delete marker of the
+// object at path.
+func (self *Bucket) DelVersion(path, versionId string) error {
+	req := &request{
+		method: "DELETE",
+		bucket: self.Name,
+		path:   path,
+		params: map[string][]string{"versionId": {versionId}},
+	}
+	return self.S3.query(req, nil)
+}
+
+// Undelete restores a deleted object of a versioned bucket by removing the
+// delete marker that hides its latest version. It fails if the latest
+// version of the object is not a delete marker.
+func (self *Bucket) Undelete(path string) error {
+	key := path
+	if len(key) > 0 && key[0] == '/' {
+		key = key[1:]
+	}
+	keyMarker, versionIdMarker := "", ""
+	for {
+		resp, err := self.ListVersions(key, "", keyMarker, versionIdMarker, 1000)
+		if err != nil {
+			return err
+		}
+		for _, v := range resp.Versions {
+			if v.Key != key || !v.IsLatest {
+				continue
+			}
+			if !v.IsDeleteMarker {
+				return fmt.Errorf("s3: %s is not deleted", key)
+			}
+			return self.DelVersion(path, v.VersionId)
+		}
+		if !resp.IsTruncated {
+			return &Error{StatusCode: 404, Code: "NoSuchKey", Message: "s3: no versions of " + key}
+		}
+		keyMarker, versionIdMarker = resp.NextKeyMarker, resp.NextVersionIdMarker
+	}
+}
@@ -0,0 +1,157 @@
+package s3
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dkln/go-aws"
+)
+
+// versionEntry is a version or delete marker kept by versionServer.
+type versionEntry struct {
+	key, id string
+	marker  bool
+}
+
+// versionServer lists the versions of a versioned bucket, newest first
+// for each key, and deletes single versions.
+type versionServer struct {
+	mu       sync.Mutex
+	versions []versionEntry
+	queries  []string
+}
+
+func (self *versionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	query := r.URL.Query()
+	self.queries = append(self.queries, r.Method+" "+r.URL.RawQuery)
+	if r.Method == "DELETE" {
+		for i, v := range self.versions {
+			if "/b/"+v.key == r.URL.Path && v.id == query.Get("versionId") {
+				self.versions = append(self.versions[:i], self.versions[i+1:]...)
+				w.WriteHeader(204)
+				return
+			}
+		}
+		w.WriteHeader(404)
+		w.Write([]byte("<Error><Code>NoSuchVersion</Code></Error>"))
+		return
+	}
+
+	max := 1000
+	if s := query.Get("max-keys"); s != "" {
+		max, _ = strconv.Atoi(s)
+	}
+	start := 0
+	if marker := query.Get("key-marker"); marker != "" {
+		for start < len(self.versions) && (self.versions[start].key != marker || self.versions[start].id != query.Get("version-id-marker")) {
+			start++
+		}
+		start++
+	}
+	var body strings.Builder
+	body.WriteString("<ListVersionsResult><Name>b</Name><Prefix>" + query.Get("prefix") + "</Prefix><Unknown><Nested/></Unknown>")
+	count, last := 0, versionEntry{}
+	latest := map[string]bool{}
+	for i, v := range self.versions {
+		isLatest := !latest[v.key]
+		latest[v.key] = true
+		if i < start || !strings.HasPrefix(v.key, query.Get("prefix")) {
+			continue
+		}
+		if count == max {
+			fmt.Fprintf(&body, "<IsTruncated>true</IsTruncated><NextKeyMarker>%s</NextKeyMarker><NextVersionIdMarker>%s</NextVersionIdMarker>", last.key, last.id)
+			break
+		}
+		element := "Version"
+		if v.marker {
+			element = "DeleteMarker"
+		}
+		fmt.Fprintf(&body, "<%s><Key>%s</Key><VersionId>%s</VersionId><IsLatest>%v</IsLatest></%s>", element, v.key, v.id, isLatest, element)
+		count, last = count+1, v
+	}
+	body.WriteString("</ListVersionsResult>")
+	w.Write([]byte(body.String()))
+}
+
+func newVersionedBucket(t *testing.T, versions ...versionEntry) (*Bucket, *versionServer) {
+	handler := &versionServer{versions: versions}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	s, err := NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Bucket("b"), handler
+}
+
+func TestListVersions(t *testing.T) {
+	b, srv := newVersionedBucket(t,
+		versionEntry{"a", "a2", true},
+		versionEntry{"a", "a1", false},
+		versionEntry{"b", "b1", false},
+	)
+	resp, err := b.ListVersions("", "", "", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Name != "b" || !resp.IsTruncated || resp.NextKeyMarker != "a" || resp.NextVersionIdMarker != "a1" || len(resp.Versions) != 2 {
+		t.Fatalf("got %+v", resp)
+	}
+	// Delete markers keep their place among the versions.
+	if v := resp.Versions[0]; !v.IsDeleteMarker || !v.IsLatest || v.VersionId != "a2" {
+		t.Fatalf("got %+v", v)
+	}
+	if v := resp.Versions[1]; v.IsDeleteMarker || v.IsLatest {
+		t.Fatalf("got %+v", v)
+	}
+
+	resp, err = b.ListVersions("", "", resp.NextKeyMarker, resp.NextVersionIdMarker, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.IsTruncated || len(resp.Versions) != 1 || resp.Versions[0].VersionId != "b1" || !resp.Versions[0].IsLatest {
+		t.Fatalf("got %+v", resp)
+	}
+	if q := srv.queries[1]; !strings.Contains(q, "key-marker=a&") || !strings.Contains(q, "version-id-marker=a1") {
+		t.Fatalf("sent %s", q)
+	}
+}
+
+func TestUndelete(t *testing.T) {
+	b, srv := newVersionedBucket(t,
+		versionEntry{"deleted", "d3", true},
+		versionEntry{"deleted", "d2", false},
+		versionEntry{"deleted/child", "c1", false},
+		versionEntry{"live", "l1", false},
+	)
+	if err := b.Undelete("/deleted"); err != nil {
+		t.Fatal(err)
+	}
+	if q := srv.queries[len(srv.queries)-1]; q != "DELETE versionId=d3" {
+		t.Fatalf("sent %s", q)
+	}
+	resp, err := b.ListVersions("deleted", "", "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := resp.Versions[0]; v.VersionId != "d2" || !v.IsLatest || v.IsDeleteMarker {
+		t.Fatalf("got %+v", resp.Versions)
+	}
+
+	if err := b.Undelete("deleted"); err == nil || !strings.Contains(err.Error(), "is not deleted") {
+		t.Fatalf("got %v", err)
+	}
+	if err := b.Undelete("missing"); !aws.IsNotFound(err) {
+		t.Fatalf("got %v", err)
+	}
+	if err := b.DelVersion("live", "nope"); aws.ErrorCode(err) != "NoSuchVersion" {
+		t.Fatalf("got %v", err)
+	}
+}
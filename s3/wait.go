@@ -0,0 +1,48 @@
+package s3
+
+import (
+	"context"
+	"github.com/dkln/go-aws"
+)
+
+// S3 is eventually consistent for bucket creation and some overwrites
+// and deletes, so a resource may not be visible right after it was
+// created. The waiters below poll until it is, following
+// aws.DefaultWaitStrategy.
+
+// WaitUntilBucketExists waits until the bucket can be accessed.
+func (self *Bucket) WaitUntilBucketExists(ctx context.Context) error {
+	return self.waiter("BucketExists", "", aws.WaiterSuccess, aws.WaiterRetry).Wait(ctx)
+}
+
+// WaitUntilBucketNotExists waits until the bucket is gone.
+func (self *Bucket) WaitUntilBucketNotExists(ctx context.Context) error {
+	return self.waiter("BucketNotExists", "", aws.WaiterRetry, aws.WaiterSuccess).Wait(ctx)
+}
+
+// WaitUntilObjectExists waits until the object at path can be read.
+func (self *Bucket) WaitUntilObjectExists(ctx context.Context, path string) error {
+	return self.waiter("ObjectExists", path, aws.WaiterSuccess, aws.WaiterRetry).Wait(ctx)
+}
+
+// WaitUntilObjectNotExists waits until the object at path is gone.
+func (self *Bucket) WaitUntilObjectNotExists(ctx context.Context, path string) error {
+	return self.waiter("ObjectNotExists", path, aws.WaiterRetry, aws.WaiterSuccess).Wait(ctx)
+}
+
+// waiter returns a Waiter polling HEAD of path, or of the bucket if path
+// is empty, whose state is found if the resource exists and notFound
+// if it does not.
+func (self *Bucket) waiter(name, path string, found, notFound aws.WaiterState) *aws.Waiter {
+	return &aws.Waiter{
+		Name: name,
+		Poll: func(ctx context.Context) (interface{}, error) {
+			return self.HeadWithContext(ctx, path)
+		},
+		Acceptors: []aws.Acceptor{
+			{State: found, Matcher: aws.MatchSuccess},
+			{State: notFound, Matcher: aws.MatchNotFound},
+		},
+		Strategy: aws.DefaultWaitStrategy,
+	}
+}
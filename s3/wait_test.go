@@ -0,0 +1,85 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+func setWaitStrategy(t *testing.T, strategy aws.AttemptStrategy) {
+	old := aws.DefaultWaitStrategy
+	aws.DefaultWaitStrategy = strategy
+	t.Cleanup(func() { aws.DefaultWaitStrategy = old })
+}
+
+func TestWaitUntilObjectExists(t *testing.T) {
+	setWaitStrategy(t, aws.AttemptStrategy{Total: 5 * time.Second, Delay: 5 * time.Millisecond})
+	bucket, _ := newTestBucket(t)
+	ctx := context.Background()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		bucket.Put("key", []byte("data"), "", Private)
+	}()
+	if err := bucket.WaitUntilObjectExists(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := bucket.Get("key"); err != nil || string(data) != "data" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		bucket.Del("key")
+	}()
+	if err := bucket.WaitUntilObjectNotExists(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := bucket.Exists("key"); exists {
+		t.Fatal("object still exists")
+	}
+}
+
+func TestWaitUntilBucketExists(t *testing.T) {
+	setWaitStrategy(t, aws.AttemptStrategy{Total: 5 * time.Second, Delay: 5 * time.Millisecond})
+	bucket, _ := newTestBucket(t)
+	ctx := context.Background()
+	if err := bucket.WaitUntilBucketExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		bucket.DelBucket()
+	}()
+	if err := bucket.WaitUntilBucketNotExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitGivesUp(t *testing.T) {
+	setWaitStrategy(t, aws.AttemptStrategy{Min: 3, Delay: time.Millisecond})
+	bucket, _ := newTestBucket(t)
+	if err := bucket.WaitUntilObjectExists(context.Background(), "missing"); err != aws.ErrWaiterTimeout {
+		t.Fatalf("got %v", err)
+	}
+
+	setWaitStrategy(t, aws.AttemptStrategy{Total: time.Minute, Delay: 5 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := bucket.WaitUntilObjectExists(ctx, "missing"); err != context.DeadlineExceeded {
+		t.Fatalf("got %v", err)
+	}
+
+	// Errors other than a missing resource end the wait.
+	bucket.S3.Client = &http.Client{Transport: statusTransport(403)}
+	err := bucket.WaitUntilObjectExists(context.Background(), "missing")
+	if aws.HTTPStatusCode(err) != 403 {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package s3
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// SkipDir can be returned from a WalkFunc. When returned for a common
+// prefix, that prefix is not descended into. When returned for a key, the
+// remaining keys and prefixes under the same parent are skipped.
+var SkipDir = filepath.SkipDir
+
+// WalkFunc is the type of the function called by WalkBucket for every key
+// and common prefix it visits. For common prefixes key is nil. If listing
+// a common prefix fails, the function is called once more for that prefix
+// with the error; returning nil or SkipDir then continues the walk.
+type WalkFunc func(path string, key *Key, err error) error
+
+// WalkBucket walks the keys below prefix, descending into every common
+// prefix delimited by "/", the way filepath.WalkDir walks a directory
+// tree. Entries under a prefix are visited in lexical order. The prefix
+// itself is not passed to fn; if listing it fails, WalkBucket returns the
+// error.
+func (self *Bucket) WalkBucket(prefix string, fn WalkFunc) error {
+	return self.WalkBucketDepth(prefix, 0, fn)
+}
+
+// WalkBucketDepth is like WalkBucket but does not descend more than
+// maxDepth levels of common prefixes below prefix. Common prefixes at the
+// maximum depth are still passed to fn. A maxDepth of 0 means no limit.
+func (self *Bucket) WalkBucketDepth(prefix string, maxDepth int, fn WalkFunc) error {
+	err := self.walk(prefix, 1, maxDepth, fn)
+	if err == SkipDir {
+		return nil
+	}
+	return err
+}
+
+func (self *Bucket) walk(prefix string, depth, maxDepth int, fn WalkFunc) error {
+	marker := ""
+	for {
+		resp, err := self.List(prefix, "/", marker, 1000)
+		if err != nil {
+			if depth == 1 {
+				return err
+			}
+			err = fn(prefix, nil, err)
+			if err == SkipDir {
+				return nil
+			}
+			return err
+		}
+
+		entries := make([]walkEntry, 0, len(resp.Contents)+len(resp.CommonPrefixes))
+		for i := range resp.Contents {
+			entries = append(entries, walkEntry{resp.Contents[i].Key, &resp.Contents[i]})
+		}
+		for _, p := range resp.CommonPrefixes {
+			entries = append(entries, walkEntry{p, nil})
+		}
+		sort.Sort(walkEntries(entries))
+
+		for _, entry := range entries {
+			err := fn(entry.path, entry.key, nil)
+			if entry.key != nil {
+				if err == SkipDir {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err == SkipDir {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if maxDepth > 0 && depth >= maxDepth {
+				continue
+			}
+			if err := self.walk(entry.path, depth+1, maxDepth, fn); err != nil {
+				return err
+			}
+		}
+
+		if !resp.IsTruncated || len(entries) == 0 {
+			return nil
+		}
+		marker = resp.NextMarker
+		if marker == "" {
+			marker = entries[len(entries)-1].path
+		}
+	}
+}
+
+type walkEntry struct {
+	path string
+	key  *Key
+}
+
+type walkEntries []walkEntry
+
+func (self walkEntries) Len() int           { return len(self) }
+func (self walkEntries) Less(i, j int) bool { return self[i].path < self[j].path }
+func (self walkEntries) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
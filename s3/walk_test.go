@@ -0,0 +1,89 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dkln/go-aws"
+)
+
+// denyPrefix refuses listings of one prefix and passes other requests on.
+type denyPrefix string
+
+func (self denyPrefix) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Query().Get("prefix") != string(self) {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	body := "<Error><Code>AccessDenied</Code><Message>denied</Message></Error>"
+	return &http.Response{
+		StatusCode: 403,
+		Status:     "403 Forbidden",
+		Header:     http.Header{"Content-Type": {"application/xml"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestWalkBucket(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	for _, key := range []string{"a/1", "a/b/2", "a/c/3", "z"} {
+		if err := bucket.Put(key, nil, "", Private); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var visited []string
+	err := bucket.WalkBucket("", func(path string, key *Key, err error) error {
+		if err != nil {
+			return err
+		}
+		visited = append(visited, path)
+		if path == "a/c/" {
+			return SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(visited); got != "[a/ a/1 a/b/ a/b/2 a/c/ z]" {
+		t.Fatalf("visited %s", got)
+	}
+}
+
+func TestWalkBucketListingErrors(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	for _, key := range []string{"a/1", "b/2", "c/3"} {
+		if err := bucket.Put(key, nil, "", Private); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bucket.S3.Client = &http.Client{Transport: denyPrefix("b/")}
+
+	// A failing common prefix is reported to fn, which may skip it.
+	var reported []string
+	err := bucket.WalkBucket("", func(path string, key *Key, err error) error {
+		if err != nil {
+			reported = append(reported, path)
+			return SkipDir
+		}
+		return nil
+	})
+	if err != nil || fmt.Sprint(reported) != "[b/]" {
+		t.Fatalf("got %v, reported %v", err, reported)
+	}
+
+	// A failing root is returned without calling fn.
+	err = bucket.WalkBucket("b/", func(path string, key *Key, err error) error {
+		t.Errorf("fn called for %q with %v", path, err)
+		return nil
+	})
+	if !aws.IsAccessDenied(err) {
+		t.Fatalf("got %v", err)
+	}
+}
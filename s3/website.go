@@ -0,0 +1,37 @@
+package s3
+
+import (
+	"net/http"
+)
+
+// WebsiteRedirectHeader is the header holding the location an object
+// redirects to when the bucket is served as a website. It may be passed
+// to PutHeader and PutReaderHeader.
+const WebsiteRedirectHeader = "x-amz-website-redirect-location"
+
+// PutWebsiteRedirect stores an empty object at path that, when requested
+// through the bucket's website endpoint, redirects to location. The
+// location is either another object in the bucket ("/other.html") or an
+// absolute URL.
+func (self *Bucket) PutWebsiteRedirect(path, location string, perm ACL) error {
+	headers := map[string][]string{
+		WebsiteRedirectHeader: {location},
+	}
+	return self.PutHeader(path, nil, headers, perm)
+}
+
+// WebsiteRedirect returns the website redirect location of the object at
+// path, or an empty string if the object does not redirect.
+func (self *Bucket) WebsiteRedirect(path string) (string, error) {
+	resp, err := self.Head(path)
+	if err != nil {
+		return "", err
+	}
+	return WebsiteRedirectLocation(resp), nil
+}
+
+// WebsiteRedirectLocation returns the website redirect location from the
+// response of Head or GetResponse.
+func WebsiteRedirectLocation(resp *http.Response) string {
+	return resp.Header.Get(WebsiteRedirectHeader)
+}
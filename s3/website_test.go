@@ -0,0 +1,49 @@
+//go:build !goaws_stable
+
+package s3
+
+import (
+	"testing"
+
+	"github.com/dkln/go-aws"
+)
+
+func TestWebsiteRedirect(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	if err := bucket.PutWebsiteRedirect("old.html", "/new.html", Private); err != nil {
+		t.Fatal(err)
+	}
+	if err := bucket.Put("new.html", []byte("<html>"), "text/html", Private); err != nil {
+		t.Fatal(err)
+	}
+
+	location, err := bucket.WebsiteRedirect("old.html")
+	if err != nil || location != "/new.html" {
+		t.Fatalf("got %q, %v", location, err)
+	}
+	location, err = bucket.WebsiteRedirect("new.html")
+	if err != nil || location != "" {
+		t.Fatalf("got %q, %v", location, err)
+	}
+	if _, err := bucket.WebsiteRedirect("missing.html"); !aws.IsNotFound(err) {
+		t.Fatalf("got %v", err)
+	}
+
+	// Head only fetches the metadata.
+	resp, err := bucket.Head("new.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ContentLength != 6 || resp.Header.Get("Content-Type") != "text/html" {
+		t.Fatalf("got %v", resp.Header)
+	}
+
+	resp, err = bucket.GetResponse("old.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if WebsiteRedirectLocation(resp) != "/new.html" {
+		t.Fatalf("got %v", resp.Header)
+	}
+}
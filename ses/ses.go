@@ -0,0 +1,111 @@
+// Package ses interacts with the Amazon Simple Email Service.
+package ses
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/dkln/go-aws"
+)
+
+// APIVersion is the version of the SES API the package speaks.
+const APIVersion = "2010-12-01"
+
+// The SES type encapsulates operations with SES in a region.
+type SES struct {
+	*aws.QueryClient
+}
+
+// New creates a new SES. Its endpoint is email.<region>.amazonaws.com,
+// and it signs with Signature Version 4, as SES requires.
+func New(auth aws.Auth, region aws.Region) *SES {
+	client := aws.NewQueryClient(auth, region, "email", APIVersion)
+	client.Endpoint.SigningName = "ses"
+	client.SigV4Only = true
+	client.IdempotentActions = map[string]bool{
+		"VerifyEmailIdentity":  true,
+		"VerifyDomainIdentity": true,
+		"VerifyDomainDkim":     true,
+		"UpdateTemplate":       true,
+	}
+	return &SES{client}
+}
+
+// ResponseMetadata is part of every SES response.
+type ResponseMetadata struct {
+	RequestId string
+}
+
+// VerifyEmailIdentity starts the verification of an email address, by
+// sending a verification link to it.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_VerifyEmailIdentity.html for details.
+func (self *SES) VerifyEmailIdentity(ctx context.Context, email string) error {
+	return self.Do(ctx, "VerifyEmailIdentity", url.Values{"EmailAddress": {email}}, nil)
+}
+
+// VerifyDomainIdentity starts the verification of a domain and returns
+// the token to publish in a TXT record named _amazonses.<domain>.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_VerifyDomainIdentity.html for details.
+func (self *SES) VerifyDomainIdentity(ctx context.Context, domain string) (string, error) {
+	var resp struct {
+		VerificationToken string `xml:"VerifyDomainIdentityResult>VerificationToken"`
+	}
+	if err := self.Do(ctx, "VerifyDomainIdentity", url.Values{"Domain": {domain}}, &resp); err != nil {
+		return "", err
+	}
+	return resp.VerificationToken, nil
+}
+
+// VerifyDomainDkim enables Easy DKIM for a domain and returns its tokens.
+// Each token must be published as a CNAME record named
+// <token>._domainkey.<domain> pointing to <token>.dkim.amazonses.com.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_VerifyDomainDkim.html for details.
+func (self *SES) VerifyDomainDkim(ctx context.Context, domain string) ([]string, error) {
+	var resp struct {
+		DkimTokens []string `xml:"VerifyDomainDkimResult>DkimTokens>member"`
+	}
+	if err := self.Do(ctx, "VerifyDomainDkim", url.Values{"Domain": {domain}}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.DkimTokens, nil
+}
+
+// IdentityVerification is the verification state of an identity.
+type IdentityVerification struct {
+	VerificationStatus string // "Pending", "Success", "Failed", ...
+	VerificationToken  string // of domains
+}
+
+// GetIdentityVerificationAttributes returns the verification state of
+// the given email addresses and domains, by identity.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_GetIdentityVerificationAttributes.html for details.
+func (self *SES) GetIdentityVerificationAttributes(ctx context.Context, identities ...string) (map[string]IdentityVerification, error) {
+	params := url.Values{}
+	aws.SetList(params, "Identities.member", identities)
+	var resp struct {
+		Entries []struct {
+			Key   string               `xml:"key"`
+			Value IdentityVerification `xml:"value"`
+		} `xml:"GetIdentityVerificationAttributesResult>VerificationAttributes>entry"`
+	}
+	if err := self.Do(ctx, "GetIdentityVerificationAttributes", params, &resp); err != nil {
+		return nil, err
+	}
+	attrs := make(map[string]IdentityVerification, len(resp.Entries))
+	for _, entry := range resp.Entries {
+		attrs[entry.Key] = entry.Value
+	}
+	return attrs, nil
+}
+
+// DeleteIdentity removes an email address or domain from the verified
+// identities.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_DeleteIdentity.html for details.
+func (self *SES) DeleteIdentity(ctx context.Context, identity string) error {
+	return self.Do(ctx, "DeleteIdentity", url.Values{"Identity": {identity}}, nil)
+}
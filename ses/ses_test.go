@@ -0,0 +1,91 @@
+//go:build !goaws_stable
+
+package ses
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/x/awstest"
+)
+
+func newTestSES(t *testing.T, responses map[string][]string) (*SES, *awstest.QueryServer) {
+	server := awstest.NewQueryServer(responses)
+	t.Cleanup(server.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.Region{Name: "eu-west-1"})
+	client.Endpoint.URL = server.URL
+	client.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+	return client, server
+}
+
+func TestSignsForSES(t *testing.T) {
+	client, server := newTestSES(t, map[string][]string{"VerifyEmailIdentity": nil})
+	if err := client.VerifyEmailIdentity(context.Background(), "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if auth := server.Header(0).Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") || !strings.Contains(auth, "/eu-west-1/ses/aws4_request") {
+		t.Fatalf("signed with %q", auth)
+	}
+	if sent := server.Request(0); sent.Get("EmailAddress") != "alice@example.com" || sent.Get("Version") != APIVersion {
+		t.Fatalf("sent %v", sent)
+	}
+}
+
+func TestVerifyDomain(t *testing.T) {
+	client, server := newTestSES(t, map[string][]string{
+		"VerifyDomainIdentity": {"<VerificationToken>tok</VerificationToken>"},
+		"VerifyDomainDkim":     {"<DkimTokens><member>d1</member><member>d2</member><member>d3</member></DkimTokens>"},
+	})
+	ctx := context.Background()
+	server.Fail("VerifyDomainIdentity", 1)
+	token, err := client.VerifyDomainIdentity(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "tok" || server.Count() != 2 {
+		t.Fatalf("got %q after %d requests", token, server.Count())
+	}
+	tokens, err := client.VerifyDomainDkim(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(tokens) != "[d1 d2 d3]" {
+		t.Fatalf("got %v", tokens)
+	}
+}
+
+func TestGetIdentityVerificationAttributes(t *testing.T) {
+	client, server := newTestSES(t, map[string][]string{
+		"GetIdentityVerificationAttributes": {`<VerificationAttributes>
+			<entry><key>example.com</key><value><VerificationStatus>Pending</VerificationStatus><VerificationToken>tok</VerificationToken></value></entry>
+			<entry><key>alice@example.com</key><value><VerificationStatus>Success</VerificationStatus></value></entry>
+		</VerificationAttributes>`},
+	})
+	attrs, err := client.GetIdentityVerificationAttributes(context.Background(), "example.com", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]IdentityVerification{
+		"example.com":       {"Pending", "tok"},
+		"alice@example.com": {VerificationStatus: "Success"},
+	}
+	if fmt.Sprint(attrs) != fmt.Sprint(want) {
+		t.Fatalf("got %v", attrs)
+	}
+	if sent := server.Request(0); sent.Get("Identities.member.2") != "alice@example.com" {
+		t.Fatalf("sent %v", sent)
+	}
+}
+
+func TestDeleteIdentity(t *testing.T) {
+	client, server := newTestSES(t, nil)
+	server.Fail("DeleteIdentity", 1)
+	err := client.DeleteIdentity(context.Background(), "example.com")
+	if aws.ErrorCode(err) != "InternalFailure" || server.Count() != 1 {
+		t.Fatalf("got %v after %d requests", err, server.Count())
+	}
+}
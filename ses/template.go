@@ -0,0 +1,109 @@
+package ses
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/dkln/go-aws"
+)
+
+// Template is an email template. Its parts may contain {{name}}
+// placeholders, which are replaced by the template data of each email.
+type Template struct {
+	TemplateName string
+	SubjectPart  string
+	TextPart     string
+	HtmlPart     string
+}
+
+func (self *Template) params() url.Values {
+	params := url.Values{
+		"Template.TemplateName": {self.TemplateName},
+		"Template.SubjectPart":  {self.SubjectPart},
+	}
+	if self.TextPart != "" {
+		params.Set("Template.TextPart", self.TextPart)
+	}
+	if self.HtmlPart != "" {
+		params.Set("Template.HtmlPart", self.HtmlPart)
+	}
+	return params
+}
+
+// CreateTemplate creates an email template.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_CreateTemplate.html for details.
+func (self *SES) CreateTemplate(ctx context.Context, template *Template) error {
+	return self.Do(ctx, "CreateTemplate", template.params(), nil)
+}
+
+// UpdateTemplate replaces the email template of the same name.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_UpdateTemplate.html for details.
+func (self *SES) UpdateTemplate(ctx context.Context, template *Template) error {
+	return self.Do(ctx, "UpdateTemplate", template.params(), nil)
+}
+
+// GetTemplate returns the email template named name.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_GetTemplate.html for details.
+func (self *SES) GetTemplate(ctx context.Context, name string) (*Template, error) {
+	var resp struct {
+		Template Template `xml:"GetTemplateResult>Template"`
+	}
+	if err := self.Do(ctx, "GetTemplate", url.Values{"TemplateName": {name}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Template, nil
+}
+
+// DeleteTemplate deletes the email template named name.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_DeleteTemplate.html for details.
+func (self *SES) DeleteTemplate(ctx context.Context, name string) error {
+	return self.Do(ctx, "DeleteTemplate", url.Values{"TemplateName": {name}}, nil)
+}
+
+// Destination lists the recipients of an email.
+type Destination struct {
+	ToAddresses  []string
+	CcAddresses  []string
+	BccAddresses []string
+}
+
+// TemplatedEmail is an email rendered from a template.
+type TemplatedEmail struct {
+	Source       string // a verified email address or one of a verified domain
+	Destination  Destination
+	ReplyTo      []string
+	Template     string
+	TemplateData interface{} // marshaled to the JSON object of placeholder values
+}
+
+// SendTemplatedEmail sends an email rendered from a template and returns
+// its message ID.
+//
+// See https://docs.aws.amazon.com/ses/latest/APIReference/API_SendTemplatedEmail.html for details.
+func (self *SES) SendTemplatedEmail(ctx context.Context, email *TemplatedEmail) (string, error) {
+	data, err := json.Marshal(email.TemplateData)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{
+		"Source":       {email.Source},
+		"Template":     {email.Template},
+		"TemplateData": {string(data)},
+	}
+	aws.SetList(params, "Destination.ToAddresses.member", email.Destination.ToAddresses)
+	aws.SetList(params, "Destination.CcAddresses.member", email.Destination.CcAddresses)
+	aws.SetList(params, "Destination.BccAddresses.member", email.Destination.BccAddresses)
+	aws.SetList(params, "ReplyToAddresses.member", email.ReplyTo)
+	var resp struct {
+		MessageId string `xml:"SendTemplatedEmailResult>MessageId"`
+	}
+	if err := self.Do(ctx, "SendTemplatedEmail", params, &resp); err != nil {
+		return "", err
+	}
+	return resp.MessageId, nil
+}
@@ -0,0 +1,78 @@
+//go:build !goaws_stable
+
+package ses
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	client, server := newTestSES(t, map[string][]string{
+		"CreateTemplate": nil,
+		"UpdateTemplate": nil,
+		"GetTemplate": {`<Template><TemplateName>welcome</TemplateName><SubjectPart>Hi {{name}}</SubjectPart>
+			<HtmlPart>&lt;p&gt;Welcome, {{name}}&lt;/p&gt;</HtmlPart></Template>`},
+	})
+	ctx := context.Background()
+	template := &Template{TemplateName: "welcome", SubjectPart: "Hi {{name}}", TextPart: "Welcome, {{name}}"}
+	if err := client.CreateTemplate(ctx, template); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Request(0); sent.Get("Template.TextPart") != "Welcome, {{name}}" {
+		t.Fatalf("sent %v", sent)
+	} else if _, ok := sent["Template.HtmlPart"]; ok {
+		t.Fatalf("sent unset HtmlPart")
+	}
+
+	template.TextPart, template.HtmlPart = "", "<p>Welcome, {{name}}</p>"
+	server.Fail("UpdateTemplate", 1)
+	if err := client.UpdateTemplate(ctx, template); err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.GetTemplate(ctx, "welcome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *template {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestSendTemplatedEmail(t *testing.T) {
+	client, server := newTestSES(t, map[string][]string{
+		"SendTemplatedEmail": {"<MessageId>msg-1</MessageId>"},
+	})
+	id, err := client.SendTemplatedEmail(context.Background(), &TemplatedEmail{
+		Source:       "noreply@example.com",
+		Destination:  Destination{ToAddresses: []string{"alice@example.com"}, BccAddresses: []string{"audit@example.com"}},
+		ReplyTo:      []string{"support@example.com"},
+		Template:     "welcome",
+		TemplateData: map[string]string{"name": "Alice"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "msg-1" {
+		t.Fatalf("got %q", id)
+	}
+	sent := server.Request(0)
+	for name, want := range map[string]string{
+		"TemplateData":                      `{"name":"Alice"}`,
+		"Destination.ToAddresses.member.1":  "alice@example.com",
+		"Destination.BccAddresses.member.1": "audit@example.com",
+		"ReplyToAddresses.member.1":         "support@example.com",
+	} {
+		if got := sent.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, ok := sent["Destination.CcAddresses.member.1"]; ok {
+		t.Errorf("sent unset CcAddresses")
+	}
+
+	_, err = client.SendTemplatedEmail(context.Background(), &TemplatedEmail{TemplateData: func() {}})
+	if err == nil {
+		t.Fatal("expected unmarshalable template data to fail")
+	}
+}
@@ -0,0 +1,253 @@
+package aws
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	hexenc "encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * Signer authenticates requests to an AWS service. Service clients call
+ * their Signer for every request instead of hardcoding a signature
+ * scheme, so new schemes and the quirks of custom endpoints can be
+ * plugged in without forking the client.
+ */
+type Signer interface {
+	// Sign adds authentication to req, usually as an Authorization
+	// header. It is called again for every retry.
+	Sign(req *http.Request, auth Auth) error
+
+	// Presign adds authentication to the query string of req, so that its
+	// URL can be used without credentials until expires.
+	Presign(req *http.Request, auth Auth, expires time.Time) error
+}
+
+/**
+ * V4Signer signs requests with AWS Signature Version 4, which every
+ * region supports and regions opened since 2014 require.
+ */
+type V4Signer struct {
+	Region  string // signing region, e.g. "eu-central-1"
+	Service string // signing name of the service, e.g. "s3"
+}
+
+const (
+	v4Algorithm       = "AWS4-HMAC-SHA256"
+	v4TimeFormat      = "20060102T150405Z"
+	v4DateFormat      = "20060102"
+	v4UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+/**
+ * Sign adds a Signature Version 4 Authorization header to req. For S3 the
+ * payload is left unsigned unless X-Amz-Content-Sha256 is already set;
+ * other services get the hash of the body, which is read through GetBody
+ * or buffered and restored.
+ */
+func (self *V4Signer) Sign(req *http.Request, auth Auth) error {
+	now := time.Now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(v4TimeFormat))
+	if auth.Token != "" {
+		req.Header.Set("X-Amz-Security-Token", auth.Token)
+	}
+
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		if self.Service == "s3" {
+			payloadHash = v4UnsignedPayload
+		} else {
+			hash, err := v4PayloadHash(req)
+			if err != nil {
+				return err
+			}
+			payloadHash = hash
+		}
+		if self.Service == "s3" {
+			req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+		}
+	}
+
+	signedHeaders, canonicalHeaders := self.canonicalHeaders(req)
+	signature := self.signature(req, auth, now, signedHeaders, canonicalHeaders, payloadHash)
+
+	req.Header.Set("Authorization", v4Algorithm+
+		" Credential="+auth.AccessKey+"/"+self.scope(now)+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+	return nil
+}
+
+/**
+ * Presign adds Signature Version 4 query string authentication to req,
+ * valid until expires but for at most seven days.
+ */
+func (self *V4Signer) Presign(req *http.Request, auth Auth, expires time.Time) error {
+	now := time.Now().UTC()
+	seconds := int64(expires.Sub(now) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	if seconds > 7*24*3600 {
+		seconds = 7 * 24 * 3600
+	}
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", v4Algorithm)
+	query.Set("X-Amz-Credential", auth.AccessKey+"/"+self.scope(now))
+	query.Set("X-Amz-Date", now.Format(v4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(seconds, 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if auth.Token != "" {
+		query.Set("X-Amz-Security-Token", auth.Token)
+	}
+	query.Del("X-Amz-Signature")
+	req.URL.RawQuery = query.Encode()
+
+	canonicalHeaders := "host:" + requestHost(req) + "\n"
+	signature := self.signature(req, auth, now, "host", canonicalHeaders, v4UnsignedPayload)
+
+	query.Set("X-Amz-Signature", signature)
+	req.URL.RawQuery = query.Encode()
+	return nil
+}
+
+func (self *V4Signer) scope(now time.Time) string {
+	return now.Format(v4DateFormat) + "/" + self.Region + "/" + self.Service + "/aws4_request"
+}
+
+func (self *V4Signer) signature(req *http.Request, auth Auth, now time.Time, signedHeaders, canonicalHeaders, payloadHash string) string {
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		self.canonicalURI(req),
+		canonicalQuery(req),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		v4Algorithm,
+		now.Format(v4TimeFormat),
+		self.scope(now),
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+auth.SecretKey), now.Format(v4DateFormat))
+	key = hmacSHA256(key, self.Region)
+	key = hmacSHA256(key, self.Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hexenc.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+/**
+ * canonicalURI encodes each path segment once for S3 and twice for all
+ * other services, as the specification requires.
+ */
+func (self *V4Signer) canonicalURI(req *http.Request) string {
+	path := req.URL.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segment = Encode(segment)
+		if self.Service != "s3" {
+			segment = Encode(segment)
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(req *http.Request) string {
+	var pairs []string
+	for key, values := range req.URL.Query() {
+		for _, value := range values {
+			pairs = append(pairs, Encode(key)+"="+Encode(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+/**
+ * canonicalHeaders signs the host, Content-Type, Content-MD5 and all
+ * x-amz-* headers. Other headers may be changed by proxies.
+ */
+func (self *V4Signer) canonicalHeaders(req *http.Request) (signed, canonical string) {
+	headers := map[string]string{"host": requestHost(req)}
+	for key, values := range req.Header {
+		key = strings.ToLower(key)
+		if key == "content-type" || key == "content-md5" || strings.HasPrefix(key, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, value := range values {
+				trimmed[i] = strings.Join(strings.Fields(value), " ")
+			}
+			headers[key] = strings.Join(trimmed, ",")
+		}
+	}
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, key := range keys {
+		buf.WriteString(key + ":" + headers[key] + "\n")
+	}
+	return strings.Join(keys, ";"), buf.String()
+}
+
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+func v4PayloadHash(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return hexSHA256(nil), nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return "", err
+		}
+		return hexSHA256(data), nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	return hexSHA256(data), nil
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hexenc.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	hash := hmac.New(sha256.New, key)
+	hash.Write([]byte(data))
+	return hash.Sum(nil)
+}
@@ -0,0 +1,93 @@
+package sns
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Names of topic attributes.
+const (
+	AttrTopicArn                = "TopicArn"
+	AttrOwner                   = "Owner"
+	AttrDisplayName             = "DisplayName"
+	AttrPolicy                  = "Policy"
+	AttrDeliveryPolicy          = "DeliveryPolicy"
+	AttrEffectiveDeliveryPolicy = "EffectiveDeliveryPolicy"
+	AttrSubscriptionsConfirmed  = "SubscriptionsConfirmed"
+	AttrSubscriptionsPending    = "SubscriptionsPending"
+	AttrSubscriptionsDeleted    = "SubscriptionsDeleted"
+)
+
+// TopicAttributes are the attributes of a topic.
+type TopicAttributes struct {
+	TopicArn    string
+	Owner       string // AWS account ID
+	DisplayName string
+
+	Policy                  string // JSON access policy
+	DeliveryPolicy          string // JSON HTTP(S) retry policy, if set
+	EffectiveDeliveryPolicy string // JSON retry policy in effect
+
+	SubscriptionsConfirmed int
+	SubscriptionsPending   int
+	SubscriptionsDeleted   int
+
+	// Raw holds every attribute returned, including those without a
+	// field above, by name.
+	Raw map[string]string
+}
+
+// GetAttributes returns the attributes of the topic.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_GetTopicAttributes.html for details.
+func (self *Topic) GetAttributes(ctx context.Context) (*TopicAttributes, error) {
+	var resp struct {
+		Entries []struct {
+			Key   string `xml:"key"`
+			Value string `xml:"value"`
+		} `xml:"GetTopicAttributesResult>Attributes>entry"`
+	}
+	if err := self.Do(ctx, "GetTopicAttributes", url.Values{"TopicArn": {self.Arn}}, &resp); err != nil {
+		return nil, err
+	}
+	attrs := &TopicAttributes{Raw: map[string]string{}}
+	for _, entry := range resp.Entries {
+		attrs.Raw[entry.Key] = entry.Value
+	}
+	attrs.TopicArn = attrs.Raw[AttrTopicArn]
+	attrs.Owner = attrs.Raw[AttrOwner]
+	attrs.DisplayName = attrs.Raw[AttrDisplayName]
+	attrs.Policy = attrs.Raw[AttrPolicy]
+	attrs.DeliveryPolicy = attrs.Raw[AttrDeliveryPolicy]
+	attrs.EffectiveDeliveryPolicy = attrs.Raw[AttrEffectiveDeliveryPolicy]
+	attrs.SubscriptionsConfirmed, _ = strconv.Atoi(attrs.Raw[AttrSubscriptionsConfirmed])
+	attrs.SubscriptionsPending, _ = strconv.Atoi(attrs.Raw[AttrSubscriptionsPending])
+	attrs.SubscriptionsDeleted, _ = strconv.Atoi(attrs.Raw[AttrSubscriptionsDeleted])
+	return attrs, nil
+}
+
+// SetAttribute sets the attribute of the topic named name, such as
+// AttrDisplayName, AttrPolicy or AttrDeliveryPolicy.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_SetTopicAttributes.html for details.
+func (self *Topic) SetAttribute(ctx context.Context, name, value string) error {
+	params := url.Values{
+		"TopicArn":       {self.Arn},
+		"AttributeName":  {name},
+		"AttributeValue": {value},
+	}
+	return self.Do(ctx, "SetTopicAttributes", params, nil)
+}
+
+// SetPolicy replaces the access policy of the topic with the JSON policy
+// document policy.
+func (self *Topic) SetPolicy(ctx context.Context, policy string) error {
+	return self.SetAttribute(ctx, AttrPolicy, policy)
+}
+
+// SetDeliveryPolicy sets the JSON policy governing the retries of
+// deliveries to HTTP(S) subscriptions of the topic.
+func (self *Topic) SetDeliveryPolicy(ctx context.Context, policy string) error {
+	return self.SetAttribute(ctx, AttrDeliveryPolicy, policy)
+}
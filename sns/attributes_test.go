@@ -0,0 +1,68 @@
+//go:build !goaws_stable
+
+package sns
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestGetAttributes(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{
+		"GetTopicAttributes": {`<Attributes>
+			<entry><key>TopicArn</key><value>` + topicArn + `</value></entry>
+			<entry><key>Owner</key><value>123</value></entry>
+			<entry><key>DisplayName</key><value>Orders</value></entry>
+			<entry><key>Policy</key><value>{"Version":"2012-10-17"}</value></entry>
+			<entry><key>EffectiveDeliveryPolicy</key><value>{"http":{}}</value></entry>
+			<entry><key>SubscriptionsConfirmed</key><value>3</value></entry>
+			<entry><key>SubscriptionsPending</key><value>1</value></entry>
+			<entry><key>SubscriptionsDeleted</key><value>0</value></entry>
+			<entry><key>KmsMasterKeyId</key><value>alias/sns</value></entry>
+		</Attributes>`},
+	})
+
+	attrs, err := client.Topic(topicArn).GetAttributes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(0), url.Values{"Action": {"GetTopicAttributes"}, "TopicArn": {topicArn}})
+	if attrs.TopicArn != topicArn || attrs.Owner != "123" || attrs.DisplayName != "Orders" ||
+		attrs.Policy != `{"Version":"2012-10-17"}` || attrs.DeliveryPolicy != "" || attrs.EffectiveDeliveryPolicy != `{"http":{}}` {
+		t.Fatalf("unexpected attributes %+v", attrs)
+	}
+	if attrs.SubscriptionsConfirmed != 3 || attrs.SubscriptionsPending != 1 || attrs.SubscriptionsDeleted != 0 {
+		t.Fatalf("unexpected counts %+v", attrs)
+	}
+	// Attributes without a field are kept in Raw.
+	if attrs.Raw["KmsMasterKeyId"] != "alias/sns" || len(attrs.Raw) != 9 {
+		t.Fatalf("unexpected raw attributes %v", attrs.Raw)
+	}
+}
+
+func TestSetAttribute(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{"SetTopicAttributes": {""}})
+	server.Fail("SetTopicAttributes", 1)
+	topic := client.Topic(topicArn)
+	ctx := context.Background()
+
+	if err := topic.SetAttribute(ctx, AttrDisplayName, "Orders"); err != nil {
+		t.Fatal(err)
+	}
+	// Setting an attribute is idempotent, so the failed first attempt is
+	// retried.
+	if server.Count() != 2 {
+		t.Fatalf("got %d requests, want 2", server.Count())
+	}
+	checkParams(t, server.Request(1), url.Values{"TopicArn": {topicArn}, "AttributeName": {"DisplayName"}, "AttributeValue": {"Orders"}})
+
+	if err := topic.SetPolicy(ctx, `{"Statement":[]}`); err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(2), url.Values{"AttributeName": {"Policy"}, "AttributeValue": {`{"Statement":[]}`}})
+	if err := topic.SetDeliveryPolicy(ctx, `{"http":{}}`); err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(3), url.Values{"AttributeName": {"DeliveryPolicy"}, "AttributeValue": {`{"http":{}}`}})
+}
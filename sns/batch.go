@@ -0,0 +1,62 @@
+package sns
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// MaxBatchSize is the largest number of entries SNS accepts in a batch.
+const MaxBatchSize = 10
+
+// PublishBatchEntry is a message to publish with PublishBatch. Id
+// identifies the entry in the results and must be unique in the batch.
+type PublishBatchEntry struct {
+	Id string
+	PublishParams
+}
+
+// PublishBatchResultEntry reports a message published by PublishBatch.
+type PublishBatchResultEntry struct {
+	Id        string
+	MessageId string
+}
+
+// BatchResultErrorEntry reports an entry of a batch that failed. The
+// other entries of the batch are not affected.
+type BatchResultErrorEntry struct {
+	Id          string
+	Code        string
+	Message     string
+	SenderFault bool
+}
+
+func (self *BatchResultErrorEntry) Error() string {
+	return self.Id + ": " + self.Code + ": " + self.Message
+}
+
+// PublishBatchResp is the result of PublishBatch.
+type PublishBatchResp struct {
+	Successful       []PublishBatchResultEntry `xml:"PublishBatchResult>Successful>member"`
+	Failed           []BatchResultErrorEntry   `xml:"PublishBatchResult>Failed>member"`
+	ResponseMetadata ResponseMetadata
+}
+
+// PublishBatch publishes up to MaxBatchSize messages to the topic in a
+// single request. Messages that could not be published are listed in
+// Failed of the result rather than failing the whole call.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_PublishBatch.html for details.
+func (self *Topic) PublishBatch(ctx context.Context, entries []PublishBatchEntry) (*PublishBatchResp, error) {
+	params := url.Values{"TopicArn": {self.Arn}}
+	for i, entry := range entries {
+		prefix := "PublishBatchRequestEntries.member." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"Id", entry.Id)
+		setPublishParams(params, prefix, entry.PublishParams)
+	}
+	resp := &PublishBatchResp{}
+	if err := self.Do(ctx, "PublishBatch", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
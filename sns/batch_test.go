@@ -0,0 +1,66 @@
+//go:build !goaws_stable
+
+package sns
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestPublishBatch(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{
+		"PublishBatch": {`<Successful><member><Id>1</Id><MessageId>msg-1</MessageId></member></Successful>
+			<Failed><member><Id>2</Id><Code>InvalidParameter</Code><Message>message too long</Message><SenderFault>true</SenderFault></member></Failed>`},
+	})
+
+	resp, err := client.Topic(topicArn).PublishBatch(context.Background(), []PublishBatchEntry{
+		{Id: "1", PublishParams: PublishParams{Message: "first", Subject: "s"}},
+		{Id: "2", PublishParams: PublishParams{Message: "second", MessageStructure: "json", MessageAttributes: map[string]MessageAttributeValue{"kind": StringAttribute("order")}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := server.Request(0)
+	checkParams(t, got, url.Values{
+		"TopicArn":                                                                        {topicArn},
+		"PublishBatchRequestEntries.member.1.Id":                                          {"1"},
+		"PublishBatchRequestEntries.member.1.Message":                                     {"first"},
+		"PublishBatchRequestEntries.member.1.Subject":                                     {"s"},
+		"PublishBatchRequestEntries.member.2.Id":                                          {"2"},
+		"PublishBatchRequestEntries.member.2.Message":                                     {"second"},
+		"PublishBatchRequestEntries.member.2.MessageStructure":                            {"json"},
+		"PublishBatchRequestEntries.member.2.MessageAttributes.entry.1.Name":              {"kind"},
+		"PublishBatchRequestEntries.member.2.MessageAttributes.entry.1.Value.DataType":    {"String"},
+		"PublishBatchRequestEntries.member.2.MessageAttributes.entry.1.Value.StringValue": {"order"},
+	})
+	for _, name := range []string{"Message", "PublishBatchRequestEntries.member.2.Subject", "PublishBatchRequestEntries.member.1.MessageStructure"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("unexpected %s=%q", name, got.Get(name))
+		}
+	}
+
+	// The failure of an entry is reported in the result, not as the
+	// error of the call.
+	if !reflect.DeepEqual(resp.Successful, []PublishBatchResultEntry{{"1", "msg-1"}}) {
+		t.Fatalf("got successful %+v", resp.Successful)
+	}
+	want := []BatchResultErrorEntry{{"2", "InvalidParameter", "message too long", true}}
+	if !reflect.DeepEqual(resp.Failed, want) {
+		t.Fatalf("got failed %+v", resp.Failed)
+	}
+	if msg := resp.Failed[0].Error(); msg != "2: InvalidParameter: message too long" {
+		t.Fatalf("got error %q", msg)
+	}
+}
+
+func TestPublishBatchError(t *testing.T) {
+	client, server := newTestSNS(t, nil)
+	server.Error("PublishBatch", 400, "TooManyEntriesInBatchRequest")
+
+	resp, err := client.Topic(topicArn).PublishBatch(context.Background(), []PublishBatchEntry{{Id: "1", PublishParams: PublishParams{Message: "m"}}})
+	if err == nil || resp != nil {
+		t.Fatalf("got %+v, %v; want the error of the request", resp, err)
+	}
+}
@@ -0,0 +1,96 @@
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/dkln/go-aws/sqs"
+)
+
+// SubscribeQueue subscribes queue to the topic, after adding a statement
+// to the queue's access policy that lets SNS send the topic's messages to
+// it, which SQS otherwise refuses silently. Statements already in the
+// policy are kept. With raw set, the queue receives the published
+// messages as they are instead of wrapped in a JSON envelope. It returns
+// the ARN of the subscription.
+func (self *Topic) SubscribeQueue(ctx context.Context, queue *sqs.Queue, raw bool) (string, error) {
+	attrs, err := queue.GetAttributesWithContext(ctx, sqs.AttrQueueArn, sqs.AttrPolicy)
+	if err != nil {
+		return "", err
+	}
+	policy, err := allowTopic(attrs.Policy, attrs.QueueArn, self.Arn)
+	if err != nil {
+		return "", err
+	}
+	if policy != attrs.Policy {
+		if err := queue.SetAttributesWithContext(ctx, map[string]string{sqs.AttrPolicy: policy}); err != nil {
+			return "", err
+		}
+	}
+
+	arn, err := self.SubscribeWithContext(ctx, "sqs", attrs.QueueArn)
+	if err != nil {
+		return "", err
+	}
+	if raw {
+		if err := self.SetSubscriptionAttribute(ctx, arn, "RawMessageDelivery", "true"); err != nil {
+			return "", err
+		}
+	}
+	return arn, nil
+}
+
+// allowTopic returns the queue policy policy with a statement allowing
+// the topic to send messages to the queue added, unless it has one.
+func allowTopic(policy, queueArn, topicArn string) (string, error) {
+	doc := map[string]interface{}{}
+	if policy != "" {
+		if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+			return "", err
+		}
+	}
+	if _, ok := doc["Version"]; !ok {
+		doc["Version"] = "2012-10-17"
+	}
+
+	var statements []interface{}
+	switch s := doc["Statement"].(type) {
+	case []interface{}:
+		statements = s
+	case map[string]interface{}:
+		statements = []interface{}{s}
+	}
+	sid := "AllowSNS-" + topicArn
+	for _, s := range statements {
+		if statement, ok := s.(map[string]interface{}); ok && statement["Sid"] == sid {
+			return policy, nil
+		}
+	}
+	doc["Statement"] = append(statements, map[string]interface{}{
+		"Sid":       sid,
+		"Effect":    "Allow",
+		"Principal": map[string]interface{}{"Service": "sns.amazonaws.com"},
+		"Action":    "sqs:SendMessage",
+		"Resource":  queueArn,
+		"Condition": map[string]interface{}{
+			"ArnEquals": map[string]interface{}{"aws:SourceArn": topicArn},
+		},
+	})
+	data, err := json.Marshal(doc)
+	return string(data), err
+}
+
+// SetSubscriptionAttribute sets the attribute of the subscription with
+// the given ARN named name, such as "RawMessageDelivery" or
+// "FilterPolicy".
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_SetSubscriptionAttributes.html for details.
+func (self *SNS) SetSubscriptionAttribute(ctx context.Context, subscriptionArn, name, value string) error {
+	params := url.Values{
+		"SubscriptionArn": {subscriptionArn},
+		"AttributeName":   {name},
+		"AttributeValue":  {value},
+	}
+	return self.Do(ctx, "SetSubscriptionAttributes", params, nil)
+}
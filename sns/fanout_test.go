@@ -0,0 +1,127 @@
+//go:build !goaws_stable
+
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/sqs"
+	"github.com/dkln/go-aws/x/awstest"
+)
+
+const queueArn = "arn:aws:sqs:us-east-1:123:orders"
+
+// newTestQueue returns a queue served by a fake SQS whose queue has the
+// access policy policy.
+func newTestQueue(t *testing.T, policy string) (*sqs.Queue, *awstest.QueryServer) {
+	attrs := "<Attribute><Name>QueueArn</Name><Value>" + queueArn + "</Value></Attribute>"
+	if policy != "" {
+		attrs += "<Attribute><Name>Policy</Name><Value>" + html.EscapeString(policy) + "</Value></Attribute>"
+	}
+	server := awstest.NewQueryServer(map[string][]string{
+		"GetQueueAttributes": {attrs},
+		"SetQueueAttributes": {""},
+	})
+	t.Cleanup(server.Close)
+	client := sqs.New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.Region{Name: "us-east-1", SQSEndpoint: server.URL, SigV4Only: true})
+	return client.Queue(server.URL + "/123/orders"), server
+}
+
+func TestSubscribeQueue(t *testing.T) {
+	queue, queueServer := newTestQueue(t, "")
+	client, server := newTestSNS(t, map[string][]string{
+		"Subscribe":                 {"<SubscriptionArn>" + topicArn + ":sub-1</SubscriptionArn>"},
+		"SetSubscriptionAttributes": {""},
+	})
+
+	arn, err := client.Topic(topicArn).SubscribeQueue(context.Background(), queue, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arn != topicArn+":sub-1" {
+		t.Fatalf("got subscription %q", arn)
+	}
+
+	if queueServer.Count() != 2 {
+		t.Fatalf("got %d SQS requests, want 2", queueServer.Count())
+	}
+	set := queueServer.Request(1)
+	if set.Get("Action") != "SetQueueAttributes" || set.Get("Attribute.1.Name") != "Policy" {
+		t.Fatalf("unexpected params %v", set)
+	}
+	var policy map[string]interface{}
+	if err := json.Unmarshal([]byte(set.Get("Attribute.1.Value")), &policy); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []interface{}{map[string]interface{}{
+			"Sid":       "AllowSNS-" + topicArn,
+			"Effect":    "Allow",
+			"Principal": map[string]interface{}{"Service": "sns.amazonaws.com"},
+			"Action":    "sqs:SendMessage",
+			"Resource":  queueArn,
+			"Condition": map[string]interface{}{
+				"ArnEquals": map[string]interface{}{"aws:SourceArn": topicArn},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Fatalf("got policy %v, want %v", policy, want)
+	}
+
+	checkParams(t, server.Request(0), url.Values{"Action": {"Subscribe"}, "TopicArn": {topicArn}, "Protocol": {"sqs"}, "Endpoint": {queueArn}})
+	checkParams(t, server.Request(1), url.Values{
+		"Action":          {"SetSubscriptionAttributes"},
+		"SubscriptionArn": {arn},
+		"AttributeName":   {"RawMessageDelivery"},
+		"AttributeValue":  {"true"},
+	})
+}
+
+func TestSubscribeQueueKeepsPolicy(t *testing.T) {
+	existing := `{"Version":"2008-10-17","Statement":{"Sid":"Other","Effect":"Allow","Principal":"*","Action":"sqs:ReceiveMessage","Resource":"` + queueArn + `"}}`
+	queue, queueServer := newTestQueue(t, existing)
+	client, server := newTestSNS(t, map[string][]string{"Subscribe": {"<SubscriptionArn>" + topicArn + ":sub-1</SubscriptionArn>"}})
+
+	if _, err := client.Topic(topicArn).SubscribeQueue(context.Background(), queue, false); err != nil {
+		t.Fatal(err)
+	}
+	var policy struct {
+		Version   string
+		Statement []struct{ Sid string }
+	}
+	if err := json.Unmarshal([]byte(queueServer.Request(1).Get("Attribute.1.Value")), &policy); err != nil {
+		t.Fatal(err)
+	}
+	if policy.Version != "2008-10-17" || len(policy.Statement) != 2 || policy.Statement[0].Sid != "Other" || policy.Statement[1].Sid != "AllowSNS-"+topicArn {
+		t.Fatalf("unexpected policy %+v", policy)
+	}
+	// Without raw delivery, the subscription is left as it is.
+	if server.Count() != 1 {
+		t.Fatalf("got %d SNS requests, want 1", server.Count())
+	}
+}
+
+func TestSubscribeQueueAlreadyAllowed(t *testing.T) {
+	policy, err := allowTopic("", queueArn, topicArn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue, queueServer := newTestQueue(t, policy)
+	client, _ := newTestSNS(t, map[string][]string{"Subscribe": {"<SubscriptionArn>" + topicArn + ":sub-1</SubscriptionArn>"}})
+
+	if _, err := client.Topic(topicArn).SubscribeQueue(context.Background(), queue, false); err != nil {
+		t.Fatal(err)
+	}
+	// The policy allows the topic already, so it isn't written again.
+	if queueServer.Count() != 1 {
+		t.Fatalf("got %d SQS requests, want 1", queueServer.Count())
+	}
+}
@@ -0,0 +1,288 @@
+package sns
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// Types of the messages SNS posts to HTTP(S) endpoints.
+const (
+	TypeNotification             = "Notification"
+	TypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	TypeUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// Notification is a message SNS posts to an HTTP(S) subscription.
+type Notification struct {
+	Type              string
+	MessageId         string
+	Token             string // of confirmations
+	TopicArn          string
+	Subject           string
+	Message           string
+	Timestamp         string
+	SignatureVersion  string
+	Signature         string
+	SigningCertURL    string
+	SubscribeURL      string // of confirmations
+	UnsubscribeURL    string // of notifications
+	MessageAttributes map[string]struct {
+		Type  string
+		Value string
+	}
+}
+
+// ErrInvalidSignature is returned for messages that were not signed by
+// SNS.
+var ErrInvalidSignature = errors.New("sns: invalid message signature")
+
+// snsHost matches the hosts SNS serves signing certificates and
+// subscription confirmations from.
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// HTTPHandler receives the messages SNS posts to an HTTP(S) subscription.
+// It verifies their signature, confirms subscriptions and hands
+// notifications to OnNotification. Messages that fail verification are
+// answered with 403.
+type HTTPHandler struct {
+	// OnNotification is called with every notification. An error makes
+	// the handler respond with 500, so SNS retries the delivery according
+	// to the topic's delivery policy.
+	OnNotification func(ctx context.Context, n *Notification) error
+
+	// TopicArns, if not empty, are the only topics whose messages are
+	// accepted. Without it, anyone can subscribe the endpoint to a topic
+	// of their own.
+	TopicArns []string
+
+	// ManualConfirm stops the handler from confirming subscriptions by
+	// visiting their SubscribeURL; OnNotification then receives the
+	// confirmations too.
+	ManualConfirm bool
+
+	// Client fetches signing certificates and confirms subscriptions. If
+	// nil, a client shared by all handlers is used.
+	Client *http.Client
+
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate // by URL
+}
+
+// NewHTTPHandler returns an HTTPHandler calling onNotification.
+func NewHTTPHandler(onNotification func(ctx context.Context, n *Notification) error) *HTTPHandler {
+	return &HTTPHandler{OnNotification: onNotification}
+}
+
+func (self *HTTPHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n := &Notification{}
+	if err := json.Unmarshal(data, n); err != nil {
+		http.Error(w, "malformed message", http.StatusBadRequest)
+		return
+	}
+	if !self.acceptsTopic(n.TopicArn) {
+		http.Error(w, "topic not accepted", http.StatusForbidden)
+		return
+	}
+	if err := self.Verify(req.Context(), n); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case n.Type == TypeSubscriptionConfirmation && !self.ManualConfirm:
+		err = self.Confirm(req.Context(), n)
+	case n.Type == TypeNotification || self.ManualConfirm:
+		if self.OnNotification != nil {
+			err = self.OnNotification(req.Context(), n)
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (self *HTTPHandler) acceptsTopic(arn string) bool {
+	if len(self.TopicArns) == 0 {
+		return true
+	}
+	for _, accepted := range self.TopicArns {
+		if accepted == arn {
+			return true
+		}
+	}
+	return false
+}
+
+// Confirm confirms the subscription n is the confirmation of.
+func (self *HTTPHandler) Confirm(ctx context.Context, n *Notification) error {
+	u, err := url.Parse(n.SubscribeURL)
+	if err != nil || u.Scheme != "https" || !snsHost.MatchString(u.Host) {
+		return fmt.Errorf("sns: refusing to confirm subscription at %q", n.SubscribeURL)
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := self.client().Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sns: confirming subscription: %s", resp.Status)
+	}
+	return nil
+}
+
+// Verify checks that n was signed by SNS, with the certificate at its
+// SigningCertURL. Certificates are cached.
+func (self *HTTPHandler) Verify(ctx context.Context, n *Notification) error {
+	var hash crypto.Hash
+	switch n.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return ErrInvalidSignature
+	}
+	signature, err := base64.StdEncoding.DecodeString(n.Signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	cert, err := self.certificate(ctx, n.SigningCertURL)
+	if err != nil {
+		return err
+	}
+
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return ErrInvalidSignature
+	}
+	// Signature Version 1 uses SHA-1, which x509 no longer verifies, so
+	// the signature is checked against the key directly.
+	h := hash.New()
+	h.Write([]byte(n.stringToSign()))
+	if rsa.VerifyPKCS1v15(key, hash, h.Sum(nil), signature) != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// stringToSign returns the fields of n SNS signs, in the order it signs
+// them.
+func (self *Notification) stringToSign() string {
+	names := []string{"Message", "MessageId", "SubscribeURL", "Timestamp", "Token", "TopicArn", "Type"}
+	if self.Type == TypeNotification {
+		names = []string{"Message", "MessageId", "Subject", "Timestamp", "TopicArn", "Type"}
+	}
+	values := map[string]string{
+		"Message":      self.Message,
+		"MessageId":    self.MessageId,
+		"Subject":      self.Subject,
+		"SubscribeURL": self.SubscribeURL,
+		"Timestamp":    self.Timestamp,
+		"Token":        self.Token,
+		"TopicArn":     self.TopicArn,
+		"Type":         self.Type,
+	}
+	var buf bytes.Buffer
+	for _, name := range names {
+		if name == "Subject" && self.Subject == "" {
+			continue
+		}
+		buf.WriteString(name + "\n" + values[name] + "\n")
+	}
+	return buf.String()
+}
+
+func (self *HTTPHandler) certificate(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	self.mu.Lock()
+	cert := self.certs[certURL]
+	self.mu.Unlock()
+	if cert != nil {
+		return cert, nil
+	}
+
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || !snsHost.MatchString(u.Host) {
+		return nil, ErrInvalidSignature
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := self.client().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sns: fetching signing certificate: %s", resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("sns: malformed signing certificate")
+	}
+	if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, err
+	}
+
+	self.mu.Lock()
+	if self.certs == nil {
+		self.certs = map[string]*x509.Certificate{}
+	}
+	self.certs[certURL] = cert
+	self.mu.Unlock()
+	return cert, nil
+}
+
+func (self *HTTPHandler) client() *http.Client {
+	if self.Client != nil {
+		return self.Client
+	}
+	return defaultClient
+}
+
+// defaultClient is used by handlers without a Client. Its connections
+// are counted in aws.Diagnostics.
+var defaultClient = newDefaultClient()
+
+func newDefaultClient() *http.Client {
+	client := aws.NewSingleTryClient(10 * time.Second)
+	client.Timeout = 30 * time.Second
+	return client
+}
@@ -0,0 +1,33 @@
+package sns
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+func TestDefaultClientConnectionsAreTracked(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// Connections of earlier tests close in the background; wait for
+	// them so they don't skew the count.
+	defaultClient.CloseIdleConnections()
+	for deadline := time.Now().Add(2 * time.Second); aws.Diagnostics().OpenConnections > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	before := aws.Diagnostics().OpenConnections
+	resp, err := (&HTTPHandler{}).client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if after := aws.Diagnostics().OpenConnections; after <= before {
+		t.Fatalf("open connections went from %d to %d", before, after)
+	}
+	defaultClient.CloseIdleConnections()
+}
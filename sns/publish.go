@@ -0,0 +1,105 @@
+package sns
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MessageAttributeValue is the value of a message attribute. DataType is
+// "String", "String.Array", "Number" or "Binary". Binary values are held
+// in BinaryValue, the others in StringValue.
+type MessageAttributeValue struct {
+	DataType    string
+	StringValue string
+	BinaryValue []byte
+}
+
+// StringAttribute returns a String message attribute value.
+func StringAttribute(value string) MessageAttributeValue {
+	return MessageAttributeValue{DataType: "String", StringValue: value}
+}
+
+// NumberAttribute returns a Number message attribute value.
+func NumberAttribute(value int64) MessageAttributeValue {
+	return MessageAttributeValue{DataType: "Number", StringValue: strconv.FormatInt(value, 10)}
+}
+
+// BinaryAttribute returns a Binary message attribute value.
+func BinaryAttribute(value []byte) MessageAttributeValue {
+	return MessageAttributeValue{DataType: "Binary", BinaryValue: value}
+}
+
+// PublishParams describe a message to publish.
+type PublishParams struct {
+	Subject string
+	Message string
+
+	// MessageStructure "json" makes Message a JSON object of messages by
+	// protocol, with the one under "default" going to the protocols not
+	// listed. See JSONMessage.
+	MessageStructure string
+
+	// MessageAttributes are metadata sent along with the message, by
+	// name, which subscription filter policies match against.
+	MessageAttributes map[string]MessageAttributeValue
+}
+
+// JSONMessage returns the message delivering defaultMessage to every
+// protocol but those in byProtocol, such as "sqs", "email" or "http",
+// which receive their own message instead. Publish it with
+// MessageStructure "json".
+func JSONMessage(defaultMessage string, byProtocol map[string]string) (string, error) {
+	messages := map[string]string{"default": defaultMessage}
+	for protocol, message := range byProtocol {
+		messages[protocol] = message
+	}
+	data, err := json.Marshal(messages)
+	return string(data), err
+}
+
+// PublishWithParams sends the message params describe to every
+// subscriber of the topic.
+func (self *Topic) PublishWithParams(ctx context.Context, params PublishParams) (*PublishResp, error) {
+	return self.publish(ctx, url.Values{"TopicArn": {self.Arn}}, params)
+}
+
+func (self *SNS) publish(ctx context.Context, query url.Values, params PublishParams) (*PublishResp, error) {
+	setPublishParams(query, "", params)
+	resp := &PublishResp{}
+	if err := self.Do(ctx, "Publish", query, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// setPublishParams adds params to query below prefix.
+func setPublishParams(query url.Values, prefix string, params PublishParams) {
+	query.Set(prefix+"Message", params.Message)
+	if params.Subject != "" {
+		query.Set(prefix+"Subject", params.Subject)
+	}
+	if params.MessageStructure != "" {
+		query.Set(prefix+"MessageStructure", params.MessageStructure)
+	}
+	names := make([]string, 0, len(params.MessageAttributes))
+	for name := range params.MessageAttributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		value := params.MessageAttributes[name]
+		n := prefix + "MessageAttributes.entry." + strconv.Itoa(i+1) + "."
+		query.Set(n+"Name", name)
+		query.Set(n+"Value.DataType", value.DataType)
+		if strings.HasPrefix(value.DataType, "Binary") {
+			query.Set(n+"Value.BinaryValue", base64.StdEncoding.EncodeToString(value.BinaryValue))
+		} else {
+			query.Set(n+"Value.StringValue", value.StringValue)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !goaws_stable
+
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestPublishMessageAttributes(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{"Publish": {"<MessageId>msg-1</MessageId>"}})
+
+	_, err := client.Topic(topicArn).PublishWithParams(context.Background(), PublishParams{
+		Message: "hello",
+		MessageAttributes: map[string]MessageAttributeValue{
+			"kind":     StringAttribute("order"),
+			"amount":   NumberAttribute(42),
+			"checksum": BinaryAttribute([]byte{0xde, 0xad}),
+			"tags":     {DataType: "String.Array", StringValue: `["a","b"]`},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Attributes are numbered in the order of their names.
+	got := server.Request(0)
+	checkParams(t, got, url.Values{
+		"Message":                                     {"hello"},
+		"MessageAttributes.entry.1.Name":              {"amount"},
+		"MessageAttributes.entry.1.Value.DataType":    {"Number"},
+		"MessageAttributes.entry.1.Value.StringValue": {"42"},
+		"MessageAttributes.entry.2.Name":              {"checksum"},
+		"MessageAttributes.entry.2.Value.DataType":    {"Binary"},
+		"MessageAttributes.entry.2.Value.BinaryValue": {"3q0="},
+		"MessageAttributes.entry.3.Name":              {"kind"},
+		"MessageAttributes.entry.3.Value.DataType":    {"String"},
+		"MessageAttributes.entry.3.Value.StringValue": {"order"},
+		"MessageAttributes.entry.4.Name":              {"tags"},
+		"MessageAttributes.entry.4.Value.DataType":    {"String.Array"},
+		"MessageAttributes.entry.4.Value.StringValue": {`["a","b"]`},
+	})
+	for _, name := range []string{"MessageAttributes.entry.2.Value.StringValue", "MessageAttributes.entry.3.Value.BinaryValue", "MessageStructure", "Subject"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("unexpected %s=%q", name, got.Get(name))
+		}
+	}
+}
+
+func TestPublishJSONMessage(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{"Publish": {"<MessageId>msg-1</MessageId>"}})
+
+	message, err := JSONMessage("order 7 placed", map[string]string{"sqs": `{"order":7}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages map[string]string
+	if err := json.Unmarshal([]byte(message), &messages); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(messages, map[string]string{"default": "order 7 placed", "sqs": `{"order":7}`}) {
+		t.Fatalf("got messages %v", messages)
+	}
+
+	if _, err := client.Topic(topicArn).PublishWithParams(context.Background(), PublishParams{Message: message, MessageStructure: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(0), url.Values{"Message": {message}, "MessageStructure": {"json"}})
+}
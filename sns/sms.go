@@ -0,0 +1,58 @@
+package sns
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/dkln/go-aws"
+)
+
+// Types of SMS messages.
+const (
+	SMSPromotional   = "Promotional"   // cheapest, for marketing
+	SMSTransactional = "Transactional" // most reliable, for alerts and one-time passwords
+)
+
+// SMSParams are the optional settings of a text message.
+type SMSParams struct {
+	// SenderID is the name shown as the sender, up to 11 alphanumeric
+	// characters, in the countries that support it.
+	SenderID string
+
+	// SMSType is SMSPromotional or SMSTransactional. If empty, the
+	// account's DefaultSMSType applies.
+	SMSType string
+
+	// MaxPrice, if set, is the highest price in USD to pay for the
+	// message, such as "0.50".
+	MaxPrice string
+}
+
+// PublishSMS sends message as a text message to phoneNumber, in E.164
+// format such as "+14155552671", without going through a topic.
+//
+// See https://docs.aws.amazon.com/sns/latest/dg/sms_publish-to-phone.html for details.
+func (self *SNS) PublishSMS(ctx context.Context, phoneNumber, message string, sms SMSParams) (*PublishResp, error) {
+	attrs := map[string]MessageAttributeValue{}
+	if sms.SenderID != "" {
+		attrs["AWS.SNS.SMS.SenderID"] = StringAttribute(sms.SenderID)
+	}
+	if sms.SMSType != "" {
+		attrs["AWS.SNS.SMS.SMSType"] = StringAttribute(sms.SMSType)
+	}
+	if sms.MaxPrice != "" {
+		attrs["AWS.SNS.SMS.MaxPrice"] = MessageAttributeValue{DataType: "Number", StringValue: sms.MaxPrice}
+	}
+	params := PublishParams{Message: message, MessageAttributes: attrs}
+	return self.publish(ctx, url.Values{"PhoneNumber": {phoneNumber}}, params)
+}
+
+// SetSMSAttributes sets the account wide SMS settings, such as
+// "DefaultSenderID", "DefaultSMSType" and "MonthlySpendLimit".
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_SetSMSAttributes.html for details.
+func (self *SNS) SetSMSAttributes(ctx context.Context, attrs map[string]string) error {
+	params := url.Values{}
+	aws.SetMap(params, "attributes.entry", "key", "value", attrs)
+	return self.Do(ctx, "SetSMSAttributes", params, nil)
+}
@@ -0,0 +1,70 @@
+//go:build !goaws_stable
+
+package sns
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestPublishSMS(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{"Publish": {"<MessageId>msg-1</MessageId>"}})
+	ctx := context.Background()
+
+	resp, err := client.PublishSMS(ctx, "+14155552671", "Your code is 123456", SMSParams{
+		SenderID: "Shop",
+		SMSType:  SMSTransactional,
+		MaxPrice: "0.50",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.MessageId != "msg-1" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	got := server.Request(0)
+	checkParams(t, got, url.Values{
+		"Action":                         {"Publish"},
+		"PhoneNumber":                    {"+14155552671"},
+		"Message":                        {"Your code is 123456"},
+		"MessageAttributes.entry.1.Name": {"AWS.SNS.SMS.MaxPrice"},
+		"MessageAttributes.entry.1.Value.DataType":    {"Number"},
+		"MessageAttributes.entry.1.Value.StringValue": {"0.50"},
+		"MessageAttributes.entry.2.Name":              {"AWS.SNS.SMS.SMSType"},
+		"MessageAttributes.entry.2.Value.DataType":    {"String"},
+		"MessageAttributes.entry.2.Value.StringValue": {"Transactional"},
+		"MessageAttributes.entry.3.Name":              {"AWS.SNS.SMS.SenderID"},
+		"MessageAttributes.entry.3.Value.StringValue": {"Shop"},
+	})
+	if _, ok := got["TopicArn"]; ok {
+		t.Errorf("TopicArn sent for a text message")
+	}
+
+	// Unset settings are left to the account defaults.
+	if _, err := client.PublishSMS(ctx, "+14155552671", "hi", SMSParams{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Request(1)["MessageAttributes.entry.1.Name"]; ok {
+		t.Errorf("unexpected attributes %v", server.Request(1))
+	}
+}
+
+func TestSetSMSAttributes(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{"SetSMSAttributes": {""}})
+	server.Fail("SetSMSAttributes", 1)
+
+	if err := client.SetSMSAttributes(context.Background(), map[string]string{"DefaultSMSType": SMSPromotional, "MonthlySpendLimit": "10"}); err != nil {
+		t.Fatal(err)
+	}
+	// The setting is idempotent, so the failed first attempt is retried.
+	if server.Count() != 2 {
+		t.Fatalf("got %d requests, want 2", server.Count())
+	}
+	checkParams(t, server.Request(1), url.Values{
+		"attributes.entry.1.key":   {"DefaultSMSType"},
+		"attributes.entry.1.value": {"Promotional"},
+		"attributes.entry.2.key":   {"MonthlySpendLimit"},
+		"attributes.entry.2.value": {"10"},
+	})
+}
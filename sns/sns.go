@@ -0,0 +1,233 @@
+// Package sns interacts with the Amazon Simple Notification Service.
+package sns
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/dkln/go-aws"
+)
+
+// APIVersion is the version of the SNS API the package speaks.
+const APIVersion = "2010-03-31"
+
+// The SNS type encapsulates operations with SNS in a region.
+type SNS struct {
+	*aws.QueryClient
+}
+
+// New creates a new SNS.
+func New(auth aws.Auth, region aws.Region) *SNS {
+	client := aws.NewQueryClient(auth, region, "sns", APIVersion)
+	client.IdempotentActions = map[string]bool{
+		"CreateTopic":               true,
+		"SetTopicAttributes":        true,
+		"SetSubscriptionAttributes": true,
+		"SetSMSAttributes":          true,
+	}
+	return &SNS{client}
+}
+
+// The Topic type encapsulates operations with an SNS topic.
+type Topic struct {
+	*SNS
+	Arn string
+}
+
+// Topic returns the topic with the given ARN, without checking that it
+// exists.
+func (self *SNS) Topic(arn string) *Topic {
+	return &Topic{self, arn}
+}
+
+// ResponseMetadata is part of every SNS response.
+type ResponseMetadata struct {
+	RequestId string
+}
+
+// CreateTopic creates a topic named name, or returns the existing topic
+// of that name.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_CreateTopic.html for details.
+func (self *SNS) CreateTopic(name string) (*Topic, error) {
+	return self.CreateTopicWithContext(context.Background(), name)
+}
+
+// CreateTopicWithContext is like CreateTopic but aborts when ctx is done.
+func (self *SNS) CreateTopicWithContext(ctx context.Context, name string) (*Topic, error) {
+	var resp struct {
+		TopicArn string `xml:"CreateTopicResult>TopicArn"`
+	}
+	if err := self.Do(ctx, "CreateTopic", url.Values{"Name": {name}}, &resp); err != nil {
+		return nil, err
+	}
+	return self.Topic(resp.TopicArn), nil
+}
+
+// Delete deletes the topic and its subscriptions.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_DeleteTopic.html for details.
+func (self *Topic) Delete() error {
+	return self.DeleteWithContext(context.Background())
+}
+
+// DeleteWithContext is like Delete but aborts when ctx is done.
+func (self *Topic) DeleteWithContext(ctx context.Context) error {
+	return self.Do(ctx, "DeleteTopic", url.Values{"TopicArn": {self.Arn}}, nil)
+}
+
+// PublishResp is the result of Publish.
+type PublishResp struct {
+	MessageId        string `xml:"PublishResult>MessageId"`
+	ResponseMetadata ResponseMetadata
+}
+
+// Publish sends message to every subscriber of the topic. Subject is
+// used by email subscriptions and may be empty.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_Publish.html for details.
+func (self *Topic) Publish(subject, message string) (*PublishResp, error) {
+	return self.PublishWithContext(context.Background(), subject, message)
+}
+
+// PublishWithContext is like Publish but aborts when ctx is done.
+func (self *Topic) PublishWithContext(ctx context.Context, subject, message string) (*PublishResp, error) {
+	return self.PublishWithParams(ctx, PublishParams{Subject: subject, Message: message})
+}
+
+// Subscribe subscribes endpoint to the topic with the given protocol,
+// such as "sqs" with a queue ARN or "https" with a URL, and returns the
+// ARN of the subscription. Subscriptions other than those of SQS queues
+// and Lambda functions in the same account must be confirmed by the
+// endpoint; their ARN is then "pending confirmation".
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_Subscribe.html for details.
+func (self *Topic) Subscribe(protocol, endpoint string) (string, error) {
+	return self.SubscribeWithContext(context.Background(), protocol, endpoint)
+}
+
+// SubscribeWithContext is like Subscribe but aborts when ctx is done.
+func (self *Topic) SubscribeWithContext(ctx context.Context, protocol, endpoint string) (string, error) {
+	params := url.Values{
+		"TopicArn":              {self.Arn},
+		"Protocol":              {protocol},
+		"Endpoint":              {endpoint},
+		"ReturnSubscriptionArn": {"true"},
+	}
+	var resp struct {
+		SubscriptionArn string `xml:"SubscribeResult>SubscriptionArn"`
+	}
+	if err := self.Do(ctx, "Subscribe", params, &resp); err != nil {
+		return "", err
+	}
+	return resp.SubscriptionArn, nil
+}
+
+// Unsubscribe deletes the subscription with the given ARN.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_Unsubscribe.html for details.
+func (self *SNS) Unsubscribe(subscriptionArn string) error {
+	return self.UnsubscribeWithContext(context.Background(), subscriptionArn)
+}
+
+// UnsubscribeWithContext is like Unsubscribe but aborts when ctx is done.
+func (self *SNS) UnsubscribeWithContext(ctx context.Context, subscriptionArn string) error {
+	return self.Do(ctx, "Unsubscribe", url.Values{"SubscriptionArn": {subscriptionArn}}, nil)
+}
+
+// ListTopicsResp is the result of ListTopics. If NextToken is not empty,
+// there are more topics, listed by passing it to the next call.
+type ListTopicsResp struct {
+	Topics           []string `xml:"ListTopicsResult>Topics>member>TopicArn"`
+	NextToken        string   `xml:"ListTopicsResult>NextToken"`
+	ResponseMetadata ResponseMetadata
+}
+
+// ListTopics lists up to 100 topics, continuing the listing nextToken was
+// returned by, if any.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_ListTopics.html for details.
+func (self *SNS) ListTopics(nextToken string) (*ListTopicsResp, error) {
+	return self.ListTopicsWithContext(context.Background(), nextToken)
+}
+
+// ListTopicsWithContext is like ListTopics but aborts when ctx is done.
+func (self *SNS) ListTopicsWithContext(ctx context.Context, nextToken string) (*ListTopicsResp, error) {
+	params := url.Values{}
+	if nextToken != "" {
+		params.Set("NextToken", nextToken)
+	}
+	resp := &ListTopicsResp{}
+	if err := self.Do(ctx, "ListTopics", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Subscription is a subscription of an endpoint to a topic.
+type Subscription struct {
+	SubscriptionArn string
+	TopicArn        string
+	Protocol        string
+	Endpoint        string
+	Owner           string
+}
+
+// ListSubscriptionsResp is the result of ListSubscriptions. If NextToken
+// is not empty, there are more subscriptions, listed by passing it to
+// the next call.
+type ListSubscriptionsResp struct {
+	Subscriptions    []Subscription `xml:"Subscriptions>member"`
+	NextToken        string
+	ResponseMetadata ResponseMetadata
+}
+
+// ListSubscriptions lists up to 100 subscriptions, continuing the listing
+// nextToken was returned by, if any.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_ListSubscriptions.html for details.
+func (self *SNS) ListSubscriptions(nextToken string) (*ListSubscriptionsResp, error) {
+	return self.ListSubscriptionsWithContext(context.Background(), nextToken)
+}
+
+// ListSubscriptionsWithContext is like ListSubscriptions but aborts when
+// ctx is done.
+func (self *SNS) ListSubscriptionsWithContext(ctx context.Context, nextToken string) (*ListSubscriptionsResp, error) {
+	return self.listSubscriptions(ctx, "ListSubscriptions", url.Values{}, nextToken)
+}
+
+// ListSubscriptions lists up to 100 subscriptions to the topic,
+// continuing the listing nextToken was returned by, if any.
+//
+// See https://docs.aws.amazon.com/sns/latest/api/API_ListSubscriptionsByTopic.html for details.
+func (self *Topic) ListSubscriptions(nextToken string) (*ListSubscriptionsResp, error) {
+	return self.ListSubscriptionsWithContext(context.Background(), nextToken)
+}
+
+// ListSubscriptionsWithContext is like ListSubscriptions but aborts when
+// ctx is done.
+func (self *Topic) ListSubscriptionsWithContext(ctx context.Context, nextToken string) (*ListSubscriptionsResp, error) {
+	return self.listSubscriptions(ctx, "ListSubscriptionsByTopic", url.Values{"TopicArn": {self.Arn}}, nextToken)
+}
+
+func (self *SNS) listSubscriptions(ctx context.Context, action string, params url.Values, nextToken string) (*ListSubscriptionsResp, error) {
+	if nextToken != "" {
+		params.Set("NextToken", nextToken)
+	}
+	// Both actions return the same result, in an element named after
+	// the action.
+	var resp struct {
+		Result           ListSubscriptionsResp `xml:"ListSubscriptionsResult"`
+		ByTopicResult    ListSubscriptionsResp `xml:"ListSubscriptionsByTopicResult"`
+		ResponseMetadata ResponseMetadata
+	}
+	if err := self.Do(ctx, action, params, &resp); err != nil {
+		return nil, err
+	}
+	result := resp.Result
+	if action == "ListSubscriptionsByTopic" {
+		result = resp.ByTopicResult
+	}
+	result.ResponseMetadata = resp.ResponseMetadata
+	return &result, nil
+}
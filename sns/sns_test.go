@@ -0,0 +1,158 @@
+//go:build !goaws_stable
+
+package sns
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/x/awstest"
+)
+
+const topicArn = "arn:aws:sns:us-east-1:123:orders"
+
+func newTestSNS(t *testing.T, responses map[string][]string) (*SNS, *awstest.QueryServer) {
+	server := awstest.NewQueryServer(responses)
+	t.Cleanup(server.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.Region{Name: "us-east-1", SNSEndpoint: server.URL, SigV4Only: true})
+	client.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+	return client, server
+}
+
+// checkParams reports the parameters of want that got lacks or has
+// different values for.
+func checkParams(t *testing.T, got, want url.Values) {
+	t.Helper()
+	for name, value := range want {
+		if !reflect.DeepEqual(got[name], value) {
+			t.Errorf("%s: got %q, want %q", name, got[name], value)
+		}
+	}
+}
+
+func TestCreateAndDeleteTopic(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{
+		"CreateTopic": {"<TopicArn>" + topicArn + "</TopicArn>"},
+		"DeleteTopic": {""},
+	})
+	server.Fail("CreateTopic", 1)
+
+	topic, err := client.CreateTopic("orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topic.Arn != topicArn {
+		t.Fatalf("got topic %q", topic.Arn)
+	}
+	// CreateTopic is idempotent, so the failed first attempt is retried.
+	if server.Count() != 2 {
+		t.Fatalf("got %d requests, want 2", server.Count())
+	}
+	checkParams(t, server.Request(1), url.Values{"Action": {"CreateTopic"}, "Name": {"orders"}, "Version": {APIVersion}})
+
+	if err := topic.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(2), url.Values{"Action": {"DeleteTopic"}, "TopicArn": {topicArn}})
+}
+
+func TestPublish(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{"Publish": {"<MessageId>msg-1</MessageId>"}})
+	server.Fail("Publish", 1)
+
+	resp, err := client.Topic(topicArn).Publish("", "hello")
+	if err == nil {
+		t.Fatalf("got %+v, want the error; Publish is not idempotent", resp)
+	}
+	if server.Count() != 1 {
+		t.Fatalf("got %d requests, want 1", server.Count())
+	}
+
+	if resp, err = client.Topic(topicArn).Publish("Order", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if resp.MessageId != "msg-1" || resp.ResponseMetadata.RequestId != "req-1" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	checkParams(t, server.Request(1), url.Values{"TopicArn": {topicArn}, "Subject": {"Order"}, "Message": {"hello"}})
+	if _, ok := server.Request(0)["Subject"]; ok {
+		t.Errorf("empty Subject sent")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{
+		"Subscribe":   {"<SubscriptionArn>" + topicArn + ":sub-1</SubscriptionArn>"},
+		"Unsubscribe": {""},
+	})
+
+	arn, err := client.Topic(topicArn).Subscribe("https", "https://example.com/hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arn != topicArn+":sub-1" {
+		t.Fatalf("got subscription %q", arn)
+	}
+	checkParams(t, server.Request(0), url.Values{
+		"TopicArn":              {topicArn},
+		"Protocol":              {"https"},
+		"Endpoint":              {"https://example.com/hook"},
+		"ReturnSubscriptionArn": {"true"},
+	})
+
+	if err := client.Unsubscribe(arn); err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(1), url.Values{"Action": {"Unsubscribe"}, "SubscriptionArn": {arn}})
+}
+
+func TestListTopics(t *testing.T) {
+	client, server := newTestSNS(t, map[string][]string{
+		"ListTopics": {"<Topics><member><TopicArn>" + topicArn + "</TopicArn></member><member><TopicArn>arn:aws:sns:us-east-1:123:refunds</TopicArn></member></Topics><NextToken>next</NextToken>"},
+	})
+
+	resp, err := client.ListTopics("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(0), url.Values{"NextToken": {"token"}})
+	if !reflect.DeepEqual(resp.Topics, []string{topicArn, "arn:aws:sns:us-east-1:123:refunds"}) || resp.NextToken != "next" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	if _, err := client.ListTopics(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Request(1)["NextToken"]; ok {
+		t.Errorf("empty NextToken sent")
+	}
+}
+
+func TestListSubscriptions(t *testing.T) {
+	subscriptions := "<Subscriptions><member><SubscriptionArn>" + topicArn + ":sub-1</SubscriptionArn><TopicArn>" + topicArn + "</TopicArn>" +
+		"<Protocol>sqs</Protocol><Endpoint>arn:aws:sqs:us-east-1:123:q</Endpoint><Owner>123</Owner></member></Subscriptions>"
+	client, server := newTestSNS(t, map[string][]string{
+		"ListSubscriptions":        {subscriptions + "<NextToken>next</NextToken>"},
+		"ListSubscriptionsByTopic": {subscriptions},
+	})
+	want := []Subscription{{topicArn + ":sub-1", topicArn, "sqs", "arn:aws:sqs:us-east-1:123:q", "123"}}
+
+	resp, err := client.ListSubscriptions("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.Subscriptions, want) || resp.NextToken != "next" || resp.ResponseMetadata.RequestId != "req-1" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	if resp, err = client.Topic(topicArn).ListSubscriptions("token"); err != nil {
+		t.Fatal(err)
+	}
+	checkParams(t, server.Request(1), url.Values{"Action": {"ListSubscriptionsByTopic"}, "TopicArn": {topicArn}, "NextToken": {"token"}})
+	if !reflect.DeepEqual(resp.Subscriptions, want) || resp.NextToken != "" || resp.ResponseMetadata.RequestId != "req-1" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+}
@@ -0,0 +1,129 @@
+package sqs
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// Names of queue attributes.
+const (
+	AttrAll                                   = "All"
+	AttrVisibilityTimeout                     = "VisibilityTimeout"
+	AttrMessageRetentionPeriod                = "MessageRetentionPeriod"
+	AttrMaximumMessageSize                    = "MaximumMessageSize"
+	AttrDelaySeconds                          = "DelaySeconds"
+	AttrReceiveMessageWaitTimeSeconds         = "ReceiveMessageWaitTimeSeconds"
+	AttrPolicy                                = "Policy"
+	AttrRedrivePolicy                         = "RedrivePolicy"
+	AttrQueueArn                              = "QueueArn"
+	AttrApproximateNumberOfMessages           = "ApproximateNumberOfMessages"
+	AttrApproximateNumberOfMessagesNotVisible = "ApproximateNumberOfMessagesNotVisible"
+	AttrApproximateNumberOfMessagesDelayed    = "ApproximateNumberOfMessagesDelayed"
+	AttrCreatedTimestamp                      = "CreatedTimestamp"
+	AttrLastModifiedTimestamp                 = "LastModifiedTimestamp"
+)
+
+// QueueAttributes are the attributes of a queue. Durations are in seconds.
+type QueueAttributes struct {
+	VisibilityTimeout             int
+	MessageRetentionPeriod        int
+	MaximumMessageSize            int // in bytes
+	DelaySeconds                  int
+	ReceiveMessageWaitTimeSeconds int
+	Policy                        string // JSON access policy
+	RedrivePolicy                 string // JSON dead-letter queue policy
+	QueueArn                      string
+
+	ApproximateNumberOfMessages           int
+	ApproximateNumberOfMessagesNotVisible int
+	ApproximateNumberOfMessagesDelayed    int
+
+	CreatedTimestamp      time.Time
+	LastModifiedTimestamp time.Time
+
+	// Raw holds every attribute returned, including those without a
+	// field above, by name.
+	Raw map[string]string
+}
+
+// GetAttributes returns the attributes of the queue with the given
+// names, or all of them if none are given.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_GetQueueAttributes.html for details.
+func (self *Queue) GetAttributes(names ...string) (*QueueAttributes, error) {
+	return self.GetAttributesWithContext(context.Background(), names...)
+}
+
+// GetAttributesWithContext is like GetAttributes but aborts when ctx is
+// done.
+func (self *Queue) GetAttributesWithContext(ctx context.Context, names ...string) (*QueueAttributes, error) {
+	if len(names) == 0 {
+		names = []string{AttrAll}
+	}
+	params := url.Values{}
+	aws.SetList(params, "AttributeName", names)
+	var resp struct {
+		Attributes []Attribute `xml:"GetQueueAttributesResult>Attribute"`
+	}
+	if err := self.do(ctx, "GetQueueAttributes", params, &resp); err != nil {
+		return nil, err
+	}
+	attrs := &QueueAttributes{Raw: map[string]string{}}
+	for _, attr := range resp.Attributes {
+		attrs.Raw[attr.Name] = attr.Value
+		attrs.set(attr.Name, attr.Value)
+	}
+	return attrs, nil
+}
+
+func (self *QueueAttributes) set(name, value string) {
+	number, _ := strconv.Atoi(value)
+	switch name {
+	case AttrVisibilityTimeout:
+		self.VisibilityTimeout = number
+	case AttrMessageRetentionPeriod:
+		self.MessageRetentionPeriod = number
+	case AttrMaximumMessageSize:
+		self.MaximumMessageSize = number
+	case AttrDelaySeconds:
+		self.DelaySeconds = number
+	case AttrReceiveMessageWaitTimeSeconds:
+		self.ReceiveMessageWaitTimeSeconds = number
+	case AttrPolicy:
+		self.Policy = value
+	case AttrRedrivePolicy:
+		self.RedrivePolicy = value
+	case AttrQueueArn:
+		self.QueueArn = value
+	case AttrApproximateNumberOfMessages:
+		self.ApproximateNumberOfMessages = number
+	case AttrApproximateNumberOfMessagesNotVisible:
+		self.ApproximateNumberOfMessagesNotVisible = number
+	case AttrApproximateNumberOfMessagesDelayed:
+		self.ApproximateNumberOfMessagesDelayed = number
+	case AttrCreatedTimestamp:
+		self.CreatedTimestamp = time.Unix(int64(number), 0)
+	case AttrLastModifiedTimestamp:
+		self.LastModifiedTimestamp = time.Unix(int64(number), 0)
+	}
+}
+
+// SetAttributes sets the given attributes of the queue, by name. Only
+// the attributes named in attrs change.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SetQueueAttributes.html for details.
+func (self *Queue) SetAttributes(attrs map[string]string) error {
+	return self.SetAttributesWithContext(context.Background(), attrs)
+}
+
+// SetAttributesWithContext is like SetAttributes but aborts when ctx is
+// done.
+func (self *Queue) SetAttributesWithContext(ctx context.Context, attrs map[string]string) error {
+	params := url.Values{}
+	aws.SetMap(params, "Attribute", "Name", "Value", attrs)
+	return self.do(ctx, "SetQueueAttributes", params, nil)
+}
@@ -0,0 +1,100 @@
+package sqs
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// attributes returns the attributes of the queue with the given names,
+// counting its messages like SQS does.
+func (self *fakeQueue) attributes(names []string) []Attribute {
+	all := map[string]string{
+		AttrCreatedTimestamp: "1700000000",
+	}
+	for k, v := range self.attrs {
+		all[k] = v
+	}
+	visible, hidden := 0, 0
+	for _, msg := range self.messages {
+		if time.Now().Before(msg.visibleAt) {
+			hidden++
+		} else {
+			visible++
+		}
+	}
+	all[AttrApproximateNumberOfMessages] = strconv.Itoa(visible)
+	all[AttrApproximateNumberOfMessagesNotVisible] = strconv.Itoa(hidden)
+
+	var attrs []Attribute
+	for name, value := range all {
+		for _, wanted := range names {
+			if wanted == AttrAll || wanted == name {
+				attrs = append(attrs, Attribute{name, value})
+				break
+			}
+		}
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Name < attrs[j].Name })
+	return attrs
+}
+
+// formList decodes a list sent with aws.SetList.
+func formList(form url.Values, prefix string) []string {
+	var list []string
+	for i := 1; form.Get(prefix+"."+strconv.Itoa(i)) != ""; i++ {
+		list = append(list, form.Get(prefix+"."+strconv.Itoa(i)))
+	}
+	return list
+}
+
+func TestQueueAttributes(t *testing.T) {
+	client, fake := newTestSQS(t)
+	queue, err := client.CreateQueue("jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = queue.SetAttributes(map[string]string{
+		AttrVisibilityTimeout: "45",
+		AttrDelaySeconds:      "3",
+		"FifoThroughputLimit": "perQueue",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"1", "2"} {
+		if _, err := queue.SendMessage(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := queue.ReceiveMessage(1); err != nil {
+		t.Fatal(err)
+	}
+
+	attrs, err := queue.GetAttributes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.form("GetQueueAttributes").Get("AttributeName.1") != AttrAll {
+		t.Fatal("did not ask for all attributes")
+	}
+	if attrs.VisibilityTimeout != 45 || attrs.DelaySeconds != 3 ||
+		attrs.ApproximateNumberOfMessages != 1 || attrs.ApproximateNumberOfMessagesNotVisible != 1 ||
+		attrs.QueueArn != "arn:aws:sqs:us-east-1:123456789012:jobs" ||
+		!attrs.CreatedTimestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("got %+v", attrs)
+	}
+	if attrs.Raw["FifoThroughputLimit"] != "perQueue" {
+		t.Fatalf("raw attributes %v", attrs.Raw)
+	}
+
+	some, err := queue.GetAttributes(AttrQueueArn, AttrDelaySeconds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(some.Raw) != 2 || some.VisibilityTimeout != 0 {
+		t.Fatalf("got %+v", some)
+	}
+}
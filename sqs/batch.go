@@ -0,0 +1,173 @@
+package sqs
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// MaxBatchSize is the largest number of entries SQS accepts in a batch.
+const MaxBatchSize = 10
+
+// BatchResultErrorEntry reports an entry of a batch that failed. The
+// other entries of the batch are not affected.
+type BatchResultErrorEntry struct {
+	Id          string
+	Code        string
+	Message     string
+	SenderFault bool
+}
+
+func (self *BatchResultErrorEntry) Error() string {
+	return self.Id + ": " + self.Code + ": " + self.Message
+}
+
+// SendMessageBatchEntry is a message to send with SendMessageBatch. Id
+// identifies the entry in the results and must be unique in the batch.
+type SendMessageBatchEntry struct {
+	Id           string
+	MessageBody  string
+	DelaySeconds int // if zero, the queue's delay applies
+
+	MessageAttributes map[string]MessageAttributeValue
+}
+
+// SendMessageBatchResultEntry reports a message sent by SendMessageBatch.
+type SendMessageBatchResultEntry struct {
+	Id                     string
+	MessageId              string
+	MD5OfMessageBody       string
+	MD5OfMessageAttributes string
+}
+
+// SendMessageBatchResp is the result of SendMessageBatch.
+type SendMessageBatchResp struct {
+	Successful       []SendMessageBatchResultEntry `xml:"SendMessageBatchResult>SendMessageBatchResultEntry"`
+	Failed           []BatchResultErrorEntry       `xml:"SendMessageBatchResult>BatchResultErrorEntry"`
+	ResponseMetadata ResponseMetadata
+}
+
+// SendMessageBatch adds up to MaxBatchSize messages to the queue. Messages
+// that could not be sent are listed in Failed of the result rather than
+// failing the whole call; that includes messages SQS received corrupted,
+// which fail with the code "ChecksumMismatch".
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SendMessageBatch.html for details.
+func (self *Queue) SendMessageBatch(entries []SendMessageBatchEntry) (*SendMessageBatchResp, error) {
+	return self.SendMessageBatchWithContext(context.Background(), entries)
+}
+
+// SendMessageBatchWithContext is like SendMessageBatch but aborts when ctx
+// is done.
+func (self *Queue) SendMessageBatchWithContext(ctx context.Context, entries []SendMessageBatchEntry) (*SendMessageBatchResp, error) {
+	params := url.Values{}
+	sent := map[string]SendMessageBatchEntry{}
+	for i, entry := range entries {
+		prefix := "SendMessageBatchRequestEntry." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"Id", entry.Id)
+		params.Set(prefix+"MessageBody", entry.MessageBody)
+		if entry.DelaySeconds != 0 {
+			params.Set(prefix+"DelaySeconds", strconv.Itoa(entry.DelaySeconds))
+		}
+		setMessageAttributes(params, prefix, entry.MessageAttributes)
+		sent[entry.Id] = entry
+	}
+	resp := &SendMessageBatchResp{}
+	if err := self.do(ctx, "SendMessageBatch", params, resp); err != nil {
+		return nil, err
+	}
+	successful := resp.Successful[:0]
+	for _, entry := range resp.Successful {
+		if entry.MD5OfMessageBody != md5Hex(sent[entry.Id].MessageBody) ||
+			entry.MD5OfMessageAttributes != md5OfMessageAttributes(sent[entry.Id].MessageAttributes) {
+			resp.Failed = append(resp.Failed, BatchResultErrorEntry{
+				Id:      entry.Id,
+				Code:    "ChecksumMismatch",
+				Message: ErrChecksum.Error(),
+			})
+			continue
+		}
+		successful = append(successful, entry)
+	}
+	resp.Successful = successful
+	return resp, nil
+}
+
+// DeleteMessageBatchEntry is a message to delete with DeleteMessageBatch.
+type DeleteMessageBatchEntry struct {
+	Id            string
+	ReceiptHandle string
+}
+
+// DeleteMessageBatchResp is the result of DeleteMessageBatch.
+type DeleteMessageBatchResp struct {
+	Successful       []string                `xml:"DeleteMessageBatchResult>DeleteMessageBatchResultEntry>Id"`
+	Failed           []BatchResultErrorEntry `xml:"DeleteMessageBatchResult>BatchResultErrorEntry"`
+	ResponseMetadata ResponseMetadata
+}
+
+// DeleteMessageBatch deletes up to MaxBatchSize messages from the queue.
+// Messages that could not be deleted are listed in Failed of the result.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_DeleteMessageBatch.html for details.
+func (self *Queue) DeleteMessageBatch(entries []DeleteMessageBatchEntry) (*DeleteMessageBatchResp, error) {
+	return self.DeleteMessageBatchWithContext(context.Background(), entries)
+}
+
+// DeleteMessageBatchWithContext is like DeleteMessageBatch but aborts when
+// ctx is done.
+func (self *Queue) DeleteMessageBatchWithContext(ctx context.Context, entries []DeleteMessageBatchEntry) (*DeleteMessageBatchResp, error) {
+	params := url.Values{}
+	for i, entry := range entries {
+		prefix := "DeleteMessageBatchRequestEntry." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"Id", entry.Id)
+		params.Set(prefix+"ReceiptHandle", entry.ReceiptHandle)
+	}
+	resp := &DeleteMessageBatchResp{}
+	if err := self.do(ctx, "DeleteMessageBatch", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ChangeMessageVisibilityBatchEntry is a message whose visibility timeout
+// ChangeMessageVisibilityBatch changes.
+type ChangeMessageVisibilityBatchEntry struct {
+	Id                string
+	ReceiptHandle     string
+	VisibilityTimeout int // in seconds, from now
+}
+
+// ChangeMessageVisibilityBatchResp is the result of
+// ChangeMessageVisibilityBatch.
+type ChangeMessageVisibilityBatchResp struct {
+	Successful       []string                `xml:"ChangeMessageVisibilityBatchResult>ChangeMessageVisibilityBatchResultEntry>Id"`
+	Failed           []BatchResultErrorEntry `xml:"ChangeMessageVisibilityBatchResult>BatchResultErrorEntry"`
+	ResponseMetadata ResponseMetadata
+}
+
+// ChangeMessageVisibilityBatch changes the visibility timeout of up to
+// MaxBatchSize messages. Messages whose timeout could not be changed are
+// listed in Failed of the result.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ChangeMessageVisibilityBatch.html for details.
+func (self *Queue) ChangeMessageVisibilityBatch(entries []ChangeMessageVisibilityBatchEntry) (*ChangeMessageVisibilityBatchResp, error) {
+	return self.ChangeMessageVisibilityBatchWithContext(context.Background(), entries)
+}
+
+// ChangeMessageVisibilityBatchWithContext is like
+// ChangeMessageVisibilityBatch but aborts when ctx is done.
+func (self *Queue) ChangeMessageVisibilityBatchWithContext(ctx context.Context, entries []ChangeMessageVisibilityBatchEntry) (*ChangeMessageVisibilityBatchResp, error) {
+	params := url.Values{}
+	for i, entry := range entries {
+		prefix := "ChangeMessageVisibilityBatchRequestEntry." + strconv.Itoa(i+1) + "."
+		params.Set(prefix+"Id", entry.Id)
+		params.Set(prefix+"ReceiptHandle", entry.ReceiptHandle)
+		params.Set(prefix+"VisibilityTimeout", strconv.Itoa(entry.VisibilityTimeout))
+	}
+	resp := &ChangeMessageVisibilityBatchResp{}
+	if err := self.do(ctx, "ChangeMessageVisibilityBatch", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
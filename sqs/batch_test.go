@@ -0,0 +1,142 @@
+package sqs
+
+import (
+	"encoding/xml"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+type fakeBatchResult struct {
+	Successful []fakeBatchEntry
+	Failed     []fakeBatchError
+}
+
+type fakeBatchEntry struct {
+	XMLName                xml.Name
+	Id                     string
+	MessageId              string `xml:",omitempty"`
+	MD5OfMessageBody       string `xml:",omitempty"`
+	MD5OfMessageAttributes string `xml:",omitempty"`
+}
+
+type fakeBatchError struct {
+	XMLName     xml.Name `xml:"BatchResultErrorEntry"`
+	Id          string
+	Code        string
+	Message     string
+	SenderFault bool
+}
+
+// handleBatch performs the entries of a batch action one by one. Entries
+// with an unknown receipt handle fail on their own.
+func (self *fakeSQS) handleBatch(action string, form url.Values, queue *fakeQueue) fakeBatchResult {
+	var result fakeBatchResult
+	for i := 1; ; i++ {
+		prefix := action + "RequestEntry." + strconv.Itoa(i) + "."
+		id := form.Get(prefix + "Id")
+		if id == "" {
+			return result
+		}
+		entry := fakeBatchEntry{XMLName: xml.Name{Local: action + "ResultEntry"}, Id: id}
+		ok := true
+		switch action {
+		case "SendMessageBatch":
+			msg := queue.send(form.Get(prefix+"MessageBody"), formMessageAttributes(form, prefix))
+			entry.MessageId = msg.id
+			entry.MD5OfMessageBody = self.digest(md5Hex(msg.body))
+			entry.MD5OfMessageAttributes = self.digest(md5OfMessageAttributes(msg.attrs))
+		case "DeleteMessageBatch":
+			ok = queue.delete(form.Get(prefix + "ReceiptHandle"))
+		case "ChangeMessageVisibilityBatch":
+			timeout, _ := strconv.Atoi(form.Get(prefix + "VisibilityTimeout"))
+			ok = queue.changeVisibility(form.Get(prefix+"ReceiptHandle"), timeout)
+		}
+		if ok {
+			result.Successful = append(result.Successful, entry)
+		} else {
+			result.Failed = append(result.Failed, fakeBatchError{Id: id, Code: "ReceiptHandleIsInvalid", Message: "invalid handle", SenderFault: true})
+		}
+	}
+}
+
+func TestSendMessageBatch(t *testing.T) {
+	client, fake := newTestSQS(t)
+	queue, err := client.CreateQueue("jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []SendMessageBatchEntry{
+		{Id: "a", MessageBody: "first"},
+		{Id: "b", MessageBody: "second", DelaySeconds: 5, MessageAttributes: map[string]MessageAttributeValue{"n": NumberAttribute(2)}},
+	}
+	sent, err := queue.SendMessageBatch(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent.Successful) != 2 || len(sent.Failed) != 0 {
+		t.Fatalf("got %+v", sent)
+	}
+	form := fake.form("SendMessageBatch")
+	if form.Get("SendMessageBatchRequestEntry.2.DelaySeconds") != "5" || form.Get("SendMessageBatchRequestEntry.1.DelaySeconds") != "" {
+		t.Fatalf("sent %v", form)
+	}
+
+	// Entries SQS reports wrong digests for are moved to Failed.
+	fake.corrupt = true
+	sent, err = queue.SendMessageBatch(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent.Successful) != 0 || len(sent.Failed) != 2 || sent.Failed[0].Code != "ChecksumMismatch" {
+		t.Fatalf("got %+v", sent)
+	}
+}
+
+func TestDeleteAndChangeVisibilityBatch(t *testing.T) {
+	client, _ := newTestSQS(t)
+	queue, err := client.CreateQueue("jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"1", "2", "3"} {
+		if _, err := queue.SendMessage(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	received, err := queue.ReceiveMessage(10)
+	if err != nil || len(received.Messages) != 3 {
+		t.Fatalf("got %+v, %v", received, err)
+	}
+	handles := []string{received.Messages[0].ReceiptHandle, received.Messages[1].ReceiptHandle, "bogus"}
+
+	changed, err := queue.ChangeMessageVisibilityBatch([]ChangeMessageVisibilityBatchEntry{
+		{Id: "a", ReceiptHandle: handles[0], VisibilityTimeout: 0},
+		{Id: "c", ReceiptHandle: handles[2], VisibilityTimeout: 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed.Successful) != 1 || changed.Successful[0] != "a" || len(changed.Failed) != 1 {
+		t.Fatalf("got %+v", changed)
+	}
+	if failed := changed.Failed[0]; failed.Id != "c" || !failed.SenderFault || failed.Error() != "c: ReceiptHandleIsInvalid: invalid handle" {
+		t.Fatalf("got failure %+v", failed)
+	}
+
+	deleted, err := queue.DeleteMessageBatch([]DeleteMessageBatchEntry{
+		{Id: "b", ReceiptHandle: handles[1]},
+		{Id: "c", ReceiptHandle: handles[2]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted.Successful) != 1 || deleted.Successful[0] != "b" || len(deleted.Failed) != 1 {
+		t.Fatalf("got %+v", deleted)
+	}
+	// Only the message made visible again is received.
+	again, err := queue.ReceiveMessage(10)
+	if err != nil || len(again.Messages) != 1 || again.Messages[0].Body != "1" {
+		t.Fatalf("got %+v, %v", again, err)
+	}
+}
@@ -0,0 +1,86 @@
+package sqs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// Heartbeat keeps a received message hidden from other consumers while it
+// is being processed, by extending its visibility timeout periodically.
+type Heartbeat struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// StartHeartbeat extends the visibility timeout of the message with the
+// given receipt handle to timeout seconds every timeout/2 seconds, until
+// ctx is done or the returned Heartbeat is stopped. Long running handlers
+// can then use a short visibility timeout, so messages of crashed
+// consumers are redelivered quickly, without being redelivered while
+// they are still processed.
+func (self *Queue) StartHeartbeat(ctx context.Context, receiptHandle string, timeout int) *Heartbeat {
+	if timeout < 2 {
+		timeout = 2
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	heartbeat := &Heartbeat{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(heartbeat.done)
+		ticker := time.NewTicker(time.Duration(timeout) * time.Second / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := self.ChangeMessageVisibilityWithContext(ctx, receiptHandle, timeout)
+			if err != nil && ctx.Err() == nil {
+				heartbeat.mu.Lock()
+				heartbeat.err = err
+				heartbeat.mu.Unlock()
+				if IsReceiptHandleInvalid(err) {
+					// The message was deleted or redelivered; extending
+					// it further can't succeed.
+					return
+				}
+			}
+		}
+	}()
+	return heartbeat
+}
+
+// Err returns the error of the last failed extension, if any. A handler
+// may check it to find out whether its message may have been redelivered
+// to another consumer.
+func (self *Heartbeat) Err() error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.err
+}
+
+// Stop stops extending the visibility timeout, once processing completed,
+// and returns the same as Err. The message is not made visible again;
+// delete it, or call ChangeMessageVisibility with zero to have it
+// redelivered right away.
+func (self *Heartbeat) Stop() error {
+	self.cancel()
+	<-self.done
+	return self.Err()
+}
+
+// IsReceiptHandleInvalid reports whether err means a receipt handle
+// doesn't refer to a received message (anymore).
+func IsReceiptHandleInvalid(err error) bool {
+	switch aws.ErrorCode(err) {
+	case "ReceiptHandleIsInvalid", "InvalidParameterValue", "AWS.SimpleQueueService.MessageNotInflight":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package sqs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	client, fake := newTestSQS(t)
+	queue, _ := client.CreateQueue("jobs", nil)
+	if _, err := queue.SendMessage("slow"); err != nil {
+		t.Fatal(err)
+	}
+	received, err := queue.ReceiveMessage(1)
+	if err != nil || len(received.Messages) != 1 {
+		t.Fatalf("got %+v, %v", received, err)
+	}
+	handle := received.Messages[0].ReceiptHandle
+
+	heartbeat := queue.StartHeartbeat(context.Background(), handle, 2)
+	for deadline := time.Now().Add(3 * time.Second); fake.form("ChangeMessageVisibility") == nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("visibility timeout not extended")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := heartbeat.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	form := fake.form("ChangeMessageVisibility")
+	if form.Get("ReceiptHandle") != handle || form.Get("VisibilityTimeout") != "2" {
+		t.Fatalf("sent %v", form)
+	}
+
+	// Extending a deleted message fails for good.
+	if err := queue.DeleteMessage(handle); err != nil {
+		t.Fatal(err)
+	}
+	heartbeat = queue.StartHeartbeat(context.Background(), handle, 2)
+	for deadline := time.Now().Add(3 * time.Second); heartbeat.Err() == nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("heartbeat of a deleted message did not fail")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := heartbeat.Stop(); !IsReceiptHandleInvalid(err) {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package sqs
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/dkln/go-aws"
+)
+
+// ListQueuesResp is the result of ListQueues. If NextToken is not empty,
+// there are more queues, listed by passing it to the next call.
+type ListQueuesResp struct {
+	QueueUrls        []string `xml:"ListQueuesResult>QueueUrl"`
+	NextToken        string   `xml:"ListQueuesResult>NextToken"`
+	ResponseMetadata ResponseMetadata
+}
+
+// ListQueues lists up to max (at most 1000) queues whose names start with
+// prefix, continuing the listing nextToken was returned by, if any. With
+// max zero, SQS returns up to 1000 queues and no NextToken.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ListQueues.html for details.
+func (self *SQS) ListQueues(prefix, nextToken string, max int) (*ListQueuesResp, error) {
+	return self.ListQueuesWithContext(context.Background(), prefix, nextToken, max)
+}
+
+// ListQueuesWithContext is like ListQueues but aborts when ctx is done.
+func (self *SQS) ListQueuesWithContext(ctx context.Context, prefix, nextToken string, max int) (*ListQueuesResp, error) {
+	params := url.Values{}
+	if prefix != "" {
+		params.Set("QueueNamePrefix", prefix)
+	}
+	if nextToken != "" {
+		params.Set("NextToken", nextToken)
+	}
+	if max != 0 {
+		params.Set("MaxResults", strconv.Itoa(max))
+	}
+	resp := &ListQueuesResp{}
+	if err := self.Do(ctx, "ListQueues", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AllQueues returns every queue whose name starts with prefix, following
+// the pages of ListQueues.
+func (self *SQS) AllQueues(ctx context.Context, prefix string) ([]*Queue, error) {
+	var queues []*Queue
+	token := ""
+	for {
+		resp, err := self.ListQueuesWithContext(ctx, prefix, token, 1000)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range resp.QueueUrls {
+			queues = append(queues, self.Queue(u))
+		}
+		if resp.NextToken == "" {
+			return queues, nil
+		}
+		token = resp.NextToken
+	}
+}
+
+// Tag adds the given tags to the queue, replacing those with the same
+// keys.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_TagQueue.html for details.
+func (self *Queue) Tag(ctx context.Context, tags map[string]string) error {
+	params := url.Values{}
+	aws.SetMap(params, "Tag", "Key", "Value", tags)
+	return self.do(ctx, "TagQueue", params, nil)
+}
+
+// Untag removes the tags with the given keys from the queue.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_UntagQueue.html for details.
+func (self *Queue) Untag(ctx context.Context, keys ...string) error {
+	params := url.Values{}
+	aws.SetList(params, "TagKey", keys)
+	return self.do(ctx, "UntagQueue", params, nil)
+}
+
+// Tags returns the tags of the queue.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ListQueueTags.html for details.
+func (self *Queue) Tags(ctx context.Context) (map[string]string, error) {
+	var resp struct {
+		Tags []struct {
+			Key   string
+			Value string
+		} `xml:"ListQueueTagsResult>Tag"`
+	}
+	if err := self.do(ctx, "ListQueueTags", url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(resp.Tags))
+	for _, tag := range resp.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return tags, nil
+}
@@ -0,0 +1,109 @@
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// handleList lists queues, page by page, and manages the tags of queue.
+func (self *fakeSQS) handleList(action string, form url.Values, queue *fakeQueue) interface{} {
+	switch action {
+	case "ListQueues":
+		var names []string
+		for name := range self.queues {
+			if strings.HasPrefix(name, form.Get("QueueNamePrefix")) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		start, _ := strconv.Atoi(form.Get("NextToken"))
+		max, _ := strconv.Atoi(form.Get("MaxResults"))
+		result := struct {
+			QueueUrl  []string
+			NextToken string `xml:",omitempty"`
+		}{}
+		for i := start; i < len(names); i++ {
+			if max != 0 && i == start+max {
+				result.NextToken = strconv.Itoa(i)
+				break
+			}
+			result.QueueUrl = append(result.QueueUrl, self.URL+"/123456789012/"+names[i])
+		}
+		return result
+	case "TagQueue":
+		if queue.tags == nil {
+			queue.tags = map[string]string{}
+		}
+		for k, v := range formMap(form, "Tag", "Key", "Value") {
+			queue.tags[k] = v
+		}
+	case "UntagQueue":
+		for _, key := range formList(form, "TagKey") {
+			delete(queue.tags, key)
+		}
+	case "ListQueueTags":
+		var result struct {
+			Tags []struct{ Key, Value string } `xml:"Tag"`
+		}
+		for k, v := range queue.tags {
+			result.Tags = append(result.Tags, struct{ Key, Value string }{k, v})
+		}
+		return result
+	}
+	return nil
+}
+
+func TestListQueues(t *testing.T) {
+	client, _ := newTestSQS(t)
+	for _, name := range []string{"jobs-a", "jobs-b", "jobs-c", "other"} {
+		if _, err := client.CreateQueue(name, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page, err := client.ListQueues("jobs-", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.QueueUrls) != 2 || page.NextToken == "" {
+		t.Fatalf("got %+v", page)
+	}
+	rest, err := client.ListQueues("jobs-", page.NextToken, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest.QueueUrls) != 1 || rest.NextToken != "" || !strings.HasSuffix(rest.QueueUrls[0], "/jobs-c") {
+		t.Fatalf("got %+v", rest)
+	}
+
+	all, err := client.AllQueues(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 || !strings.HasSuffix(all[3].URL, "/other") {
+		t.Fatalf("got %d queues", len(all))
+	}
+}
+
+func TestQueueTags(t *testing.T) {
+	client, _ := newTestSQS(t)
+	ctx := context.Background()
+	queue, _ := client.CreateQueue("jobs", nil)
+	if err := queue.Tag(ctx, map[string]string{"team": "infra", "env": "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Untag(ctx, "env"); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := queue.Tags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(tags) != "map[team:infra]" {
+		t.Fatalf("got %v", tags)
+	}
+}
@@ -0,0 +1,141 @@
+package sqs
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MessageAttributeValue is the value of a message attribute. DataType is
+// "String", "Number" or "Binary", optionally followed by a custom type
+// label such as "Number.float". Binary values are held in BinaryValue,
+// the others in StringValue.
+type MessageAttributeValue struct {
+	DataType    string
+	StringValue string
+	BinaryValue []byte
+}
+
+// StringAttribute returns a String message attribute value.
+func StringAttribute(value string) MessageAttributeValue {
+	return MessageAttributeValue{DataType: "String", StringValue: value}
+}
+
+// NumberAttribute returns a Number message attribute value.
+func NumberAttribute(value int64) MessageAttributeValue {
+	return MessageAttributeValue{DataType: "Number", StringValue: strconv.FormatInt(value, 10)}
+}
+
+// BinaryAttribute returns a Binary message attribute value.
+func BinaryAttribute(value []byte) MessageAttributeValue {
+	return MessageAttributeValue{DataType: "Binary", BinaryValue: value}
+}
+
+func (self MessageAttributeValue) binary() bool {
+	return strings.HasPrefix(self.DataType, "Binary")
+}
+
+// UnmarshalXML decodes the base64 encoded BinaryValue of a response.
+func (self *MessageAttributeValue) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var value struct {
+		DataType    string
+		StringValue string
+		BinaryValue string
+	}
+	if err := d.DecodeElement(&value, &start); err != nil {
+		return err
+	}
+	*self = MessageAttributeValue{DataType: value.DataType, StringValue: value.StringValue}
+	if value.BinaryValue != "" {
+		data, err := base64.StdEncoding.DecodeString(value.BinaryValue)
+		if err != nil {
+			return err
+		}
+		self.BinaryValue = data
+	}
+	return nil
+}
+
+// MessageAttribute is a message attribute of a received message.
+type MessageAttribute struct {
+	Name  string
+	Value MessageAttributeValue
+}
+
+// MessageAttribute returns the message attribute of msg named name.
+func (self *Message) MessageAttribute(name string) (MessageAttributeValue, bool) {
+	for _, attr := range self.MessageAttributes {
+		if attr.Name == name {
+			return attr.Value, true
+		}
+	}
+	return MessageAttributeValue{}, false
+}
+
+// setMessageAttributes adds attrs to params below prefix, sorted by name.
+func setMessageAttributes(params url.Values, prefix string, attrs map[string]MessageAttributeValue) {
+	for i, name := range sortedNames(attrs) {
+		value := attrs[name]
+		n := prefix + "MessageAttribute." + strconv.Itoa(i+1) + "."
+		params.Set(n+"Name", name)
+		params.Set(n+"Value.DataType", value.DataType)
+		if value.binary() {
+			params.Set(n+"Value.BinaryValue", base64.StdEncoding.EncodeToString(value.BinaryValue))
+		} else {
+			params.Set(n+"Value.StringValue", value.StringValue)
+		}
+	}
+}
+
+// md5OfMessageAttributes computes the digest SQS returns for a set of
+// message attributes: every attribute, sorted by name, contributes its
+// name, data type, transport type and value, each string or binary
+// prefixed by its length as a big endian 32 bit integer.
+func md5OfMessageAttributes(attrs map[string]MessageAttributeValue) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	hash := md5.New()
+	write := func(data []byte) {
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+		hash.Write(size[:])
+		hash.Write(data)
+	}
+	for _, name := range sortedNames(attrs) {
+		value := attrs[name]
+		write([]byte(name))
+		write([]byte(value.DataType))
+		if value.binary() {
+			hash.Write([]byte{2})
+			write(value.BinaryValue)
+		} else {
+			hash.Write([]byte{1})
+			write([]byte(value.StringValue))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func messageAttributeMap(attrs []MessageAttribute) map[string]MessageAttributeValue {
+	m := make(map[string]MessageAttributeValue, len(attrs))
+	for _, attr := range attrs {
+		m[attr.Name] = attr.Value
+	}
+	return m
+}
+
+func sortedNames(attrs map[string]MessageAttributeValue) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,73 @@
+package sqs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestMessageAttributes(t *testing.T) {
+	client, fake := newTestSQS(t)
+	ctx := context.Background()
+	queue, _ := client.CreateQueue("jobs", nil)
+	attrs := map[string]MessageAttributeValue{
+		"trace":   StringAttribute("abc"),
+		"retries": NumberAttribute(3),
+		"payload": BinaryAttribute([]byte{0, 1, 0xff}),
+		"ratio":   {DataType: "Number.float", StringValue: "0.5"},
+	}
+	if _, err := queue.SendMessageWithParams(ctx, SendParams{MessageBody: "job", MessageAttributes: attrs}); err != nil {
+		t.Fatal(err)
+	}
+	form := fake.form("SendMessage")
+	if form.Get("MessageAttribute.1.Name") != "payload" || form.Get("MessageAttribute.1.Value.BinaryValue") != "AAH/" {
+		t.Fatalf("sent %v", form)
+	}
+
+	received, err := queue.ReceiveMessageWithParams(ctx, ReceiveParams{MessageAttributeNames: []string{"All"}})
+	if err != nil || len(received.Messages) != 1 {
+		t.Fatalf("got %+v, %v", received, err)
+	}
+	msg := received.Messages[0]
+	if len(msg.MessageAttributes) != 4 {
+		t.Fatalf("got attributes %+v", msg.MessageAttributes)
+	}
+	if value, ok := msg.MessageAttribute("payload"); !ok || value.DataType != "Binary" || !bytes.Equal(value.BinaryValue, []byte{0, 1, 0xff}) {
+		t.Fatalf("got payload %+v", value)
+	}
+	if value, _ := msg.MessageAttribute("ratio"); value.DataType != "Number.float" || value.StringValue != "0.5" {
+		t.Fatalf("got ratio %+v", value)
+	}
+	if _, ok := msg.MessageAttribute("missing"); ok {
+		t.Fatal("found a missing attribute")
+	}
+}
+
+func TestMessageAttributesByName(t *testing.T) {
+	client, _ := newTestSQS(t)
+	ctx := context.Background()
+	queue, _ := client.CreateQueue("jobs", nil)
+	attrs := map[string]MessageAttributeValue{"trace": StringAttribute("abc"), "retries": NumberAttribute(3)}
+	if _, err := queue.SendMessageWithParams(ctx, SendParams{MessageBody: "job", MessageAttributes: attrs}); err != nil {
+		t.Fatal(err)
+	}
+	received, err := queue.ReceiveMessageWithParams(ctx, ReceiveParams{MessageAttributeNames: []string{"trace"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := received.Messages[0]; len(msg.MessageAttributes) != 1 || msg.MessageAttributes[0].Name != "trace" {
+		t.Fatalf("got %+v", msg.MessageAttributes)
+	}
+}
+
+func TestMD5OfMessageAttributes(t *testing.T) {
+	if digest := md5OfMessageAttributes(nil); digest != "" {
+		t.Fatalf("got %q for no attributes", digest)
+	}
+	a := md5OfMessageAttributes(map[string]MessageAttributeValue{"a": StringAttribute("1"), "b": StringAttribute("2")})
+	b := md5OfMessageAttributes(map[string]MessageAttributeValue{"a": StringAttribute("12"), "b": StringAttribute("")})
+	c := md5OfMessageAttributes(map[string]MessageAttributeValue{"a": {DataType: "Binary", BinaryValue: []byte("1")}, "b": StringAttribute("2")})
+	if a == b || a == c || len(a) != 32 {
+		t.Fatalf("digests %s, %s, %s", a, b, c)
+	}
+}
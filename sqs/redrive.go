@@ -0,0 +1,138 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// RedrivePolicy moves messages that were received MaxReceiveCount times
+// without being deleted to the dead-letter queue DeadLetterTargetArn.
+type RedrivePolicy struct {
+	DeadLetterTargetArn string `json:"deadLetterTargetArn"`
+	MaxReceiveCount     int    `json:"maxReceiveCount"`
+}
+
+// MarshalJSON encodes the policy the way SQS returns it, with the count
+// as a string.
+func (self RedrivePolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"deadLetterTargetArn": self.DeadLetterTargetArn,
+		"maxReceiveCount":     strconv.Itoa(self.MaxReceiveCount),
+	})
+}
+
+// UnmarshalJSON accepts the count both as a number and as a string.
+func (self *RedrivePolicy) UnmarshalJSON(data []byte) error {
+	var policy struct {
+		DeadLetterTargetArn string      `json:"deadLetterTargetArn"`
+		MaxReceiveCount     json.Number `json:"maxReceiveCount"`
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return err
+	}
+	count, err := strconv.Atoi(string(policy.MaxReceiveCount))
+	if err != nil && policy.MaxReceiveCount != "" {
+		return err
+	}
+	*self = RedrivePolicy{policy.DeadLetterTargetArn, count}
+	return nil
+}
+
+// SetRedrivePolicy makes dlq the dead-letter queue of the queue, which
+// receives the messages that were received maxReceiveCount times without
+// being deleted.
+func (self *Queue) SetRedrivePolicy(ctx context.Context, dlq *Queue, maxReceiveCount int) error {
+	attrs, err := dlq.GetAttributesWithContext(ctx, AttrQueueArn)
+	if err != nil {
+		return err
+	}
+	policy, err := json.Marshal(RedrivePolicy{attrs.QueueArn, maxReceiveCount})
+	if err != nil {
+		return err
+	}
+	return self.SetAttributesWithContext(ctx, map[string]string{AttrRedrivePolicy: string(policy)})
+}
+
+// RemoveRedrivePolicy stops the queue from moving messages to a
+// dead-letter queue.
+func (self *Queue) RemoveRedrivePolicy(ctx context.Context) error {
+	return self.SetAttributesWithContext(ctx, map[string]string{AttrRedrivePolicy: ""})
+}
+
+// GetRedrivePolicy returns the redrive policy of the queue, or nil if it
+// has none.
+func (self *Queue) GetRedrivePolicy(ctx context.Context) (*RedrivePolicy, error) {
+	attrs, err := self.GetAttributesWithContext(ctx, AttrRedrivePolicy)
+	if err != nil {
+		return nil, err
+	}
+	if attrs.RedrivePolicy == "" {
+		return nil, nil
+	}
+	policy := &RedrivePolicy{}
+	if err := json.Unmarshal([]byte(attrs.RedrivePolicy), policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Redrive moves up to max messages (all of them if max is zero) from the
+// dead-letter queue dlq back to the queue, typically its source queue
+// once the cause of the failures has been fixed. Messages are deleted
+// from dlq only after they were sent to the queue, so a failure midway
+// may duplicate but never lose messages. It returns the number of
+// messages moved, and stops when a receive finds dlq empty.
+func (self *Queue) Redrive(ctx context.Context, dlq *Queue, max int) (int, error) {
+	moved := 0
+	for max == 0 || moved < max {
+		n := MaxBatchSize
+		if max != 0 && max-moved < n {
+			n = max - moved
+		}
+		received, err := dlq.ReceiveMessageWithParams(ctx, ReceiveParams{
+			MaxNumberOfMessages:   n,
+			WaitTimeSeconds:       1,
+			MessageAttributeNames: []string{"All"},
+		})
+		if err != nil {
+			return moved, err
+		}
+		if len(received.Messages) == 0 {
+			return moved, nil
+		}
+
+		entries := make([]SendMessageBatchEntry, len(received.Messages))
+		handles := map[string]string{}
+		for i, msg := range received.Messages {
+			id := strconv.Itoa(i)
+			entries[i] = SendMessageBatchEntry{
+				Id:                id,
+				MessageBody:       msg.Body,
+				MessageAttributes: messageAttributeMap(msg.MessageAttributes),
+			}
+			handles[id] = msg.ReceiptHandle
+		}
+		sent, err := self.SendMessageBatchWithContext(ctx, entries)
+		if err != nil {
+			return moved, err
+		}
+		if len(sent.Successful) == 0 && len(sent.Failed) > 0 {
+			return moved, &sent.Failed[0]
+		}
+
+		deletes := make([]DeleteMessageBatchEntry, len(sent.Successful))
+		for i, entry := range sent.Successful {
+			deletes[i] = DeleteMessageBatchEntry{Id: entry.Id, ReceiptHandle: handles[entry.Id]}
+		}
+		deleted, err := dlq.DeleteMessageBatchWithContext(ctx, deletes)
+		if err != nil {
+			return moved, err
+		}
+		moved += len(deleted.Successful)
+		if len(deleted.Failed) > 0 {
+			return moved, &deleted.Failed[0]
+		}
+	}
+	return moved, nil
+}
@@ -0,0 +1,88 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestRedrivePolicyJSON(t *testing.T) {
+	for _, data := range []string{
+		`{"deadLetterTargetArn":"arn:dlq","maxReceiveCount":"5"}`,
+		`{"deadLetterTargetArn":"arn:dlq","maxReceiveCount":5}`,
+	} {
+		var policy RedrivePolicy
+		if err := json.Unmarshal([]byte(data), &policy); err != nil {
+			t.Fatal(err)
+		}
+		if policy != (RedrivePolicy{"arn:dlq", 5}) {
+			t.Fatalf("%s: got %+v", data, policy)
+		}
+	}
+	data, err := json.Marshal(RedrivePolicy{"arn:dlq", 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"deadLetterTargetArn":"arn:dlq","maxReceiveCount":"5"}` {
+		t.Fatalf("got %s", data)
+	}
+}
+
+func TestRedrivePolicy(t *testing.T) {
+	client, _ := newTestSQS(t)
+	ctx := context.Background()
+	queue, _ := client.CreateQueue("jobs", nil)
+	dlq, _ := client.CreateQueue("jobs-dlq", nil)
+
+	if policy, err := queue.GetRedrivePolicy(ctx); err != nil || policy != nil {
+		t.Fatalf("got %+v, %v", policy, err)
+	}
+	if err := queue.SetRedrivePolicy(ctx, dlq, 3); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := queue.GetRedrivePolicy(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *policy != (RedrivePolicy{"arn:aws:sqs:us-east-1:123456789012:jobs-dlq", 3}) {
+		t.Fatalf("got %+v", policy)
+	}
+	if err := queue.RemoveRedrivePolicy(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if policy, err := queue.GetRedrivePolicy(ctx); err != nil || policy != nil {
+		t.Fatalf("got %+v, %v", policy, err)
+	}
+}
+
+func TestRedrive(t *testing.T) {
+	client, fake := newTestSQS(t)
+	ctx := context.Background()
+	queue, _ := client.CreateQueue("jobs", nil)
+	dlq, _ := client.CreateQueue("jobs-dlq", nil)
+	for i := 0; i < 13; i++ {
+		_, err := dlq.SendMessageWithParams(ctx, SendParams{
+			MessageBody:       "failed",
+			MessageAttributes: map[string]MessageAttributeValue{"attempt": NumberAttribute(int64(i))},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moved, err := queue.Redrive(ctx, dlq, 12)
+	if err != nil || moved != 12 {
+		t.Fatalf("moved %d, %v", moved, err)
+	}
+	moved, err = queue.Redrive(ctx, dlq, 0)
+	if err != nil || moved != 1 {
+		t.Fatalf("moved %d, %v", moved, err)
+	}
+	if left := len(fake.queues["jobs-dlq"].messages); left != 0 {
+		t.Fatalf("%d messages left in the dead-letter queue", left)
+	}
+	messages := fake.queues["jobs"].messages
+	if len(messages) != 13 || messages[12].attrs["attempt"].StringValue != "12" {
+		t.Fatalf("moved %d messages, last with attributes %v", len(messages), messages[len(messages)-1].attrs)
+	}
+}
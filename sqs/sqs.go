@@ -0,0 +1,300 @@
+// Package sqs interacts with the Amazon Simple Queue Service.
+package sqs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/dkln/go-aws"
+)
+
+// APIVersion is the version of the SQS API the package speaks.
+const APIVersion = "2012-11-05"
+
+// The SQS type encapsulates operations with SQS in a region.
+type SQS struct {
+	*aws.QueryClient
+}
+
+// New creates a new SQS.
+func New(auth aws.Auth, region aws.Region) *SQS {
+	client := aws.NewQueryClient(auth, region, "sqs", APIVersion)
+	client.IdempotentActions = map[string]bool{
+		"ReceiveMessage":               true,
+		"DeleteMessage":                true,
+		"DeleteMessageBatch":           true,
+		"ChangeMessageVisibility":      true,
+		"ChangeMessageVisibilityBatch": true,
+		"SetQueueAttributes":           true,
+		"TagQueue":                     true,
+		"UntagQueue":                   true,
+	}
+	return &SQS{client}
+}
+
+// The Queue type encapsulates operations with an SQS queue.
+type Queue struct {
+	*SQS
+	URL string
+}
+
+// Queue returns the queue at url, without checking that it exists.
+func (self *SQS) Queue(url string) *Queue {
+	return &Queue{self, url}
+}
+
+// ErrChecksum is returned when the MD5 digest SQS reports for a message
+// doesn't match the message, which means it was corrupted on the way.
+var ErrChecksum = errors.New("sqs: message checksum mismatch")
+
+// ResponseMetadata is part of every SQS response.
+type ResponseMetadata struct {
+	RequestId string
+}
+
+// CreateQueue creates a queue named name with the given attributes, or
+// returns the existing queue if one with the same attributes exists.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_CreateQueue.html for details.
+func (self *SQS) CreateQueue(name string, attributes map[string]string) (*Queue, error) {
+	return self.CreateQueueWithContext(context.Background(), name, attributes)
+}
+
+// CreateQueueWithContext is like CreateQueue but aborts when ctx is done.
+func (self *SQS) CreateQueueWithContext(ctx context.Context, name string, attributes map[string]string) (*Queue, error) {
+	params := url.Values{"QueueName": {name}}
+	aws.SetMap(params, "Attribute", "Name", "Value", attributes)
+	var resp struct {
+		QueueUrl string `xml:"CreateQueueResult>QueueUrl"`
+	}
+	if err := self.Do(ctx, "CreateQueue", params, &resp); err != nil {
+		return nil, err
+	}
+	return self.Queue(resp.QueueUrl), nil
+}
+
+// GetQueue returns the queue named name.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_GetQueueUrl.html for details.
+func (self *SQS) GetQueue(name string) (*Queue, error) {
+	return self.GetQueueWithContext(context.Background(), name)
+}
+
+// GetQueueWithContext is like GetQueue but aborts when ctx is done.
+func (self *SQS) GetQueueWithContext(ctx context.Context, name string) (*Queue, error) {
+	var resp struct {
+		QueueUrl string `xml:"GetQueueUrlResult>QueueUrl"`
+	}
+	if err := self.Do(ctx, "GetQueueUrl", url.Values{"QueueName": {name}}, &resp); err != nil {
+		return nil, err
+	}
+	return self.Queue(resp.QueueUrl), nil
+}
+
+// Delete deletes the queue and the messages in it.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_DeleteQueue.html for details.
+func (self *Queue) Delete() error {
+	return self.DeleteWithContext(context.Background())
+}
+
+// DeleteWithContext is like Delete but aborts when ctx is done.
+func (self *Queue) DeleteWithContext(ctx context.Context) error {
+	return self.do(ctx, "DeleteQueue", url.Values{}, nil)
+}
+
+// SendMessageResp is the result of SendMessage.
+type SendMessageResp struct {
+	MessageId              string `xml:"SendMessageResult>MessageId"`
+	MD5OfMessageBody       string `xml:"SendMessageResult>MD5OfMessageBody"`
+	MD5OfMessageAttributes string `xml:"SendMessageResult>MD5OfMessageAttributes"`
+	ResponseMetadata       ResponseMetadata
+}
+
+// SendMessage adds a message with the given body to the queue.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SendMessage.html for details.
+func (self *Queue) SendMessage(body string) (*SendMessageResp, error) {
+	return self.SendMessageWithContext(context.Background(), body)
+}
+
+// SendMessageWithContext is like SendMessage but aborts when ctx is done.
+func (self *Queue) SendMessageWithContext(ctx context.Context, body string) (*SendMessageResp, error) {
+	return self.SendMessageWithParams(ctx, SendParams{MessageBody: body})
+}
+
+// SendParams describe a message to send.
+type SendParams struct {
+	MessageBody  string
+	DelaySeconds int // if zero, the queue's delay applies
+
+	// MessageAttributes are metadata sent along with the body, by name.
+	// Consumers only receive those they ask for in ReceiveParams.
+	MessageAttributes map[string]MessageAttributeValue
+}
+
+// SendMessageWithParams adds the message params describe to the queue.
+func (self *Queue) SendMessageWithParams(ctx context.Context, params SendParams) (*SendMessageResp, error) {
+	query := url.Values{"MessageBody": {params.MessageBody}}
+	if params.DelaySeconds != 0 {
+		query.Set("DelaySeconds", strconv.Itoa(params.DelaySeconds))
+	}
+	setMessageAttributes(query, "", params.MessageAttributes)
+	resp := &SendMessageResp{}
+	if err := self.do(ctx, "SendMessage", query, resp); err != nil {
+		return nil, err
+	}
+	if resp.MD5OfMessageBody != md5Hex(params.MessageBody) ||
+		resp.MD5OfMessageAttributes != md5OfMessageAttributes(params.MessageAttributes) {
+		return nil, ErrChecksum
+	}
+	return resp, nil
+}
+
+// Message is a message received from a queue.
+type Message struct {
+	MessageId     string
+	ReceiptHandle string
+	MD5OfBody     string
+	Body          string
+	Attributes    []Attribute `xml:"Attribute"`
+
+	MD5OfMessageAttributes string
+	MessageAttributes      []MessageAttribute `xml:"MessageAttribute"`
+}
+
+// Attribute is a system attribute of a message or queue.
+type Attribute struct {
+	Name  string
+	Value string
+}
+
+// ReceiveMessageResp is the result of ReceiveMessage.
+type ReceiveMessageResp struct {
+	Messages         []Message `xml:"ReceiveMessageResult>Message"`
+	ResponseMetadata ResponseMetadata
+}
+
+// ReceiveMessage receives up to max messages (1 to 10) from the queue.
+// It may return fewer, or none, even if the queue has more.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ReceiveMessage.html for details.
+func (self *Queue) ReceiveMessage(max int) (*ReceiveMessageResp, error) {
+	return self.ReceiveMessageWithContext(context.Background(), max)
+}
+
+// ReceiveMessageWithContext is like ReceiveMessage but aborts when ctx is
+// done.
+func (self *Queue) ReceiveMessageWithContext(ctx context.Context, max int) (*ReceiveMessageResp, error) {
+	return self.ReceiveMessageWithParams(ctx, ReceiveParams{MaxNumberOfMessages: max})
+}
+
+// MaxWaitTimeSeconds is the longest SQS waits for messages to arrive in a
+// long polling receive.
+const MaxWaitTimeSeconds = 20
+
+// ReceiveParams are the optional parameters of a receive.
+type ReceiveParams struct {
+	MaxNumberOfMessages int // 1 to 10; if zero, 1
+
+	// VisibilityTimeout, if not zero, hides the received messages for
+	// that many seconds instead of the queue's visibility timeout.
+	VisibilityTimeout int
+
+	// WaitTimeSeconds, if not zero, makes the receive wait up to that
+	// many seconds (at most MaxWaitTimeSeconds) for a message to arrive
+	// instead of returning empty handed at once. If zero, the queue's
+	// ReceiveMessageWaitTimeSeconds attribute applies. Long polling saves
+	// the requests a consumer of a mostly empty queue would otherwise
+	// make; the http.Client of the SQS value must then not time out
+	// sooner.
+	WaitTimeSeconds int
+
+	// MessageAttributeNames are the names of the message attributes to
+	// receive. "All" receives all of them, and a name ending in ".*" all
+	// those with that prefix.
+	MessageAttributeNames []string
+}
+
+// ReceiveMessageWithParams receives messages from the queue as params
+// ask for.
+func (self *Queue) ReceiveMessageWithParams(ctx context.Context, params ReceiveParams) (*ReceiveMessageResp, error) {
+	query := url.Values{"AttributeName": {"All"}}
+	if params.MaxNumberOfMessages != 0 {
+		query.Set("MaxNumberOfMessages", strconv.Itoa(params.MaxNumberOfMessages))
+	}
+	if params.VisibilityTimeout != 0 {
+		query.Set("VisibilityTimeout", strconv.Itoa(params.VisibilityTimeout))
+	}
+	if params.WaitTimeSeconds != 0 {
+		query.Set("WaitTimeSeconds", strconv.Itoa(params.WaitTimeSeconds))
+	}
+	aws.SetList(query, "MessageAttributeName", params.MessageAttributeNames)
+	resp := &ReceiveMessageResp{}
+	if err := self.do(ctx, "ReceiveMessage", query, resp); err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Messages {
+		if msg.MD5OfBody != md5Hex(msg.Body) ||
+			msg.MD5OfMessageAttributes != md5OfMessageAttributes(messageAttributeMap(msg.MessageAttributes)) {
+			return nil, ErrChecksum
+		}
+	}
+	return resp, nil
+}
+
+// SetReceiveMessageWaitTime sets the ReceiveMessageWaitTimeSeconds
+// attribute of the queue, the time receives that don't ask for a wait
+// time of their own wait for messages to arrive. Zero turns long polling
+// off.
+func (self *Queue) SetReceiveMessageWaitTime(ctx context.Context, seconds int) error {
+	return self.SetAttributesWithContext(ctx, map[string]string{
+		AttrReceiveMessageWaitTimeSeconds: strconv.Itoa(seconds),
+	})
+}
+
+// DeleteMessage deletes the message with the given receipt handle from
+// the queue.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_DeleteMessage.html for details.
+func (self *Queue) DeleteMessage(receiptHandle string) error {
+	return self.DeleteMessageWithContext(context.Background(), receiptHandle)
+}
+
+// DeleteMessageWithContext is like DeleteMessage but aborts when ctx is
+// done.
+func (self *Queue) DeleteMessageWithContext(ctx context.Context, receiptHandle string) error {
+	return self.do(ctx, "DeleteMessage", url.Values{"ReceiptHandle": {receiptHandle}}, nil)
+}
+
+// ChangeMessageVisibility hides the message with the given receipt
+// handle from receives for timeout seconds from now. Zero makes it
+// visible again at once.
+//
+// See https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_ChangeMessageVisibility.html for details.
+func (self *Queue) ChangeMessageVisibility(receiptHandle string, timeout int) error {
+	return self.ChangeMessageVisibilityWithContext(context.Background(), receiptHandle, timeout)
+}
+
+// ChangeMessageVisibilityWithContext is like ChangeMessageVisibility but
+// aborts when ctx is done.
+func (self *Queue) ChangeMessageVisibilityWithContext(ctx context.Context, receiptHandle string, timeout int) error {
+	params := url.Values{
+		"ReceiptHandle":     {receiptHandle},
+		"VisibilityTimeout": {strconv.Itoa(timeout)},
+	}
+	return self.do(ctx, "ChangeMessageVisibility", params, nil)
+}
+
+func (self *Queue) do(ctx context.Context, action string, params url.Values, resp interface{}) error {
+	return self.DoPath(ctx, self.URL, action, params, resp)
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
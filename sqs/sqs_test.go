@@ -0,0 +1,450 @@
+package sqs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// fakeSQS is an in-memory SQS speaking enough of the Query protocol for
+// the tests of the package.
+type fakeSQS struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	queues   map[string]*fakeQueue // by name
+	forms    map[string]url.Values // last request by action
+	failures map[string]int        // server errors still to return, by action
+	corrupt  bool                  // report wrong digests
+}
+
+type fakeQueue struct {
+	attrs    map[string]string
+	tags     map[string]string
+	messages []*fakeMessage
+	sent     int
+}
+
+type fakeMessage struct {
+	id        string
+	body      string
+	attrs     map[string]MessageAttributeValue
+	handle    string
+	receives  int
+	visibleAt time.Time
+}
+
+// newTestSQS returns an SQS client of a fresh fakeSQS.
+func newTestSQS(t *testing.T) (*SQS, *fakeSQS) {
+	fake := &fakeSQS{
+		queues:   map[string]*fakeQueue{},
+		forms:    map[string]url.Values{},
+		failures: map[string]int{},
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.Region{Name: "us-east-1", SQSEndpoint: fake.URL, SigV4Only: true})
+	client.Attempts = &aws.AttemptStrategy{Min: 3, Delay: time.Millisecond}
+	return client, fake
+}
+
+// form returns the last request for action.
+func (self *fakeSQS) form(action string) url.Values {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.forms[action]
+}
+
+type fakeError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (self *fakeSQS) serve(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(403)
+		return
+	}
+	r.ParseForm()
+	action := r.Form.Get("Action")
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.forms[action] = r.Form
+	if self.failures[action] > 0 {
+		self.failures[action]--
+		w.WriteHeader(500)
+		fmt.Fprint(w, "<ErrorResponse><Error><Type>Receiver</Type><Code>InternalError</Code><Message>try again</Message></Error></ErrorResponse>")
+		return
+	}
+
+	var queue *fakeQueue
+	if name := path.Base(r.URL.Path); r.URL.Path != "/" {
+		if queue = self.queues[name]; queue == nil {
+			writeFakeError(w, fakeError{400, "AWS.SimpleQueueService.NonExistentQueue", "The specified queue does not exist."})
+			return
+		}
+	}
+	result, ferr := self.handle(r, action, queue)
+	if ferr != nil {
+		writeFakeError(w, *ferr)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	fmt.Fprintf(w, "<%sResponse>", action)
+	if result != nil {
+		xml.NewEncoder(w).EncodeElement(result, xml.StartElement{Name: xml.Name{Local: action + "Result"}})
+	}
+	fmt.Fprintf(w, "<ResponseMetadata><RequestId>req-1</RequestId></ResponseMetadata></%sResponse>", action)
+}
+
+func writeFakeError(w http.ResponseWriter, err fakeError) {
+	w.WriteHeader(err.status)
+	fmt.Fprintf(w, "<ErrorResponse><Error><Type>Sender</Type><Code>%s</Code><Message>%s</Message></Error><RequestId>req-1</RequestId></ErrorResponse>",
+		err.code, err.message)
+}
+
+type fakeReceivedMessage struct {
+	XMLName                xml.Name `xml:"Message"`
+	MessageId              string
+	ReceiptHandle          string
+	MD5OfBody              string
+	Body                   string
+	Attributes             []Attribute `xml:"Attribute"`
+	MD5OfMessageAttributes string      `xml:",omitempty"`
+	MessageAttributes      []fakeMessageAttribute
+}
+
+type fakeMessageAttribute struct {
+	XMLName xml.Name `xml:"MessageAttribute"`
+	Name    string
+	Value   struct {
+		DataType    string
+		StringValue string `xml:",omitempty"`
+		BinaryValue string `xml:",omitempty"`
+	}
+}
+
+type fakeQueueUrl struct {
+	QueueUrl string
+}
+
+func (self *fakeSQS) handle(r *http.Request, action string, queue *fakeQueue) (interface{}, *fakeError) {
+	form := r.Form
+	switch action {
+	case "CreateQueue":
+		name := form.Get("QueueName")
+		if self.queues[name] == nil {
+			self.queues[name] = &fakeQueue{attrs: map[string]string{
+				AttrVisibilityTimeout: "30",
+				AttrQueueArn:          "arn:aws:sqs:us-east-1:123456789012:" + name,
+			}}
+		}
+		for k, v := range formMap(form, "Attribute", "Name", "Value") {
+			self.queues[name].attrs[k] = v
+		}
+		return fakeQueueUrl{QueueUrl: self.URL + "/123456789012/" + name}, nil
+	case "GetQueueUrl":
+		name := form.Get("QueueName")
+		if self.queues[name] == nil {
+			return nil, &fakeError{400, "AWS.SimpleQueueService.NonExistentQueue", "The specified queue does not exist."}
+		}
+		return fakeQueueUrl{QueueUrl: self.URL + "/123456789012/" + name}, nil
+	case "DeleteQueue":
+		delete(self.queues, path.Base(r.URL.Path))
+		return nil, nil
+	case "SendMessage":
+		msg := queue.send(form.Get("MessageBody"), formMessageAttributes(form, ""))
+		return struct {
+			MessageId              string
+			MD5OfMessageBody       string
+			MD5OfMessageAttributes string `xml:",omitempty"`
+		}{msg.id, self.digest(md5Hex(msg.body)), self.digest(md5OfMessageAttributes(msg.attrs))}, nil
+	case "ReceiveMessage":
+		max, _ := strconv.Atoi(form.Get("MaxNumberOfMessages"))
+		if max == 0 {
+			max = 1
+		}
+		visibility, err := strconv.Atoi(form.Get("VisibilityTimeout"))
+		if err != nil {
+			visibility, _ = strconv.Atoi(queue.attrs[AttrVisibilityTimeout])
+		}
+		var received []fakeReceivedMessage
+		for _, msg := range queue.messages {
+			if len(received) == max || time.Now().Before(msg.visibleAt) {
+				continue
+			}
+			msg.receives++
+			msg.handle = msg.id + "-" + strconv.Itoa(msg.receives)
+			msg.visibleAt = time.Now().Add(time.Duration(visibility) * time.Second)
+			received = append(received, self.received(msg, form))
+		}
+		return struct{ Messages []fakeReceivedMessage }{received}, nil
+	case "DeleteMessage":
+		if !queue.delete(form.Get("ReceiptHandle")) {
+			return nil, &fakeError{400, "ReceiptHandleIsInvalid", "The receipt handle is not valid."}
+		}
+		return nil, nil
+	case "ChangeMessageVisibility":
+		timeout, _ := strconv.Atoi(form.Get("VisibilityTimeout"))
+		if !queue.changeVisibility(form.Get("ReceiptHandle"), timeout) {
+			return nil, &fakeError{400, "ReceiptHandleIsInvalid", "The receipt handle is not valid."}
+		}
+		return nil, nil
+	case "ListQueues", "TagQueue", "UntagQueue", "ListQueueTags":
+		return self.handleList(action, form, queue), nil
+	case "GetQueueAttributes":
+		return struct {
+			Attributes []Attribute `xml:"Attribute"`
+		}{queue.attributes(formList(form, "AttributeName"))}, nil
+	case "SetQueueAttributes":
+		for k, v := range formMap(form, "Attribute", "Name", "Value") {
+			queue.attrs[k] = v
+		}
+		return nil, nil
+	case "SendMessageBatch", "DeleteMessageBatch", "ChangeMessageVisibilityBatch":
+		return self.handleBatch(action, form, queue), nil
+	}
+	return nil, &fakeError{400, "InvalidAction", "The action " + action + " is not valid for this endpoint."}
+}
+
+// digest returns digest, or a wrong one if the fake corrupts messages.
+func (self *fakeSQS) digest(digest string) string {
+	if self.corrupt && digest != "" {
+		return md5Hex(digest)
+	}
+	return digest
+}
+
+// received returns msg as ReceiveMessage returns it, with the message
+// attributes form asks for.
+func (self *fakeSQS) received(msg *fakeMessage, form url.Values) fakeReceivedMessage {
+	wanted := map[string]bool{}
+	for i := 1; form.Get("MessageAttributeName."+strconv.Itoa(i)) != ""; i++ {
+		wanted[form.Get("MessageAttributeName."+strconv.Itoa(i))] = true
+	}
+	attrs := map[string]MessageAttributeValue{}
+	for name, value := range msg.attrs {
+		if wanted["All"] || wanted[name] {
+			attrs[name] = value
+		}
+	}
+	received := fakeReceivedMessage{
+		MessageId:              msg.id,
+		ReceiptHandle:          msg.handle,
+		MD5OfBody:              self.digest(md5Hex(msg.body)),
+		Body:                   msg.body,
+		Attributes:             []Attribute{{"ApproximateReceiveCount", strconv.Itoa(msg.receives)}},
+		MD5OfMessageAttributes: self.digest(md5OfMessageAttributes(attrs)),
+	}
+	for _, name := range sortedNames(attrs) {
+		attr := fakeMessageAttribute{Name: name}
+		attr.Value.DataType = attrs[name].DataType
+		attr.Value.StringValue = attrs[name].StringValue
+		if attrs[name].binary() {
+			attr.Value.BinaryValue = base64.StdEncoding.EncodeToString(attrs[name].BinaryValue)
+		}
+		received.MessageAttributes = append(received.MessageAttributes, attr)
+	}
+	return received
+}
+
+func (self *fakeQueue) send(body string, attrs map[string]MessageAttributeValue) *fakeMessage {
+	self.sent++
+	msg := &fakeMessage{id: "msg-" + strconv.Itoa(self.sent), body: body, attrs: attrs}
+	self.messages = append(self.messages, msg)
+	return msg
+}
+
+func (self *fakeQueue) delete(handle string) bool {
+	for i, msg := range self.messages {
+		if handle != "" && msg.handle == handle {
+			self.messages = append(self.messages[:i], self.messages[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (self *fakeQueue) changeVisibility(handle string, timeout int) bool {
+	for _, msg := range self.messages {
+		if handle != "" && msg.handle == handle {
+			msg.visibleAt = time.Now().Add(time.Duration(timeout) * time.Second)
+			return true
+		}
+	}
+	return false
+}
+
+// formMap decodes a map sent with aws.SetMap.
+func formMap(form url.Values, prefix, keyName, valueName string) map[string]string {
+	m := map[string]string{}
+	for i := 1; ; i++ {
+		n := prefix + "." + strconv.Itoa(i) + "."
+		if _, ok := form[n+keyName]; !ok {
+			return m
+		}
+		m[form.Get(n+keyName)] = form.Get(n + valueName)
+	}
+}
+
+// formMessageAttributes decodes the message attributes sent below prefix.
+func formMessageAttributes(form url.Values, prefix string) map[string]MessageAttributeValue {
+	attrs := map[string]MessageAttributeValue{}
+	for i := 1; ; i++ {
+		n := prefix + "MessageAttribute." + strconv.Itoa(i) + "."
+		name := form.Get(n + "Name")
+		if name == "" {
+			return attrs
+		}
+		value := MessageAttributeValue{
+			DataType:    form.Get(n + "Value.DataType"),
+			StringValue: form.Get(n + "Value.StringValue"),
+		}
+		if b64 := form.Get(n + "Value.BinaryValue"); b64 != "" {
+			value.BinaryValue, _ = base64.StdEncoding.DecodeString(b64)
+		}
+		attrs[name] = value
+	}
+}
+
+func TestQueueLifecycle(t *testing.T) {
+	client, _ := newTestSQS(t)
+	created, err := client.CreateQueue("jobs", map[string]string{AttrVisibilityTimeout: "60"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue, err := client.GetQueue("jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queue.URL != created.URL || !strings.HasSuffix(queue.URL, "/jobs") {
+		t.Fatalf("got queue %q, created %q", queue.URL, created.URL)
+	}
+
+	sent, err := queue.SendMessage("hello <world> & all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received, err := queue.ReceiveMessage(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received.Messages) != 1 || received.ResponseMetadata.RequestId != "req-1" {
+		t.Fatalf("got %+v", received)
+	}
+	msg := received.Messages[0]
+	if msg.MessageId != sent.MessageId || msg.Body != "hello <world> & all" {
+		t.Fatalf("got message %+v", msg)
+	}
+
+	// The message is hidden until its visibility timeout is reset.
+	if again, _ := queue.ReceiveMessage(10); len(again.Messages) != 0 {
+		t.Fatal("received a message twice")
+	}
+	if err := queue.ChangeMessageVisibility(msg.ReceiptHandle, 0); err != nil {
+		t.Fatal(err)
+	}
+	again, err := queue.ReceiveMessage(10)
+	if err != nil || len(again.Messages) != 1 {
+		t.Fatalf("got %+v, %v", again, err)
+	}
+	if err := queue.DeleteMessage(again.Messages[0].ReceiptHandle); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := queue.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetQueue("jobs")
+	if aws.ErrorCode(err) != "AWS.SimpleQueueService.NonExistentQueue" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	client, fake := newTestSQS(t)
+	queue, err := client.CreateQueue("jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queue.SendMessage("intact"); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.corrupt = true
+	if _, err := queue.SendMessage("corrupted"); err != ErrChecksum {
+		t.Fatalf("send: got %v, want ErrChecksum", err)
+	}
+	if _, err := queue.ReceiveMessage(1); err != ErrChecksum {
+		t.Fatalf("receive: got %v, want ErrChecksum", err)
+	}
+}
+
+func TestOnlyIdempotentActionsAreRetried(t *testing.T) {
+	client, fake := newTestSQS(t)
+	queue, err := client.CreateQueue("jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake.failures["ReceiveMessage"] = 1
+	if _, err := queue.ReceiveMessageWithContext(context.Background(), 1); err != nil {
+		t.Fatalf("receive was not retried: %v", err)
+	}
+	fake.failures["SendMessage"] = 1
+	if _, err := queue.SendMessage("once"); aws.ErrorCode(err) != "InternalError" {
+		t.Fatalf("send: got %v", err)
+	}
+	if fake.queues["jobs"].sent != 0 {
+		t.Fatal("failed send was repeated")
+	}
+}
+
+func TestLongPolling(t *testing.T) {
+	client, fake := newTestSQS(t)
+	queue, err := client.CreateQueue("jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.SetReceiveMessageWaitTime(context.Background(), MaxWaitTimeSeconds); err != nil {
+		t.Fatal(err)
+	}
+	if wait := fake.queues["jobs"].attrs[AttrReceiveMessageWaitTimeSeconds]; wait != "20" {
+		t.Fatalf("queue waits %q seconds", wait)
+	}
+
+	_, err = queue.ReceiveMessageWithParams(context.Background(), ReceiveParams{
+		MaxNumberOfMessages: 5,
+		VisibilityTimeout:   90,
+		WaitTimeSeconds:     10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := fake.form("ReceiveMessage")
+	if form.Get("WaitTimeSeconds") != "10" || form.Get("VisibilityTimeout") != "90" || form.Get("MaxNumberOfMessages") != "5" {
+		t.Fatalf("sent %v", form)
+	}
+
+	// Without a wait time of its own, a receive leaves it to the queue.
+	if _, err := queue.ReceiveMessage(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.form("ReceiveMessage")["WaitTimeSeconds"]; ok {
+		t.Fatal("sent a wait time")
+	}
+}
@@ -0,0 +1,96 @@
+package sts
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// AssumeRoleParams are the parameters of AssumeRole.
+type AssumeRoleParams struct {
+	RoleArn         string
+	RoleSessionName string
+	Duration        time.Duration // defaults to one hour
+	ExternalId      string
+
+	// Policy, if set, is a JSON policy further restricting the
+	// permissions of the credentials.
+	Policy string
+
+	// MFA, if set, authenticates the caller for roles requiring it.
+	MFA *MFA
+}
+
+// AssumedRoleUser identifies the session of an assumed role.
+type AssumedRoleUser struct {
+	AssumedRoleId string // role ID and session name
+	Arn           string
+}
+
+// AssumeRoleResult is the result of AssumeRole.
+type AssumeRoleResult struct {
+	Credentials      Credentials
+	AssumedRoleUser  AssumedRoleUser
+	PackedPolicySize int // percentage of the allowed policy size used
+}
+
+// AssumeRole returns temporary credentials of a role.
+// aws.AssumeRoleProvider assumes roles for long-running processes.
+//
+// See https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRole.html for details.
+func (self *STS) AssumeRole(ctx context.Context, params *AssumeRoleParams) (*AssumeRoleResult, error) {
+	query := url.Values{
+		"RoleArn":         {params.RoleArn},
+		"RoleSessionName": {params.RoleSessionName},
+	}
+	setDuration(query, params.Duration)
+	if params.ExternalId != "" {
+		query.Set("ExternalId", params.ExternalId)
+	}
+	if params.Policy != "" {
+		query.Set("Policy", params.Policy)
+	}
+	params.MFA.setParams(query)
+	var resp struct {
+		Result AssumeRoleResult `xml:"AssumeRoleResult"`
+	}
+	if err := self.Do(ctx, "AssumeRole", query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Result, nil
+}
+
+// FederatedUser identifies a federated user.
+type FederatedUser struct {
+	FederatedUserId string // account ID and user name
+	Arn             string
+}
+
+// FederationTokenResult is the result of GetFederationToken.
+type FederationTokenResult struct {
+	Credentials      Credentials
+	FederatedUser    FederatedUser
+	PackedPolicySize int
+}
+
+// GetFederationToken returns temporary credentials of the federated user
+// named name, such as a user of a proxy application, valid for duration,
+// or 12 hours if zero. Their permissions are those of the calling IAM
+// user restricted by the JSON policy, which must be set for them to
+// have any.
+//
+// See https://docs.aws.amazon.com/STS/latest/APIReference/API_GetFederationToken.html for details.
+func (self *STS) GetFederationToken(ctx context.Context, name, policy string, duration time.Duration) (*FederationTokenResult, error) {
+	params := url.Values{"Name": {name}}
+	if policy != "" {
+		params.Set("Policy", policy)
+	}
+	setDuration(params, duration)
+	var resp struct {
+		Result FederationTokenResult `xml:"GetFederationTokenResult"`
+	}
+	if err := self.Do(ctx, "GetFederationToken", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Result, nil
+}
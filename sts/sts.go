@@ -0,0 +1,120 @@
+// Package sts interacts with the AWS Security Token Service, which
+// issues temporary credentials.
+package sts
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// APIVersion is the version of the STS API the package speaks.
+const APIVersion = "2011-06-15"
+
+// The STS type encapsulates operations with STS. STS is served from a
+// global endpoint; the region only selects the partition.
+type STS struct {
+	*aws.QueryClient
+}
+
+// New creates a new STS.
+func New(auth aws.Auth, region aws.Region) *STS {
+	client := aws.NewQueryClient(auth, region, "sts", APIVersion)
+	client.IdempotentActions = map[string]bool{
+		"AssumeRole":         true,
+		"GetSessionToken":    true,
+		"GetFederationToken": true,
+	}
+	return &STS{client}
+}
+
+// Credentials are temporary credentials issued by STS. They implement
+// aws.Provider, so they can be passed to aws.NewCredentials or a chain
+// until they expire; see aws.AssumeRoleProvider for role credentials
+// that are renewed.
+type Credentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// Auth returns the credentials as an aws.Auth.
+func (self *Credentials) Auth() aws.Auth {
+	return aws.Auth{
+		AccessKey: self.AccessKeyId,
+		SecretKey: self.SecretAccessKey,
+		Token:     self.SessionToken,
+	}
+}
+
+func (self *Credentials) Retrieve() (aws.Auth, error) {
+	return self.Auth(), nil
+}
+
+func (self *Credentials) IsExpired() bool {
+	return !time.Now().Before(self.Expiration)
+}
+
+// setDuration sets the DurationSeconds parameter, if d is not zero.
+func setDuration(params url.Values, d time.Duration) {
+	if d != 0 {
+		params.Set("DurationSeconds", strconv.Itoa(int(d/time.Second)))
+	}
+}
+
+// MFA identifies the MFA device of the caller and holds a code it
+// generated, for calls requiring MFA.
+type MFA struct {
+	SerialNumber string // ARN of a virtual device or serial number
+	TokenCode    string
+}
+
+func (self *MFA) setParams(params url.Values) {
+	if self != nil && self.SerialNumber != "" {
+		params.Set("SerialNumber", self.SerialNumber)
+		params.Set("TokenCode", self.TokenCode)
+	}
+}
+
+// GetSessionToken returns temporary credentials of the calling IAM user
+// valid for duration, or 12 hours if zero, authenticated with mfa if it
+// is not nil.
+//
+// See https://docs.aws.amazon.com/STS/latest/APIReference/API_GetSessionToken.html for details.
+func (self *STS) GetSessionToken(ctx context.Context, duration time.Duration, mfa *MFA) (*Credentials, error) {
+	params := url.Values{}
+	setDuration(params, duration)
+	mfa.setParams(params)
+	var resp struct {
+		Credentials Credentials `xml:"GetSessionTokenResult>Credentials"`
+	}
+	if err := self.Do(ctx, "GetSessionToken", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Credentials, nil
+}
+
+// CallerIdentity identifies the caller of GetCallerIdentity.
+type CallerIdentity struct {
+	Account string
+	Arn     string
+	UserId  string
+}
+
+// GetCallerIdentity returns the account and the user or role whose
+// credentials sign the call. It needs no permissions.
+//
+// See https://docs.aws.amazon.com/STS/latest/APIReference/API_GetCallerIdentity.html for details.
+func (self *STS) GetCallerIdentity(ctx context.Context) (*CallerIdentity, error) {
+	var resp struct {
+		Identity CallerIdentity `xml:"GetCallerIdentityResult"`
+	}
+	if err := self.Do(ctx, "GetCallerIdentity", url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Identity, nil
+}
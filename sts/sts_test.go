@@ -0,0 +1,92 @@
+package sts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+const assumeRoleResponse = `<AssumeRoleResponse><AssumeRoleResult>
+<Credentials><AccessKeyId>ASIAKEY</AccessKeyId><SecretAccessKey>sekrit</SecretAccessKey><SessionToken>tokentoken</SessionToken><Expiration>2030-01-01T00:00:00Z</Expiration></Credentials>
+<AssumedRoleUser><Arn>arn:aws:sts::123:assumed-role/r/s</Arn><AssumedRoleId>AROA:s</AssumedRoleId></AssumedRoleUser>
+<PackedPolicySize>6</PackedPolicySize>
+</AssumeRoleResult></AssumeRoleResponse>`
+
+func newTestSTS(t *testing.T, handler http.HandlerFunc) *STS {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client := New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.USEast)
+	client.Endpoint.URL = srv.URL
+	return client
+}
+
+func TestAssumeRole(t *testing.T) {
+	client := newTestSTS(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("Action") != "AssumeRole" || r.Form.Get("RoleArn") != "arn:aws:iam::123:role/r" ||
+			r.Form.Get("DurationSeconds") != "900" || r.Form.Get("SerialNumber") != "mfa" || r.Form.Get("TokenCode") != "123456" {
+			t.Errorf("unexpected request %v", r.Form)
+		}
+		fmt.Fprint(w, assumeRoleResponse)
+	})
+	result, err := client.AssumeRole(context.Background(), &AssumeRoleParams{
+		RoleArn:         "arn:aws:iam::123:role/r",
+		RoleSessionName: "s",
+		Duration:        15 * time.Minute,
+		MFA:             &MFA{SerialNumber: "mfa", TokenCode: "123456"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := aws.Auth{AccessKey: "ASIAKEY", SecretKey: "sekrit", Token: "tokentoken"}
+	if result.Credentials.Auth() != want || result.AssumedRoleUser.AssumedRoleId != "AROA:s" || result.PackedPolicySize != 6 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.Credentials.IsExpired() {
+		t.Fatal("credentials expiring in 2030 reported as expired")
+	}
+
+	creds := aws.NewCredentials(&result.Credentials)
+	if auth, err := creds.Get(); err != nil || auth != want {
+		t.Fatalf("got %v, %v from the provider", auth, err)
+	}
+}
+
+func TestWireLogRedactsCredentials(t *testing.T) {
+	client := newTestSTS(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, assumeRoleResponse)
+	})
+	var log strings.Builder
+	client.Logger = aws.LoggerFunc(func(level aws.LogLevel, msg string) {
+		log.WriteString(msg + "\n")
+	})
+	client.LogLevel = aws.LogWire
+	if _, err := client.AssumeRole(context.Background(), &AssumeRoleParams{RoleArn: "r", RoleSessionName: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(log.String(), "sekrit") || strings.Contains(log.String(), "tokentoken") {
+		t.Fatalf("wire log leaks credentials:\n%s", log.String())
+	}
+	if !strings.Contains(log.String(), "ASIAKEY") {
+		t.Fatalf("wire log lacks the response body:\n%s", log.String())
+	}
+}
+
+func TestGetCallerIdentity(t *testing.T) {
+	client := newTestSTS(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<GetCallerIdentityResponse><GetCallerIdentityResult><Arn>arn:aws:iam::123:user/u</Arn><UserId>AIDA</UserId><Account>123</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`)
+	})
+	identity, err := client.GetCallerIdentity(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *identity != (CallerIdentity{Account: "123", Arn: "arn:aws:iam::123:user/u", UserId: "AIDA"}) {
+		t.Fatalf("unexpected identity %+v", identity)
+	}
+}
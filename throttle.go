@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * throttleCodes are the error codes AWS services use to ask clients to
+ * slow down.
+ */
+var throttleCodes = map[string]bool{
+	"BandwidthLimitExceeded":                 true,
+	"EC2ThrottledException":                  true,
+	"LimitExceededException":                 true,
+	"PriorRequestNotComplete":                true,
+	"ProvisionedThroughputExceededException": true,
+	"RequestLimitExceeded":                   true,
+	"RequestThrottled":                       true,
+	"RequestThrottledException":              true,
+	"SlowDown":                               true,
+	"ThrottledException":                     true,
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"TooManyRequestsException":               true,
+}
+
+/**
+ * IsThrottleCode reports whether code is an AWS error code asking the
+ * client to slow down.
+ */
+func IsThrottleCode(code string) bool {
+	return throttleCodes[code]
+}
+
+/**
+ * MaxRetryAfter is the longest Retry-After a request is retried after.
+ * Responses asking for longer waits are returned to the caller instead.
+ */
+var MaxRetryAfter = time.Minute
+
+/**
+ * RetryAfter returns the wait requested by the Retry-After header of
+ * resp, given either in seconds or as an HTTP date.
+ */
+func RetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+var (
+	xmlCodePattern  = regexp.MustCompile(`<Code>([^<]+)</Code>`)
+	jsonCodePattern = regexp.MustCompile(`"(?:__type|code)"\s*:\s*"(?:[^"#]*#)?([^"]+)"`)
+)
+
+/**
+ * errorCode peeks at the body of an error response for the AWS error
+ * code, in XML or JSON. The body is restored for the caller.
+ */
+func errorCode(resp *http.Response) string {
+	if resp.Body == nil {
+		return ""
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body = &peekedBody{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	if err != nil {
+		return ""
+	}
+	if m := xmlCodePattern.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	if m := jsonCodePattern.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+/**
+ * isThrottleResponse reports whether resp asks the client to slow down.
+ */
+func isThrottleResponse(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case 429, 503:
+		return true
+	case 400, 403:
+		return IsThrottleCode(errorCode(resp))
+	}
+	return false
+}
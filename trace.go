@@ -0,0 +1,91 @@
+package aws
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+/**
+ * Timings break down where the time of a request went, as recorded by
+ * an httptrace.ClientTrace. Phases that did not happen, such as dialing
+ * when a kept-alive connection was reused, are zero.
+ */
+type Timings struct {
+	DNS             time.Duration
+	Connect         time.Duration
+	TLSHandshake    time.Duration
+	TimeToFirstByte time.Duration // from sending the request
+	ConnReused      bool
+
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wrote        time.Time
+}
+
+/**
+ * WithTimings returns a context that records the Timings of the request
+ * made with it, in addition to any ClientTrace ctx already carries.
+ */
+func WithTimings(ctx context.Context) (context.Context, *Timings) {
+	timings := &Timings{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			timings.mu.Lock()
+			timings.ConnReused = info.Reused
+			timings.mu.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			timings.mu.Lock()
+			timings.dnsStart = time.Now()
+			timings.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			timings.mu.Lock()
+			timings.DNS = time.Since(timings.dnsStart)
+			timings.mu.Unlock()
+		},
+		ConnectStart: func(network, addr string) {
+			timings.mu.Lock()
+			if timings.connectStart.IsZero() {
+				timings.connectStart = time.Now()
+			}
+			timings.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			timings.mu.Lock()
+			if err == nil && timings.Connect == 0 {
+				timings.Connect = time.Since(timings.connectStart)
+			}
+			timings.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			timings.mu.Lock()
+			timings.tlsStart = time.Now()
+			timings.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			timings.mu.Lock()
+			timings.TLSHandshake = time.Since(timings.tlsStart)
+			timings.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			timings.mu.Lock()
+			timings.wrote = time.Now()
+			timings.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			timings.mu.Lock()
+			if !timings.wrote.IsZero() {
+				timings.TimeToFirstByte = time.Since(timings.wrote)
+			}
+			timings.mu.Unlock()
+		},
+	}
+	return httptrace.WithClientTrace(ctx, trace), timings
+}
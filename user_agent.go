@@ -0,0 +1,59 @@
+package aws
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+/**
+ * Version is the version of this library, sent in the User-Agent.
+ */
+const Version = "0.1.0"
+
+var userAgent struct {
+	sync.RWMutex
+	products []string
+}
+
+/**
+ * UserAgent returns the User-Agent sent by the clients of this library:
+ * its name and version, the Go version and platform, and the product
+ * tokens added with AddUserAgent.
+ */
+func UserAgent() string {
+	userAgent.RLock()
+	defer userAgent.RUnlock()
+
+	parts := []string{
+		"go-aws/" + Version,
+		"(" + runtime.Version() + "; " + runtime.GOOS + "; " + runtime.GOARCH + ")",
+	}
+	return strings.Join(append(parts, userAgent.products...), " ")
+}
+
+/**
+ * AddUserAgent appends an application's product token, e.g.
+ * "backup-tool/2.3", to the User-Agent of all clients, so its requests
+ * can be told apart in server side logs and by AWS support.
+ */
+func AddUserAgent(product string) {
+	product = strings.TrimSpace(product)
+	if product == "" {
+		return
+	}
+	userAgent.Lock()
+	userAgent.products = append(userAgent.products, product)
+	userAgent.Unlock()
+}
+
+/**
+ * SetUserAgent sets the User-Agent header of req, unless it was already
+ * set by the caller.
+ */
+func SetUserAgent(req *http.Request) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent())
+	}
+}
@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+/**
+ * WaiterState is the outcome of a poll of a Waiter.
+ */
+type WaiterState int
+
+const (
+	WaiterRetry   WaiterState = iota // poll again
+	WaiterSuccess                    // the awaited condition holds
+	WaiterFailure                    // the condition will never hold
+)
+
+/**
+ * Acceptor maps the outcome of a poll to a WaiterState when its Matcher
+ * returns true.
+ */
+type Acceptor struct {
+	State   WaiterState
+	Matcher func(result interface{}, err error) bool
+}
+
+/**
+ * Waiter polls an operation until one of its acceptors declares success
+ * or failure, e.g. until a newly created resource becomes visible. Polls
+ * are spaced and bounded by Strategy.
+ *
+ * Polls no acceptor matches are retried if they succeeded and end the
+ * wait with their error otherwise.
+ */
+type Waiter struct {
+	Name      string
+	Poll      func(ctx context.Context) (interface{}, error)
+	Acceptors []Acceptor
+	Strategy  AttemptStrategy
+}
+
+/**
+ * ErrWaiterTimeout is returned by Wait when the strategy is exhausted
+ * before the awaited condition holds.
+ */
+var ErrWaiterTimeout = errors.New("waiter timed out")
+
+/**
+ * WaiterError is returned by Wait when an acceptor declares failure.
+ */
+type WaiterError struct {
+	Name string
+	Err  error // error of the last poll, if any
+}
+
+func (self *WaiterError) Error() string {
+	if self.Err != nil {
+		return self.Name + ": failure state reached: " + self.Err.Error()
+	}
+	return self.Name + ": failure state reached"
+}
+
+func (self *WaiterError) Unwrap() error {
+	return self.Err
+}
+
+/**
+ * Wait polls until the condition holds, an acceptor declares failure, an
+ * unexpected error occurs, the strategy is exhausted or ctx is done. A nil
+ * ctx never is.
+ */
+func (self *Waiter) Wait(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for attempt := self.Strategy.StartWithContext(ctx); attempt.Next(); {
+		result, err := self.Poll(ctx)
+		state, matched := WaiterRetry, false
+		for _, acceptor := range self.Acceptors {
+			if acceptor.Matcher(result, err) {
+				state, matched = acceptor.State, true
+				break
+			}
+		}
+		switch {
+		case state == WaiterSuccess:
+			return nil
+		case state == WaiterFailure:
+			return &WaiterError{Name: self.Name, Err: err}
+		case !matched && err != nil:
+			if ctx.Err() != nil {
+				// The poll failed because the wait was cut short.
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ErrWaiterTimeout
+}
+
+/**
+ * Simulate returns the schedule of polls Wait makes if the condition never
+ * holds, as offsets from the start of the wait, without polling or
+ * sleeping. The schedule is that of Strategy, whose Simulate documents
+ * latency.
+ */
+func (self *Waiter) Simulate(latency func(try int) time.Duration) []time.Duration {
+	return self.Strategy.Simulate(latency)
+}
+
+/**
+ * MatchSuccess matches polls that succeeded.
+ */
+func MatchSuccess(result interface{}, err error) bool {
+	return err == nil
+}
+
+/**
+ * MatchNotFound matches polls that failed because the resource does not
+ * exist.
+ */
+func MatchNotFound(result interface{}, err error) bool {
+	return IsNotFound(err)
+}
+
+/**
+ * MatchErrorCode returns a matcher for polls that failed with code.
+ */
+func MatchErrorCode(code string) func(interface{}, error) bool {
+	return func(result interface{}, err error) bool {
+		return err != nil && ErrorCode(err) == code
+	}
+}
+
+/**
+ * DefaultWaitStrategy polls every five seconds for up to two minutes.
+ */
+var DefaultWaitStrategy = AttemptStrategy{
+	Total: 2 * time.Minute,
+	Delay: 5 * time.Second,
+}
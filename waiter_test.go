@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWaiterSimulate(t *testing.T) {
+	waiter := &Waiter{Strategy: AttemptStrategy{Total: 20 * time.Second, Delay: 5 * time.Second}}
+	want := []time.Duration{0, 5 * time.Second, 10 * time.Second, 15 * time.Second}
+	if got := waiter.Simulate(nil); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestWaiterPollsAsSimulated(t *testing.T) {
+	polls := 0
+	waiter := &Waiter{
+		Name: "Ready",
+		Poll: func(ctx context.Context) (interface{}, error) {
+			polls++
+			return polls, nil
+		},
+		Acceptors: []Acceptor{{
+			State:   WaiterSuccess,
+			Matcher: func(result interface{}, err error) bool { return result == 100 },
+		}},
+		Strategy: AttemptStrategy{Min: 4, Delay: time.Millisecond},
+	}
+	if err := waiter.Wait(context.Background()); err != ErrWaiterTimeout {
+		t.Fatalf("got %v, want ErrWaiterTimeout", err)
+	}
+	if simulated := len(waiter.Simulate(nil)); polls != simulated {
+		t.Fatalf("polled %d times, simulated %d", polls, simulated)
+	}
+}
+
+func TestWaiterAcceptors(t *testing.T) {
+	notFound := &QueryError{StatusCode: 400, Code: "NotFound"}
+	failed := errors.New("boom")
+	results := []error{notFound, nil}
+	waiter := &Waiter{
+		Name: "Exists",
+		Poll: func(ctx context.Context) (interface{}, error) {
+			err := results[0]
+			results = results[1:]
+			return nil, err
+		},
+		Acceptors: []Acceptor{
+			{State: WaiterSuccess, Matcher: MatchSuccess},
+			{State: WaiterRetry, Matcher: MatchErrorCode("NotFound")},
+		},
+		Strategy: AttemptStrategy{Min: 5, Delay: time.Millisecond},
+	}
+	if err := waiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	results = []error{failed}
+	waiter.Acceptors = []Acceptor{{State: WaiterFailure, Matcher: func(_ interface{}, err error) bool { return err == failed }}}
+	var waitErr *WaiterError
+	if err := waiter.Wait(context.Background()); !errors.As(err, &waitErr) || !errors.Is(err, failed) {
+		t.Fatalf("got %v", err)
+	}
+
+	results = []error{failed}
+	waiter.Acceptors = nil
+	if err := waiter.Wait(context.Background()); err != failed {
+		t.Fatalf("unmatched error: got %v", err)
+	}
+}
+
+func TestWaiterContextDone(t *testing.T) {
+	waiter := &Waiter{
+		Name: "Slow",
+		Poll: func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done()
+			return nil, fmt.Errorf("poll: %w", ctx.Err())
+		},
+		Strategy: AttemptStrategy{Total: time.Minute, Delay: time.Millisecond},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := waiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestWaiterNilContext(t *testing.T) {
+	waiter := &Waiter{
+		Name: "Ready",
+		Poll: func(ctx context.Context) (interface{}, error) {
+			if ctx == nil {
+				t.Fatal("polled with a nil context")
+			}
+			return nil, errors.New("not yet")
+		},
+		Strategy: AttemptStrategy{Min: 2, Delay: time.Millisecond},
+	}
+	if err := waiter.Wait(nil); err == nil || err.Error() != "not yet" {
+		t.Fatalf("got %v, want the error of the poll", err)
+	}
+}
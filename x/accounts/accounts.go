@@ -0,0 +1,183 @@
+//go:build !goaws_stable
+
+// Package accounts builds service clients for many AWS accounts at once,
+// each signed with the credentials of a role assumed in its account.
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/ec2"
+	"github.com/dkln/go-aws/s3"
+	"github.com/dkln/go-aws/sqs"
+)
+
+// ErrUnknownAccount is returned for accounts the ClientFactory has no
+// role in.
+var ErrUnknownAccount = errors.New("no role to assume in account")
+
+// ClientFactory assumes a role in each of a set of accounts with the
+// credentials of Source and builds clients signed with the credentials
+// of the role. The credentials of every account are cached and assumed
+// again shortly before they expire, and all clients of an account share
+// them. For example,
+//
+//	factory, err := accounts.NewClientFactory(source, aws.EUWest, []string{
+//	    "arn:aws:iam::111111111111:role/audit",
+//	    "arn:aws:iam::222222222222:role/audit",
+//	})
+//	for _, account := range factory.Accounts() {
+//	    client, err := factory.EC2(account)
+//	    ...
+//	}
+type ClientFactory struct {
+	// Source provides the credentials the roles are assumed with.
+	Source *aws.Credentials
+
+	// Region the clients are created for.
+	Region aws.Region
+
+	// RoleSessionName, ExternalID and Duration are passed on to
+	// aws.AssumeRoleProvider.
+	RoleSessionName string
+	ExternalID      string
+	Duration        time.Duration
+
+	// STSRegion and STSEndpoint select the STS endpoint as in
+	// aws.AssumeRoleProvider. By default the global endpoint is used.
+	STSRegion   string
+	STSEndpoint string
+
+	accounts []string
+	roles    map[string]string // role ARN by account
+
+	mu          sync.Mutex
+	credentials map[string]*aws.Credentials // by account
+}
+
+// NewClientFactory returns a ClientFactory assuming the roles named by
+// roleARNs, at most one per account.
+func NewClientFactory(source *aws.Credentials, region aws.Region, roleARNs []string) (*ClientFactory, error) {
+	factory := &ClientFactory{
+		Source:      source,
+		Region:      region,
+		roles:       make(map[string]string),
+		credentials: make(map[string]*aws.Credentials),
+	}
+	for _, arn := range roleARNs {
+		account, err := AccountOf(arn)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := factory.roles[account]; ok {
+			return nil, fmt.Errorf("roles %s and %s are in the same account", other, arn)
+		}
+		factory.roles[account] = arn
+		factory.accounts = append(factory.accounts, account)
+	}
+	return factory, nil
+}
+
+// AccountOf returns the account ID of the role named by arn, such as
+// "123456789012" for arn:aws:iam::123456789012:role/audit.
+func AccountOf(arn string) (string, error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || !strings.HasPrefix(parts[5], "role/") || parts[4] == "" {
+		return "", fmt.Errorf("%q is not the ARN of an IAM role", arn)
+	}
+	return parts[4], nil
+}
+
+// Accounts returns the IDs of the accounts, in the order of the role
+// ARNs the factory was created with.
+func (self *ClientFactory) Accounts() []string {
+	return append([]string(nil), self.accounts...)
+}
+
+// RoleARN returns the role assumed in account.
+func (self *ClientFactory) RoleARN(account string) (string, error) {
+	arn, ok := self.roles[account]
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrUnknownAccount, account)
+	}
+	return arn, nil
+}
+
+// Credentials returns the cached credentials of the role in account.
+// They are only assumed when first used.
+func (self *ClientFactory) Credentials(account string) (*aws.Credentials, error) {
+	arn, err := self.RoleARN(account)
+	if err != nil {
+		return nil, err
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if credentials, ok := self.credentials[account]; ok {
+		return credentials, nil
+	}
+	credentials := aws.NewCredentials(&aws.AssumeRoleProvider{
+		Source:          self.Source,
+		RoleARN:         arn,
+		RoleSessionName: self.RoleSessionName,
+		ExternalID:      self.ExternalID,
+		Duration:        self.Duration,
+		Region:          self.STSRegion,
+		Endpoint:        self.STSEndpoint,
+	})
+	self.credentials[account] = credentials
+	return credentials, nil
+}
+
+// AssumeAll assumes the role in every account, so missing permissions
+// show up before any work is done. It returns the error of the first
+// account whose role cannot be assumed.
+func (self *ClientFactory) AssumeAll() error {
+	for _, account := range self.accounts {
+		credentials, err := self.Credentials(account)
+		if err != nil {
+			return err
+		}
+		if _, err := credentials.Get(); err != nil {
+			return fmt.Errorf("account %s: %w", account, err)
+		}
+	}
+	return nil
+}
+
+// S3 returns an S3 client for account.
+func (self *ClientFactory) S3(account string) (*s3.S3, error) {
+	credentials, err := self.Credentials(account)
+	if err != nil {
+		return nil, err
+	}
+	client := s3.NewS3(aws.Auth{}, self.Region)
+	client.Credentials = credentials
+	return client, nil
+}
+
+// SQS returns an SQS client for account.
+func (self *ClientFactory) SQS(account string) (*sqs.SQS, error) {
+	credentials, err := self.Credentials(account)
+	if err != nil {
+		return nil, err
+	}
+	client := sqs.New(aws.Auth{}, self.Region)
+	client.Credentials = credentials
+	return client, nil
+}
+
+// EC2 returns an EC2 client for account.
+func (self *ClientFactory) EC2(account string) (*ec2.EC2, error) {
+	credentials, err := self.Credentials(account)
+	if err != nil {
+		return nil, err
+	}
+	client := ec2.New(aws.Auth{}, self.Region)
+	client.Credentials = credentials
+	return client, nil
+}
@@ -0,0 +1,122 @@
+//go:build !goaws_stable
+
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dkln/go-aws"
+)
+
+// newTestServer serves AssumeRole, issuing keys named after the account
+// of the role, and ListQueues, answering with the key the request was
+// signed with.
+func newTestServer(t *testing.T) (*httptest.Server, map[string]int) {
+	var mu sync.Mutex
+	assumed := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Form.Get("Action") {
+		case "AssumeRole":
+			account, _ := AccountOf(r.Form.Get("RoleArn"))
+			mu.Lock()
+			assumed[account]++
+			mu.Unlock()
+			fmt.Fprintf(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ASIA%s</AccessKeyId><SecretAccessKey>secret</SecretAccessKey>
+<SessionToken>token</SessionToken><Expiration>2030-01-01T00:00:00Z</Expiration>
+</Credentials></AssumeRoleResult></AssumeRoleResponse>`, account)
+		case "ListQueues":
+			auth := r.Header.Get("Authorization")
+			key := auth[strings.Index(auth, "Credential=")+len("Credential=") : strings.Index(auth, "/")]
+			fmt.Fprintf(w, `<ListQueuesResponse><ListQueuesResult><QueueUrl>https://sqs/%s/q</QueueUrl></ListQueuesResult></ListQueuesResponse>`, key)
+		default:
+			w.WriteHeader(400)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, assumed
+}
+
+func newTestFactory(t *testing.T, roles ...string) (*ClientFactory, map[string]int) {
+	srv, assumed := newTestServer(t)
+	source := aws.NewCredentials(&aws.StaticProvider{Auth: aws.Auth{AccessKey: "AKID", SecretKey: "secret"}})
+	region := aws.Region{Name: "us-east-1", SQSEndpoint: srv.URL, SigV4Only: true}
+	factory, err := NewClientFactory(source, region, roles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	factory.STSEndpoint = srv.URL
+	return factory, assumed
+}
+
+func TestClientFactory(t *testing.T) {
+	factory, assumed := newTestFactory(t, "arn:aws:iam::111111111111:role/audit", "arn:aws:iam::222222222222:role/audit")
+
+	if got := factory.Accounts(); len(got) != 2 || got[0] != "111111111111" || got[1] != "222222222222" {
+		t.Fatalf("got accounts %v", got)
+	}
+	for i := 0; i < 2; i++ {
+		for _, account := range factory.Accounts() {
+			client, err := factory.SQS(account)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.ListQueues("", "", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "https://sqs/ASIA" + account + "/q"; len(resp.QueueUrls) != 1 || resp.QueueUrls[0] != want {
+				t.Fatalf("got %v, want %s", resp.QueueUrls, want)
+			}
+		}
+	}
+	if assumed["111111111111"] != 1 || assumed["222222222222"] != 1 {
+		t.Fatalf("roles assumed %v times, want once each", assumed)
+	}
+
+	s3client, err := factory.S3("111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqsCredentials, _ := factory.Credentials("111111111111")
+	if s3client.Credentials != sqsCredentials {
+		t.Fatal("clients of an account do not share credentials")
+	}
+}
+
+func TestClientFactoryUnknownAccount(t *testing.T) {
+	factory, _ := newTestFactory(t, "arn:aws:iam::111111111111:role/audit")
+	if _, err := factory.EC2("333333333333"); !errors.Is(err, ErrUnknownAccount) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestClientFactoryAssumeAll(t *testing.T) {
+	factory, assumed := newTestFactory(t, "arn:aws:iam::111111111111:role/audit", "arn:aws:iam::222222222222:role/audit")
+	if err := factory.AssumeAll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(assumed) != 2 {
+		t.Fatalf("assumed %v", assumed)
+	}
+}
+
+func TestNewClientFactoryRejectsBadRoles(t *testing.T) {
+	source := aws.NewCredentials(&aws.StaticProvider{})
+	for _, roles := range [][]string{
+		{"arn:aws:iam::111111111111:user/bob"},
+		{"not an arn"},
+		{"arn:aws:iam::111111111111:role/a", "arn:aws:iam::111111111111:role/b"},
+	} {
+		if _, err := NewClientFactory(source, aws.USEast, roles); err == nil {
+			t.Errorf("%v accepted", roles)
+		}
+	}
+}
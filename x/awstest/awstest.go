@@ -0,0 +1,284 @@
+//go:build !goaws_stable
+
+// Package awstest records the HTTP traffic between a client of this
+// library and AWS, and replays it later, so that code using AWS can be
+// tested deterministically and without credentials or network access.
+//
+// A typical test records once against the real service and commits the
+// resulting file:
+//
+//	rec, err := awstest.NewRecorder("testdata/upload.json", awstest.ModeFromEnv())
+//	...
+//	defer rec.Close()
+//	s := s3.NewS3(auth, aws.EUWest)
+//	s.Client = rec.Client()
+//
+// Credentials are never written to the recording.
+//
+// For tests of clients of services speaking the AWS Query protocol that
+// need no recording, QueryServer fakes the service with scripted answers.
+package awstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkln/go-aws"
+)
+
+// Mode selects whether a Recorder records or replays.
+type Mode int
+
+const (
+	// Replay answers requests from the recording and fails those that
+	// are not in it.
+	Replay Mode = iota
+	// Record sends requests to AWS and adds them to the recording.
+	Record
+)
+
+// ModeFromEnv returns Record if $AWSTEST_RECORD is set to a non-empty
+// value and Replay otherwise.
+func ModeFromEnv() Mode {
+	if os.Getenv("AWSTEST_RECORD") != "" {
+		return Record
+	}
+	return Replay
+}
+
+// Interaction is a recorded request and the response to it.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request that is recorded. URL has its
+// authentication parameters removed.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder returns a Recorder backed by the recording at path. In
+// Replay mode the recording must exist; in Record mode it is written by
+// Close.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	self := &Recorder{
+		mode:      mode,
+		path:      path,
+		transport: aws.NewSingleTryClient(10 * time.Second).Transport,
+	}
+	if mode == Replay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("awstest: %v (record it with AWSTEST_RECORD=1)", err)
+		}
+		if err := json.Unmarshal(data, &self.interactions); err != nil {
+			return nil, fmt.Errorf("awstest: %s: %v", path, err)
+		}
+		self.used = make([]bool, len(self.interactions))
+	}
+	return self, nil
+}
+
+// SetTransport sets the transport requests are sent with in Record mode.
+// It defaults to one whose connections are counted in aws.Diagnostics.
+func (self *Recorder) SetTransport(transport http.RoundTripper) {
+	self.transport = transport
+}
+
+// Client returns an http.Client using the Recorder.
+func (self *Recorder) Client() *http.Client {
+	return &http.Client{Transport: self}
+}
+
+// Close writes the recording in Record mode.
+func (self *Recorder) Close() error {
+	if self.mode != Record {
+		return nil
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	data, err := json.MarshalIndent(self.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(self.path, append(data, '\n'), 0644)
+}
+
+// RoundTrip records or replays req.
+func (self *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    canonicalURL(req.URL),
+		Body:   string(body),
+	}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		// Query API requests carry their parameters, signature included,
+		// in the body.
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			recorded.Body = canonicalQuery(form)
+		}
+	}
+	if self.mode == Record {
+		return self.record(req, recorded)
+	}
+	return self.replay(req, recorded)
+}
+
+func (self *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	resp, err := self.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	self.mu.Lock()
+	self.interactions = append(self.interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       string(data),
+		},
+	})
+	self.mu.Unlock()
+	return resp, nil
+}
+
+func (self *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	// Interactions are replayed in the order they were recorded, so a
+	// request repeated with different outcomes gets them in turn.
+	for i, interaction := range self.interactions {
+		if self.used[i] || interaction.Request != recorded {
+			continue
+		}
+		self.used[i] = true
+		header := interaction.Response.Header
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, errors.New("awstest: no recorded interaction for " + recorded.Method + " " + recorded.URL)
+}
+
+// Unused returns the recorded interactions that have not been replayed,
+// to check that a test made all the requests it was recorded with.
+func (self *Recorder) Unused() []Interaction {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	var unused []Interaction
+	for i, interaction := range self.interactions {
+		if !self.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// volatileParams change with every request and are left out of the
+// recording and of matching.
+var volatileParams = map[string]bool{
+	"awsaccesskeyid":       true,
+	"expires":              true,
+	"signature":            true,
+	"signaturemethod":      true,
+	"signatureversion":     true,
+	"securitytoken":        true,
+	"timestamp":            true,
+	"x-amz-algorithm":      true,
+	"x-amz-credential":     true,
+	"x-amz-date":           true,
+	"x-amz-expires":        true,
+	"x-amz-security-token": true,
+	"x-amz-signature":      true,
+	"x-amz-signedheaders":  true,
+}
+
+// canonicalURL returns u without authentication parameters and with the
+// remaining ones sorted.
+func canonicalURL(u *url.URL) string {
+	copy := *u
+	copy.RawQuery = canonicalQuery(u.Query())
+	copy.User = nil
+	return copy.String()
+}
+
+// canonicalQuery encodes query without authentication parameters, sorted
+// by key.
+func canonicalQuery(query url.Values) string {
+	clean := make(url.Values, len(query))
+	for k, v := range query {
+		if !volatileParams[strings.ToLower(k)] {
+			clean[k] = v
+		}
+	}
+	// Encode sorts by key.
+	return clean.Encode()
+}
@@ -0,0 +1,125 @@
+//go:build !goaws_stable
+
+package awstest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// get sends a GET through client and returns the status and body.
+func get(t *testing.T, client *http.Client, u string) (int, string) {
+	t.Helper()
+	resp, err := client.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		r.ParseForm()
+		w.Header().Set("X-Amz-Request-Id", fmt.Sprint("req-", n))
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(404)
+		}
+		fmt.Fprintf(w, "%d %s %s", n, r.URL.Path, r.Form.Get("Action"))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	rec, err := NewRecorder(path, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := rec.Client()
+	signed := srv.URL + "/bucket/key?X-Amz-Credential=AKIDSECRET%2F20240301&X-Amz-Signature=abc&versionId=2&acl"
+	var recorded []string
+	for _, u := range []string{signed, signed, srv.URL + "/missing"} {
+		status, body := get(t, client, u)
+		recorded = append(recorded, fmt.Sprint(status, " ", body))
+	}
+	form := url.Values{"Action": {"ListQueues"}, "AWSAccessKeyId": {"AKIDSECRET"}, "Signature": {"sig"}, "Timestamp": {"2024-03-01T12:00:00Z"}}
+	resp, err := client.PostForm(srv.URL+"/", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "AKIDSECRET") || strings.Contains(string(data), "Signature") {
+		t.Fatalf("recording holds credentials:\n%s", data)
+	}
+
+	// Replay, with other credentials and timestamps, against no server.
+	srv.Close()
+	rec, err = NewRecorder(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = rec.Client()
+	signed = strings.Replace(signed, "X-Amz-Signature=abc", "X-Amz-Signature=def&X-Amz-Date=20240302T000000Z", 1)
+	for i, u := range []string{signed, signed, srv.URL + "/missing"} {
+		status, body := get(t, client, u)
+		if got := fmt.Sprint(status, " ", body); got != recorded[i] {
+			t.Errorf("replay %d got %q, recorded %q", i, got, recorded[i])
+		}
+	}
+	if len(rec.Unused()) != 1 {
+		t.Fatalf("unused %+v", rec.Unused())
+	}
+	form.Set("Signature", "other")
+	form.Set("Timestamp", "2024-03-02T00:00:00Z")
+	resp, err = client.PostForm(srv.URL+"/", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("X-Amz-Request-Id") != "req-4" || len(rec.Unused()) != 0 {
+		t.Fatalf("got %v, unused %+v", resp.Header, rec.Unused())
+	}
+
+	// Every interaction is replayed once.
+	if _, err := client.Get(srv.URL + "/missing"); err == nil || !strings.Contains(err.Error(), "no recorded interaction for GET") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestReplayNeedsRecording(t *testing.T) {
+	_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), Replay)
+	if err == nil || !strings.Contains(err.Error(), "AWSTEST_RECORD") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv("AWSTEST_RECORD", "")
+	if ModeFromEnv() != Replay {
+		t.Error("expected Replay")
+	}
+	t.Setenv("AWSTEST_RECORD", "1")
+	if ModeFromEnv() != Record {
+		t.Error("expected Record")
+	}
+}
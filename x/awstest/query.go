@@ -0,0 +1,151 @@
+//go:build !goaws_stable
+
+package awstest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+)
+
+// QueryServer is a fake of a service speaking the AWS Query protocol, such
+// as IAM, SES, CloudWatch or Auto Scaling. It answers every action with
+// the Result elements scripted for it, one per request, repeating the last
+// one, and records the requests. Actions without a script fail with a 400
+// ValidationError.
+//
+//	srv := awstest.NewQueryServer(map[string][]string{
+//		"ListMetrics": {"<Metrics>...</Metrics>"},
+//	})
+//	defer srv.Close()
+//	client.Endpoint.URL = srv.URL
+type QueryServer struct {
+	URL string // base URL of the server
+
+	// EC2 makes the server answer in the flavor of EC2, whose responses
+	// have no Result element and whose errors come in a Response element.
+	EC2 bool
+
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	responses map[string][]string
+	errors    map[string]queryError
+	failures  map[string]int
+	requests  []url.Values
+	headers   []http.Header
+}
+
+type queryError struct {
+	status int
+	code   string
+}
+
+// NewQueryServer starts a QueryServer answering with responses, the
+// Result elements by action. An action scripted with no responses answers
+// with an empty Result.
+func NewQueryServer(responses map[string][]string) *QueryServer {
+	self := &QueryServer{
+		responses: make(map[string][]string),
+		errors:    make(map[string]queryError),
+		failures:  make(map[string]int),
+	}
+	for action, results := range responses {
+		self.responses[action] = results
+	}
+	self.srv = httptest.NewServer(http.HandlerFunc(self.serveHTTP))
+	self.URL = self.srv.URL
+	return self
+}
+
+// Close shuts the server down.
+func (self *QueryServer) Close() {
+	self.srv.Close()
+}
+
+// Fail makes the next n requests of action fail with a 500
+// InternalFailure, to exercise retries.
+func (self *QueryServer) Fail(action string, n int) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.failures[action] = n
+}
+
+// Error makes every request of action fail with status and the error
+// code, e.g. 404 and "NoSuchEntity".
+func (self *QueryServer) Error(action string, status int, code string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.errors[action] = queryError{status, code}
+}
+
+// Request returns the form of the n-th request received.
+func (self *QueryServer) Request(n int) url.Values {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.requests[n]
+}
+
+// Header returns the headers of the n-th request received, e.g. to check
+// how it was signed.
+func (self *QueryServer) Header(n int) http.Header {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.headers[n]
+}
+
+// Count returns the number of requests received.
+func (self *QueryServer) Count() int {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return len(self.requests)
+}
+
+func (self *QueryServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.requests = append(self.requests, r.Form)
+	self.headers = append(self.headers, r.Header.Clone())
+	action := r.Form.Get("Action")
+	if self.failures[action] > 0 {
+		self.failures[action]--
+		self.writeError(w, 500, "Receiver", "InternalFailure", "try again")
+		return
+	}
+	if e, ok := self.errors[action]; ok {
+		self.writeError(w, e.status, "Sender", e.code, action+" failed")
+		return
+	}
+	responses, ok := self.responses[action]
+	if !ok {
+		self.writeError(w, 400, "Sender", "ValidationError", action+" not scripted")
+		return
+	}
+	response := ""
+	if len(responses) > 0 {
+		response = responses[0]
+	}
+	if len(responses) > 1 {
+		self.responses[action] = responses[1:]
+	}
+	if self.EC2 {
+		fmt.Fprintf(w, "<%sResponse><requestId>req-1</requestId>%s</%sResponse>", action, response, action)
+		return
+	}
+	fmt.Fprintf(w, "<%sResponse><%sResult>%s</%sResult><ResponseMetadata><RequestId>req-1</RequestId></ResponseMetadata></%sResponse>",
+		action, action, response, action, action)
+}
+
+func (self *QueryServer) writeError(w http.ResponseWriter, status int, kind, code, message string) {
+	w.WriteHeader(status)
+	if self.EC2 {
+		fmt.Fprintf(w, "<Response><Errors><Error><Code>%s</Code><Message>%s</Message></Error></Errors><RequestID>req-1</RequestID></Response>",
+			code, message)
+		return
+	}
+	fmt.Fprintf(w, "<ErrorResponse><Error><Type>%s</Type><Code>%s</Code><Message>%s</Message></Error><RequestId>req-1</RequestId></ErrorResponse>",
+		kind, code, message)
+}
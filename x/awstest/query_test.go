@@ -0,0 +1,82 @@
+//go:build !goaws_stable
+
+package awstest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// post sends a Query request for action and returns the status and body.
+func post(t *testing.T, srv *QueryServer, action string) (int, string) {
+	t.Helper()
+	resp, err := http.PostForm(srv.URL, url.Values{"Action": {action}, "Name": {"n"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestQueryServer(t *testing.T) {
+	srv := NewQueryServer(map[string][]string{
+		"List":  {"<A/>", "<B/>"},
+		"Empty": nil,
+	})
+	defer srv.Close()
+
+	for _, want := range []string{"<A/>", "<B/>", "<B/>"} {
+		status, body := post(t, srv, "List")
+		if status != 200 || body != "<ListResponse><ListResult>"+want+"</ListResult><ResponseMetadata><RequestId>req-1</RequestId></ResponseMetadata></ListResponse>" {
+			t.Fatalf("got %d %s, want %s", status, body, want)
+		}
+	}
+	if status, body := post(t, srv, "Empty"); status != 200 || !strings.Contains(body, "<EmptyResult></EmptyResult>") {
+		t.Fatalf("got %d %s", status, body)
+	}
+	if status, body := post(t, srv, "Other"); status != 400 || !strings.Contains(body, "<Code>ValidationError</Code>") {
+		t.Fatalf("got %d %s", status, body)
+	}
+
+	srv.Fail("List", 1)
+	if status, body := post(t, srv, "List"); status != 500 || !strings.Contains(body, "<Code>InternalFailure</Code>") {
+		t.Fatalf("got %d %s", status, body)
+	}
+	if status, _ := post(t, srv, "List"); status != 200 {
+		t.Fatalf("got %d after the failure", status)
+	}
+	srv.Error("Empty", 404, "NoSuchEntity")
+	if status, body := post(t, srv, "Empty"); status != 404 || !strings.Contains(body, "<Code>NoSuchEntity</Code>") {
+		t.Fatalf("got %d %s", status, body)
+	}
+
+	if n := srv.Count(); n != 8 {
+		t.Fatalf("got %d requests", n)
+	}
+	if form := srv.Request(0); form.Get("Action") != "List" || form.Get("Name") != "n" {
+		t.Fatalf("got %v", form)
+	}
+	if ctype := srv.Header(0).Get("Content-Type"); ctype != "application/x-www-form-urlencoded" {
+		t.Fatalf("got %q", ctype)
+	}
+}
+
+func TestQueryServerEC2(t *testing.T) {
+	srv := NewQueryServer(map[string][]string{"DescribeThings": {"<thingSet/>"}})
+	defer srv.Close()
+	srv.EC2 = true
+
+	if status, body := post(t, srv, "DescribeThings"); status != 200 || body != "<DescribeThingsResponse><requestId>req-1</requestId><thingSet/></DescribeThingsResponse>" {
+		t.Fatalf("got %d %s", status, body)
+	}
+	if status, body := post(t, srv, "Other"); status != 400 || !strings.Contains(body, "<Response><Errors><Error><Code>ValidationError</Code>") {
+		t.Fatalf("got %d %s", status, body)
+	}
+}
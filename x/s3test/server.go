@@ -0,0 +1,790 @@
+//go:build !goaws_stable
+
+// Package s3test implements a fake S3 server running in process, so
+// code using the s3 package can be tested offline. It supports buckets,
+// objects with metadata, conditional and ranged gets, conditional puts, copies,
+// listings and multipart uploads, addressed by path. Requests are not
+// authenticated.
+//
+//	srv := s3test.NewServer()
+//	defer srv.Close()
+//	s, _ := s3.NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL(), "")
+package s3test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake S3 server.
+type Server struct {
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	nextId  int
+}
+
+type bucket struct {
+	name    string
+	created time.Time
+	objects map[string]*object
+	uploads map[string]*upload
+}
+
+type object struct {
+	name     string
+	data     []byte
+	modified time.Time
+	etag     string
+	header   http.Header // Content-Type and x-amz-meta-*
+}
+
+type upload struct {
+	id     string
+	key    string
+	header http.Header
+	parts  map[int]*object
+}
+
+// NewServer starts a fake S3 server without any buckets.
+func NewServer() *Server {
+	self := &Server{buckets: make(map[string]*bucket)}
+	self.srv = httptest.NewServer(http.HandlerFunc(self.serveHTTP))
+	return self
+}
+
+// URL returns the endpoint of the server, for s3.NewS3Endpoint.
+func (self *Server) URL() string {
+	return self.srv.URL
+}
+
+// Close shuts the server down.
+func (self *Server) Close() {
+	self.srv.Close()
+}
+
+// s3Error is an error response.
+type s3Error struct {
+	statusCode int
+	XMLName    xml.Name `xml:"Error"`
+	Code       string
+	Message    string
+	BucketName string `xml:",omitempty"`
+	RequestId  string
+}
+
+func fail(status int, code, format string, args ...interface{}) *s3Error {
+	return &s3Error{statusCode: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (self *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.nextId++
+	requestId := strconv.Itoa(self.nextId)
+	w.Header().Set("x-amz-request-id", requestId)
+
+	var result interface{}
+	err := self.handle(w, r, &result)
+	if err != nil {
+		err.RequestId = requestId
+		writeXML(w, err.statusCode, err, r.Method != "HEAD")
+		return
+	}
+	if result != nil {
+		writeXML(w, http.StatusOK, result, true)
+	}
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}, body bool) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if body {
+		w.Write([]byte(xml.Header))
+		w.Write(data)
+	}
+}
+
+// handle serves r. It either writes the response itself, sets result to
+// be written as XML, or returns an error.
+func (self *Server) handle(w http.ResponseWriter, r *http.Request, result *interface{}) *s3Error {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == "" {
+		if r.Method == "GET" {
+			*result = self.listBuckets()
+			return nil
+		}
+		return fail(405, "MethodNotAllowed", "method %s not allowed on service", r.Method)
+	}
+	bucketName, key := path, ""
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		bucketName, key = path[:i], path[i+1:]
+	}
+	query := r.URL.Query()
+
+	if key == "" {
+		return self.handleBucket(w, r, bucketName, query, result)
+	}
+	b, ok := self.buckets[bucketName]
+	if !ok {
+		err := fail(404, "NoSuchBucket", "The specified bucket does not exist")
+		err.BucketName = bucketName
+		return err
+	}
+	return self.handleObject(w, r, b, key, query, result)
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name      `xml:"ListAllMyBucketsResult"`
+	Buckets []bucketEntry `xml:"Buckets>Bucket"`
+}
+
+type bucketEntry struct {
+	Name         string
+	CreationDate string
+}
+
+func (self *Server) listBuckets() *listAllMyBucketsResult {
+	result := &listAllMyBucketsResult{}
+	for _, b := range self.buckets {
+		result.Buckets = append(result.Buckets, bucketEntry{b.name, formatTime(b.created)})
+	}
+	sort.Slice(result.Buckets, func(i, j int) bool {
+		return result.Buckets[i].Name < result.Buckets[j].Name
+	})
+	return result
+}
+
+func (self *Server) handleBucket(w http.ResponseWriter, r *http.Request, name string, query url.Values, result *interface{}) *s3Error {
+	b, exists := self.buckets[name]
+	if r.Method == "PUT" {
+		if len(query) > 0 {
+			if !exists {
+				return noSuchBucket(name)
+			}
+			// Bucket configuration is accepted and ignored.
+			return nil
+		}
+		if exists {
+			return fail(409, "BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded and you already own it.")
+		}
+		self.buckets[name] = &bucket{
+			name:    name,
+			created: time.Now(),
+			objects: make(map[string]*object),
+			uploads: make(map[string]*upload),
+		}
+		return nil
+	}
+	if !exists {
+		return noSuchBucket(name)
+	}
+
+	switch r.Method {
+	case "HEAD":
+		return nil
+	case "DELETE":
+		if len(query) > 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+		if len(b.objects) > 0 {
+			return fail(409, "BucketNotEmpty", "The bucket you tried to delete is not empty")
+		}
+		delete(self.buckets, name)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	case "GET":
+		if _, ok := query["location"]; ok {
+			*result = &locationConstraint{}
+			return nil
+		}
+		if _, ok := query["uploads"]; ok {
+			*result = b.listUploads()
+			return nil
+		}
+		for k := range query {
+			switch k {
+			case "prefix", "delimiter", "marker", "max-keys", "encoding-type":
+			default:
+				return fail(501, "NotImplemented", "subresource %s is not implemented", k)
+			}
+		}
+		return b.list(query, result)
+	case "POST":
+		if _, ok := query["delete"]; ok {
+			return b.deleteObjects(r, result)
+		}
+	}
+	return fail(405, "MethodNotAllowed", "method %s not allowed on bucket", r.Method)
+}
+
+func noSuchBucket(name string) *s3Error {
+	err := fail(404, "NoSuchBucket", "The specified bucket does not exist")
+	err.BucketName = name
+	return err
+}
+
+type locationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+}
+
+type listBucketResult struct {
+	XMLName        xml.Name `xml:"ListBucketResult"`
+	Name           string
+	Prefix         string
+	Marker         string
+	NextMarker     string `xml:",omitempty"`
+	MaxKeys        int
+	Delimiter      string `xml:",omitempty"`
+	IsTruncated    bool
+	Contents       []keyEntry
+	CommonPrefixes []commonPrefix `xml:",omitempty"`
+}
+
+type keyEntry struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+type commonPrefix struct {
+	Prefix string
+}
+
+func (self *bucket) list(query url.Values, result *interface{}) *s3Error {
+	prefix := query.Get("prefix")
+	delim := query.Get("delimiter")
+	marker := query.Get("marker")
+	max := 1000
+	if s := query.Get("max-keys"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return fail(400, "InvalidArgument", "invalid max-keys %q", s)
+		}
+		max = n
+	}
+
+	names := make([]string, 0, len(self.objects))
+	for name := range self.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resp := &listBucketResult{
+		Name:      self.name,
+		Prefix:    prefix,
+		Marker:    marker,
+		MaxKeys:   max,
+		Delimiter: delim,
+	}
+	lastPrefix := ""
+	count := 0
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) || name <= marker {
+			continue
+		}
+		if delim != "" {
+			if i := strings.Index(name[len(prefix):], delim); i >= 0 {
+				common := name[:len(prefix)+i+len(delim)]
+				if common == lastPrefix {
+					continue
+				}
+				if count == max {
+					resp.IsTruncated = true
+					break
+				}
+				lastPrefix = common
+				resp.CommonPrefixes = append(resp.CommonPrefixes, commonPrefix{common})
+				resp.NextMarker = common
+				count++
+				continue
+			}
+		}
+		if count == max {
+			resp.IsTruncated = true
+			break
+		}
+		obj := self.objects[name]
+		resp.Contents = append(resp.Contents, keyEntry{
+			Key:          name,
+			LastModified: formatTime(obj.modified),
+			ETag:         obj.etag,
+			Size:         int64(len(obj.data)),
+			StorageClass: "STANDARD",
+		})
+		resp.NextMarker = name
+		count++
+	}
+	if !resp.IsTruncated || delim == "" {
+		// S3 only returns NextMarker for truncated, delimited listings.
+		resp.NextMarker = ""
+	}
+	*result = resp
+	return nil
+}
+
+type deleteRequest struct {
+	Objects []struct {
+		Key string
+	} `xml:"Object"`
+	Quiet bool
+}
+
+type deleteResult struct {
+	XMLName xml.Name `xml:"DeleteResult"`
+	Deleted []struct {
+		Key string
+	} `xml:"Deleted"`
+}
+
+func (self *bucket) deleteObjects(r *http.Request, result *interface{}) *s3Error {
+	var req deleteRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		return fail(400, "MalformedXML", "%v", err)
+	}
+	resp := &deleteResult{}
+	for _, o := range req.Objects {
+		delete(self.objects, o.Key)
+		if !req.Quiet {
+			resp.Deleted = append(resp.Deleted, struct{ Key string }{o.Key})
+		}
+	}
+	*result = resp
+	return nil
+}
+
+func (self *Server) handleObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, query url.Values, result *interface{}) *s3Error {
+	if _, ok := query["uploads"]; ok && r.Method == "POST" {
+		return self.initiateUpload(r, b, key, result)
+	}
+	if id := query.Get("uploadId"); id != "" {
+		up, ok := b.uploads[id]
+		if !ok || up.key != key {
+			return fail(404, "NoSuchUpload", "The specified upload does not exist")
+		}
+		switch r.Method {
+		case "PUT":
+			return self.putPart(w, r, b, up, query, result)
+		case "POST":
+			return b.completeUpload(r, up, result)
+		case "DELETE":
+			delete(b.uploads, id)
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		case "GET":
+			*result = up.listParts(b.name)
+			return nil
+		}
+		return fail(405, "MethodNotAllowed", "method %s not allowed on upload", r.Method)
+	}
+
+	switch r.Method {
+	case "PUT":
+		return self.putObject(r, b, key, result)
+	case "GET", "HEAD":
+		obj, ok := b.objects[key]
+		if !ok {
+			return fail(404, "NoSuchKey", "The specified key does not exist.")
+		}
+		serveObject(w, r, obj)
+		return nil
+	case "DELETE":
+		delete(b.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return fail(405, "MethodNotAllowed", "method %s not allowed on object", r.Method)
+}
+
+type copyObjectResult struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	ETag         string
+	LastModified string
+}
+
+func (self *Server) putObject(r *http.Request, b *bucket, key string, result *interface{}) *s3Error {
+	// Conditional writes, as in S3: If-Match guards an overwrite and
+	// If-None-Match: * a creation.
+	existing, exists := b.objects[key]
+	if match := r.Header.Get("If-Match"); match != "" {
+		if !exists {
+			return fail(404, "NoSuchKey", "The specified key does not exist.")
+		}
+		if match != existing.etag {
+			return fail(412, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+		}
+	}
+	if r.Header.Get("If-None-Match") == "*" && exists {
+		return fail(412, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+	}
+
+	if source := r.Header.Get("x-amz-copy-source"); source != "" {
+		src, err := self.copySource(source)
+		if err != nil {
+			return err
+		}
+		header := src.header
+		if r.Header.Get("x-amz-metadata-directive") == "REPLACE" {
+			header = objectHeader(r.Header)
+		}
+		obj := newObject(key, src.data, header)
+		b.objects[key] = obj
+		*result = &copyObjectResult{ETag: obj.etag, LastModified: formatTime(obj.modified)}
+		return nil
+	}
+
+	data, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	obj := newObject(key, data, objectHeader(r.Header))
+	b.objects[key] = obj
+	return nil
+}
+
+// readBody reads the request body, checking Content-MD5 if given.
+func readBody(r *http.Request) ([]byte, *s3Error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, fail(400, "IncompleteBody", "%v", err)
+	}
+	if sum := r.Header.Get("Content-MD5"); sum != "" {
+		actual := md5.Sum(data)
+		if sum != encodeBase64(actual[:]) {
+			return nil, fail(400, "BadDigest", "The Content-MD5 you specified did not match what we received.")
+		}
+	}
+	return data, nil
+}
+
+// copySource looks up the object named by an x-amz-copy-source header.
+func (self *Server) copySource(source string) (*object, *s3Error) {
+	source, err := url.PathUnescape(strings.TrimPrefix(source, "/"))
+	if err != nil {
+		return nil, fail(400, "InvalidArgument", "bad copy source %q", source)
+	}
+	i := strings.IndexByte(source, '/')
+	if i < 0 {
+		return nil, fail(400, "InvalidArgument", "bad copy source %q", source)
+	}
+	b, ok := self.buckets[source[:i]]
+	if !ok {
+		return nil, noSuchBucket(source[:i])
+	}
+	obj, ok := b.objects[source[i+1:]]
+	if !ok {
+		return nil, fail(404, "NoSuchKey", "The specified key does not exist.")
+	}
+	return obj, nil
+}
+
+func newObject(key string, data []byte, header http.Header) *object {
+	sum := md5.Sum(data)
+	return &object{
+		name:     key,
+		data:     data,
+		modified: time.Now(),
+		etag:     `"` + hex.EncodeToString(sum[:]) + `"`,
+		header:   header,
+	}
+}
+
+// objectHeader returns the headers of a request that are stored with
+// the object.
+func objectHeader(h http.Header) http.Header {
+	header := make(http.Header)
+	for k, v := range h {
+		lower := strings.ToLower(k)
+		switch {
+		case strings.HasPrefix(lower, "x-amz-meta-"),
+			lower == "content-type", lower == "content-encoding",
+			lower == "content-disposition", lower == "cache-control",
+			lower == "x-amz-website-redirect-location":
+			header[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "binary/octet-stream")
+	}
+	return header
+}
+
+// serveObject answers a GET or HEAD of obj, honoring conditional and
+// range headers.
+func serveObject(w http.ResponseWriter, r *http.Request, obj *object) {
+	for k, v := range obj.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("ETag", obj.etag)
+	w.Header().Set("Last-Modified", obj.modified.UTC().Format(http.TimeFormat))
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	if match := r.Header.Get("If-Match"); match != "" && match != obj.etag {
+		writeXML(w, http.StatusPreconditionFailed, fail(412, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold"), r.Method != "HEAD")
+		return
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" && match == obj.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	// If-Modified-Since only applies without If-None-Match.
+	if since := r.Header.Get("If-Modified-Since"); since != "" && r.Header.Get("If-None-Match") == "" {
+		if t, err := http.ParseTime(since); err == nil && !obj.modified.Truncate(time.Second).After(t) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	data := obj.data
+	status := http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" && (r.Header.Get("If-Range") == "" || r.Header.Get("If-Range") == obj.etag) {
+		start, end, ok := parseRange(rng, int64(len(data)))
+		if !ok {
+			writeXML(w, http.StatusRequestedRangeNotSatisfiable, fail(416, "InvalidRange", "The requested range is not satisfiable"), r.Method != "HEAD")
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method != "HEAD" {
+		w.Write(data)
+	}
+}
+
+// parseRange parses a single "bytes=start-end" range, where either end
+// may be omitted, against an object of the given size.
+func parseRange(rng string, size int64) (start, end int64, ok bool) {
+	if !strings.HasPrefix(rng, "bytes=") || strings.Contains(rng, ",") {
+		return 0, 0, false
+	}
+	spec := strings.SplitN(strings.TrimPrefix(rng, "bytes="), "-", 2)
+	if len(spec) != 2 {
+		return 0, 0, false
+	}
+	var err error
+	switch {
+	case spec[0] == "":
+		n, err := strconv.ParseInt(spec[1], 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		start, end = size-n, size-1
+	default:
+		if start, err = strconv.ParseInt(spec[0], 10, 64); err != nil {
+			return 0, 0, false
+		}
+		end = size - 1
+		if spec[1] != "" {
+			if end, err = strconv.ParseInt(spec[1], 10, 64); err != nil {
+				return 0, 0, false
+			}
+			if end >= size {
+				end = size - 1
+			}
+		}
+	}
+	if start < 0 || start >= size || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Bucket   string
+	Key      string
+	UploadId string
+}
+
+func (self *Server) initiateUpload(r *http.Request, b *bucket, key string, result *interface{}) *s3Error {
+	self.nextId++
+	id := fmt.Sprintf("upload-%d", self.nextId)
+	b.uploads[id] = &upload{
+		id:     id,
+		key:    key,
+		header: objectHeader(r.Header),
+		parts:  make(map[int]*object),
+	}
+	*result = &initiateMultipartUploadResult{Bucket: b.name, Key: key, UploadId: id}
+	return nil
+}
+
+type copyPartResult struct {
+	XMLName      xml.Name `xml:"CopyPartResult"`
+	ETag         string
+	LastModified string
+}
+
+func (self *Server) putPart(w http.ResponseWriter, r *http.Request, b *bucket, up *upload, query url.Values, result *interface{}) *s3Error {
+	n, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || n < 1 || n > 10000 {
+		return fail(400, "InvalidArgument", "Part number must be an integer between 1 and 10000, inclusive")
+	}
+
+	if source := r.Header.Get("x-amz-copy-source"); source != "" {
+		src, serr := self.copySource(source)
+		if serr != nil {
+			return serr
+		}
+		data := src.data
+		if rng := r.Header.Get("x-amz-copy-source-range"); rng != "" {
+			start, end, ok := parseRange(rng, int64(len(data)))
+			if !ok {
+				return fail(416, "InvalidRange", "The requested range is not satisfiable")
+			}
+			data = data[start : end+1]
+		}
+		part := newObject("", append([]byte(nil), data...), nil)
+		up.parts[n] = part
+		*result = &copyPartResult{ETag: part.etag, LastModified: formatTime(part.modified)}
+		return nil
+	}
+
+	data, serr := readBody(r)
+	if serr != nil {
+		return serr
+	}
+	part := newObject("", data, nil)
+	up.parts[n] = part
+	w.Header().Set("ETag", part.etag)
+	return nil
+}
+
+type completeMultipartUpload struct {
+	Parts []struct {
+		PartNumber int
+		ETag       string
+	} `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+	Bucket  string
+	Key     string
+	ETag    string
+}
+
+func (self *bucket) completeUpload(r *http.Request, up *upload, result *interface{}) *s3Error {
+	var req completeMultipartUpload
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		return fail(400, "MalformedXML", "%v", err)
+	}
+	if len(req.Parts) == 0 {
+		return fail(400, "MalformedXML", "no parts given")
+	}
+
+	var data bytes.Buffer
+	sums := md5.New()
+	last := 0
+	for i, p := range req.Parts {
+		if p.PartNumber <= last {
+			return fail(400, "InvalidPartOrder", "The list of parts was not in ascending order.")
+		}
+		last = p.PartNumber
+		part, ok := up.parts[p.PartNumber]
+		if !ok || part.etag != p.ETag {
+			return fail(400, "InvalidPart", "One or more of the specified parts could not be found.")
+		}
+		if i < len(req.Parts)-1 && len(part.data) < 5<<20 {
+			return fail(400, "EntityTooSmall", "Your proposed upload is smaller than the minimum allowed object size.")
+		}
+		data.Write(part.data)
+		raw, _ := hex.DecodeString(strings.Trim(part.etag, `"`))
+		sums.Write(raw)
+	}
+
+	obj := newObject(up.key, data.Bytes(), up.header)
+	// Multipart ETags are the MD5 of the part MD5s plus the part count.
+	obj.etag = fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sums.Sum(nil)), len(req.Parts))
+	self.objects[up.key] = obj
+	delete(self.uploads, up.id)
+	*result = &completeMultipartUploadResult{Bucket: self.name, Key: up.key, ETag: obj.etag}
+	return nil
+}
+
+type listPartsResult struct {
+	XMLName  xml.Name `xml:"ListPartsResult"`
+	Bucket   string
+	Key      string
+	UploadId string
+	Parts    []partEntry `xml:"Part"`
+}
+
+type partEntry struct {
+	PartNumber   int
+	LastModified string
+	ETag         string
+	Size         int64
+}
+
+func (self *upload) listParts(bucketName string) *listPartsResult {
+	result := &listPartsResult{Bucket: bucketName, Key: self.key, UploadId: self.id}
+	for n, part := range self.parts {
+		result.Parts = append(result.Parts, partEntry{n, formatTime(part.modified), part.etag, int64(len(part.data))})
+	}
+	sort.Slice(result.Parts, func(i, j int) bool {
+		return result.Parts[i].PartNumber < result.Parts[j].PartNumber
+	})
+	return result
+}
+
+type listMultipartUploadsResult struct {
+	XMLName xml.Name `xml:"ListMultipartUploadsResult"`
+	Bucket  string
+	Uploads []uploadEntry `xml:"Upload"`
+}
+
+type uploadEntry struct {
+	Key      string
+	UploadId string
+}
+
+func (self *bucket) listUploads() *listMultipartUploadsResult {
+	result := &listMultipartUploadsResult{Bucket: self.name}
+	for _, up := range self.uploads {
+		result.Uploads = append(result.Uploads, uploadEntry{up.key, up.id})
+	}
+	sort.Slice(result.Uploads, func(i, j int) bool {
+		if result.Uploads[i].Key != result.Uploads[j].Key {
+			return result.Uploads[i].Key < result.Uploads[j].Key
+		}
+		return result.Uploads[i].UploadId < result.Uploads[j].UploadId
+	})
+	return result
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func encodeBase64(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,268 @@
+//go:build !goaws_stable
+
+package s3test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// request is a request to the server and what it answered.
+type request struct {
+	method, path string
+	header       http.Header
+	body         []byte
+
+	status     int
+	respHeader http.Header
+	respBody   string
+}
+
+func newTestServer(t *testing.T) *Server {
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// do sends req to srv and fills in the response.
+func (self *request) do(t *testing.T, srv *Server) *request {
+	t.Helper()
+	req, err := http.NewRequest(self.method, srv.URL()+self.path, bytes.NewReader(self.body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range self.header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	self.status, self.respHeader, self.respBody = resp.StatusCode, resp.Header, string(data)
+	return self
+}
+
+// expect fails the test unless the response has the status and, if not
+// empty, an error with the code.
+func (self *request) expect(t *testing.T, status int, code string) *request {
+	t.Helper()
+	if self.status != status || code != "" && !strings.Contains(self.respBody, "<Code>"+code+"</Code>") {
+		t.Fatalf("%s %s: got %d %s, want %d %s", self.method, self.path, self.status, self.respBody, status, code)
+	}
+	return self
+}
+
+func TestBuckets(t *testing.T) {
+	srv := newTestServer(t)
+	(&request{method: "PUT", path: "/b"}).do(t, srv).expect(t, 200, "")
+	(&request{method: "PUT", path: "/b"}).do(t, srv).expect(t, 409, "BucketAlreadyOwnedByYou")
+	(&request{method: "PUT", path: "/a"}).do(t, srv).expect(t, 200, "")
+	list := (&request{method: "GET", path: "/"}).do(t, srv).expect(t, 200, "")
+	var buckets struct {
+		Names []string `xml:"Buckets>Bucket>Name"`
+	}
+	if err := xml.Unmarshal([]byte(list.respBody), &buckets); err != nil || fmt.Sprint(buckets.Names) != "[a b]" {
+		t.Fatalf("got %v, %v", buckets.Names, err)
+	}
+
+	(&request{method: "HEAD", path: "/missing"}).do(t, srv).expect(t, 404, "")
+	(&request{method: "GET", path: "/missing/key"}).do(t, srv).expect(t, 404, "NoSuchBucket")
+	(&request{method: "GET", path: "/b?versioning"}).do(t, srv).expect(t, 501, "NotImplemented")
+	(&request{method: "PUT", path: "/b/key", body: []byte("x")}).do(t, srv).expect(t, 200, "")
+	(&request{method: "DELETE", path: "/b"}).do(t, srv).expect(t, 409, "BucketNotEmpty")
+	(&request{method: "DELETE", path: "/b/key"}).do(t, srv).expect(t, 204, "")
+	(&request{method: "DELETE", path: "/b"}).do(t, srv).expect(t, 204, "")
+	(&request{method: "HEAD", path: "/b"}).do(t, srv).expect(t, 404, "")
+}
+
+func TestObjects(t *testing.T) {
+	srv := newTestServer(t)
+	(&request{method: "PUT", path: "/b"}).do(t, srv)
+	data := []byte("0123456789")
+	sum := md5.Sum(data)
+	(&request{method: "PUT", path: "/b/dir/key", body: data, header: http.Header{
+		"Content-Md5":    {encodeBase64(sum[:])},
+		"X-Amz-Meta-One": {"1"},
+		"Authorization":  {"not stored"},
+	}}).do(t, srv).expect(t, 200, "")
+	(&request{method: "PUT", path: "/b/bad", body: data, header: http.Header{"Content-Md5": {encodeBase64(make([]byte, 16))}}}).
+		do(t, srv).expect(t, 400, "BadDigest")
+
+	get := (&request{method: "GET", path: "/b/dir/key"}).do(t, srv).expect(t, 200, "")
+	etag := get.respHeader.Get("ETag")
+	if get.respBody != "0123456789" || get.respHeader.Get("X-Amz-Meta-One") != "1" || get.respHeader.Get("Authorization") != "" ||
+		get.respHeader.Get("Content-Type") != "binary/octet-stream" || etag != fmt.Sprintf(`"%x"`, sum) {
+		t.Fatalf("got %v %q", get.respHeader, get.respBody)
+	}
+	(&request{method: "GET", path: "/b/nokey"}).do(t, srv).expect(t, 404, "NoSuchKey")
+
+	for rng, want := range map[string]string{"bytes=2-4": "234", "bytes=7-": "789", "bytes=-2": "89", "bytes=8-20": "89"} {
+		r := (&request{method: "GET", path: "/b/dir/key", header: http.Header{"Range": {rng}}}).do(t, srv).expect(t, 206, "")
+		if r.respBody != want {
+			t.Errorf("%s got %q", rng, r.respBody)
+		}
+	}
+	for _, rng := range []string{"bytes=10-", "bytes=5-2", "bytes=0-1,3-4", "items=0-1"} {
+		(&request{method: "GET", path: "/b/dir/key", header: http.Header{"Range": {rng}}}).do(t, srv).expect(t, 416, "InvalidRange")
+	}
+	(&request{method: "GET", path: "/b/dir/key", header: http.Header{"Range": {"bytes=0-1"}, "If-Range": {`"stale"`}}}).
+		do(t, srv).expect(t, 200, "")
+	(&request{method: "GET", path: "/b/dir/key", header: http.Header{"If-None-Match": {etag}}}).do(t, srv).expect(t, 304, "")
+	since := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	(&request{method: "GET", path: "/b/dir/key", header: http.Header{"If-Modified-Since": {since}}}).do(t, srv).expect(t, 304, "")
+	(&request{method: "GET", path: "/b/dir/key", header: http.Header{"If-None-Match": {`"stale"`}, "If-Modified-Since": {since}}}).
+		do(t, srv).expect(t, 200, "")
+	(&request{method: "GET", path: "/b/dir/key", header: http.Header{"If-Match": {`"stale"`}}}).do(t, srv).expect(t, 412, "PreconditionFailed")
+	if head := (&request{method: "HEAD", path: "/b/dir/key"}).do(t, srv).expect(t, 200, ""); head.respBody != "" || head.respHeader.Get("Content-Length") != "10" {
+		t.Fatalf("got %v %q", head.respHeader, head.respBody)
+	}
+
+	(&request{method: "PUT", path: "/b/copy", header: http.Header{"X-Amz-Copy-Source": {"/b/dir%2Fkey"}}}).do(t, srv).expect(t, 200, "")
+	(&request{method: "PUT", path: "/b/replaced", header: http.Header{
+		"X-Amz-Copy-Source":        {"/b/dir/key"},
+		"X-Amz-Metadata-Directive": {"REPLACE"},
+		"Content-Type":             {"text/plain"},
+	}}).do(t, srv).expect(t, 200, "")
+	if r := (&request{method: "GET", path: "/b/copy"}).do(t, srv); r.respBody != "0123456789" || r.respHeader.Get("X-Amz-Meta-One") != "1" {
+		t.Fatalf("copy got %v %q", r.respHeader, r.respBody)
+	}
+	if r := (&request{method: "GET", path: "/b/replaced"}).do(t, srv); r.respHeader.Get("X-Amz-Meta-One") != "" || r.respHeader.Get("Content-Type") != "text/plain" {
+		t.Fatalf("replaced got %v", r.respHeader)
+	}
+}
+
+func TestConditionalPut(t *testing.T) {
+	srv := newTestServer(t)
+	(&request{method: "PUT", path: "/b"}).do(t, srv).expect(t, 200, "")
+	create := http.Header{"If-None-Match": {"*"}}
+	(&request{method: "PUT", path: "/b/k", header: create, body: []byte("one")}).do(t, srv).expect(t, 200, "")
+	(&request{method: "PUT", path: "/b/k", header: create, body: []byte("two")}).do(t, srv).expect(t, 412, "PreconditionFailed")
+
+	etag := (&request{method: "HEAD", path: "/b/k"}).do(t, srv).expect(t, 200, "").respHeader.Get("ETag")
+	(&request{method: "PUT", path: "/b/k", header: http.Header{"If-Match": {`"stale"`}}, body: []byte("two")}).do(t, srv).expect(t, 412, "PreconditionFailed")
+	(&request{method: "PUT", path: "/b/k", header: http.Header{"If-Match": {etag}}, body: []byte("two")}).do(t, srv).expect(t, 200, "")
+	(&request{method: "PUT", path: "/b/k", header: http.Header{"If-Match": {etag}}, body: []byte("three")}).do(t, srv).expect(t, 412, "PreconditionFailed")
+	(&request{method: "PUT", path: "/b/missing", header: http.Header{"If-Match": {etag}}}).do(t, srv).expect(t, 404, "NoSuchKey")
+
+	if got := (&request{method: "GET", path: "/b/k"}).do(t, srv).expect(t, 200, "").respBody; got != "two" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestList(t *testing.T) {
+	srv := newTestServer(t)
+	(&request{method: "PUT", path: "/b"}).do(t, srv)
+	for _, key := range []string{"a/1", "a/2", "b/1", "c", "d"} {
+		(&request{method: "PUT", path: "/b/" + key}).do(t, srv)
+	}
+	type listing struct {
+		Keys           []string `xml:"Contents>Key"`
+		CommonPrefixes []string `xml:"CommonPrefixes>Prefix"`
+		IsTruncated    bool
+		NextMarker     string
+	}
+	list := func(query string) listing {
+		var result listing
+		r := (&request{method: "GET", path: "/b?" + query}).do(t, srv).expect(t, 200, "")
+		if err := xml.Unmarshal([]byte(r.respBody), &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	for query, want := range map[string]string{
+		"":                                 "{[a/1 a/2 b/1 c d] [] false }",
+		"prefix=a/":                        "{[a/1 a/2] [] false }",
+		"max-keys=2":                       "{[a/1 a/2] [] true }",
+		"marker=a/2":                       "{[b/1 c d] [] false }",
+		"delimiter=/":                      "{[c d] [a/ b/] false }",
+		"delimiter=/&max-keys=3":           "{[c] [a/ b/] true c}",
+		"delimiter=/&marker=c&max-keys=3":  "{[d] [] false }",
+		"prefix=a/&delimiter=/&max-keys=1": "{[a/1] [] true a/1}",
+	} {
+		if got := fmt.Sprint(list(query)); got != want {
+			t.Errorf("%q got %s, want %s", query, got, want)
+		}
+	}
+	(&request{method: "GET", path: "/b?max-keys=-1"}).do(t, srv).expect(t, 400, "InvalidArgument")
+
+	(&request{method: "POST", path: "/b?delete", body: []byte("<Delete><Object><Key>a/1</Key></Object><Object><Key>c</Key></Object></Delete>")}).
+		do(t, srv).expect(t, 200, "")
+	if got := fmt.Sprint(list("").Keys); got != "[a/2 b/1 d]" {
+		t.Fatalf("after deleting got %s", got)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	srv := newTestServer(t)
+	(&request{method: "PUT", path: "/b"}).do(t, srv)
+	(&request{method: "PUT", path: "/b/src", body: []byte("tail")}).do(t, srv)
+	var initiated struct{ UploadId string }
+	r := (&request{method: "POST", path: "/b/big?uploads", header: http.Header{"X-Amz-Meta-Kind": {"big"}}}).do(t, srv).expect(t, 200, "")
+	if err := xml.Unmarshal([]byte(r.respBody), &initiated); err != nil || initiated.UploadId == "" {
+		t.Fatalf("got %q, %v", r.respBody, err)
+	}
+	upload := "/b/big?uploadId=" + initiated.UploadId
+
+	first := bytes.Repeat([]byte("a"), 5<<20)
+	etag1 := (&request{method: "PUT", path: upload + "&partNumber=1", body: first}).do(t, srv).expect(t, 200, "").respHeader.Get("ETag")
+	var copied struct{ ETag string }
+	r = (&request{method: "PUT", path: upload + "&partNumber=2", header: http.Header{
+		"X-Amz-Copy-Source":       {"/b/src"},
+		"X-Amz-Copy-Source-Range": {"bytes=1-3"},
+	}}).do(t, srv).expect(t, 200, "")
+	xml.Unmarshal([]byte(r.respBody), &copied)
+	(&request{method: "PUT", path: upload + "&partNumber=0"}).do(t, srv).expect(t, 400, "InvalidArgument")
+
+	if r := (&request{method: "GET", path: "/b?uploads"}).do(t, srv); !strings.Contains(r.respBody, initiated.UploadId) {
+		t.Fatalf("uploads got %s", r.respBody)
+	}
+	complete := func(parts ...interface{}) *request {
+		body := "<CompleteMultipartUpload>"
+		for i := 0; i < len(parts); i += 2 {
+			body += fmt.Sprintf("<Part><PartNumber>%d</PartNumber><ETag>%s</ETag></Part>", parts[i], parts[i+1])
+		}
+		return (&request{method: "POST", path: upload, body: []byte(body + "</CompleteMultipartUpload>")}).do(t, srv)
+	}
+	complete(1, etag1, 1, etag1).expect(t, 400, "InvalidPartOrder")
+	complete(1, `"stale"`).expect(t, 400, "InvalidPart")
+	complete(1, etag1, 3, copied.ETag).expect(t, 400, "InvalidPart")
+	r = complete(1, etag1, 2, copied.ETag).expect(t, 200, "")
+	if !strings.Contains(r.respBody, `-2&#34;</ETag>`) {
+		t.Fatalf("got %s", r.respBody)
+	}
+
+	get := (&request{method: "GET", path: "/b/big", header: http.Header{"Range": {"bytes=-4"}}}).do(t, srv).expect(t, 206, "")
+	if get.respBody != "aail" || get.respHeader.Get("X-Amz-Meta-Kind") != "big" {
+		t.Fatalf("got %v %q", get.respHeader, get.respBody)
+	}
+	(&request{method: "GET", path: upload}).do(t, srv).expect(t, 404, "NoSuchUpload")
+}
+
+func TestEntityTooSmall(t *testing.T) {
+	srv := newTestServer(t)
+	(&request{method: "PUT", path: "/b"}).do(t, srv)
+	var initiated struct{ UploadId string }
+	r := (&request{method: "POST", path: "/b/k?uploads"}).do(t, srv)
+	xml.Unmarshal([]byte(r.respBody), &initiated)
+	upload := "/b/k?uploadId=" + initiated.UploadId
+	etag := (&request{method: "PUT", path: upload + "&partNumber=1", body: []byte("small")}).do(t, srv).respHeader.Get("ETag")
+	body := fmt.Sprintf("<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>%s</ETag></Part><Part><PartNumber>2</PartNumber><ETag>%s</ETag></Part></CompleteMultipartUpload>", etag, etag)
+	(&request{method: "PUT", path: upload + "&partNumber=2", body: []byte("small")}).do(t, srv)
+	(&request{method: "POST", path: upload, body: []byte(body)}).do(t, srv).expect(t, 400, "EntityTooSmall")
+
+	// Aborting drops the upload.
+	(&request{method: "DELETE", path: upload}).do(t, srv).expect(t, 204, "")
+	(&request{method: "PUT", path: upload + "&partNumber=3"}).do(t, srv).expect(t, 404, "NoSuchUpload")
+}
@@ -0,0 +1,170 @@
+//go:build !goaws_stable
+
+// Package sqsconsumer processes the messages of an SQS queue with a pool
+// of workers.
+package sqsconsumer
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/sqs"
+)
+
+// A Handler processes messages received by a Consumer. A nil error means
+// the message was processed and may be deleted; otherwise it is left in
+// the queue to be redelivered once its visibility timeout expires.
+type Handler interface {
+	HandleMessage(ctx context.Context, msg *sqs.Message) error
+}
+
+// HandlerFunc adapts a function to a Handler.
+type HandlerFunc func(ctx context.Context, msg *sqs.Message) error
+
+func (self HandlerFunc) HandleMessage(ctx context.Context, msg *sqs.Message) error {
+	return self(ctx, msg)
+}
+
+// PanicError is the error of a message whose handler panicked.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (self *PanicError) Error() string {
+	return fmt.Sprintf("sqsconsumer: handler panicked: %v", self.Value)
+}
+
+// Consumer receives messages from Queue with a number of workers and
+// hands them to Handler, one at a time per worker.
+type Consumer struct {
+	Queue   *sqs.Queue
+	Handler Handler
+
+	// Workers is the number of messages processed concurrently. If zero,
+	// one.
+	Workers int
+
+	// ReceiveParams are the parameters of the receives. If
+	// MaxNumberOfMessages is zero, every worker receives one message at a
+	// time, and if WaitTimeSeconds is zero, it long polls for
+	// MaxWaitTimeSeconds.
+	ReceiveParams sqs.ReceiveParams
+
+	// HeartbeatTimeout, if not zero, keeps the messages being processed
+	// hidden by extending their visibility timeout to that many seconds
+	// as long as their handler runs; see sqs.Queue.StartHeartbeat.
+	HeartbeatTimeout int
+
+	// ShutdownTimeout is how long Run waits for the handlers still
+	// running when its context is done before their context is canceled
+	// too. If zero, Run waits for them indefinitely.
+	ShutdownTimeout time.Duration
+
+	// OnError, if set, is called with the messages that could not be
+	// processed or deleted and why. Receive errors come with a nil message.
+	OnError func(msg *sqs.Message, err error)
+
+	// Logger, if set, receives a log line for every failure.
+	Logger aws.Logger
+}
+
+// Run consumes messages until ctx is done, then stops receiving, waits
+// for the messages being processed to complete (see ShutdownTimeout) and
+// returns ctx's error. A panicking handler doesn't bring down the
+// consumer; its message fails with a *PanicError.
+func (self *Consumer) Run(ctx context.Context) error {
+	workers := self.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	params := self.ReceiveParams
+	if params.MaxNumberOfMessages == 0 {
+		params.MaxNumberOfMessages = 1
+	}
+	if params.WaitTimeSeconds == 0 {
+		params.WaitTimeSeconds = sqs.MaxWaitTimeSeconds
+	}
+
+	// Handlers run in a context of their own, so messages being
+	// processed when ctx is done can complete.
+	handlerCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			self.work(ctx, handlerCtx, params)
+		}()
+	}
+
+	<-ctx.Done()
+	if self.ShutdownTimeout > 0 {
+		timer := time.AfterFunc(self.ShutdownTimeout, cancel)
+		defer timer.Stop()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (self *Consumer) work(ctx, handlerCtx context.Context, params sqs.ReceiveParams) {
+	for ctx.Err() == nil {
+		resp, err := self.Queue.ReceiveMessageWithParams(ctx, params)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			self.fail(nil, err)
+			aws.SleepWithContext(ctx, time.Second)
+			continue
+		}
+		for i := range resp.Messages {
+			// Messages received are processed even if ctx is done
+			// meanwhile, as they are hidden from other consumers.
+			self.process(handlerCtx, &resp.Messages[i])
+		}
+	}
+}
+
+func (self *Consumer) process(ctx context.Context, msg *sqs.Message) {
+	var heartbeat *sqs.Heartbeat
+	if self.HeartbeatTimeout > 0 {
+		heartbeat = self.Queue.StartHeartbeat(ctx, msg.ReceiptHandle, self.HeartbeatTimeout)
+	}
+	err := self.handle(ctx, msg)
+	if heartbeat != nil {
+		heartbeat.Stop()
+	}
+	if err == nil {
+		err = self.Queue.DeleteMessageWithContext(ctx, msg.ReceiptHandle)
+	}
+	if err != nil {
+		self.fail(msg, err)
+	}
+}
+
+func (self *Consumer) handle(ctx context.Context, msg *sqs.Message) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = &PanicError{value, debug.Stack()}
+		}
+	}()
+	return self.Handler.HandleMessage(ctx, msg)
+}
+
+func (self *Consumer) fail(msg *sqs.Message, err error) {
+	if msg != nil {
+		aws.Logf(self.Logger, aws.LogRequest, aws.LogRequest, "sqsconsumer: message %s of %s failed: %v", msg.MessageId, self.Queue.URL, err)
+	} else {
+		aws.Logf(self.Logger, aws.LogRequest, aws.LogRequest, "sqsconsumer: receiving from %s failed: %v", self.Queue.URL, err)
+	}
+	if self.OnError != nil {
+		self.OnError(msg, err)
+	}
+}
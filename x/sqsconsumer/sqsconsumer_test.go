@@ -0,0 +1,175 @@
+//go:build !goaws_stable
+
+package sqsconsumer
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/sqs"
+)
+
+// fakeQueue serves the messages of a single queue, each once, and
+// records the ones deleted.
+type fakeQueue struct {
+	mu      sync.Mutex
+	pending []string
+	deleted []string
+}
+
+func (self *fakeQueue) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	switch action := r.Form.Get("Action"); action {
+	case "ReceiveMessage":
+		fmt.Fprint(w, "<ReceiveMessageResponse><ReceiveMessageResult>")
+		if len(self.pending) > 0 {
+			body := self.pending[0]
+			self.pending = self.pending[1:]
+			sum := md5.Sum([]byte(body))
+			fmt.Fprintf(w, "<Message><MessageId>%s</MessageId><ReceiptHandle>%s</ReceiptHandle><MD5OfBody>%s</MD5OfBody><Body>%s</Body></Message>",
+				body, body, hex.EncodeToString(sum[:]), body)
+		} else {
+			// Stand in for a long poll without holding the lock.
+			self.mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			self.mu.Lock()
+		}
+		fmt.Fprint(w, "</ReceiveMessageResult></ReceiveMessageResponse>")
+	case "DeleteMessage":
+		self.deleted = append(self.deleted, r.Form.Get("ReceiptHandle"))
+		fmt.Fprint(w, "<DeleteMessageResponse/>")
+	default:
+		w.WriteHeader(400)
+	}
+}
+
+func (self *fakeQueue) deletedMessages() []string {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	deleted := append([]string(nil), self.deleted...)
+	sort.Strings(deleted)
+	return deleted
+}
+
+func newTestQueue(t *testing.T, messages ...string) (*sqs.Queue, *fakeQueue) {
+	fake := &fakeQueue{pending: messages}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	client := sqs.New(aws.Auth{AccessKey: "a", SecretKey: "s"}, aws.Region{Name: "us-east-1", SQSEndpoint: srv.URL, SigV4Only: true})
+	return client.Queue(srv.URL + "/123456789012/jobs"), fake
+}
+
+func TestConsumerDeletesProcessedMessages(t *testing.T) {
+	queue, fake := newTestQueue(t, "ok-1", "fail", "panic", "ok-2")
+	var mu sync.Mutex
+	var failures []error
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := &Consumer{
+		Queue:   queue,
+		Workers: 2,
+		Handler: HandlerFunc(func(ctx context.Context, msg *sqs.Message) error {
+			switch msg.Body {
+			case "fail":
+				return errors.New("failed")
+			case "panic":
+				panic("boom")
+			}
+			return nil
+		}),
+		OnError: func(msg *sqs.Message, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, err)
+		},
+	}
+	done := make(chan error)
+	go func() { done <- consumer.Run(ctx) }()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		mu.Lock()
+		failed := len(failures)
+		mu.Unlock()
+		if failed == 2 && len(fake.deletedMessages()) == 2 {
+			break
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("got %v", err)
+	}
+	if deleted := fake.deletedMessages(); fmt.Sprint(deleted) != "[ok-1 ok-2]" {
+		t.Fatalf("deleted %v", deleted)
+	}
+	var panicErr *PanicError
+	for _, err := range failures {
+		if errors.As(err, &panicErr) {
+			break
+		}
+	}
+	if panicErr == nil || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Fatalf("got failures %v", failures)
+	}
+}
+
+func TestConsumerCompletesMessagesOnShutdown(t *testing.T) {
+	queue, fake := newTestQueue(t, "slow")
+	started := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := &Consumer{
+		Queue: queue,
+		Handler: HandlerFunc(func(handlerCtx context.Context, msg *sqs.Message) error {
+			close(started)
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond)
+			return handlerCtx.Err()
+		}),
+	}
+	done := make(chan error)
+	go func() { done <- consumer.Run(ctx) }()
+	<-started
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("got %v", err)
+	}
+	if deleted := fake.deletedMessages(); fmt.Sprint(deleted) != "[slow]" {
+		t.Fatalf("deleted %v", deleted)
+	}
+}
+
+func TestConsumerShutdownTimeout(t *testing.T) {
+	queue, fake := newTestQueue(t, "stuck")
+	started := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := &Consumer{
+		Queue:           queue,
+		ShutdownTimeout: 10 * time.Millisecond,
+		Handler: HandlerFunc(func(handlerCtx context.Context, msg *sqs.Message) error {
+			close(started)
+			<-handlerCtx.Done()
+			return handlerCtx.Err()
+		}),
+	}
+	done := make(chan error)
+	go func() { done <- consumer.Run(ctx) }()
+	<-started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+	if deleted := fake.deletedMessages(); len(deleted) != 0 {
+		t.Fatalf("deleted %v", deleted)
+	}
+}
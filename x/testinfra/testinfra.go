@@ -0,0 +1,218 @@
+//go:build !goaws_stable
+
+// Package testinfra creates ephemeral AWS resources for integration tests
+// and guarantees they are removed once the test finishes, even when the
+// test fails or panics.
+//
+// Buckets need the S3 client passed to New; queues and topics need SQS
+// and SNS clients set on the Harness.
+package testinfra
+
+import (
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/s3"
+	"github.com/dkln/go-aws/sns"
+	"github.com/dkln/go-aws/sqs"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Harness tracks the resources created for a single test.
+type Harness struct {
+	SQS *sqs.SQS // creates the queues of Queue
+	SNS *sns.SNS // creates the topics of Topic
+
+	t        testing.TB
+	s3       *s3.S3
+	prefix   string
+	mu       sync.Mutex
+	teardown []func() error
+}
+
+// New returns a Harness bound to t. Every resource created through it is
+// torn down, in reverse order of creation, when t completes.
+func New(t testing.TB, s *s3.S3) *Harness {
+	self := &Harness{
+		t:      t,
+		s3:     s,
+		prefix: "goaws-test",
+	}
+	t.Cleanup(func() {
+		if err := self.Teardown(); err != nil {
+			t.Errorf("testinfra: teardown: %v", err)
+		}
+	})
+	return self
+}
+
+// UniqueName returns a lower case name derived from name which is very
+// unlikely to collide with resources of concurrent test runs.
+func (self *Harness) UniqueName(name string) string {
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(rand.Int63n(1<<30), 36)
+	unique := strings.ToLower(self.prefix + "-" + name + "-" + suffix)
+	if len(unique) > 63 {
+		unique = unique[len(unique)-63:]
+		unique = strings.TrimLeft(unique, "-.")
+	}
+	return unique
+}
+
+// Bucket creates a private bucket with a unique name derived from name.
+// The bucket and everything stored in it are deleted on teardown.
+func (self *Harness) Bucket(name string) *s3.Bucket {
+	self.t.Helper()
+
+	bucket := self.s3.Bucket(self.UniqueName(name))
+	if err := bucket.PutBucket(s3.Private); err != nil {
+		self.t.Fatalf("testinfra: creating bucket %s: %v", bucket.Name, err)
+	}
+	self.Defer(func() error {
+		return emptyAndDeleteBucket(bucket)
+	})
+	return bucket
+}
+
+// Queue creates a queue with a unique name derived from name and the
+// given attributes. The queue and its messages are deleted on teardown.
+func (self *Harness) Queue(name string, attributes map[string]string) *sqs.Queue {
+	self.t.Helper()
+
+	if self.SQS == nil {
+		self.t.Fatalf("testinfra: creating queue %s: Harness has no SQS client", name)
+	}
+	queueName := self.UniqueName(name)
+	if attributes["FifoQueue"] == "true" {
+		queueName += ".fifo"
+	}
+	queue, err := self.SQS.CreateQueue(queueName, attributes)
+	if err != nil {
+		self.t.Fatalf("testinfra: creating queue %s: %v", queueName, err)
+	}
+	self.Defer(func() error {
+		err := queue.Delete()
+		if aws.ErrorCode(err) == "AWS.SimpleQueueService.NonExistentQueue" {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("deleting queue %s: %v", queue.URL, err)
+		}
+		return nil
+	})
+	return queue
+}
+
+// Topic creates a topic with a unique name derived from name. The topic
+// and its subscriptions are deleted on teardown.
+func (self *Harness) Topic(name string) *sns.Topic {
+	self.t.Helper()
+
+	if self.SNS == nil {
+		self.t.Fatalf("testinfra: creating topic %s: Harness has no SNS client", name)
+	}
+	topic, err := self.SNS.CreateTopic(self.UniqueName(name))
+	if err != nil {
+		self.t.Fatalf("testinfra: creating topic %s: %v", name, err)
+	}
+	self.Defer(func() error {
+		if err := topic.Delete(); err != nil {
+			return fmt.Errorf("deleting topic %s: %v", topic.Arn, err)
+		}
+		return nil
+	})
+	return topic
+}
+
+// Defer registers fn to run on teardown. It can be used for resources the
+// harness does not know how to create itself.
+func (self *Harness) Defer(fn func() error) {
+	self.mu.Lock()
+	self.teardown = append(self.teardown, fn)
+	self.mu.Unlock()
+}
+
+// Teardown removes all resources created so far. It is called
+// automatically when the test completes but may be called earlier;
+// resources are only removed once. All teardown functions run even if
+// some of them fail, and the first error is returned.
+func (self *Harness) Teardown() error {
+	self.mu.Lock()
+	fns := self.teardown
+	self.teardown = nil
+	self.mu.Unlock()
+
+	var first error
+	for i := len(fns) - 1; i >= 0; i-- {
+		if err := runTeardown(fns[i]); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// runTeardown calls fn, turning a panic into an error so one broken
+// teardown cannot leak the remaining resources.
+func runTeardown(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// emptyAndDeleteBucket deletes every version of every object in bucket,
+// delete markers included, and then the bucket.
+func emptyAndDeleteBucket(bucket *s3.Bucket) error {
+	err := deleteVersions(bucket)
+	if e, ok := err.(*s3.Error); ok && e.Code == "NotImplemented" {
+		// S3 compatible services without versioning.
+		err = deleteKeys(bucket)
+	}
+	if err != nil {
+		return err
+	}
+	if err := bucket.DelBucket(); err != nil {
+		return fmt.Errorf("deleting bucket %s: %v", bucket.Name, err)
+	}
+	return nil
+}
+
+// deleteVersions deletes all versions of the objects in bucket. Objects
+// of unversioned buckets are listed with the version "null".
+func deleteVersions(bucket *s3.Bucket) error {
+	keyMarker, versionIdMarker := "", ""
+	for {
+		resp, err := bucket.ListVersions("", "", keyMarker, versionIdMarker, 1000)
+		if err != nil {
+			return err
+		}
+		for _, version := range resp.Versions {
+			if err := bucket.DelVersion(version.Key, version.VersionId); err != nil {
+				return fmt.Errorf("deleting %s/%s version %s: %v", bucket.Name, version.Key, version.VersionId, err)
+			}
+		}
+		if !resp.IsTruncated {
+			return nil
+		}
+		keyMarker, versionIdMarker = resp.NextKeyMarker, resp.NextVersionIdMarker
+	}
+}
+
+// deleteKeys deletes the objects in bucket.
+func deleteKeys(bucket *s3.Bucket) error {
+	contents, err := bucket.GetBucketContents()
+	if err != nil {
+		return fmt.Errorf("listing bucket %s: %v", bucket.Name, err)
+	}
+	for key := range *contents {
+		if err := bucket.Del(key); err != nil {
+			return fmt.Errorf("deleting %s/%s: %v", bucket.Name, key, err)
+		}
+	}
+	return nil
+}
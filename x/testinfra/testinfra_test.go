@@ -0,0 +1,80 @@
+//go:build !goaws_stable
+
+package testinfra
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dkln/go-aws"
+	"github.com/dkln/go-aws/s3"
+	"github.com/dkln/go-aws/sns"
+	"github.com/dkln/go-aws/sqs"
+	"github.com/dkln/go-aws/x/s3test"
+)
+
+func TestBucketTeardownPagesPastOneThousandKeys(t *testing.T) {
+	srv := s3test.NewServer()
+	defer srv.Close()
+	client, err := s3.NewS3Endpoint(aws.Auth{AccessKey: "a", SecretKey: "s"}, srv.URL(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bucket *s3.Bucket
+	t.Run("harness", func(t *testing.T) {
+		bucket = New(t, client).Bucket("paging")
+		for i := 0; i < 1001; i++ {
+			if err := bucket.Put("key"+strconv.Itoa(i), []byte("x"), "text/plain", s3.Private); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if _, err := bucket.List("", "", "", 1); err == nil {
+		t.Fatal("bucket survived teardown")
+	}
+}
+
+func TestQueueAndTopicTeardown(t *testing.T) {
+	var mu sync.Mutex
+	var actions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		action := r.Form.Get("Action")
+		mu.Lock()
+		actions = append(actions, action)
+		mu.Unlock()
+		switch action {
+		case "CreateQueue":
+			fmt.Fprintf(w, "<CreateQueueResponse><CreateQueueResult><QueueUrl>http://%s/123/%s</QueueUrl></CreateQueueResult></CreateQueueResponse>",
+				r.Host, r.Form.Get("QueueName"))
+		case "CreateTopic":
+			fmt.Fprintf(w, "<CreateTopicResponse><CreateTopicResult><TopicArn>arn:aws:sns:us-east-1:123:%s</TopicArn></CreateTopicResult></CreateTopicResponse>",
+				r.Form.Get("Name"))
+		case "DeleteQueue", "DeleteTopic":
+			fmt.Fprintf(w, "<%sResponse/>", action)
+		default:
+			w.WriteHeader(400)
+		}
+	}))
+	defer srv.Close()
+	region := aws.Region{Name: "us-east-1", SQSEndpoint: srv.URL, SNSEndpoint: srv.URL, SigV4Only: true}
+	auth := aws.Auth{AccessKey: "a", SecretKey: "s"}
+
+	t.Run("harness", func(t *testing.T) {
+		harness := New(t, nil)
+		harness.SQS = sqs.New(auth, region)
+		harness.SNS = sns.New(auth, region)
+		harness.Queue("jobs", nil)
+		harness.Topic("events")
+	})
+
+	want := []string{"CreateQueue", "CreateTopic", "DeleteTopic", "DeleteQueue"}
+	if fmt.Sprint(actions) != fmt.Sprint(want) {
+		t.Fatalf("got actions %v, want %v", actions, want)
+	}
+}